@@ -17,8 +17,8 @@ import (
 	"strings"
 	"text/scanner"
 
-	"github.com/hyangah/vulns/internal/checker"
-	"github.com/hyangah/vulns/internal/testenv"
+	"github.com/hyangah/vulns/internal/xtools/checker"
+	"github.com/hyangah/vulns/internal/xtools/testenv"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/packages"
 )
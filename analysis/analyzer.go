@@ -8,15 +8,11 @@ package analysis
 
 import (
 	"bytes"
-	"encoding/json"
-	"errors"
 	"fmt"
 	"go/ast"
 	"go/token"
 	"go/types"
-	"io/ioutil"
 	"log"
-	"os"
 	"sort"
 	"strings"
 	"sync"
@@ -30,7 +26,7 @@ import (
 var vulnsJSONFile = ""
 
 func init() {
-	Analyzer.Flags.StringVar(&vulnsJSONFile, "vulns-json", vulnsJSONFile, "JSON file containing the list of ModuleVulns to be scanned")
+	Analyzer.Flags.StringVar(&vulnsJSONFile, "vulns-json", vulnsJSONFile, "catalog file of the OSV entries to be scanned (see CatalogVersion for the format)")
 }
 
 var Analyzer = &analysis.Analyzer{
@@ -39,7 +35,7 @@ var Analyzer = &analysis.Analyzer{
 	Requires:         []*analysis.Analyzer{inspect.Analyzer},
 	Run:              run,
 	RunDespiteErrors: true,
-	FactTypes:        []analysis.Fact{(*vulnFact)(nil)},
+	FactTypes:        []analysis.Fact{(*vulnFact)(nil), (*ModuleFact)(nil)},
 }
 
 const Name = "vulns"
@@ -47,8 +43,8 @@ const Doc = `detect access to vulnerable symbols
 
 The vuln analysis reports reference paths computed
 based on the vulnerability information stored in
-a json file (-vulns-json flag). The easiest way of
-creating the vulns-json file is to use "vuln dump"
+a catalog file (-vulns-json flag). The easiest way of
+creating the catalog file is to use "vulns catalog"
 command that fetches relevant osv entries from GOVULNDB.`
 
 // TODO: Support light-weight import-graph based analysis.
@@ -56,16 +52,32 @@ command that fetches relevant osv entries from GOVULNDB.`
 // references a vulnerable symbol directly or indirectly,
 // treat that package completely vulnerable.
 
+// FactVersion is the version of the encoding of the facts exported
+// by the analyzer. It must be incremented whenever the meaning or the
+// encoding of a fact type changes. Facts of other versions, such as
+// those produced by an older analyzer and stored in a fact cache, are
+// ignored as if they were absent.
+const FactVersion = 2
+
 // A vulnFact records a path to a known vulnerable function.
 // TODO: optimize the presentation to share common tails.
 type vulnFact struct {
-	// Vuln ID -> Reference path to a known vulnerable symbol.
+	// FactVersion is the version of the fact encoding.
+	// Facts without it predate versioning and decode as 0.
+	FactVersion int
+
+	// Vulnerability key (see vulnKey) -> Reference path to a known
+	// vulnerable symbol.
 	// Existence of an entry with an empty path indicates
 	// the whole package is affected by the vulnerability.
 	// (e.g. init)
 	Path map[string][]string
 }
 
+func newVulnFact(path map[string][]string) *vulnFact {
+	return &vulnFact{FactVersion: FactVersion, Path: path}
+}
+
 func (f *vulnFact) AFact() {}
 func (f *vulnFact) String() string {
 	var b strings.Builder
@@ -79,60 +91,50 @@ func (f *vulnFact) String() string {
 	return b.String()
 }
 
-// Catalog is the list of osv entries.
-type Catalog struct {
-	PkgToVulns map[string][]*osv.Entry
-	Err        error
-
-	// TODO(hyangah): ID to vulns to report details about detected vulnerability
-	// (short description, href, fixed version)
-}
-
-// Refresh repopulates the Catalog.
-func (c *Catalog) Refresh() {
-	if vulnsJSONFile != "" {
-		catalog.readFile(vulnsJSONFile)
-	} else {
-		catalog.Err = errors.New("catalog not initialized")
-	}
-	if catalog.Err != nil {
-		log.Printf("catalog initialization failed: %v", catalog.Err)
-	}
-}
+var (
+	catalogMu   sync.Mutex
+	catalog     Catalog
+	catalogPath string // vulnsJSONFile the catalog was read from
+	loaded      bool
+)
 
-func (c *Catalog) readFile(catalogFile string) {
-	f, err := os.Open(catalogFile)
-	if err != nil {
-		c.Err = err
-		return
-	}
-	defer f.Close()
-	var pkg2vulns map[string][]*osv.Entry
-	if err := json.NewDecoder(f).Decode(&pkg2vulns); err != nil {
-		c.Err = err
-		return
+// loadCatalog returns the catalog, reading it first if the -vulns-json
+// flag changed since it was last read. This lets a process analyze
+// packages with different catalogs in turn (see quickcheck.Analyze).
+func loadCatalog() Catalog {
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+	if !loaded || catalogPath != vulnsJSONFile {
+		catalog = Catalog{}
+		catalog.Refresh()
+		catalogPath, loaded = vulnsJSONFile, true
 	}
-	c.PkgToVulns = pkg2vulns
-	c.Err = nil
+	return catalog
 }
 
-var (
-	catalog Catalog
-	once    sync.Once
-)
-
 func run(pass *analysis.Pass) (interface{}, error) {
 	// TODO(hyangah): caching mechanism for use in a long-lived analysis server.
-	once.Do(catalog.Refresh)
+	catalog := loadCatalog()
 
 	if catalog.Err != nil {
 		return nil, catalog.Err
 	}
 
+	if moduleFacts {
+		if m := moduleOf(pass); m != nil {
+			pass.ExportPackageFact(m)
+		}
+	}
+
 	if len(catalog.PkgToVulns) == 0 { // no vulnerability.
 		return nil, nil
 	}
 
+	roots, err := newRootSelector(pass.Fset, rootsMode, rootSymbols, rootFiles)
+	if err != nil {
+		return nil, err
+	}
+
 	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
 	var (
 		// bucket is the current receptacle for references.
@@ -274,56 +276,86 @@ func run(pass *analysis.Pass) (interface{}, error) {
 		return res
 	}
 	format := func(obj types.Object) string {
-		//return types.ObjectString(obj, (*types.Package).Name) // TODO: position
 		return objectString(obj, pass.Fset)
 	}
+	hidden := hiddenRefs(pass)
+	// Positions are formatted only for the frames of the paths that
+	// are reported or exported as facts, and at most once per frame.
+	formatPath := func(p *frame) []string {
+		n := 0
+		for fr := p; fr != nil; fr = fr.next {
+			n++
+		}
+		res := make([]string, 0, n)
+		for fr := p; fr != nil; fr = fr.next {
+			if fr.str == "" {
+				fr.str = format(fr.obj)
+			}
+			if fr.imported && fr.next != nil && fr.next.str == fr.str {
+				continue // the imported path starts with the object
+			}
+			res = append(res, fr.str)
+		}
+		return res
+	}
 	// Simple depth-first path query with memoization.
 	// The reported paths may be much longer than necessary.
 	// TODO: Compute shortest paths using Floyd-Warshall.
 	//
 	// memo is a memoization of the path to a vulnerable object.
-	// A nonempty slice indicates a path.
-	// An empty non-nil slice indicates no path.
-	// An nil slice marks a node as grey to detect cycles.
-	memo := make(map[types.Object]map[string][]string)
-	var findPath func(obj types.Object) map[string][]string
-	findPath = func(obj types.Object) map[string][]string {
+	// A nonempty map indicates paths, keyed by vulnerable symbol.
+	// An empty non-nil map indicates no path.
+	// An nil map marks a node as grey to detect cycles.
+	// Paths share their tails, so extending a path is cheap.
+	memo := make(map[types.Object]map[string]*frame)
+	var findPath func(obj types.Object) map[string]*frame
+	findPath = func(obj types.Object) map[string]*frame {
 		path, ok := memo[obj]
 		if !ok {
 			memo[obj] = nil // mark grey to break cycles
-			path = map[string][]string{}
+			path = map[string]*frame{}
 
 			if vulns := catalog.isDirectlyVulnerable(obj); len(vulns) > 0 {
 				// obj itself is vulnerable.
-				o := []string{format(obj)}
+				o := &frame{obj: obj}
+				sym := symbolOf(obj)
 				for _, v := range vulns {
-					// format returns both qualified name and position info.
-					// use only object name part (symbol) as the key.
-					objName, _, _ := strings.Cut(o[0], " ")
-					k := v + ":" + objName
-					path[k] = o
+					path[vulnKey(v, sym)] = o
 				}
-			} else if fact := (&vulnFact{}); pass.ImportObjectFact(obj, fact) {
-				o := format(obj)
+			} else if fact := (&vulnFact{}); pass.ImportObjectFact(obj, fact) && compatibleFact(fact.FactVersion) {
 				// obj is indirectly vulnerable by induction over packages.
 				for vuln, prev := range fact.Path {
-					if len(prev) > 0 && prev[0] == o {
-						path[vuln] = append([]string{}, prev...)
-					} else {
-						path[vuln] = append([]string{format(obj)}, prev...)
+					var p *frame
+					viaType := ViaType(prev)
+					for i := len(prev) - 1; i >= 0; i-- {
+						p = &frame{str: prev[i], next: p, viaType: viaType}
 					}
+					path[vuln] = &frame{obj: obj, next: p, viaType: viaType, imported: true}
 				}
 			} else {
+				// Does obj reference a vulnerable function through
+				// a go:linkname directive or assembly? The targets
+				// are not objects of the pass, and their frames
+				// have no position (see ViaLinkname).
+				for _, sym := range hidden[obj] {
+					for _, v := range catalog.symbolVulns(sym.PkgPath, sym.Symbol(), true) {
+						path[vulnKey(v, sym)] = (&frame{str: sym.String()}).extend(obj)
+					}
+				}
 				// Does obj indirectly reference a vulnerable function?
-				o := format(obj)
 				for _, succ := range succs(obj) {
 					if path0 := findPath(succ); len(path0) > 0 {
 						for vuln, prev := range path0 {
-							if len(prev) > 0 && prev[0] == o {
-								path[vuln] = append([]string{}, prev...)
-							} else {
-								path[vuln] = append([]string{o}, prev...)
+							p := prev
+							if prev.obj != obj {
+								p = prev.extend(obj)
 							}
+							// Prefer paths of references to paths
+							// through types.
+							if old := path[vuln]; old != nil && !old.viaType && p.viaType {
+								continue
+							}
+							path[vuln] = p
 						}
 					}
 				}
@@ -337,6 +369,7 @@ func run(pass *analysis.Pass) (interface{}, error) {
 	}
 
 	findings := map[string]bool{}
+	reporting := reports(pass.Pkg.Path())
 
 	packageFactPath := make(map[string][]string)
 
@@ -344,16 +377,22 @@ func run(pass *analysis.Pass) (interface{}, error) {
 		pkg := member.(*types.PkgName).Imported()
 
 		var fact vulnFact
-		if pass.ImportPackageFact(pkg, &fact) {
+		if pass.ImportPackageFact(pkg, &fact) && compatibleFact(fact.FactVersion) {
+			var m string // member, formatted once it has a path
 			for vuln, p := range fact.Path {
-				p = append([]string{format(member)}, p...)
+				if m == "" {
+					m = format(member)
+				}
+				p = append([]string{m}, p...)
 				id, _, _ := strings.Cut(vuln, ":")
-				pass.Report(analysis.Diagnostic{
-					Pos:      member.Pos(),
-					End:      0,
-					Category: vuln,
-					Message:  id + "|" + strings.Join(p, "\t"),
-				})
+				if reporting && roots.reportImports() {
+					pass.Report(analysis.Diagnostic{
+						Pos:      member.Pos(),
+						End:      0,
+						Category: vuln,
+						Message:  catalog.describe(id) + "|" + strings.Join(p, "\t"),
+					})
+				}
 				if existing, ok := packageFactPath[vuln]; !ok || len(existing) > len(p) {
 					packageFactPath[vuln] = p
 				}
@@ -372,12 +411,11 @@ func run(pass *analysis.Pass) (interface{}, error) {
 		}
 
 		for vuln, p := range path {
-			if len(p) == 0 {
+			if p == nil || !reporting || !roots.isRoot(member) {
 				continue
 			}
 			findings[vuln] = true
 			id, _, _ := strings.Cut(vuln, ":")
-			// TODO(hyangah): report only for packages that are requested to analyze.
 			pass.Report(analysis.Diagnostic{
 				Pos:      member.Pos(),
 				End:      0,
@@ -386,55 +424,101 @@ func run(pass *analysis.Pass) (interface{}, error) {
 				// Considered RelatedInformation, but that takes token.Pos, which
 				// is strange given that we need to refer to the findings from
 				// analysis of other packages.
-				Message: id + "|" + strings.Join(p, "\t"),
+				Message: catalog.describe(id) + "|" + strings.Join(formatPath(p), "\t"),
 				// TODO(hyangah): suggested fix - upgrade module
 			})
 		}
 		// Propagate only exported object facts.
 		if member.Exported() {
-			v := &vulnFact{Path: path}
-			pass.ExportObjectFact(member, v)
+			factPath := make(map[string][]string, len(path))
+			for vuln, p := range path {
+				factPath[vuln] = formatPath(p)
+			}
+			pass.ExportObjectFact(member, newVulnFact(factPath))
 		}
 		if member.Name() == "init" {
 			for vuln, trace := range path {
 				if _, ok := packageFactPath[vuln]; !ok {
-					packageFactPath[vuln] = append([]string(nil), trace...)
+					packageFactPath[vuln] = formatPath(trace)
 				}
 			}
 		}
 	}
 	if len(packageFactPath) > 0 {
-		pass.ExportPackageFact(&vulnFact{Path: packageFactPath})
+		pass.ExportPackageFact(newVulnFact(packageFactPath))
 	}
 	return nil, nil
 }
 
-func (c *Catalog) isDirectlyVulnerable(o types.Object) []string {
-	var vuln []string // vulnerability ID
+var incompatibleFactOnce sync.Once
 
-	fn, ok := o.(*types.Func)
-	if !ok {
-		return nil
+// compatibleFact reports whether a fact of the given version can be used.
+// It logs the first incompatible fact it sees.
+func compatibleFact(version int) bool {
+	if version == FactVersion {
+		return true
 	}
-	pkg := fn.Pkg()
+	incompatibleFactOnce.Do(func() {
+		log.Printf("ignoring %s facts of version %d (want %d); results may be incomplete", Name, version, FactVersion)
+	})
+	return false
+}
+
+// isDirectlyVulnerable returns the IDs of the vulnerabilities
+// affecting o. Functions and methods are affected if they are listed
+// as vulnerable symbols or if the whole package is affected. With the
+// -method-name-fallback flag, methods matching none are also affected
+// if they are listed under a receiver type that no longer exists.
+// Package-level types and variables are affected only if they are
+// listed explicitly.
+func (c *Catalog) isDirectlyVulnerable(o types.Object) []string {
+	pkg := o.Pkg()
 	if pkg == nil {
 		return nil
 	}
-	vulns := c.PkgToVulns[pkg.Path()]
+	var name string
+	switch o := o.(type) {
+	case *types.Func:
+		vulns := c.symbolVulns(pkg.Path(), dbFuncName(o), true)
+		if len(vulns) == 0 && methodNameFallback && o.Type().(*types.Signature).Recv() != nil {
+			vulns = c.renamedReceiverVulns(o)
+		}
+		return vulns
+	case *types.TypeName, *types.Var:
+		if o.Parent() != pkg.Scope() { // local, or a struct field
+			return nil
+		}
+		name = o.Name()
+	default:
+		return nil
+	}
+	// The vulnerabilities of whole packages are reported
+	// at the functions of the packages only.
+	return c.symbolVulns(pkg.Path(), name, false)
+}
+
+// symbolVulns returns the IDs of the vulnerabilities affecting the
+// symbol with the name, such as "Decoder.Decode", in the package.
+// With wholePackage, the symbol is also affected by vulnerabilities
+// of the whole package.
+func (c *Catalog) symbolVulns(pkgPath, name string, wholePackage bool) []string {
+	var vuln []string // vulnerability ID
+	vulns := c.PkgToVulns[pkgPath]
 	if len(vulns) == 0 {
 		return nil
 	}
-	fnName := dbFuncName(fn)
 	for _, v := range vulns {
-		syms := affectedSymbols(pkg.Path(), v)
+		syms := affectedSymbols(pkgPath, v)
 		if len(syms) == 0 {
-			vuln = append(vuln, v.ID)
+			if wholePackage {
+				vuln = append(vuln, v.ID)
+			}
 			continue // the entire package is vulnerable.
 		}
 		for _, s := range syms {
-			if s == fnName {
+			if s == name {
 				vuln = append(vuln, v.ID)
-				continue
+				break
 			}
 		}
 	}
@@ -494,6 +578,69 @@ func exportedSymbols(in []string) []string {
 	return out
 }
 
+// A frame is an element of a reference path, linked to the rest of
+// the path. Frames of objects hold the object, so that the position
+// is formatted only when the path is reported or exported, including
+// the objects of other packages whose facts are imported. The rest of
+// the paths of the facts is already formatted.
+type frame struct {
+	obj  types.Object // nil for formatted frames of facts
+	str  string       // formatted frame, once known
+	next *frame
+
+	// viaType reports whether the path goes from a type to one of
+	// its methods (see ViaType). Such paths are less certain than
+	// paths of references.
+	viaType bool
+
+	// imported reports that the frame of obj starts the path of its
+	// fact, which the package of obj usually starts with obj itself;
+	// the frame is then left out when formatted.
+	imported bool
+}
+
+// extend returns the path from obj to the rest of the path p.
+func (p *frame) extend(obj types.Object) *frame {
+	return &frame{obj: obj, next: p, viaType: p.viaType || isMethodOf(p.obj, obj)}
+}
+
+// isMethodOf reports whether m is a method of the named type t.
+func isMethodOf(m, t types.Object) bool {
+	fn, ok := m.(*types.Func)
+	if !ok {
+		return false
+	}
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return false
+	}
+	rt := recv.Type()
+	if p, ok := rt.(*types.Pointer); ok {
+		rt = p.Elem()
+	}
+	n, ok := rt.(*types.Named)
+	return ok && n.Obj() == t
+}
+
+// ViaType reports whether the reference path reported by the analyzer
+// goes from a type to one of its methods, that is, whether a frame of
+// a type "pkgpath.T" is followed by a frame of its method "pkgpath.T.M".
+// Such a path only shows that a value of the type is used, e.g.
+// constructed, so the method may or may not be called.
+func ViaType(path []string) bool {
+	for i := 0; i+1 < len(path); i++ {
+		t, _, _ := strings.Cut(path[i], " ")
+		m, _, _ := strings.Cut(path[i+1], " ")
+		if !strings.Contains(t[strings.LastIndex(t, "/")+1:], ".") {
+			continue // a package, not a type
+		}
+		if rest := strings.TrimPrefix(m, t+"."); rest != m && !strings.Contains(rest, ".") {
+			return true
+		}
+	}
+	return false
+}
+
 // objectString returns qualified object name followed by its position info (file:line:col)
 func objectString(obj types.Object, fset *token.FileSet) string {
 	var buf bytes.Buffer
@@ -532,23 +679,3 @@ func objectString0(buf *bytes.Buffer, obj types.Object) {
 	}
 	buf.WriteString(obj.Name())
 }
-
-// DumpVulnInfo writes the provided osv entry list to a temporary file
-// and returns the file name.
-func DumpVulnInfo(pkg2vulns map[string][]*osv.Entry) (fname string, err error) {
-	vulnsFile, err := ioutil.TempFile("", "vuln")
-	if err != nil {
-		return "", fmt.Errorf("failed to create a temp file: %v", err)
-	}
-	defer func() {
-		err2 := vulnsFile.Close()
-		if err == nil && err2 != nil {
-			fname, err = "", err2
-		}
-	}()
-
-	if err := json.NewEncoder(vulnsFile).Encode(pkg2vulns); err != nil {
-		return "", fmt.Errorf("failed to encode module vulnerability info: %v", err)
-	}
-	return vulnsFile.Name(), nil
-}
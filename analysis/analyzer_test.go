@@ -1,14 +1,22 @@
 package analysis
 
 import (
+	"bytes"
 	"context"
+	"encoding/gob"
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hyangah/vulns/internal/osvutil"
+	"github.com/hyangah/vulns/internal/xtools/checker"
 	"github.com/hyangah/vulns/testutils"
+	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/packages"
 	"golang.org/x/tools/go/packages/packagestest"
 	"golang.org/x/vuln/client"
@@ -23,7 +31,7 @@ func Test(t *testing.T) {
 				"x/x.go": `
 			package x
 			import "work/y"
-			func X() {	y.Y() } // want "GO02\\|.*" X:"GO02:.*"
+			func X() {	y.Y() } // want "GO02\\|work/x\\.X [^\\t]*\\twork/y\\.Y [^\\t]*\\tb\\.com/m/vuln\\.Vuln [^\\t]*$" X:"GO02:.*"
 			`,
 				"y/y.go": `
 			package y
@@ -118,6 +126,714 @@ published: 2021-04-14T20:04:52Z
 	RunWithPackages(t, e.Config.Dir, Analyzer, pkgs)
 }
 
+func TestModuleFacts(t *testing.T) {
+	e := packagestest.Export(t, packagestest.Modules, []packagestest.Module{
+		{
+			Name: "work",
+			Files: map[string]interface{}{
+				"y/y.go": `
+			package y
+			import b "b.com/m/vuln"
+			func Y() { b.Vuln() }
+		`}},
+		{
+			Name: "b.com/m@v1.0.1",
+			Files: map[string]interface{}{
+				"go.mod": `module b.com/m`,
+				"vuln/vuln.go": `
+			package vuln
+			func Vuln() {}
+		`}},
+	})
+	defer e.Cleanup()
+	pkgs, err := LoadPackages(e, "work/y", "b.com/m/vuln")
+	if err != nil {
+		t.Fatal(err)
+	}
+	setCatalog(t, map[string][]*osv.Entry{})
+	Analyzer.Flags.Set("module-facts", "true")
+	defer Analyzer.Flags.Set("module-facts", "false")
+
+	want := map[string]ModuleFact{
+		"work/y":       {FactVersion: FactVersion, Path: "work"},
+		"b.com/m/vuln": {FactVersion: FactVersion, Path: "b.com/m", Version: "v1.0.1"},
+	}
+	for _, r := range checker.TestAnalyzer(Analyzer, pkgs) {
+		if r.Err != nil {
+			t.Fatalf("error analyzing %s: %v", r.Pass, r.Err)
+		}
+		path := r.Pass.Pkg.Path()
+		var got []ModuleFact
+		for _, f := range r.Facts[nil] {
+			if m, ok := f.(*ModuleFact); ok {
+				got = append(got, *m)
+			}
+		}
+		if len(got) != 1 || got[0] != want[path] {
+			t.Errorf("module facts of %s = %v, want %v", path, got, want[path])
+		}
+	}
+}
+
+func TestReportPackages(t *testing.T) {
+	e := packagestest.Export(t, packagestest.Modules, []packagestest.Module{
+		{
+			Name: "work",
+			Files: map[string]interface{}{
+				"y/y.go": `
+			package y
+			import "b.com/m/mid"
+			func Y() { mid.Mid() }
+		`}},
+		{
+			Name: "b.com/m@v1.0.1",
+			Files: map[string]interface{}{
+				"go.mod": `module b.com/m`,
+				"mid/mid.go": `
+			package mid
+			import "b.com/m/vuln"
+			func Mid() { vuln.Vuln() }
+		`,
+				"vuln/vuln.go": `
+			package vuln
+			func Vuln() {}
+		`}},
+	})
+	defer e.Cleanup()
+	pkgs, err := LoadPackages(e, "work/y", "b.com/m/mid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	setCatalog(t, map[string][]*osv.Entry{
+		"b.com/m/vuln": {{
+			ID: "GO02",
+			Affected: []osv.Affected{{
+				Package: osv.Package{Name: "b.com/m", Ecosystem: osv.GoEcosystem},
+				EcosystemSpecific: osv.EcosystemSpecific{
+					Imports: []osv.EcosystemSpecificImport{{Path: "b.com/m/vuln", Symbols: []string{"Vuln"}}},
+				},
+			}},
+		}},
+	})
+	defer Analyzer.Flags.Set("report-packages", "")
+	defer Analyzer.Flags.Set("facts-only", "false")
+
+	for _, tc := range []struct {
+		flag, value string
+		want        map[string]int // package path -> number of diagnostics
+	}{
+		{"report-packages", "", map[string]int{"work/y": 1, "b.com/m/mid": 1}},
+		{"report-packages", "work/...", map[string]int{"work/y": 1, "b.com/m/mid": 0}},
+		{"report-packages", "b.com/m/mid", map[string]int{"work/y": 0, "b.com/m/mid": 1}},
+		{"facts-only", "true", map[string]int{"work/y": 0, "b.com/m/mid": 0}},
+	} {
+		Analyzer.Flags.Set(tc.flag, tc.value)
+		for _, r := range checker.TestAnalyzer(Analyzer, pkgs) {
+			if r.Err != nil {
+				t.Fatalf("error analyzing %s: %v", r.Pass, r.Err)
+			}
+			path := r.Pass.Pkg.Path()
+			if got := len(r.Diagnostics); got != tc.want[path] {
+				t.Errorf("-%s=%s: %s reported %d diagnostics, want %d", tc.flag, tc.value, path, got, tc.want[path])
+			}
+			// The facts are exported regardless.
+			if len(r.Facts) == 0 {
+				t.Errorf("-%s=%s: %s exported no facts", tc.flag, tc.value, path)
+			}
+		}
+	}
+}
+
+func TestRoots(t *testing.T) {
+	for _, tc := range []struct {
+		roots, symbols string
+		rootFiles      string // relative to module work
+		files          map[string]interface{}
+	}{
+		{
+			roots: RootsExported,
+			files: map[string]interface{}{
+				"p/p.go": `
+			package p
+			import b "b.com/m/vuln"
+			func Exported() { helper() } // want "GO02\\|.*" Exported:"GO02:.*"
+			func helper() { b.Vuln() }
+			type T struct{} // want T:"GO02:.*"
+			func (T) M() { b.Vuln() } // want "GO02\\|.*" M:"GO02:.*"
+			type t struct{}
+			func (t) M() { b.Vuln() } // want M:"GO02:.*"
+			`},
+		},
+		{
+			roots: RootsMain,
+			files: map[string]interface{}{
+				"p/main.go": `
+			package main
+			import b "b.com/m/vuln"
+			func main() { run() } // want "GO02\\|.*"
+			func run() { b.Vuln() }
+			func Other() { b.Vuln() } // want Other:"GO02:.*"
+			`},
+		},
+		{
+			roots:   RootsSymbols,
+			symbols: "work/p.Handler",
+			files: map[string]interface{}{
+				"p/p.go": `
+			package p
+			import b "b.com/m/vuln"
+			func Handler() { b.Vuln() } // want "GO02\\|.*" Handler:"GO02:.*"
+			func Other() { b.Vuln() } // want Other:"GO02:.*"
+			`},
+		},
+		{
+			// -root-symbols implies -roots=symbols.
+			roots:   RootsAll,
+			symbols: "work/p.Handler",
+			files: map[string]interface{}{
+				"p/p.go": `
+			package p
+			import b "b.com/m/vuln"
+			func Handler() { b.Vuln() } // want "GO02\\|.*" Handler:"GO02:.*"
+			func Other() { b.Vuln() } // want Other:"GO02:.*"
+			`},
+		},
+		{
+			roots:     RootsFiles,
+			rootFiles: "p/handler.go",
+			files: map[string]interface{}{
+				"p/handler.go": `
+			package p
+			import b "b.com/m/vuln"
+			func Handler() { b.Vuln() } // want "GO02\\|.*" Handler:"GO02:.*"
+			func handler() { Other() } // want "GO02\\|.*"
+			`,
+				"p/p.go": `
+			package p
+			import b "b.com/m/vuln"
+			func Other() { b.Vuln() } // want Other:"GO02:.*"
+			`},
+		},
+	} {
+		t.Run(tc.roots, func(t *testing.T) {
+			e := packagestest.Export(t, packagestest.Modules, []packagestest.Module{
+				{Name: "work", Files: tc.files},
+				{
+					Name: "b.com/m@v1.0.1",
+					Files: map[string]interface{}{
+						"go.mod": `module b.com/m`,
+						"vuln/vuln.go": `
+			package vuln
+			func Vuln() {}
+			`}},
+			})
+			defer e.Cleanup()
+			pkgs, err := LoadPackages(e, "work/p")
+			if err != nil {
+				t.Fatal(err)
+			}
+			setCatalog(t, map[string][]*osv.Entry{
+				"b.com/m/vuln": {{
+					ID: "GO02",
+					Affected: []osv.Affected{{
+						Package: osv.Package{Name: "b.com/m", Ecosystem: osv.GoEcosystem},
+						EcosystemSpecific: osv.EcosystemSpecific{
+							Imports: []osv.EcosystemSpecificImport{{Path: "b.com/m/vuln", Symbols: []string{"Vuln"}}},
+						},
+					}},
+				}},
+			})
+			rootFiles := ""
+			if tc.rootFiles != "" {
+				rootFiles = e.File("work", tc.rootFiles)
+			}
+			Analyzer.Flags.Set("roots", tc.roots)
+			Analyzer.Flags.Set("root-symbols", tc.symbols)
+			Analyzer.Flags.Set("root-files", rootFiles)
+			defer Analyzer.Flags.Set("roots", RootsAll)
+			defer Analyzer.Flags.Set("root-symbols", "")
+			defer Analyzer.Flags.Set("root-files", "")
+			RunWithPackages(t, e.Config.Dir, Analyzer, pkgs)
+		})
+	}
+}
+
+func TestAnalysisCache(t *testing.T) {
+	e := packagestest.Export(t, packagestest.Modules, []packagestest.Module{
+		{
+			Name: "work",
+			Files: map[string]interface{}{
+				"x/x.go": `
+			package x
+			import "work/y"
+			func X() { y.Y() }
+			`,
+				"y/y.go": `
+			package y
+			import b "b.com/m/vuln"
+			func Y() { b.Vuln() }
+			`}},
+		{
+			Name: "b.com/m@v1.0.1",
+			Files: map[string]interface{}{
+				"go.mod": `module b.com/m`,
+				"vuln/vuln.go": `
+			package vuln
+			func Vuln() {}
+			`}},
+	})
+	defer e.Cleanup()
+	pkg2vulns := map[string][]*osv.Entry{
+		"b.com/m/vuln": {{
+			ID: "GO02",
+			Affected: []osv.Affected{{
+				Package: osv.Package{Name: "b.com/m", Ecosystem: osv.GoEcosystem},
+				EcosystemSpecific: osv.EcosystemSpecific{
+					Imports: []osv.EcosystemSpecificImport{{Path: "b.com/m/vuln", Symbols: []string{"Vuln"}}},
+				},
+			}},
+		}},
+	}
+	checker.CacheDir = t.TempDir()
+	defer func() { checker.CacheDir = "" }()
+
+	// analyze returns the diagnostics of a fresh load of work/x,
+	// formatted with their positions.
+	analyze := func() []string {
+		pkgs, err := LoadPackages(e, "work/x")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var diags []string
+		for _, r := range checker.Analyze(pkgs, []*analysis.Analyzer{Analyzer}) {
+			if r.Err != nil {
+				t.Fatalf("error analyzing %s: %v", r, r.Err)
+			}
+			for _, d := range r.Diagnostics {
+				diags = append(diags, fmt.Sprintf("%s: %s: %s", r.Package.Fset.Position(d.Pos), d.Category, d.Message))
+			}
+		}
+		return diags
+	}
+
+	setCatalog(t, pkg2vulns)
+	want := analyze()
+	if len(want) == 0 {
+		t.Fatal("no diagnostics")
+	}
+	if entries, err := filepath.Glob(filepath.Join(checker.CacheDir, "*", "*")); err != nil || len(entries) == 0 {
+		t.Fatalf("no cache entries written (%v)", err)
+	}
+	// The same catalog in a new file hits the cache.
+	setCatalog(t, pkg2vulns)
+	if got := analyze(); !reflect.DeepEqual(got, want) {
+		t.Errorf("diagnostics from the cache = %q, want %q", got, want)
+	}
+	// Another catalog does not.
+	setCatalog(t, map[string][]*osv.Entry{})
+	if got := analyze(); len(got) != 0 {
+		t.Errorf("diagnostics without vulnerabilities = %q, want none", got)
+	}
+}
+
+func TestVulnerableTypesAndVars(t *testing.T) {
+	e := packagestest.Export(t, packagestest.Modules, []packagestest.Module{
+		{
+			Name: "work",
+			Files: map[string]interface{}{
+				"p/p.go": `
+			package p
+			import b "b.com/m/vuln"
+			func UseType() { var c *b.Config; _ = c } // want "GO03\\|.*" UseType:"GO03:.*"
+			func UseVar() { _ = b.Default } // want "GO03\\|.*" UseVar:"GO03:.*"
+			func UseField(o b.Other) { _ = o.Default }
+			func UseOK() { _ = b.OK }
+			func UseFunc() { b.F() } // want "GO04\\|.*" UseFunc:"GO04:.*"
+			`}},
+		{
+			Name: "b.com/m@v1.0.1",
+			Files: map[string]interface{}{
+				"go.mod": `module b.com/m`,
+				"vuln/vuln.go": `
+			package vuln
+			type Config struct{}
+			var Default, OK string
+			type Other struct{ Default string }
+			func F() {}
+			`}},
+	})
+	defer e.Cleanup()
+	pkgs, err := LoadPackages(e, "work/p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	setCatalog(t, map[string][]*osv.Entry{
+		"b.com/m/vuln": {{
+			ID: "GO03",
+			Affected: []osv.Affected{{
+				Package: osv.Package{Name: "b.com/m", Ecosystem: osv.GoEcosystem},
+				EcosystemSpecific: osv.EcosystemSpecific{
+					Imports: []osv.EcosystemSpecificImport{{Path: "b.com/m/vuln", Symbols: []string{"Config", "Default"}}},
+				},
+			}},
+		}, {
+			// A whole-package vulnerability affects the
+			// functions of the package, not its types and
+			// variables.
+			ID: "GO04",
+			Affected: []osv.Affected{{
+				Package: osv.Package{Name: "b.com/m", Ecosystem: osv.GoEcosystem},
+				EcosystemSpecific: osv.EcosystemSpecific{
+					Imports: []osv.EcosystemSpecificImport{{Path: "b.com/m/vuln"}},
+				},
+			}},
+		}},
+	})
+	RunWithPackages(t, e.Config.Dir, Analyzer, pkgs)
+}
+
+func TestProvenance(t *testing.T) {
+	e := packagestest.Export(t, packagestest.Modules, []packagestest.Module{
+		{
+			Name: "work",
+			Files: map[string]interface{}{
+				"p/p.go": `
+			package p
+			import b "b.com/m/vuln"
+			func Use() { b.F() } // want "GO05 \\(from https://vuln.example.com, fetched 2022-01-02T00:00:00Z\\)\\|.*" Use:"GO05:.*"
+			`}},
+		{
+			Name: "b.com/m@v1.0.1",
+			Files: map[string]interface{}{
+				"go.mod": `module b.com/m`,
+				"vuln/vuln.go": `
+			package vuln
+			func F() {}
+			`}},
+	})
+	defer e.Cleanup()
+	pkgs, err := LoadPackages(e, "work/p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetched := time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC)
+	vulnsJSONFile, err := DumpCatalog(&Catalog{
+		PkgToVulns: map[string][]*osv.Entry{
+			"b.com/m/vuln": {{
+				ID: "GO05",
+				Affected: []osv.Affected{{
+					Package: osv.Package{Name: "b.com/m", Ecosystem: osv.GoEcosystem},
+					EcosystemSpecific: osv.EcosystemSpecific{
+						Imports: []osv.EcosystemSpecificImport{{Path: "b.com/m/vuln", Symbols: []string{"F"}}},
+					},
+				}},
+			}},
+		},
+		Provenance: map[string]Provenance{
+			"GO05": {Source: "https://vuln.example.com", Fetched: fetched, Modified: fetched},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(vulnsJSONFile) })
+	Analyzer.Flags.Set("vulns-json", vulnsJSONFile)
+	RunWithPackages(t, e.Config.Dir, Analyzer, pkgs)
+}
+
+func TestTypePaths(t *testing.T) {
+	e := packagestest.Export(t, packagestest.Modules, []packagestest.Module{
+		{
+			Name: "work",
+			Files: map[string]interface{}{
+				"p/p.go": `
+			package p
+			import b "b.com/m/vuln"
+			func Construct() { _ = &b.Conn{} } // want "GO04\\|work/p.Construct [^\t]*\tb.com/m/vuln.Conn [^\t]*\tb.com/m/vuln.Conn.Close [^\t]*$" Construct:"GO04:.*"
+			func Close(c *b.Conn) { c.Close() } // want "GO04\\|work/p.Close [^\t]*\tb.com/m/vuln.Conn.Close [^\t]*$" Close:"GO04:.*"
+			`}},
+		{
+			Name: "b.com/m@v1.0.1",
+			Files: map[string]interface{}{
+				"go.mod": `module b.com/m`,
+				"vuln/vuln.go": `
+			package vuln
+			type Conn struct{}
+			func (*Conn) Close() {}
+			`}},
+	})
+	defer e.Cleanup()
+	pkgs, err := LoadPackages(e, "work/p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	setCatalog(t, map[string][]*osv.Entry{
+		"b.com/m/vuln": {{
+			ID: "GO04",
+			Affected: []osv.Affected{{
+				Package: osv.Package{Name: "b.com/m", Ecosystem: osv.GoEcosystem},
+				EcosystemSpecific: osv.EcosystemSpecific{
+					Imports: []osv.EcosystemSpecificImport{{Path: "b.com/m/vuln", Symbols: []string{"Conn.Close"}}},
+				},
+			}},
+		}},
+	})
+	RunWithPackages(t, e.Config.Dir, Analyzer, pkgs)
+}
+
+func TestMethodNameFallback(t *testing.T) {
+	e := packagestest.Export(t, packagestest.Modules, []packagestest.Module{
+		{
+			Name: "work",
+			Files: map[string]interface{}{
+				"p/p.go": `
+			package p
+			import b "b.com/m/vuln"
+			func Close(c *b.Connection) { c.Close() } // want "GO05\\|work/p.Close [^\t]*\tb.com/m/vuln.Connection.Close [^\t]*$" Close:"GO05:.*"
+			func Open(t b.Third) { t.Open() }
+			`}},
+		{
+			Name: "b.com/m@v1.0.1",
+			Files: map[string]interface{}{
+				"go.mod": `module b.com/m`,
+				"vuln/vuln.go": `
+			package vuln
+			type Connection struct{} // formerly Conn
+			func (*Connection) Close() {}
+			type Other struct{}
+			type Third struct{}
+			func (Third) Open() {}
+			`}},
+	})
+	defer e.Cleanup()
+	pkgs, err := LoadPackages(e, "work/p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	setCatalog(t, map[string][]*osv.Entry{
+		"b.com/m/vuln": {{
+			ID: "GO05",
+			Affected: []osv.Affected{{
+				Package: osv.Package{Name: "b.com/m", Ecosystem: osv.GoEcosystem},
+				EcosystemSpecific: osv.EcosystemSpecific{
+					Imports: []osv.EcosystemSpecificImport{{Path: "b.com/m/vuln", Symbols: []string{"Conn.Close", "Other.Open"}}},
+				},
+			}},
+		}},
+	})
+	Analyzer.Flags.Set("method-name-fallback", "true")
+	defer Analyzer.Flags.Set("method-name-fallback", "false")
+	RunWithPackages(t, e.Config.Dir, Analyzer, pkgs)
+}
+
+func TestRenamedReceiver(t *testing.T) {
+	e := &osv.Entry{Affected: []osv.Affected{{
+		EcosystemSpecific: osv.EcosystemSpecific{
+			Imports: []osv.EcosystemSpecificImport{{Path: "b.com/m/vuln", Symbols: []string{"Conn.Close", "Other.Open"}}},
+		},
+	}}}
+	for _, tc := range []struct {
+		sym  SymbolID
+		want bool
+	}{
+		{SymbolID{PkgPath: "b.com/m/vuln", Recv: "Connection", Name: "Close"}, true},
+		{SymbolID{PkgPath: "b.com/m/vuln", Recv: "Conn", Name: "Close"}, false},
+		{SymbolID{PkgPath: "b.com/m/vuln", Recv: "Other", Name: "Close"}, true},
+		{SymbolID{PkgPath: "b.com/m/vuln", Name: "Close"}, false},
+		{SymbolID{PkgPath: "b.com/m/other", Recv: "Connection", Name: "Close"}, false},
+	} {
+		if got := RenamedReceiver(e, tc.sym); got != tc.want {
+			t.Errorf("RenamedReceiver(%v) = %v, want %v", tc.sym, got, tc.want)
+		}
+	}
+}
+
+func TestViaType(t *testing.T) {
+	for _, tc := range []struct {
+		path []string
+		want bool
+	}{
+		{[]string{"work/p.F p.go:1:1", "b.com/m/vuln.Conn vuln.go:2:6", "b.com/m/vuln.Conn.Close vuln.go:3:1"}, true},
+		{[]string{"work/p.F p.go:1:1", "b.com/m/vuln.Conn.Close vuln.go:3:1"}, false},
+		{[]string{"b.com/m/vuln p.go:2:8", "b.com/m/vuln.init vuln.go:3:6"}, false},
+	} {
+		if got := ViaType(tc.path); got != tc.want {
+			t.Errorf("ViaType(%q) = %v, want %v", tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestAssembly(t *testing.T) {
+	e := packagestest.Export(t, packagestest.Modules, []packagestest.Module{
+		{
+			Name: "work",
+			Files: map[string]interface{}{
+				"p/p.go": `
+			package p
+			func asmVuln() // want "GO06\\|work/p.asmVuln [^\t]*\tb.com/m/vuln.Vuln$"
+			func asmOK()
+			func F() { asmVuln() } // want "GO06\\|.*" F:"GO06:.*"
+			func G() { asmOK() }
+			`,
+				"p/p.s": `
+#include "textflag.h"
+
+TEXT ·asmVuln(SB),NOSPLIT,$0
+	MOVQ $b∕com∕m∕vuln·OK(SB), AX
+	JMP b.com∕m∕vuln·Vuln(SB)
+
+TEXT ·asmOK(SB),NOSPLIT,$0
+	CALL b.com∕m∕vuln·OK(SB)
+	RET
+`}},
+		{
+			Name: "b.com/m@v1.0.1",
+			Files: map[string]interface{}{
+				"go.mod": `module b.com/m`,
+				"vuln/vuln.go": `
+			package vuln
+			func Vuln() {}
+			func OK() {}
+			`}},
+	})
+	defer e.Cleanup()
+	pkgs, err := LoadPackages(e, "work/p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	setCatalog(t, map[string][]*osv.Entry{
+		"b.com/m/vuln": {{
+			ID: "GO06",
+			Affected: []osv.Affected{{
+				Package: osv.Package{Name: "b.com/m", Ecosystem: osv.GoEcosystem},
+				EcosystemSpecific: osv.EcosystemSpecific{
+					Imports: []osv.EcosystemSpecificImport{{Path: "b.com/m/vuln", Symbols: []string{"Vuln"}}},
+				},
+			}},
+		}},
+	})
+	RunWithPackages(t, e.Config.Dir, Analyzer, pkgs)
+}
+
+func TestViaLinkname(t *testing.T) {
+	for _, tc := range []struct {
+		path []string
+		want bool
+	}{
+		{[]string{"work/p.F p.go:3:6", "work/p.vuln p.go:2:6", "b.com/m/vuln.Vuln"}, true},
+		{[]string{"work/p.F p.go:3:6", "b.com/m/vuln.Vuln vuln.go:2:6"}, false},
+		{[]string{"b.com/m/vuln", "b.com/m/vuln.init vuln.go:3:6"}, false},
+		{[]string{"b.com/m/vuln.Vuln"}, false},
+	} {
+		if got := ViaLinkname(tc.path); got != tc.want {
+			t.Errorf("ViaLinkname(%q) = %v, want %v", tc.path, got, tc.want)
+		}
+	}
+}
+
+// BenchmarkAnalyzer measures the analysis of a package whose
+// reference graph is large but has few paths to vulnerable symbols.
+func BenchmarkAnalyzer(b *testing.B) {
+	var src strings.Builder
+	src.WriteString("package p\nimport v \"b.com/m/vuln\"\n")
+	const n = 500
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&src, "func F%d() { F%d(); v.OK() }\n", i, i+1)
+	}
+	fmt.Fprintf(&src, "func F%d() { v.OK() }\n", n)
+	src.WriteString("func G() { F0(); v.Vuln() }\n")
+	e := packagestest.Export(b, packagestest.Modules, []packagestest.Module{
+		{Name: "work", Files: map[string]interface{}{"p/p.go": src.String()}},
+		{
+			Name: "b.com/m@v1.0.1",
+			Files: map[string]interface{}{
+				"go.mod": `module b.com/m`,
+				"vuln/vuln.go": `
+			package vuln
+			func Vuln() {}
+			func OK() {}
+			`}},
+	})
+	defer e.Cleanup()
+	pkgs, err := LoadPackages(e, "work/p")
+	if err != nil {
+		b.Fatal(err)
+	}
+	vulnsJSONFile, err := DumpVulnInfo(map[string][]*osv.Entry{
+		"b.com/m/vuln": {{
+			ID: "GO02",
+			Affected: []osv.Affected{{
+				Package: osv.Package{Name: "b.com/m", Ecosystem: osv.GoEcosystem},
+				EcosystemSpecific: osv.EcosystemSpecific{
+					Imports: []osv.EcosystemSpecificImport{{Path: "b.com/m/vuln", Symbols: []string{"Vuln"}}},
+				},
+			}},
+		}},
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(vulnsJSONFile)
+	Analyzer.Flags.Set("vulns-json", vulnsJSONFile)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, r := range checker.TestAnalyzer(Analyzer, pkgs) {
+			if r.Err != nil {
+				b.Fatal(r.Err)
+			}
+		}
+	}
+}
+
+func TestFactVersionCompatibility(t *testing.T) {
+	path := map[string][]string{"GO02:b.com/m/vuln Vuln": {"b.com/m/vuln.Vuln vuln.go:3:9"}}
+	type legacyFact struct { // before versioning
+		Path map[string][]string
+	}
+	type futureFact struct { // a newer encoding with an additional field
+		FactVersion int
+		Path        map[string][]string
+		Depth       int
+	}
+	for _, tc := range []struct {
+		name string
+		in   interface{}
+		want bool
+	}{
+		{"current", newVulnFact(path), true},
+		{"legacy", &legacyFact{Path: path}, false},
+		{"future", &futureFact{FactVersion: FactVersion + 1, Path: path, Depth: 3}, false},
+	} {
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(tc.in); err != nil {
+			t.Fatal(err)
+		}
+		var got vulnFact
+		if err := gob.NewDecoder(&buf).Decode(&got); err != nil {
+			t.Errorf("%s: decoding failed: %v", tc.name, err)
+			continue
+		}
+		if compatibleFact(got.FactVersion) != tc.want {
+			t.Errorf("%s: compatibleFact(%d) = %v, want %v", tc.name, got.FactVersion, !tc.want, tc.want)
+		}
+		if !reflect.DeepEqual(got.Path, path) {
+			t.Errorf("%s: decoded path = %v, want %v", tc.name, got.Path, path)
+		}
+	}
+}
+
+// setCatalog makes the analyzer use the given catalog.
+func setCatalog(t *testing.T, pkg2vulns map[string][]*osv.Entry) {
+	vulnsJSONFile, err := DumpVulnInfo(pkg2vulns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(vulnsJSONFile) })
+	Analyzer.Flags.Set("vulns-json", vulnsJSONFile)
+}
+
 func LoadPackages(e *packagestest.Exported, patterns ...string) ([]*packages.Package, error) {
 	e.Config.Mode |= packages.NeedModule | packages.NeedName | packages.NeedFiles |
 		packages.NeedCompiledGoFiles | packages.NeedImports | packages.NeedTypes |
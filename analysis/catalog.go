@@ -0,0 +1,229 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyangah/vulns/internal/osvutil"
+	"golang.org/x/vuln/osv"
+)
+
+// CatalogVersion is the version of the catalog file format
+// written by WriteCatalog.
+//
+// A catalog file is a JSON object of the form
+//
+//	{
+//	  "Version": 1,
+//	  "Packages": {
+//	    "example.com/m/p": [<OSV entry>, ...],
+//	    ...
+//	  }
+//	}
+//
+// where Packages maps each vulnerable package path to the OSV entries
+// affecting it, in the OSV JSON schema (https://ossf.github.io/osv-schema/).
+// An optional "Provenance" object maps the IDs of the entries to where
+// they came from (see Provenance).
+// The entries should be limited to the module versions and the platform
+// being analyzed; the analyzer does not filter them. Files consisting of
+// only the Packages object, written by older versions of this package,
+// are read as well.
+const CatalogVersion = 1
+
+// Catalog is the list of osv entries.
+type Catalog struct {
+	PkgToVulns map[string][]*osv.Entry
+	// Provenance maps the IDs of the entries to where they came
+	// from, if known. The diagnostics of the analyzer name the
+	// source of the entries that have one.
+	Provenance map[string]Provenance
+	Err        error
+
+	// TODO(hyangah): ID to vulns to report details about detected vulnerability
+	// (short description, href, fixed version)
+}
+
+// Provenance records where an OSV entry came from.
+type Provenance = osvutil.Provenance
+
+// catalogFile is the encoding of a catalog file.
+type catalogFile struct {
+	Version    int
+	Packages   map[string][]*osv.Entry
+	Provenance map[string]Provenance `json:",omitempty"`
+}
+
+// WriteCatalog writes the catalog in the catalog file format.
+func WriteCatalog(w io.Writer, c *Catalog) error {
+	return json.NewEncoder(w).Encode(catalogFile{Version: CatalogVersion, Packages: c.PkgToVulns, Provenance: c.Provenance})
+}
+
+// ReadCatalog reads the catalog file.
+func ReadCatalog(path string) (*Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("invalid catalog file %s: %v", path, err)
+	}
+	if _, ok := fields["Version"]; !ok {
+		// Unversioned file holding only the packages.
+		var pkg2vulns map[string][]*osv.Entry
+		if err := json.Unmarshal(data, &pkg2vulns); err != nil {
+			return nil, fmt.Errorf("invalid catalog file %s: %v", path, err)
+		}
+		return &Catalog{PkgToVulns: pkg2vulns}, nil
+	}
+	var cf catalogFile
+	if err := json.Unmarshal(data, &cf); err != nil {
+		return nil, fmt.Errorf("invalid catalog file %s: %v", path, err)
+	}
+	if cf.Version != CatalogVersion {
+		return nil, fmt.Errorf("catalog file %s has unsupported version %d (want %d)", path, cf.Version, CatalogVersion)
+	}
+	return &Catalog{PkgToVulns: cf.Packages, Provenance: cf.Provenance}, nil
+}
+
+// A CatalogChange is a vulnerable symbol, or a whole vulnerable
+// package, that a catalog gained or lost (see DiffCatalogs).
+type CatalogChange struct {
+	Added  bool // false if removed
+	ID     string
+	Symbol SymbolID // the Name is empty if the whole package is vulnerable
+}
+
+// DiffCatalogs returns the vulnerable symbols and packages, with the
+// vulnerabilities affecting them, that are in only one of the old
+// and new catalogs, sorted by symbol and then by ID. Changes to the
+// entries that do not affect which symbols are vulnerable, such as to
+// their descriptions, do not matter to the analyzer and are ignored.
+func DiffCatalogs(old, new *Catalog) []CatalogChange {
+	before, after := catalogSymbols(old), catalogSymbols(new)
+	var changes []CatalogChange
+	for c := range before {
+		if !after[c] {
+			changes = append(changes, c)
+		}
+	}
+	for c := range after {
+		if !before[c] {
+			c.Added = true
+			changes = append(changes, c)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		ci, cj := changes[i], changes[j]
+		if ci.Symbol != cj.Symbol {
+			return ci.Symbol.String() < cj.Symbol.String()
+		}
+		return ci.ID < cj.ID
+	})
+	return changes
+}
+
+// catalogSymbols returns the vulnerable symbols of the catalog, as
+// changes with Added unset.
+func catalogSymbols(c *Catalog) map[CatalogChange]bool {
+	syms := make(map[CatalogChange]bool)
+	for pkg, vulns := range c.PkgToVulns {
+		for _, v := range vulns {
+			names := affectedSymbols(pkg, v)
+			if len(names) == 0 {
+				syms[CatalogChange{ID: v.ID, Symbol: SymbolID{PkgPath: pkg}}] = true
+			}
+			for _, name := range names {
+				sym := SymbolID{PkgPath: pkg, Name: name}
+				if recv, name, ok := strings.Cut(name, "."); ok {
+					sym.Recv, sym.Name = recv, name
+				}
+				syms[CatalogChange{ID: v.ID, Symbol: sym}] = true
+			}
+		}
+	}
+	return syms
+}
+
+// Refresh repopulates the Catalog.
+func (c *Catalog) Refresh() {
+	if vulnsJSONFile != "" {
+		catalog.readFile(vulnsJSONFile)
+	} else {
+		catalog.Err = errors.New("catalog not initialized")
+	}
+	if catalog.Err != nil {
+		log.Printf("catalog initialization failed: %v", catalog.Err)
+	}
+}
+
+// ReloadCatalog makes the analyzer read its catalog file again before
+// analyzing the next package. Long-running programs embedding the
+// analyzer call it when the file was rewritten in place, e.g. on SIGHUP.
+// Facts and other state cached by the driver are kept.
+func ReloadCatalog() {
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+	loaded = false
+}
+
+func (c *Catalog) readFile(catalogFile string) {
+	read, err := ReadCatalog(catalogFile)
+	if err != nil {
+		c.Err = err
+		return
+	}
+	c.PkgToVulns = read.PkgToVulns
+	c.Provenance = read.Provenance
+	c.Err = nil
+}
+
+// describe returns the vulnerability with the ID as the diagnostics
+// of the analyzer name it: the ID, followed by the source of its
+// entry and the time it was fetched, if known.
+func (c *Catalog) describe(id string) string {
+	p, ok := c.Provenance[id]
+	if !ok {
+		return id
+	}
+	return fmt.Sprintf("%s (from %s, fetched %s)", id, p.Source, p.Fetched.UTC().Format(time.RFC3339))
+}
+
+// DumpVulnInfo writes the provided osv entry list to a temporary
+// catalog file and returns the file name.
+func DumpVulnInfo(pkg2vulns map[string][]*osv.Entry) (fname string, err error) {
+	return DumpCatalog(&Catalog{PkgToVulns: pkg2vulns})
+}
+
+// DumpCatalog is like DumpVulnInfo, but writes the catalog, with the
+// provenance of its entries.
+func DumpCatalog(c *Catalog) (fname string, err error) {
+	vulnsFile, err := ioutil.TempFile("", "vuln")
+	if err != nil {
+		return "", fmt.Errorf("failed to create a temp file: %v", err)
+	}
+	defer func() {
+		err2 := vulnsFile.Close()
+		if err == nil && err2 != nil {
+			fname, err = "", err2
+		}
+	}()
+
+	if err := WriteCatalog(vulnsFile, c); err != nil {
+		return "", fmt.Errorf("failed to encode module vulnerability info: %v", err)
+	}
+	return vulnsFile.Name(), nil
+}
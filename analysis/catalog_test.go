@@ -0,0 +1,126 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hyangah/vulns/testutils"
+	"golang.org/x/vuln/osv"
+)
+
+func TestCatalogFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0666); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	var buf bytes.Buffer
+	fetched := time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC)
+	want := &Catalog{
+		PkgToVulns: map[string][]*osv.Entry{"example.com/m/p": {{ID: "GO-2022-0001"}}},
+		Provenance: map[string]Provenance{"GO-2022-0001": {Source: "https://vuln.go.dev", Fetched: fetched, Modified: fetched}},
+	}
+	if err := WriteCatalog(&buf, want); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name, content string
+		wantErr       bool
+	}{
+		{"current", buf.String(), false},
+		{"legacy", `{"example.com/m/p": [{"id": "GO-2022-0001"}]}`, false},
+		{"future", `{"Version": 2, "Packages": {}}`, true},
+		{"invalid", `[]`, true},
+	} {
+		got, err := ReadCatalog(write(tc.name+".json", tc.content))
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: ReadCatalog error = %v, want error %v", tc.name, err, tc.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if vulns := got.PkgToVulns["example.com/m/p"]; len(vulns) != 1 || vulns[0].ID != "GO-2022-0001" {
+			t.Errorf("%s: ReadCatalog = %v", tc.name, got.PkgToVulns)
+		}
+		if tc.name == "current" && !reflect.DeepEqual(got.Provenance, want.Provenance) {
+			t.Errorf("%s: ReadCatalog provenance = %v, want %v", tc.name, got.Provenance, want.Provenance)
+		}
+	}
+}
+
+func TestReloadCatalog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "catalog.json")
+	write := func(id string) {
+		var buf bytes.Buffer
+		c := &Catalog{PkgToVulns: map[string][]*osv.Entry{"example.com/m/p": {{ID: id}}}}
+		if err := WriteCatalog(&buf, c); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, buf.Bytes(), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	id := func() string {
+		c := loadCatalog()
+		if c.Err != nil {
+			t.Fatal(c.Err)
+		}
+		return c.PkgToVulns["example.com/m/p"][0].ID
+	}
+
+	write("GO-2022-0001")
+	Analyzer.Flags.Set("vulns-json", path)
+	if got := id(); got != "GO-2022-0001" {
+		t.Fatalf("loaded %s, want GO-2022-0001", got)
+	}
+	write("GO-2022-0002")
+	if got := id(); got != "GO-2022-0001" {
+		t.Errorf("loaded %s before reload, want the cached GO-2022-0001", got)
+	}
+	ReloadCatalog()
+	if got := id(); got != "GO-2022-0002" {
+		t.Errorf("loaded %s after reload, want GO-2022-0002", got)
+	}
+}
+
+func TestDiffCatalogs(t *testing.T) {
+	withSymbols := func(e *osv.Entry, symbols ...string) *osv.Entry {
+		e.Affected[0].EcosystemSpecific.Imports[0].Symbols = symbols
+		return e
+	}
+	old := &Catalog{PkgToVulns: map[string][]*osv.Entry{
+		"example.com/m/p": {withSymbols(testutils.Entry("GO-2022-0001", "example.com/m", "", "example.com/m/p"), "F", "T.M")},
+		"example.com/m/q": {testutils.Entry("GO-2022-0002", "example.com/m", "", "example.com/m/q")},
+	}}
+	updated := withSymbols(testutils.Entry("GO-2022-0001", "example.com/m", "", "example.com/m/p"), "F", "G")
+	updated.Details = "Not relevant to the analyzer."
+	new := &Catalog{PkgToVulns: map[string][]*osv.Entry{
+		"example.com/m/p": {updated},
+		"example.com/m/r": {withSymbols(testutils.Entry("GO-2022-0003", "example.com/m", "", "example.com/m/r"), "H")},
+	}}
+	want := []CatalogChange{
+		{Added: true, ID: "GO-2022-0001", Symbol: SymbolID{PkgPath: "example.com/m/p", Name: "G"}},
+		{Added: false, ID: "GO-2022-0001", Symbol: SymbolID{PkgPath: "example.com/m/p", Recv: "T", Name: "M"}},
+		{Added: false, ID: "GO-2022-0002", Symbol: SymbolID{PkgPath: "example.com/m/q"}},
+		{Added: true, ID: "GO-2022-0003", Symbol: SymbolID{PkgPath: "example.com/m/r", Name: "H"}},
+	}
+	if got := DiffCatalogs(old, new); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffCatalogs = %+v, want %+v", got, want)
+	}
+	if got := DiffCatalogs(old, old); len(got) != 0 {
+		t.Errorf("DiffCatalogs of the same catalog = %+v, want none", got)
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import "strings"
+
+// Drivers analyzing a build in stages, such as build systems running
+// the analyzer in an action per package, run it on every package with
+// the same flags, and would print the findings of each dependency
+// along with the ones of the packages they build. With -facts-only,
+// or for the packages not listed in -report-packages, a pass exports
+// its facts but reports nothing. The passes of the packages listed in
+// -report-packages, such as the main packages, materialize the
+// diagnostics from the facts they import, in a final step.
+var (
+	factsOnly      = false
+	reportPackages = ""
+)
+
+func init() {
+	Analyzer.Flags.BoolVar(&factsOnly, "facts-only", factsOnly, "export the facts of the packages without reporting diagnostics, as for the dependencies analyzed by multi-stage drivers")
+	Analyzer.Flags.StringVar(&reportPackages, "report-packages", reportPackages, "comma-separated list of the paths of the packages, or path prefixes ending in /..., whose diagnostics are reported; the other packages only export facts")
+}
+
+// reports reports whether the pass of the package with the path
+// reports diagnostics. The external test package of a package is
+// reported along with it.
+func reports(pkgPath string) bool {
+	if factsOnly {
+		return false
+	}
+	if reportPackages == "" {
+		return true
+	}
+	pkgPath = strings.TrimSuffix(pkgPath, "_test")
+	for _, p := range splitList(reportPackages) {
+		if prefix := strings.TrimSuffix(p, "/..."); prefix != p {
+			if pkgPath == prefix || strings.HasPrefix(pkgPath, prefix+"/") {
+				return true
+			}
+		} else if pkgPath == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/packages/packagestest"
+	"golang.org/x/vuln/osv"
+)
+
+// TestGraphEdges checks that references to vulnerable symbols are
+// found through the various ways Go code can reach a function.
+// Each case is the source of package work/p, with 'want' comments
+// as for RunWithPackages.
+func TestGraphEdges(t *testing.T) {
+	vuln := packagestest.Module{
+		Name: "b.com/m@v1.0.1",
+		Files: map[string]interface{}{
+			"go.mod": `module b.com/m`,
+			"vuln/vuln.go": `
+			package vuln
+			func Vuln() {}
+			func OK() {}
+			type Conn struct{}
+			func (*Conn) Close() {}
+			func (*Conn) Read() {}
+			func Generic[T any](T) { Vuln() }
+			`}}
+	setCatalog(t, map[string][]*osv.Entry{
+		"b.com/m/vuln": {{
+			ID: "GO05",
+			Affected: []osv.Affected{{
+				Package: osv.Package{Name: "b.com/m", Ecosystem: osv.GoEcosystem},
+				EcosystemSpecific: osv.EcosystemSpecific{
+					Imports: []osv.EcosystemSpecificImport{{Path: "b.com/m/vuln", Symbols: []string{"Vuln", "Conn.Close"}}},
+				},
+			}},
+		}},
+	})
+	for _, tc := range []struct {
+		name, src string
+	}{
+		{"call", `
+			package p
+			import b "b.com/m/vuln"
+			func F() { b.Vuln() } // want "GO05\\|.*" F:"GO05:.*"
+			func G() { b.OK() }
+			`},
+		{"value", `
+			package p
+			import b "b.com/m/vuln"
+			func F() { f := b.Vuln; f() } // want "GO05\\|.*" F:"GO05:.*"
+			`},
+		{"method expression", `
+			package p
+			import b "b.com/m/vuln"
+			func F() { _ = (*b.Conn).Close } // want "GO05\\|.*" F:"GO05:.*"
+			// Using the type reaches its vulnerable methods.
+			func G() { _ = (*b.Conn).Read } // want "GO05\\|work/p.G .*\tb.com/m/vuln.Conn " G:"GO05:.*"
+			`},
+		{"closure", `
+			package p
+			import b "b.com/m/vuln"
+			func F() { func() { b.Vuln() }() } // want "GO05\\|.*" F:"GO05:.*"
+			`},
+		{"defer and go", `
+			package p
+			import b "b.com/m/vuln"
+			func F() { defer b.Vuln() } // want "GO05\\|.*" F:"GO05:.*"
+			func G() { go b.Vuln() } // want "GO05\\|.*" G:"GO05:.*"
+			`},
+		{"promoted method", `
+			package p
+			import b "b.com/m/vuln"
+			type W struct{ *b.Conn } // want "GO05\\|.*" W:"GO05:.*"
+			func F(w W) { w.Close() } // want "GO05\\|.*" F:"GO05:.*"
+			`},
+		{"generic", `
+			package p
+			import b "b.com/m/vuln"
+			func F() { b.Generic(1) } // want "GO05\\|.*" F:"GO05:.*"
+			`},
+		{"linkname", `
+			package p
+			import _ "unsafe"
+			//go:linkname vuln b.com/m/vuln.Vuln
+			func vuln() // want "GO05\\|work/p.vuln [^\t]*\tb.com/m/vuln.Vuln$"
+			func F() { vuln() } // want "GO05\\|.*" F:"GO05:.*"
+			`},
+		{"transitive", `
+			package p
+			import b "b.com/m/vuln"
+			func F() { G() } // want "GO05\\|.*" F:"GO05:.*"
+			func G() { b.Vuln() } // want "GO05\\|.*" G:"GO05:.*"
+			`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pkgs, err := loadInMemory([]packagestest.Module{
+				{Name: "work", Files: map[string]interface{}{"p/p.go": tc.src}},
+				vuln,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			RunWithPackages(t, "", Analyzer, pkgs)
+		})
+	}
+}
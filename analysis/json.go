@@ -0,0 +1,90 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"io"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// A JSONTree is the object vet and the analysis drivers print under
+// -json, mapping package IDs to analyzer names to either a list of
+// JSONDiagnostic or a JSONError. Drivers embedding Analyzer add the
+// diagnostics of each package with Add and print the tree with Write,
+// so that triage tools consuming vet output can read theirs as well.
+type JSONTree map[string]map[string]interface{}
+
+// A JSONDiagnostic is a diagnostic in a JSONTree. The Category of the
+// diagnostics of Analyzer is parsed by ParseCategory.
+type JSONDiagnostic struct {
+	Category string                   `json:"category,omitempty"`
+	Posn     string                   `json:"posn"`
+	Message  string                   `json:"message"`
+	Related  []JSONRelatedInformation `json:"related,omitempty"`
+}
+
+// A JSONRelatedInformation is the related information of a
+// JSONDiagnostic, such as where the drivers folding near-duplicate
+// diagnostics found the ones they folded.
+type JSONRelatedInformation struct {
+	Posn    string `json:"posn"`
+	Message string `json:"message"`
+}
+
+// A JSONError is the result in a JSONTree of an analysis that failed.
+type JSONError struct {
+	Err string `json:"error"`
+}
+
+// Add adds the result of the analyzer name on the package id: the
+// diagnostics, with their positions in fset, or the error if err is
+// not nil. Analyses without diagnostics are left out, as in vet.
+func (tree JSONTree) Add(fset *token.FileSet, id, name string, diags []analysis.Diagnostic, err error) {
+	var v interface{}
+	if err != nil {
+		v = JSONError{err.Error()}
+	} else if len(diags) > 0 {
+		var diagnostics []JSONDiagnostic
+		for _, d := range diags {
+			var related []JSONRelatedInformation
+			for _, r := range d.Related {
+				related = append(related, JSONRelatedInformation{
+					Posn:    fset.Position(r.Pos).String(),
+					Message: r.Message,
+				})
+			}
+			diagnostics = append(diagnostics, JSONDiagnostic{
+				Category: d.Category,
+				Posn:     fset.Position(d.Pos).String(),
+				Message:  d.Message,
+				Related:  related,
+			})
+		}
+		v = diagnostics
+	}
+	if v == nil {
+		return
+	}
+	m, ok := tree[id]
+	if !ok {
+		m = make(map[string]interface{})
+		tree[id] = m
+	}
+	m[name] = v
+}
+
+// Write writes the tree to w as indented JSON.
+func (tree JSONTree) Write(w io.Writer) error {
+	data, err := json.MarshalIndent(tree, "", "\t")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
@@ -0,0 +1,52 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"bytes"
+	"errors"
+	"go/token"
+	"testing"
+
+	"github.com/hyangah/vulns/internal/xtools/analysisflags"
+	"golang.org/x/tools/go/analysis"
+)
+
+// TestJSONTree checks that JSONTree encodes the results as the
+// checker does under -json.
+func TestJSONTree(t *testing.T) {
+	fset := token.NewFileSet()
+	f := fset.AddFile("a.go", -1, 100)
+	f.SetLines([]int{0, 10, 20})
+	diags := []analysis.Diagnostic{{
+		Pos:      f.Pos(12),
+		Category: "GO-2022-0001",
+		Message:  "example.com/m/p.F is reachable",
+		Related:  []analysis.RelatedInformation{{Pos: f.Pos(22), Message: "folded"}},
+	}}
+
+	tree := make(JSONTree)
+	tree.Add(fset, "example.com/a", "vulns", diags, nil)
+	tree.Add(fset, "example.com/b", "vulns", nil, errors.New("failed"))
+	tree.Add(fset, "example.com/c", "vulns", nil, nil)
+	want := make(analysisflags.JSONTree)
+	want.Add(fset, "example.com/a", "vulns", diags, nil)
+	want.Add(fset, "example.com/b", "vulns", nil, errors.New("failed"))
+	want.Add(fset, "example.com/c", "vulns", nil, nil)
+
+	var got, wantOut bytes.Buffer
+	if err := tree.Write(&got); err != nil {
+		t.Fatal(err)
+	}
+	if err := want.Write(&wantOut); err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != wantOut.String() {
+		t.Errorf("Write =\n%s\nwant\n%s", got.String(), wantOut.String())
+	}
+	if _, ok := tree["example.com/c"]; ok {
+		t.Errorf("Add of a result without diagnostics added the package")
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"go/types"
+	"os"
+	"regexp"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// hiddenRefs returns the symbols of other packages that the members
+// of the package of the pass refer to in ways the reference graph
+// does not see: go:linkname directives pulling in a symbol, and the
+// CALL and JMP instructions of the assembly implementing a function.
+// The detection is best effort. Method targets are ignored.
+func hiddenRefs(pass *analysis.Pass) map[types.Object][]SymbolID {
+	refs := make(map[types.Object][]SymbolID)
+	scope := pass.Pkg.Scope()
+	add := func(local string, sym SymbolID) {
+		if sym.PkgPath == pass.Pkg.Path() || sym.Recv != "" {
+			return
+		}
+		if obj := scope.Lookup(local); obj != nil {
+			refs[obj] = append(refs[obj], sym)
+		}
+	}
+	for _, f := range pass.Files {
+		for _, cg := range f.Comments {
+			for _, c := range cg.List {
+				// //go:linkname localname importpath.name
+				fields := strings.Fields(c.Text)
+				if len(fields) != 3 || fields[0] != "//go:linkname" {
+					continue
+				}
+				if sym, ok := ParseSymbolID(fields[2]); ok {
+					add(fields[1], sym)
+				}
+			}
+		}
+	}
+	for _, name := range pass.OtherFiles {
+		if !strings.HasSuffix(name, ".s") {
+			continue
+		}
+		data, err := os.ReadFile(name)
+		if err != nil {
+			continue
+		}
+		local := "" // the function whose body is being read
+		for _, line := range strings.Split(string(data), "\n") {
+			if m := asmTextRE.FindStringSubmatch(line); m != nil {
+				local = ""
+				if pkg := asmPackage(m[1]); pkg == "" || pkg == pass.Pkg.Path() {
+					local = m[2]
+				}
+				continue
+			}
+			if local == "" || !asmBranchRE.MatchString(line) {
+				continue
+			}
+			for _, m := range asmSymbolRE.FindAllStringSubmatch(line, -1) {
+				if pkg := asmPackage(m[1]); pkg != "" {
+					add(local, SymbolID{PkgPath: pkg, Name: m[2]})
+				}
+			}
+		}
+	}
+	return refs
+}
+
+var (
+	// asmTextRE matches the TEXT directive starting a function,
+	// such as "TEXT ·Syscall(SB),NOSPLIT,$0-56".
+	asmTextRE = regexp.MustCompile(`^\s*TEXT\s+([^\s(),$·]*)·(\w+)(?:<[^>]*>)?\(SB\)`)
+	// asmBranchRE matches the instructions transferring control.
+	asmBranchRE = regexp.MustCompile(`^\s*(?:\w+:\s*)?(?:CALL|JMP|B|BL|BR|JAL|TAIL)\s`)
+	// asmSymbolRE matches a reference to the symbol of a package,
+	// such as "runtime·entersyscall(SB)".
+	asmSymbolRE = regexp.MustCompile(`([^\s(),$·]*)·(\w+)(?:<[^>]*>)?\(SB\)`)
+)
+
+// asmPackage returns the import path in the package part of an
+// assembly symbol, in which the slashes are division slashes.
+func asmPackage(s string) string {
+	return strings.ReplaceAll(s, "∕", "/")
+}
+
+// ViaLinkname reports whether the reference path reported by the
+// analyzer ends with a symbol reached through a go:linkname directive
+// or assembly. Such frames have no position: the analyzer does not
+// see the declaration of the symbol. The detection of these
+// references is best effort, so such paths are less certain than
+// paths of references.
+func ViaLinkname(path []string) bool {
+	if len(path) < 2 {
+		return false
+	}
+	last := path[len(path)-1]
+	if strings.Contains(last, " ") {
+		return false
+	}
+	_, ok := ParseSymbolID(last)
+	return ok
+}
@@ -0,0 +1,142 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path"
+	"runtime"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/packages/packagestest"
+)
+
+// loadInMemory is a fast alternative to packagestest.Export and
+// LoadPackages for analyzer unit tests. It parses and type-checks
+// the Go files of the modules in memory, without writing them to
+// disk or running the go command, and returns the packages of the
+// first module, sorted by path, with their in-memory dependencies.
+//
+// The modules are described as for packagestest.Export: each is
+// named "path" or "path@version", and its Files map file names
+// relative to the module root to their contents, which must be
+// strings. Other files, such as go.mod, are ignored. Imports of
+// packages outside the modules are type-checked from the sources
+// in GOROOT; the analyzer does not visit those packages.
+//
+// As no files exist on disk, the module of a package cannot be
+// determined from its go.mod file, so tests of -module-facts
+// still need packagestest.
+func loadInMemory(modules []packagestest.Module) ([]*packages.Package, error) {
+	if len(modules) == 0 {
+		return nil, fmt.Errorf("no modules")
+	}
+	fset := token.NewFileSet()
+	byPath := make(map[string]*packages.Package)
+	for i, m := range modules {
+		modPath, version, _ := strings.Cut(m.Name, "@")
+		mod := &packages.Module{Path: modPath, Version: version, Main: i == 0}
+		for name, content := range m.Files {
+			if !strings.HasSuffix(name, ".go") {
+				continue
+			}
+			src, ok := content.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s/%s: contents of type %T, want string", m.Name, name, content)
+			}
+			pkgPath := path.Join(modPath, path.Dir(name))
+			filename := path.Join(m.Name, name)
+			f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+			if err != nil {
+				return nil, err
+			}
+			p := byPath[pkgPath]
+			if p == nil {
+				p = &packages.Package{ID: pkgPath, PkgPath: pkgPath, Module: mod, Fset: fset, Imports: make(map[string]*packages.Package)}
+				byPath[pkgPath] = p
+			}
+			p.GoFiles = append(p.GoFiles, filename)
+			p.Syntax = append(p.Syntax, f)
+		}
+	}
+
+	std := importer.ForCompiler(fset, "source", nil)
+	sizes := types.SizesFor("gc", runtime.GOARCH)
+	checking := make(map[string]bool)
+	var check func(p *packages.Package) error
+	check = func(p *packages.Package) error {
+		if p.Types != nil {
+			return nil
+		}
+		if checking[p.PkgPath] {
+			return fmt.Errorf("import cycle through %s", p.PkgPath)
+		}
+		checking[p.PkgPath] = true
+		// Type-check the files in a deterministic order.
+		sort.Sort(byFilename{p})
+		p.CompiledGoFiles = p.GoFiles
+		p.Name = p.Syntax[0].Name.Name
+		p.TypesSizes = sizes
+		p.TypesInfo = &types.Info{
+			Types:      make(map[ast.Expr]types.TypeAndValue),
+			Defs:       make(map[*ast.Ident]types.Object),
+			Uses:       make(map[*ast.Ident]types.Object),
+			Implicits:  make(map[ast.Node]types.Object),
+			Selections: make(map[*ast.SelectorExpr]*types.Selection),
+			Scopes:     make(map[ast.Node]*types.Scope),
+			Instances:  make(map[*ast.Ident]types.Instance),
+		}
+		conf := &types.Config{
+			Sizes: sizes,
+			Importer: importerFunc(func(importPath string) (*types.Package, error) {
+				dep := byPath[importPath]
+				if dep == nil {
+					return std.Import(importPath)
+				}
+				if err := check(dep); err != nil {
+					return nil, err
+				}
+				p.Imports[importPath] = dep
+				return dep.Types, nil
+			}),
+		}
+		var err error
+		p.Types, err = conf.Check(p.PkgPath, fset, p.Syntax, p.TypesInfo)
+		return err
+	}
+
+	var pkgs []*packages.Package
+	for _, p := range byPath {
+		if err := check(p); err != nil {
+			return nil, err
+		}
+		if p.Module.Main {
+			pkgs = append(pkgs, p)
+		}
+	}
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].PkgPath < pkgs[j].PkgPath })
+	return pkgs, nil
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) { return f(path) }
+
+// byFilename sorts the files of a package by name.
+type byFilename struct{ p *packages.Package }
+
+func (s byFilename) Len() int           { return len(s.p.GoFiles) }
+func (s byFilename) Less(i, j int) bool { return s.p.GoFiles[i] < s.p.GoFiles[j] }
+func (s byFilename) Swap(i, j int) {
+	s.p.GoFiles[i], s.p.GoFiles[j] = s.p.GoFiles[j], s.p.GoFiles[i]
+	s.p.Syntax[i], s.p.Syntax[j] = s.p.Syntax[j], s.p.Syntax[i]
+}
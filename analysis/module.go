@@ -0,0 +1,111 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"go/build"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/hyangah/vulns/stdlib"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/tools/go/analysis"
+)
+
+// A ModuleFact records the module an analyzed package belongs to.
+// It is exported as a package fact when the -module-facts flag is set,
+// so analyzers requiring this analyzer and drivers can aggregate
+// results by module without loading module metadata again.
+// Packages of the standard library belong to the "stdlib" module.
+type ModuleFact struct {
+	FactVersion int // see FactVersion
+	Path        string
+	Version     string // empty for the main module or if unknown
+}
+
+func (*ModuleFact) AFact() {}
+func (f *ModuleFact) String() string {
+	if f.Version == "" {
+		return "module " + f.Path
+	}
+	return "module " + f.Path + "@" + f.Version
+}
+
+var moduleFacts = false
+
+func init() {
+	Analyzer.Flags.BoolVar(&moduleFacts, "module-facts", moduleFacts, "export the module of each analyzed package as a package fact")
+}
+
+// moduleCache memoizes the module of each go.mod directory.
+var moduleCache sync.Map // dir -> *ModuleFact (nil if none)
+
+// moduleOf returns the module the package of the pass belongs to.
+// It locates the go.mod file enclosing the package's files, and
+// determines the version from the module cache directory name.
+func moduleOf(pass *analysis.Pass) *ModuleFact {
+	if len(pass.Files) == 0 {
+		return nil
+	}
+	tf := pass.Fset.File(pass.Files[0].Pos())
+	if tf == nil {
+		return nil
+	}
+	dir := filepath.Dir(tf.Name())
+	if goroot := filepath.Join(build.Default.GOROOT, "src"); strings.HasPrefix(dir, goroot+string(filepath.Separator)) {
+		return &ModuleFact{FactVersion: FactVersion, Path: stdlib.ModulePath, Version: goVersion()}
+	}
+	for d := dir; ; d = filepath.Dir(d) {
+		if v, ok := moduleCache.Load(d); ok {
+			return v.(*ModuleFact)
+		}
+		if m := readModule(d); m != nil {
+			moduleCache.Store(d, m)
+			return m
+		}
+		if parent := filepath.Dir(d); parent == d {
+			return nil
+		}
+	}
+}
+
+// readModule returns the module declared by the go.mod file
+// in dir, or nil if there is none.
+func readModule(dir string) *ModuleFact {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return nil
+	}
+	path := modfile.ModulePath(data)
+	if path == "" {
+		return nil
+	}
+	m := &ModuleFact{FactVersion: FactVersion, Path: path}
+	// Modules in the module cache are stored in
+	// directories named <escaped path>@<escaped version>.
+	if _, ev, found := strings.Cut(filepath.Base(dir), "@"); found {
+		if v, err := module.UnescapeVersion(ev); err == nil {
+			m.Version = v
+		}
+	}
+	return m
+}
+
+var (
+	goVersionOnce sync.Once
+	goSemver      string
+)
+
+func goVersion() string {
+	goVersionOnce.Do(func() {
+		if v, err := stdlib.GoVersion(); err == nil {
+			goSemver = stdlib.GoTagToSemver(v)
+		}
+	})
+	return goSemver
+}
@@ -0,0 +1,71 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"go/types"
+	"strings"
+
+	"golang.org/x/vuln/osv"
+)
+
+var methodNameFallback = false
+
+func init() {
+	Analyzer.Flags.BoolVar(&methodNameFallback, "method-name-fallback", methodNameFallback, "also match methods by package and method name when the vulnerable receiver type listed in the database no longer exists, e.g. it was renamed (see RenamedReceiver)")
+}
+
+// renamedReceiverVulns returns the IDs of the vulnerabilities listing
+// a method with the name of fn, a method of a package-level type, on a
+// receiver type that does not exist in the package of fn. The entries
+// of a method whose receiver type was renamed since they were written
+// list it under the old name, which the receiver-qualified matching of
+// isDirectlyVulnerable misses.
+func (c *Catalog) renamedReceiverVulns(fn *types.Func) []string {
+	pkg := fn.Pkg()
+	var vuln []string
+	for _, v := range c.PkgToVulns[pkg.Path()] {
+		for _, s := range affectedSymbols(pkg.Path(), v) {
+			if renamedMethod(pkg, s, fn.Name()) {
+				vuln = append(vuln, v.ID)
+				break
+			}
+		}
+	}
+	return vuln
+}
+
+// renamedMethod reports whether the symbol s of the package is the
+// method with the name on a receiver type the package does not have.
+func renamedMethod(pkg *types.Package, s, name string) bool {
+	recv, method, ok := strings.Cut(s, ".")
+	if !ok || method != name {
+		return false
+	}
+	_, isType := pkg.Scope().Lookup(recv).(*types.TypeName)
+	return !isType
+}
+
+// RenamedReceiver reports whether the entry affects the method sym
+// only through the fallback of the -method-name-fallback flag: the
+// entry lists the method under another receiver type, but not sym
+// itself. Such findings are less certain than the ones matching the
+// listed symbols, as another type may have had the method.
+func RenamedReceiver(e *osv.Entry, sym SymbolID) bool {
+	if sym.Recv == "" {
+		return false
+	}
+	syms := affectedSymbols(sym.PkgPath, e)
+	renamed := false
+	for _, s := range syms {
+		if s == sym.Symbol() {
+			return false
+		}
+		if recv, method, ok := strings.Cut(s, "."); ok && recv != sym.Recv && method == sym.Name {
+			renamed = true
+		}
+	}
+	return renamed
+}
@@ -0,0 +1,147 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"strings"
+)
+
+// Root selection modes. They determine which package members
+// count as entry points, and so are reported when they reach
+// vulnerable symbols. Facts are exported regardless of the mode
+// so that reachability still propagates across packages.
+const (
+	// RootsAll reports every package member (the default).
+	RootsAll = "all"
+	// RootsMain reports main.main and package initializers,
+	// answering whether a binary reaches vulnerable code.
+	RootsMain = "main"
+	// RootsExported reports exported functions and methods,
+	// answering whether a library API exposes vulnerable code.
+	RootsExported = "exported"
+	// RootsSymbols reports only the symbols listed with
+	// the -root-symbols flag, e.g. a single handler.
+	RootsSymbols = "symbols"
+	// RootsFiles reports only the package members declared
+	// in the files listed with the -root-files flag, e.g.
+	// a file with new handlers.
+	RootsFiles = "files"
+)
+
+var (
+	rootsMode   = RootsAll
+	rootSymbols = ""
+	rootFiles   = ""
+)
+
+func init() {
+	Analyzer.Flags.StringVar(&rootsMode, "roots", rootsMode, "entry points to report: all, main, exported, symbols, or files")
+	Analyzer.Flags.StringVar(&rootSymbols, "root-symbols", rootSymbols, "comma-separated list of qualified symbols (e.g. example.com/p.Handler, example.com/p.T.Method) used as entry points; implies -roots=symbols")
+	Analyzer.Flags.StringVar(&rootFiles, "root-files", rootFiles, "comma-separated list of Go files whose package members are used as entry points; implies -roots=files")
+}
+
+// rootSelector decides which package members are entry points.
+type rootSelector struct {
+	mode    string
+	symbols map[string]bool
+	files   map[string]bool // absolute file names
+	fset    *token.FileSet
+}
+
+// newRootSelector returns the selector for the flag values. If mode is
+// the default, a list of symbols or files selects the matching mode.
+func newRootSelector(fset *token.FileSet, mode, symbols, files string) (*rootSelector, error) {
+	if mode == RootsAll {
+		switch {
+		case symbols != "" && files != "":
+			return nil, fmt.Errorf("-root-symbols and -root-files are exclusive")
+		case symbols != "":
+			mode = RootsSymbols
+		case files != "":
+			mode = RootsFiles
+		}
+	}
+	rs := &rootSelector{mode: mode, fset: fset}
+	switch mode {
+	case RootsAll, RootsMain, RootsExported:
+	case RootsSymbols:
+		rs.symbols = make(map[string]bool)
+		for _, s := range splitList(symbols) {
+			rs.symbols[s] = true
+		}
+	case RootsFiles:
+		rs.files = make(map[string]bool)
+		for _, f := range splitList(files) {
+			abs, err := filepath.Abs(f)
+			if err != nil {
+				return nil, err
+			}
+			rs.files[abs] = true
+		}
+	default:
+		return nil, fmt.Errorf("invalid -roots value %q", mode)
+	}
+	return rs, nil
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, e := range strings.Split(s, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			list = append(list, e)
+		}
+	}
+	return list
+}
+
+// isRoot reports whether the package member obj is an entry point.
+func (rs *rootSelector) isRoot(obj types.Object) bool {
+	switch rs.mode {
+	case RootsMain:
+		fn, ok := obj.(*types.Func)
+		if !ok || fn.Type().(*types.Signature).Recv() != nil {
+			return false
+		}
+		return fn.Name() == "init" || fn.Name() == "main" && fn.Pkg() != nil && fn.Pkg().Name() == "main"
+	case RootsExported:
+		fn, ok := obj.(*types.Func)
+		if !ok || !fn.Exported() {
+			return false
+		}
+		if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+			return isExportedType(recv.Type())
+		}
+		return true
+	case RootsSymbols:
+		var buf bytes.Buffer
+		objectString0(&buf, obj)
+		return rs.symbols[buf.String()]
+	case RootsFiles:
+		f := rs.fset.File(obj.Pos())
+		return f != nil && rs.files[filepath.Clean(f.Name())]
+	}
+	return true
+}
+
+// reportImports reports whether package initialization reached
+// through imports counts as an entry point.
+func (rs *rootSelector) reportImports() bool {
+	return rs.mode != RootsSymbols && rs.mode != RootsFiles
+}
+
+func isExportedType(t types.Type) bool {
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	if n, ok := t.(*types.Named); ok {
+		return n.Obj().Exported()
+	}
+	return false
+}
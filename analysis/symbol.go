@@ -0,0 +1,114 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"fmt"
+	"go/types"
+	"regexp"
+	"strings"
+)
+
+// A SymbolID identifies a package-level function, method, type,
+// or variable, as vulnerable symbols are listed in OSV entries.
+type SymbolID struct {
+	PkgPath string
+	Recv    string // the receiver type name of a method, without *
+	Name    string
+}
+
+// symbolOf returns the identity of the package-level object.
+func symbolOf(obj types.Object) SymbolID {
+	s := SymbolID{Name: obj.Name()}
+	if obj.Pkg() != nil {
+		s.PkgPath = obj.Pkg().Path()
+	}
+	if fn, ok := obj.(*types.Func); ok {
+		if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+			s.Recv = dbTypeFormat(recv.Type())
+		}
+	}
+	return s
+}
+
+// Symbol returns the name of the symbol in its package,
+// "Recv.Name" for methods.
+func (s SymbolID) Symbol() string {
+	if s.Recv == "" {
+		return s.Name
+	}
+	return s.Recv + "." + s.Name
+}
+
+// String returns the qualified name of the symbol,
+// "pkgpath.Name" or "pkgpath.Recv.Name".
+func (s SymbolID) String() string {
+	if s.PkgPath == "" {
+		return s.Symbol()
+	}
+	return s.PkgPath + "." + s.Symbol()
+}
+
+// vulnKey returns the key of the vulnerability affecting the symbol
+// in the paths of vulnFacts and the categories of the diagnostics.
+// It separates the package path from the symbol with a space, which
+// import paths may not contain, so that ParseCategory needs no
+// guessing.
+func vulnKey(id string, s SymbolID) string {
+	return id + ":" + s.PkgPath + " " + s.Symbol()
+}
+
+// ParseCategory returns the vulnerability ID and the vulnerable
+// symbol in the category of a diagnostic reported by the analyzer.
+func ParseCategory(category string) (id string, sym SymbolID, err error) {
+	id, rest, ok := strings.Cut(category, ":")
+	if !ok {
+		return "", SymbolID{}, fmt.Errorf("invalid category %q: missing vulnerability ID", category)
+	}
+	pkgpath, name, ok := strings.Cut(rest, " ")
+	if !ok {
+		return "", SymbolID{}, fmt.Errorf("invalid category %q: missing symbol", category)
+	}
+	sym = SymbolID{PkgPath: pkgpath, Name: name}
+	if recv, name, ok := strings.Cut(name, "."); ok {
+		sym.Recv, sym.Name = recv, name
+	}
+	return id, sym, nil
+}
+
+// majorVersion matches the major version suffixes of package paths,
+// such as the v2 of gopkg.in/yaml.v2.
+var majorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// ParseSymbolID parses a qualified symbol name of the form
+// "pkgpath.Name" or "pkgpath.Recv.Name", as formatted by
+// SymbolID.String and in reference paths. The package path ends at
+// the last slash, or at the first dot if there is none, followed
+// by the dot-separated elements that are major version suffixes,
+// such as "gopkg.in/yaml.v3.Unmarshal". It reports false if s
+// does not have a symbol.
+func ParseSymbolID(s string) (SymbolID, bool) {
+	dir, elems := "", s
+	if i := strings.LastIndex(s, "/"); i >= 0 {
+		dir, elems = s[:i+1], s[i+1:]
+	}
+	parts := strings.Split(elems, ".")
+	n := 1 // the number of parts in the package path
+	for n < len(parts) && majorVersion.MatchString(parts[n]) {
+		n++
+	}
+	syms := parts[n:]
+	if len(syms) == 0 || len(syms) > 2 || parts[0] == "" {
+		return SymbolID{}, false
+	}
+	sym := SymbolID{PkgPath: dir + strings.Join(parts[:n], "."), Name: syms[len(syms)-1]}
+	if len(syms) == 2 {
+		sym.Recv = syms[0]
+	}
+	if sym.Name == "" || (len(syms) == 2 && sym.Recv == "") {
+		return SymbolID{}, false
+	}
+	return sym, true
+}
@@ -0,0 +1,50 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import "testing"
+
+func TestParseCategory(t *testing.T) {
+	for _, sym := range []SymbolID{
+		{PkgPath: "fmt", Name: "Println"},
+		{PkgPath: "gopkg.in/yaml.v2", Name: "Unmarshal"},
+		{PkgPath: "example.com/a.b/c.d", Recv: "Decoder", Name: "Decode"},
+	} {
+		id, got, err := ParseCategory(vulnKey("GO-2022-0001", sym))
+		if err != nil || id != "GO-2022-0001" || got != sym {
+			t.Errorf("ParseCategory(vulnKey(%v)) = %q, %+v, %v; want the ID and the symbol", sym, id, got, err)
+		}
+	}
+	for _, c := range []string{"GO-2022-0001", "GO-2022-0001:fmt.Println"} {
+		if _, _, err := ParseCategory(c); err == nil {
+			t.Errorf("ParseCategory(%q) succeeded, want error", c)
+		}
+	}
+}
+
+func TestParseSymbolID(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want SymbolID
+		ok   bool
+	}{
+		{"fmt.Println", SymbolID{PkgPath: "fmt", Name: "Println"}, true},
+		{"net/http.Client.Do", SymbolID{PkgPath: "net/http", Recv: "Client", Name: "Do"}, true},
+		{"gopkg.in/yaml.v2.Unmarshal", SymbolID{PkgPath: "gopkg.in/yaml.v2", Name: "Unmarshal"}, true},
+		{"gopkg.in/yaml.v2.Decoder.Decode", SymbolID{PkgPath: "gopkg.in/yaml.v2", Recv: "Decoder", Name: "Decode"}, true},
+		{"example.com/m", SymbolID{}, false},
+		{"gopkg.in/yaml.v2", SymbolID{}, false},
+		{"example.com/m.a.b.c", SymbolID{}, false},
+		{"example.com/m.", SymbolID{}, false},
+	} {
+		got, ok := ParseSymbolID(tc.in)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("ParseSymbolID(%q) = %+v, %v; want %+v, %v", tc.in, got, ok, tc.want, tc.ok)
+		}
+		if ok && got.String() != tc.in {
+			t.Errorf("ParseSymbolID(%q).String() = %q", tc.in, got.String())
+		}
+	}
+}
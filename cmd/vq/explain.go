@@ -0,0 +1,229 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hyangah/vulns/stdlib"
+	"golang.org/x/mod/semver"
+	"golang.org/x/vuln/client"
+	"golang.org/x/vuln/osv"
+)
+
+// An explanation summarizes an entry for people, such as the readers
+// of an incident channel.
+type explanation struct {
+	ID        string
+	Aliases   []link `json:",omitempty"`
+	Details   string
+	Affected  []affectedModule
+	Published time.Time
+	Modified  time.Time
+	Withdrawn *time.Time `json:",omitempty"`
+	// References lists the URLs of the entry, starting with
+	// the page of the Go vulnerability database.
+	References []string
+}
+
+// A link is an identifier with the URL of its page.
+type link struct {
+	ID  string
+	URL string `json:",omitempty"`
+}
+
+// An affectedModule describes the affected versions of a module.
+type affectedModule struct {
+	Module string
+	Ranges string // such as "[0, v1.1.0), [v1.3.0, v1.3.1)"
+	// Fixed lists the versions fixing each range. Recommended is
+	// the highest of them, which fixes all the ranges, unless the
+	// latest versions are affected.
+	Fixed       []string `json:",omitempty"`
+	Recommended string   `json:",omitempty"`
+	Packages    []affectedPackage
+}
+
+type affectedPackage struct {
+	Path    string
+	Symbols []string `json:",omitempty"` // empty if the whole package is affected
+	GOOS    []string `json:",omitempty"`
+	GOARCH  []string `json:",omitempty"`
+}
+
+// explain prints the explanations of the entries with the IDs,
+// or with the aliases.
+func explain(ctx context.Context, cli client.Client, ids []string) {
+	var res []*explanation
+	for _, id := range ids {
+		var es []*osv.Entry
+		if isAlias(id) {
+			var err error
+			if es, err = cli.GetByAlias(ctx, id); err != nil {
+				exitf("failed: %v", err)
+			}
+		} else {
+			e, err := cli.GetByID(ctx, id)
+			if err != nil {
+				exitf("failed: %v", err)
+			}
+			if e != nil {
+				es = append(es, e)
+			}
+		}
+		if len(es) == 0 {
+			exitf("%s: no entry found\n", id)
+		}
+		for _, e := range es {
+			res = append(res, explainEntry(e))
+		}
+	}
+	if *flagFormat != "text" {
+		printStructured(res)
+		return
+	}
+	for i, ex := range res {
+		if i > 0 {
+			fmt.Println()
+		}
+		ex.write(os.Stdout)
+	}
+}
+
+// explainEntry returns the explanation of the entry.
+func explainEntry(e *osv.Entry) *explanation {
+	ex := &explanation{
+		ID:         e.ID,
+		Details:    strings.TrimSpace(e.Details),
+		Published:  e.Published,
+		Modified:   e.Modified,
+		Withdrawn:  e.Withdrawn,
+		References: []string{"https://pkg.go.dev/vuln/" + e.ID},
+	}
+	for _, a := range e.Aliases {
+		ex.Aliases = append(ex.Aliases, link{ID: a, URL: aliasURL(a)})
+	}
+	for _, r := range e.References {
+		ex.References = append(ex.References, r.URL)
+	}
+	for _, a := range e.Affected {
+		isStd := stdlib.Contains(a.Package.Name)
+		m := affectedModule{Module: a.Package.Name, Ranges: rangesToText(isStd, a.Ranges)}
+		prefix := "v"
+		if isStd {
+			prefix = "go"
+		}
+		open := false // whether the latest versions are affected
+		recommended := ""
+		for _, r := range a.Ranges {
+			for _, ev := range r.Events {
+				switch {
+				case ev.Introduced != "":
+					open = true
+				case ev.Fixed != "":
+					open = false
+					m.Fixed = append(m.Fixed, prefix+ev.Fixed)
+					if recommended == "" || semver.Compare("v"+ev.Fixed, "v"+recommended) > 0 {
+						recommended = ev.Fixed
+					}
+				}
+			}
+		}
+		if !open && recommended != "" {
+			m.Recommended = prefix + recommended
+		}
+		for _, p := range a.EcosystemSpecific.Imports {
+			m.Packages = append(m.Packages, affectedPackage{Path: p.Path, Symbols: p.Symbols, GOOS: p.GOOS, GOARCH: p.GOARCH})
+		}
+		ex.Affected = append(ex.Affected, m)
+	}
+	return ex
+}
+
+// aliasURL returns the URL of the page of the CVE or GHSA alias,
+// or the empty string for other aliases.
+func aliasURL(alias string) string {
+	switch {
+	case strings.HasPrefix(alias, "CVE-"):
+		return "https://nvd.nist.gov/vuln/detail/" + alias
+	case strings.HasPrefix(alias, "GHSA-"):
+		return "https://github.com/advisories/" + alias
+	}
+	return ""
+}
+
+// write prints the explanation as text.
+func (ex *explanation) write(w io.Writer) {
+	title := ex.ID
+	if len(ex.Aliases) > 0 {
+		var ids []string
+		for _, a := range ex.Aliases {
+			ids = append(ids, a.ID)
+		}
+		title += " (" + strings.Join(ids, ", ") + ")"
+	}
+	fmt.Fprintln(w, title)
+	fmt.Fprintln(w, strings.Repeat("=", len(title)))
+	if ex.Withdrawn != nil {
+		fmt.Fprintf(w, "\nWITHDRAWN on %s.\n", ex.Withdrawn.Format("2006-01-02"))
+	}
+	if ex.Details != "" {
+		fmt.Fprintf(w, "\n%s\n", ex.Details)
+	}
+	for _, m := range ex.Affected {
+		fmt.Fprintf(w, "\nAffected: %s %s\n", m.Module, m.Ranges)
+		switch {
+		case m.Recommended != "":
+			fmt.Fprintf(w, "Fix:      upgrade to %s or later", m.Recommended)
+			if len(m.Fixed) > 1 {
+				fmt.Fprintf(w, " (fixed in %s)", strings.Join(m.Fixed, ", "))
+			}
+			fmt.Fprintln(w)
+		case len(m.Fixed) > 0:
+			fmt.Fprintf(w, "Fix:      none for the latest versions (fixed in %s)\n", strings.Join(m.Fixed, ", "))
+		default:
+			fmt.Fprintln(w, "Fix:      none available")
+		}
+		for _, p := range m.Packages {
+			fmt.Fprintf(w, "  Package %s", p.Path)
+			var platforms []string
+			if len(p.GOOS) > 0 {
+				platforms = append(platforms, "GOOS="+strings.Join(p.GOOS, ","))
+			}
+			if len(p.GOARCH) > 0 {
+				platforms = append(platforms, "GOARCH="+strings.Join(p.GOARCH, ","))
+			}
+			if len(platforms) > 0 {
+				fmt.Fprintf(w, " (only %s)", strings.Join(platforms, " "))
+			}
+			fmt.Fprintln(w)
+			if len(p.Symbols) == 0 {
+				fmt.Fprintln(w, "    all symbols")
+			}
+			for _, s := range p.Symbols {
+				fmt.Fprintf(w, "    %s\n", s)
+			}
+		}
+	}
+	if len(ex.Aliases) > 0 || len(ex.References) > 0 {
+		fmt.Fprintln(w, "\nLinks:")
+		for _, u := range ex.References {
+			fmt.Fprintf(w, "  %s\n", u)
+		}
+		for _, a := range ex.Aliases {
+			if a.URL != "" {
+				fmt.Fprintf(w, "  %s: %s\n", a.ID, a.URL)
+			}
+		}
+	}
+	if !ex.Published.IsZero() {
+		fmt.Fprintf(w, "\nPublished %s, modified %s.\n", ex.Published.Format("2006-01-02"), ex.Modified.Format("2006-01-02"))
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hyangah/vulns/internal/osvutil"
+	"golang.org/x/vuln/client"
+	"golang.org/x/vuln/osv"
+)
+
+// A lookupClient is a client of the databases at urls whose alias
+// lookups stream the aliases.json files of the databases, rather than
+// decoding them whole, so that simple queries stay fast and need
+// little memory against large mirrors of the database. The lookups
+// by ID and by module read only the files of the entries and the
+// modules, except that the index of databases served over HTTP is
+// fetched and cached by the underlying client.
+type lookupClient struct {
+	client.Client
+	urls []string
+	http *http.Client // sends the credentials of the databases
+}
+
+// GetByAlias returns the entries with the alias in any of the databases.
+func (c *lookupClient) GetByAlias(ctx context.Context, alias string) ([]*osv.Entry, error) {
+	seen := make(map[string]bool)
+	var entries []*osv.Entry
+	for _, u := range c.urls {
+		var ids []string
+		if err := c.lookupIndex(ctx, u, "aliases.json", alias, &ids); err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			e, err := c.GetByID(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			if e != nil {
+				entries = append(entries, e)
+			}
+		}
+	}
+	return entries, nil
+}
+
+// lookupIndex decodes into v the value of the key in the index file
+// of the database at the URL (see osvutil.LookupIndex). A missing
+// index or key leaves v unchanged.
+func (c *lookupClient) lookupIndex(ctx context.Context, dbURL, file, key string, v interface{}) error {
+	var r io.ReadCloser
+	dbURL = strings.TrimRight(dbURL, "/")
+	if strings.HasPrefix(dbURL, "file://") {
+		f, err := os.Open(filepath.Join(strings.TrimPrefix(dbURL, "file://"), file))
+		if os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		r = f
+	} else {
+		req, err := http.NewRequestWithContext(ctx, "GET", dbURL+"/"+file, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			resp.Body.Close()
+			return nil
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return fmt.Errorf("GET %s/%s: %s", dbURL, file, resp.Status)
+		}
+		r = resp.Body
+	}
+	defer r.Close()
+	if _, err := osvutil.LookupIndex(r, key, v); err != nil {
+		return fmt.Errorf("%s/%s: %v", dbURL, file, err)
+	}
+	return nil
+}
+
+// isAlias reports whether the ID is a CVE or GHSA alias of entries,
+// rather than the ID of an entry.
+func isAlias(id string) bool {
+	return strings.HasPrefix(id, "CVE-") || strings.HasPrefix(id, "GHSA-")
+}
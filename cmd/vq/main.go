@@ -9,10 +9,16 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
+	"time"
 
-	"github.com/hyangah/vulns/internal/govulncheck"
+	"github.com/hyangah/vulns/internal/jsonyaml"
+	"github.com/hyangah/vulns/internal/osvutil"
+	"github.com/hyangah/vulns/stdlib"
+	"github.com/hyangah/vulns/testutils"
+	"github.com/hyangah/vulns/vulncache"
 	"golang.org/x/vuln/client"
 	"golang.org/x/vuln/osv"
 )
@@ -20,14 +26,36 @@ import (
 const usageHdr = `vq: simple vulndb lookup tool
 
 Usage:
-  vq id <osv-entry-id>
+  vq id <osv-entry-id or alias>
+     aliases are CVE or GHSA IDs.
+
+  vq explain <osv-entry-id or alias>
+     summarizes the entry for people: the details, the affected
+     versions, the recommended fix, the links, and the affected
+     symbols.
 
   vq mod module[@version]
      for vulnerabilities in standard libraries, use 'stdlib'
 	 as the module name.
 
+  vq smoke <db-url> [osv-entry-id...]
+     checks the health of a database, such as a self-hosted mirror:
+     fetches well-known entries, or the ones listed, checks that the
+     index, the aliases, and the modules of the database lead to them,
+     and that the responses take less than -max-latency. Exits with
+     status 1 on problems. With -format json or yaml, the checks are
+     printed as a list.
+
+  vq lint-report report.yaml...
+     checks vulnerability reports in the vulndb YAML format.
+     With -format json or yaml, the issues are printed as a list.
+
 Environments:
-  GOVULNDB: vulnerability database. (default: https://vuln.go.dev)
+  GOVULNDB: vulnerability database, unless set with -db. (default: https://vuln.go.dev)
+  GOVULNDB_AUTH: credentials of the databases requiring authentication,
+     as a comma-separated list of url=user:password or url=<Authorization
+     header>, such as "https://vulndb.example.com=Bearer token". The
+     credentials of the .netrc file ($NETRC) are used as well.
 `
 
 func usage() {
@@ -38,21 +66,47 @@ func usage() {
 }
 
 var (
-	flagJSON = flag.Bool("json", false, "output in json format")
+	flagJSON       = flag.Bool("json", false, "output in json format (shorthand for -format json)")
+	flagFormat     = flag.String("format", "text", "output format: text, json, or yaml")
+	flagDB         = flag.String("db", "", "comma-separated list of vulnerability database URLs (default: $GOVULNDB or https://vuln.go.dev)")
+	flagMaxLatency = flag.Duration("max-latency", 2*time.Second, "with smoke, the maximum latency of a database response")
 )
 
 func main() {
 	flag.Usage = usage
 	flag.Parse()
+	switch *flagFormat {
+	case "text", "json", "yaml":
+	default:
+		exitf("unknown output format: %v\n", *flagFormat)
+	}
+	if *flagJSON {
+		*flagFormat = "json"
+	}
 
 	if len(flag.Args()) < 2 {
 		exitf("insufficient number of args")
 	}
+	if flag.Arg(0) == "lint-report" {
+		lintReports(flag.Args()[1:])
+		return
+	}
+	if flag.Arg(0) == "smoke" {
+		smoke(context.Background(), flag.Arg(1), flag.Args()[2:])
+		return
+	}
 
-	dbClient, err := client.NewClient(findGOVULNDB(), client.Options{HTTPCache: govulncheck.DefaultCache()})
+	urls := findGOVULNDB()
+	creds, err := osvutil.LoadCredentials()
+	if err != nil {
+		exitf("failed to load the credentials of the vulnerability databases: %v\n", err)
+	}
+	hc := &http.Client{Transport: creds.Transport(nil)}
+	cli, err := client.NewClient(urls, client.Options{HTTPCache: vulncache.Default(), HTTPClient: hc})
 	if err != nil {
 		exitf("failed to setup vulncheck client: %v", err)
 	}
+	dbClient := &lookupClient{Client: cli, urls: urls, http: hc}
 
 	var (
 		res [][]*osv.Entry
@@ -65,6 +119,9 @@ func main() {
 		res, err = byID(ctx, dbClient, keys...)
 	case "mod":
 		res, err = byModule(ctx, dbClient, keys...)
+	case "explain":
+		explain(ctx, dbClient, keys)
+		return
 	default:
 		exitf("unknown mode: %v", x)
 	}
@@ -75,15 +132,25 @@ func main() {
 		fmt.Printf("no entry found\n")
 		return
 	}
-	if *flagJSON {
-		toJSON(res)
-	} else {
+	if *flagFormat == "text" {
 		toText(keys, res)
+	} else {
+		printStructured(res)
 	}
 }
 
-func toJSON(res [][]*osv.Entry) {
-	s, _ := json.MarshalIndent(res, " ", " ")
+// printStructured prints v in the JSON or YAML format
+// selected with the -format flag.
+func printStructured(v any) {
+	s, _ := json.MarshalIndent(v, " ", " ")
+	if *flagFormat == "yaml" {
+		y, err := jsonyaml.Convert(s)
+		if err != nil {
+			exitf("failed to convert to yaml: %v\n", err)
+		}
+		os.Stdout.Write(y)
+		return
+	}
 	fmt.Printf("%s\n", s)
 }
 
@@ -107,7 +174,7 @@ func toText(keys []string, res [][]*osv.Entry) {
 			for _, affecting := range e.Affected {
 				for _, p := range affecting.EcosystemSpecific.Imports {
 					fmt.Println("Package:", p.Path)
-					fmt.Println("Range  :", rangesToText(isStdPackage(affecting.Package.Name), affecting.Ranges))
+					fmt.Println("Range  :", rangesToText(stdlib.Contains(affecting.Package.Name), affecting.Ranges))
 					fmt.Println("Symbols:", strings.Join(p.Symbols, ", "))
 					if goos := p.GOOS; len(goos) > 0 {
 						fmt.Println("GOOS   :", strings.Join(goos, ", "))
@@ -122,18 +189,6 @@ func toText(keys []string, res [][]*osv.Entry) {
 	}
 }
 
-func isStdPackage(pkg string) bool {
-	if pkg == "" {
-		return false
-	}
-	// std packages do not have a "." in their path. For instance, see
-	// Contains in pkgsite/+/refs/heads/master/internal/stdlbib/stdlib.go.
-	if i := strings.IndexByte(pkg, '/'); i != -1 {
-		pkg = pkg[:i]
-	}
-	return !strings.Contains(pkg, ".")
-}
-
 func rangesToText(isStd bool, affects osv.Affects) string {
 	type pair struct {
 		in, fixed string
@@ -183,6 +238,14 @@ func rangesToText(isStd bool, affects osv.Affects) string {
 
 func byID(ctx context.Context, cli client.Client, ids ...string) (res [][]*osv.Entry, _ error) {
 	for _, id := range ids {
+		if isAlias(id) {
+			es, err := cli.GetByAlias(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, es)
+			continue
+		}
 		e, err := cli.GetByID(ctx, id)
 		if err != nil {
 			return nil, err
@@ -199,8 +262,8 @@ func byID(ctx context.Context, cli client.Client, ids ...string) (res [][]*osv.E
 func byModule(ctx context.Context, cli client.Client, mods ...string) (res [][]*osv.Entry, _ error) {
 	for _, mod := range mods {
 		name, ver, found := strings.Cut(mod, "@")
-		if name == "stdlib" && strings.HasPrefix(ver, "go") {
-			ver = "v" + ver[2:]
+		if name == stdlib.ModulePath && strings.HasPrefix(ver, "go") {
+			ver = stdlib.GoTagToSemver(ver)
 		}
 		e, err := cli.GetByModule(ctx, name)
 		if err != nil {
@@ -229,6 +292,45 @@ func byModule(ctx context.Context, cli client.Client, mods ...string) (res [][]*
 	return res, nil
 }
 
+// A lintIssue is a lint issue of a report file.
+type lintIssue struct {
+	File string
+	testutils.LintIssue
+}
+
+// lintReports lints the report files and
+// exits with status 1 if any has issues.
+func lintReports(files []string) {
+	issues := []lintIssue{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			exitf("lint-report: %v\n", err)
+		}
+		lints, err := testutils.LintReport(file, data)
+		if err != nil {
+			exitf("lint-report: %s: %v\n", file, err)
+		}
+		for _, l := range lints {
+			issues = append(issues, lintIssue{File: file, LintIssue: l})
+		}
+	}
+	if *flagFormat != "text" {
+		printStructured(issues)
+	} else {
+		for _, iss := range issues {
+			pos := iss.File
+			if iss.Line > 0 {
+				pos = fmt.Sprintf("%s:%d:%d", iss.File, iss.Line, iss.Column)
+			}
+			fmt.Printf("%s: %s (%s)\n", pos, iss.Message, iss.Rule)
+		}
+	}
+	if len(issues) > 0 {
+		os.Exit(1)
+	}
+}
+
 func exitf(format string, args ...interface{}) {
 	fmt.Fprintf(os.Stderr, format, args...)
 	usage()
@@ -236,6 +338,9 @@ func exitf(format string, args ...interface{}) {
 }
 
 func findGOVULNDB() []string {
+	if *flagDB != "" {
+		return strings.Split(*flagDB, ",")
+	}
 	if GOVULNDB := os.Getenv("GOVULNDB"); GOVULNDB != "" {
 		return strings.Split(GOVULNDB, ",")
 	}
@@ -0,0 +1,174 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/hyangah/vulns/internal/osvutil"
+	"golang.org/x/vuln/client"
+	"golang.org/x/vuln/osv"
+)
+
+// wellKnownIDs are the entries smoke checks by default: old entries
+// of the public database, affecting a module, a golang.org/x module,
+// and the standard library, that its mirrors have.
+var wellKnownIDs = []string{"GO-2020-0001", "GO-2021-0113", "GO-2022-0969"}
+
+// smokeTimeout bounds each request of smoke, so that an unresponsive
+// database fails the check rather than hanging it.
+const smokeTimeout = 30 * time.Second
+
+// A smokeCheck is the result of a request of smoke.
+type smokeCheck struct {
+	Name    string        // the request, such as "GET GO-2020-0001"
+	Latency time.Duration // in nanoseconds
+	Problem string        `json:",omitempty"`
+}
+
+// smoke checks the health of the database at dbURL, such as a
+// self-hosted mirror: it fetches the entries with the IDs, or the
+// wellKnownIDs, checks that the index, the aliases, and the modules
+// of the database lead to them, and that the responses take less
+// than -max-latency. It exits with status 1 if there are problems.
+func smoke(ctx context.Context, dbURL string, ids []string) {
+	if len(ids) == 0 {
+		ids = wellKnownIDs
+	}
+	creds, err := osvutil.LoadCredentials()
+	if err != nil {
+		exitf("failed to load the credentials of the vulnerability databases: %v\n", err)
+	}
+	// No HTTP cache: the responses must come from the database.
+	hc := &http.Client{Transport: creds.Transport(nil)}
+	cli, err := client.NewClient([]string{dbURL}, client.Options{HTTPClient: hc})
+	if err != nil {
+		exitf("failed to setup vulncheck client: %v", err)
+	}
+	db := &lookupClient{Client: cli, urls: []string{dbURL}, http: hc}
+
+	checks := []*smokeCheck{}
+	check := func(name string, f func(context.Context) string) {
+		ctx, cancel := context.WithTimeout(ctx, smokeTimeout)
+		defer cancel()
+		start := time.Now()
+		c := &smokeCheck{Name: name, Problem: f(ctx)}
+		c.Latency = time.Since(start)
+		if c.Problem == "" && c.Latency > *flagMaxLatency {
+			c.Problem = fmt.Sprintf("slower than -max-latency %v", *flagMaxLatency)
+		}
+		checks = append(checks, c)
+	}
+
+	check("GET last modified time", func(ctx context.Context) string {
+		if _, err := db.LastModifiedTime(ctx); err != nil {
+			return err.Error()
+		}
+		return ""
+	})
+	index := make(map[string]bool)
+	check("GET index of IDs", func(ctx context.Context) string {
+		all, err := db.ListIDs(ctx)
+		if err != nil {
+			return err.Error()
+		}
+		for _, id := range all {
+			index[id] = true
+		}
+		if len(all) == 0 {
+			return "no entries"
+		}
+		return ""
+	})
+	for _, id := range ids {
+		var e *osv.Entry
+		check("GET "+id, func(ctx context.Context) string {
+			var err error
+			switch e, err = db.GetByID(ctx, id); {
+			case err != nil:
+				return err.Error()
+			case e == nil:
+				return "not found"
+			case e.ID != id:
+				return fmt.Sprintf("got entry %s", e.ID)
+			case len(index) > 0 && !index[id]:
+				return "missing in the index of IDs"
+			}
+			return ""
+		})
+		if e == nil || e.ID != id {
+			continue
+		}
+		for _, alias := range e.Aliases {
+			check(fmt.Sprintf("GET alias %s of %s", alias, id), func(ctx context.Context) string {
+				entries, err := db.GetByAlias(ctx, alias)
+				if err != nil {
+					return err.Error()
+				}
+				if !hasEntry(entries, id) {
+					return "missing " + id
+				}
+				return ""
+			})
+		}
+		seen := make(map[string]bool)
+		for _, a := range e.Affected {
+			mod := a.Package.Name
+			if seen[mod] {
+				continue
+			}
+			seen[mod] = true
+			check(fmt.Sprintf("GET module %s of %s", mod, id), func(ctx context.Context) string {
+				entries, err := db.GetByModule(ctx, mod)
+				if err != nil {
+					return err.Error()
+				}
+				if !hasEntry(entries, id) {
+					return "missing " + id
+				}
+				return ""
+			})
+		}
+	}
+
+	problems := 0
+	for _, c := range checks {
+		if c.Problem != "" {
+			problems++
+		}
+	}
+	if *flagFormat != "text" {
+		printStructured(checks)
+	} else {
+		for _, c := range checks {
+			status := "ok"
+			if c.Problem != "" {
+				status = "FAIL"
+			}
+			fmt.Printf("%-4s %s (%v)", status, c.Name, c.Latency.Round(time.Millisecond))
+			if c.Problem != "" {
+				fmt.Printf(": %s", c.Problem)
+			}
+			fmt.Println()
+		}
+	}
+	if problems > 0 {
+		fmt.Fprintf(os.Stderr, "%s: %d of %d checks failed\n", dbURL, problems, len(checks))
+		os.Exit(1)
+	}
+}
+
+func hasEntry(entries []*osv.Entry, id string) bool {
+	for _, e := range entries {
+		if e.ID == id {
+			return true
+		}
+	}
+	return false
+}
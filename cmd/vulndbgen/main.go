@@ -0,0 +1,138 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command vulndbgen generates a vulnerability database from reports
+// in the YAML format of golang.org/x/vulndb, so that teams can publish
+// small curated databases, such as of the vulnerabilities of their
+// internal modules, without writing Go code.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hyangah/vulns/testutils"
+	"golang.org/x/tools/txtar"
+)
+
+const usageHdr = `vulndbgen: vulnerability database generator
+
+Usage:
+  vulndbgen -o dir report.yaml|reports-dir|reports.txtar...
+
+Generates a vulnerability database in dir from the reports, in the
+YAML format of golang.org/x/vulndb. Each report is named after its ID,
+such as GO-2022-0001.yaml. Directories are searched recursively for
+.yaml files, and txtar archives hold one report per file.
+
+The reports are checked as with 'vq lint-report'. The generated
+directory can be served over HTTP as it is, or used with a file URL,
+for example with GOVULNDB=file:///path/to/dir.
+`
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprint(out, usageHdr)
+	flag.PrintDefaults()
+	fmt.Fprintln(out)
+}
+
+var (
+	flagOut    = flag.String("o", "", "output directory; must not exist or be empty")
+	flagIndent = flag.Bool("indent", false, "indent the JSON files")
+)
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if *flagOut == "" {
+		exitf("-o is required\n")
+	}
+	if flag.NArg() == 0 {
+		exitf("no reports\n")
+	}
+	if ents, err := os.ReadDir(*flagOut); err == nil && len(ents) > 0 {
+		exitf("output directory %s is not empty\n", *flagOut)
+	}
+
+	var archive txtar.Archive
+	for _, arg := range flag.Args() {
+		files, err := readReports(arg)
+		if err != nil {
+			exitf("%v\n", err)
+		}
+		archive.Files = append(archive.Files, files...)
+	}
+	seen := make(map[string]string)
+	for _, f := range archive.Files {
+		id := strings.TrimSuffix(filepath.Base(f.Name), ".yaml")
+		if prev, ok := seen[id]; ok {
+			exitf("duplicate report %s: %s and %s\n", id, prev, f.Name)
+		}
+		seen[id] = f.Name
+	}
+	if len(archive.Files) == 0 {
+		exitf("no reports found in %s\n", strings.Join(flag.Args(), ", "))
+	}
+
+	if err := os.MkdirAll(*flagOut, 0755); err != nil {
+		exitf("%v\n", err)
+	}
+	if err := testutils.GenerateDatabase(context.Background(), txtar.Format(&archive), *flagOut, *flagIndent); err != nil {
+		exitf("failed to generate the database: %v\n", err)
+	}
+	fmt.Fprintf(os.Stderr, "wrote %d reports to %s\n", len(archive.Files), *flagOut)
+}
+
+// readReports returns the .yaml files of the report, directory, or
+// txtar archive at path, named by their paths, so that the base name
+// of their directory selects the lint rules as in 'vq lint-report'.
+func readReports(path string) ([]txtar.File, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasSuffix(path, ".yaml") {
+			return []txtar.File{{Name: path, Data: data}}, nil
+		}
+		var files []txtar.File
+		for _, f := range txtar.Parse(data).Files {
+			if strings.HasSuffix(f.Name, ".yaml") {
+				files = append(files, f)
+			}
+		}
+		return files, nil
+	}
+	var files []txtar.File
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(p, ".yaml") {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		files = append(files, txtar.File{Name: p, Data: data})
+		return nil
+	})
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, err
+}
+
+func exitf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format, args...)
+	usage()
+	os.Exit(1)
+}
@@ -0,0 +1,50 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"github.com/hyangah/vulns/quickcheck"
+	"github.com/hyangah/vulns/render"
+)
+
+// binary reports the vulnerabilities affecting the module versions
+// recorded in a Go binary, such as a deployed artifact, without
+// its source. Unlike dir, it does not need the symbol table, so
+// it works with stripped binaries, but it is less precise.
+func binary(args []string) {
+	if len(args) != 1 {
+		exitf("binary: want exactly one binary\n")
+	}
+	renderer := render.Lookup(flagFormat)
+	if renderer == nil {
+		exitf("unknown output format %q (available: %s)\n", flagFormat, strings.Join(render.Names(), ", "))
+	}
+	mods, err := quickcheck.BinaryModules(args[0])
+	if err != nil {
+		exitf("binary: %v\n", err)
+	}
+	if goos, goarch, err := quickcheck.BinaryPlatform(args[0]); err != nil {
+		log.Printf("binary: %v", err)
+	} else {
+		// Match the platform the binary is built for,
+		// unless overridden with -goos and -goarch.
+		if flagGOOS == "" {
+			os.Setenv("GOOS", goos)
+		}
+		if flagGOARCH == "" {
+			os.Setenv("GOARCH", goarch)
+		}
+	}
+	known := reportModules(renderer, mods)
+	// Without the symbol table, a vulnerable module is all we know.
+	exitFailOn(known, known)
+}
@@ -0,0 +1,184 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	myanalysis "github.com/hyangah/vulns/analysis"
+	"github.com/hyangah/vulns/internal/osvutil"
+	"github.com/hyangah/vulns/internal/xtools/checker"
+	"golang.org/x/mod/module"
+	"golang.org/x/tools/go/packages"
+)
+
+// The flags of catalog and dump.
+var (
+	catalogOut = ""
+	dumpOut    = ""
+)
+
+// catalogFlags registers the flags of catalog.
+func catalogFlags(fs *flag.FlagSet) {
+	fs.StringVar(&catalogOut, "o", catalogOut, "output file (default: stdout)")
+	addDBFlags(fs)
+}
+
+// dumpFlags registers the flags of dump.
+func dumpFlags(fs *flag.FlagSet) {
+	fs.StringVar(&dumpOut, "o", dumpOut, "output file")
+	addDBFlags(fs)
+	addFilterFlags(fs)
+	addLoadFlags(fs)
+}
+
+// catalog writes the catalog of OSV entries the analyzer reads with
+// its -vulns-json flag, for the listed modules (path or path@version)
+// or for the whole database if no module is listed. This allows
+// running the analyzer alone, e.g. with go vet -vettool, in
+// environments without access to the database.
+func catalog(args []string) {
+	var mods []module.Version
+	for _, arg := range args {
+		path, version, _ := strings.Cut(arg, "@")
+		if err := module.CheckPath(path); err != nil {
+			exitf("catalog: %v\n", err)
+		}
+		mods = append(mods, module.Version{Path: path, Version: version})
+	}
+
+	urls := databases(&packages.Config{})
+	checkOffline(urls, nil)
+	dbClient, err := osvutil.NewClient(urls, dbOptions())
+	if err != nil {
+		exitf("failed to setup vulncheck client: %v", err)
+	}
+	dbClient.Ecosystems = ecosystems()
+	dbClient.Concurrency = flagConcurrency
+	dbClient.SnapshotTime = dbSnapshotTime
+	pkg2vulns, err := osvutil.FetchCatalog(context.Background(), dbClient, mods)
+	if err != nil {
+		exitf("catalog: failed to fetch OSV entries: %v\n", err)
+	}
+	writeCatalog("catalog", catalogOut, &myanalysis.Catalog{PkgToVulns: pkg2vulns, Provenance: osvutil.Provenances(dbClient, pkg2vulns)})
+}
+
+// dump writes the catalog of the OSV entries affecting the modules of
+// the packages matching the patterns and their dependencies, as the
+// analyzer reads it with its -vulns-json flag. The entries are
+// filtered and normalized as for a scan of the packages, so that
+// running the analyzer alone with the catalog finds the same
+// vulnerabilities as vulns.
+func dump(args []string) {
+	if dumpOut == "" || len(args) == 0 {
+		exitUsage("dump")
+	}
+
+	cfg := &packages.Config{
+		Mode:  packages.NeedName | packages.NeedModule | packages.NeedImports | packages.NeedDeps,
+		Tests: checker.IncludeTests,
+	}
+	pkgs, err := load(cfg, args)
+	if err != nil {
+		exitf("dump: %v\n", err)
+	}
+	dbURLs := databases(cfg)
+	if flagOffline {
+		checkOffline(dbURLs, osvutil.Modules(pkgs))
+	}
+	dbClient, err := osvutil.NewClient(dbURLs, dbOptions())
+	if err != nil {
+		exitf("failed to setup vulncheck client: %v", err)
+	}
+	dbClient.Only = onlyIDs()
+	dbClient.Ignore = ignoredIDs()
+	dbClient.Ecosystems = ecosystems()
+	dbClient.Concurrency = flagConcurrency
+	dbClient.SnapshotTime = dbSnapshotTime
+	pkg2vulns, err := osvutil.FetchOSVEntries(context.Background(), dbClient, pkgs)
+	if err != nil {
+		exitf("dump: failed to fetch OSV entries: %v\n", err)
+	}
+	writeCatalog("dump", dumpOut, &myanalysis.Catalog{PkgToVulns: pkg2vulns, Provenance: osvutil.Provenances(dbClient, pkg2vulns)})
+}
+
+// writeCatalog writes the catalog to the file, or to stdout if file
+// is empty. cmd names the subcommand in error messages.
+func writeCatalog(cmd, file string, c *myanalysis.Catalog) {
+	if file == "" {
+		if err := myanalysis.WriteCatalog(os.Stdout, c); err != nil {
+			exitf("%s: failed to encode the catalog: %v\n", cmd, err)
+		}
+		return
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		exitf("%s: %v\n", cmd, err)
+	}
+	err = myanalysis.WriteCatalog(f, c)
+	if err2 := f.Close(); err == nil {
+		err = err2
+	}
+	if err != nil {
+		exitf("%s: failed to write the catalog: %v\n", cmd, err)
+	}
+}
+
+// catalogDiff reports the vulnerable packages and symbols added or
+// removed between two catalog files written by catalog, so that a
+// regenerated catalog can be reviewed before it is rolled out.
+func catalogDiff(args []string) {
+	if len(args) != 2 {
+		exitUsage("catalog-diff")
+	}
+	old, err := myanalysis.ReadCatalog(args[0])
+	if err != nil {
+		exitf("catalog-diff: %v\n", err)
+	}
+	new, err := myanalysis.ReadCatalog(args[1])
+	if err != nil {
+		exitf("catalog-diff: %v\n", err)
+	}
+	changes := myanalysis.DiffCatalogs(old, new)
+
+	switch flagFormat {
+	case "text":
+		added := 0
+		for _, c := range changes {
+			op, sym := "-", c.Symbol.String()
+			if c.Added {
+				op = "+"
+				added++
+			}
+			if c.Symbol.Name == "" {
+				sym = c.Symbol.PkgPath + " (whole package)"
+			}
+			fmt.Printf("%s %s %s\n", op, c.ID, sym)
+		}
+		if len(changes) > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%d added, %d removed\n", added, len(changes)-added)
+	case "json":
+		if changes == nil {
+			changes = []myanalysis.CatalogChange{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(changes); err != nil {
+			exitf("catalog-diff: %v\n", err)
+		}
+	default:
+		exitf("catalog-diff supports only text and json formats\n")
+	}
+}
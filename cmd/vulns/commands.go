@@ -0,0 +1,303 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	myanalysis "github.com/hyangah/vulns/analysis"
+	"github.com/hyangah/vulns/vulncache"
+)
+
+// A command is a subcommand of vulns.
+//
+// Each command takes the flags registered in its own flag set by
+// flags, after its name. The scan command is the command run when no
+// command is named, such as in "vulns -format json ./...".
+type command struct {
+	name  string
+	args  string // the usage of the arguments, such as "[package]"
+	short string // one-line description
+	long  string // the rest of the help, if any
+	// flags registers the flags of the command in fs.
+	// It is nil for the commands without flags.
+	flags func(fs *flag.FlagSet)
+	// run runs the command with the arguments after its flags.
+	// It is nil for scan and module, which main runs.
+	run func(args []string)
+	// hidden commands are not listed, such as the deprecated ones.
+	hidden bool
+
+	fs *flag.FlagSet // built by flagSet
+}
+
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{
+			name:  "scan",
+			args:  "[package]",
+			short: "report the known vulnerabilities reachable from the packages",
+			long: `"vulns [-flag] package" is short for "vulns scan [-flag] package".
+Packages named like a command are scanned with "vulns scan", or
+with a relative pattern such as ./diff.`,
+			flags: scanFlags,
+		},
+		{
+			name:  "module",
+			args:  "path@version [package]",
+			short: "download a module version and scan its packages (default ./...)",
+			flags: scanFlags,
+		},
+		{
+			name:  "binary",
+			args:  "file",
+			short: "report the vulnerabilities of the module versions recorded in a Go binary",
+			flags: reportModulesFlags,
+			run:   binary,
+		},
+		{
+			name:  "sbom",
+			args:  "file",
+			short: "report the vulnerabilities of the Go modules listed in an SPDX or CycloneDX SBOM",
+			flags: reportModulesFlags,
+			run:   sbom,
+		},
+		{
+			name:  "dir",
+			args:  "[directory ...]",
+			short: "scan the Go binaries in directory trees, with -format text or json",
+			flags: dirFlags,
+			run:   dir,
+		},
+		{
+			name:  "multi",
+			args:  "-manifest repos.yaml",
+			short: "scan several repositories listed in a manifest",
+			flags: multiFlags,
+			run:   multi,
+		},
+		{
+			name:  "diff",
+			args:  "old new [package]",
+			short: "report the findings introduced, removed, or changed between two revisions",
+			long: `old and new are directories or git revisions of the repository of the
+current directory, which are checked out into temporary worktrees.
+Under -fail-on, only the introduced findings fail, so that CI fails
+on the vulnerabilities a change introduces and not on the ones the
+code already had.`,
+			flags: diffFlags,
+			run:   diff,
+		},
+		{
+			name:  "dump",
+			args:  "-o file [package]",
+			short: "write the catalog of the OSV entries affecting the packages",
+			long: `The catalog holds the entries a scan of the packages uses, filtered and
+normalized the same way. The analyzer reads it with -vulns-json, such
+as when run alone with go vet -vettool.`,
+			flags: dumpFlags,
+			run:   dump,
+		},
+		{
+			name:  "catalog",
+			args:  "[module[@version] ...]",
+			short: "write the catalog of the OSV entries of modules, or of the whole database",
+			flags: catalogFlags,
+			run:   catalog,
+		},
+		{
+			name:  "catalog-diff",
+			args:  "old.json new.json",
+			short: "report the vulnerable symbols added or removed between two catalogs",
+			flags: addFormatFlag,
+			run:   catalogDiff,
+		},
+		{
+			name:  "stamp",
+			args:  "[package]",
+			short: "write the summary of a scan to build into the binary of the packages",
+			flags: stampFlags,
+			run:   writeStamp,
+		},
+		{
+			name:  "history",
+			args:  "store",
+			short: "show the history of the scans recorded with -history",
+			flags: historyFlags,
+			run:   showHistory,
+		},
+		{
+			name:  "cache",
+			args:  "warm [package] | dir | clean -f",
+			short: "manage the cache of the vulnerability database responses",
+			long: `"cache warm" fetches the entries of the modules in the import graph of
+the packages into the cache, so that a later scan can run without
+network access while the cached index is fresh. "cache dir" prints
+the directory of the cache, and "cache clean -f" removes it. The
+cache is shared with govulncheck, so "cache clean" requires -f.
+The flags, before the subcommand, are those of "cache warm".`,
+			flags: warmFlags,
+			run:   cache,
+		},
+		{
+			name:  "version",
+			short: "print the version of vulns",
+			run:   version,
+		},
+		{
+			name:  "help",
+			args:  "[command]",
+			short: "print the help of a command",
+			run:   help,
+		},
+		{
+			name:   "warm",
+			args:   "[package]",
+			short:  `deprecated alias of "cache warm"`,
+			flags:  warmFlags,
+			run:    warm,
+			hidden: true,
+		},
+	}
+}
+
+// lookupCommand returns the command with the name, or nil.
+// Only the exact names of the commands name them: other arguments,
+// such as package patterns, are the arguments of scan.
+func lookupCommand(name string) *command {
+	for _, c := range commands {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// flagSet returns the flag set of the command. It is built on first
+// use, before parsing the flags, so that the defaults the help of the
+// command prints are not the parsed values.
+func (c *command) flagSet() *flag.FlagSet {
+	if c.fs == nil {
+		c.fs = flag.NewFlagSet(c.name, flag.ExitOnError)
+		if c.flags != nil {
+			c.flags(c.fs)
+		}
+		c.fs.Usage = func() { help([]string{c.name}) }
+	}
+	return c.fs
+}
+
+// exitUsage prints the help of the command and exits
+// with status 2, as for invalid flags.
+func exitUsage(name string) {
+	help([]string{name})
+	exit(2)
+}
+
+// usage prints the help of vulns, listing the commands.
+func usage() {
+	a := myanalysis.Analyzer
+	paras := strings.Split(a.Doc, "\n\n")
+	fmt.Fprintf(os.Stderr, "%s: %s\n\n", a.Name, paras[0])
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [-flag] [arguments]\n", a.Name)
+	fmt.Fprintf(os.Stderr, "       %s [-flag] [package]   (short for scan)\n\n", a.Name)
+	fmt.Fprintf(os.Stderr, "Each command takes its own flags, after its name.\n\n")
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, c := range commands {
+		if !c.hidden {
+			fmt.Fprintf(os.Stderr, "  %-13s %s\n", c.name, c.short)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "\nRun '%s help <command>' for the help and the flags of a command.\n", a.Name)
+	if len(paras) > 1 {
+		fmt.Fprintf(os.Stderr, "\n%s\n", strings.Join(paras[1:], "\n\n"))
+	}
+	fmt.Fprintln(os.Stderr, "\nExit status:")
+	fmt.Fprintln(os.Stderr, "  0  no vulnerabilities violating -fail-on were found")
+	fmt.Fprintln(os.Stderr, "  1  an error occurred, or under -q, vulnerabilities were found")
+	fmt.Fprintln(os.Stderr, "  2  under -q, an error occurred")
+	fmt.Fprintln(os.Stderr, "  3  vulnerabilities violating -fail-on were found")
+}
+
+// help prints the help of the command named by the argument,
+// or of vulns.
+func help(args []string) {
+	if len(args) == 0 {
+		usage()
+		return
+	}
+	if len(args) > 1 {
+		exitf("help: want at most one command\n")
+	}
+	c := lookupCommand(args[0])
+	if c == nil {
+		exitf("help: unknown command %q; run 'vulns help' for the list\n", args[0])
+	}
+	usage := "vulns " + c.name
+	if c.flags != nil {
+		usage += " [-flag]"
+	}
+	if c.args != "" {
+		usage += " " + c.args
+	}
+	fmt.Fprintf(os.Stderr, "Usage: %s\n\n", usage)
+	fmt.Fprintf(os.Stderr, "%s%s.\n", strings.ToUpper(c.short[:1]), c.short[1:])
+	if c.long != "" {
+		fmt.Fprintf(os.Stderr, "\n%s\n", c.long)
+	}
+	if c.flags != nil {
+		fmt.Fprintln(os.Stderr, "\nFlags:")
+		c.flagSet().PrintDefaults()
+	}
+}
+
+// version prints the version of vulns and of the Go toolchain
+// that built it.
+func version(args []string) {
+	if len(args) > 0 {
+		exitf("version: no arguments expected\n")
+	}
+	v := "devel"
+	if bi, ok := debug.ReadBuildInfo(); ok && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+		v = bi.Main.Version
+	}
+	fmt.Printf("vulns %s (%s %s/%s)\n", v, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}
+
+// cache runs the subcommands managing the cache of the vulnerability
+// database responses.
+func cache(args []string) {
+	if len(args) == 0 {
+		exitf("cache: want warm, dir, or clean\n")
+	}
+	switch args[0] {
+	case "warm":
+		if flagOffline {
+			exitf("cache warm conflicts with -offline\n")
+		}
+		warm(args[1:])
+	case "dir":
+		fmt.Println(vulncache.DefaultDir())
+	case "clean":
+		if len(args) != 2 || args[1] != "-f" {
+			exitf("cache clean: %s is shared with govulncheck; run \"vulns cache clean -f\" to remove it\n", vulncache.DefaultDir())
+		}
+		if err := os.RemoveAll(vulncache.DefaultDir()); err != nil {
+			exitf("cache clean: %v\n", err)
+		}
+	default:
+		exitf("cache: unknown subcommand %q; want warm, dir, or clean\n", args[0])
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLookupCommand(t *testing.T) {
+	dir := t.TempDir()
+	// A package named like a command.
+	if err := os.Mkdir(filepath.Join(dir, "diff"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	for _, tc := range []struct {
+		arg, want string // want is "" for the arguments of scan
+	}{
+		{"diff", "diff"}, // the command, not the package
+		{"./diff", ""},
+		{"example.com/cache", ""},
+		{"./...", ""},
+		{"cache", "cache"},
+		{"help", "help"},
+		{"scan", "scan"},
+		{"Scan", ""},
+		{"-format", ""},
+		{"nosuch", ""},
+	} {
+		got := ""
+		if c := lookupCommand(tc.arg); c != nil {
+			got = c.name
+		}
+		if got != tc.want {
+			t.Errorf("lookupCommand(%q) = %q, want %q", tc.arg, got, tc.want)
+		}
+	}
+}
+
+func TestCommandFlags(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		has     []string
+		hasNot  []string
+		formatD string // the default of -format, if any
+	}{
+		{"scan", []string{"format", "fix", "roots", "template-file", "json", "db", "fail-on", "test"}, nil, "text"},
+		{"module", []string{"format", "roots", "db"}, nil, "text"},
+		{"binary", []string{"format", "db", "goos", "fail-on", "on-db-error"}, []string{"fix", "roots", "template-file", "test", "json"}, "text"},
+		{"sbom", []string{"format", "db"}, []string{"fix", "roots", "test"}, "text"},
+		{"dir", []string{"format", "db", "fail-on"}, []string{"roots", "goos", "on-db-error"}, "text"},
+		{"multi", []string{"manifest", "nosync", "format", "roots", "test"}, []string{"fix", "baseline"}, "text"},
+		{"diff", []string{"format", "baseline", "roots"}, []string{"fix", "manifest"}, "text"},
+		{"dump", []string{"o", "db", "id", "test"}, []string{"format", "roots", "fail-on"}, ""},
+		{"catalog", []string{"o", "db"}, []string{"format", "id", "test"}, ""},
+		{"catalog-diff", []string{"format"}, []string{"db"}, "text"},
+		{"stamp", []string{"o", "var", "baseline", "roots"}, []string{"fix", "fail-on"}, "json"},
+		{"history", []string{"module", "target", "json"}, []string{"history", "format", "db"}, ""},
+		{"cache", []string{"db", "test"}, []string{"format"}, ""},
+		{"version", nil, []string{"format", "db"}, ""},
+		{"help", nil, []string{"format", "db"}, ""},
+	} {
+		c := lookupCommand(tc.name)
+		if c == nil {
+			t.Errorf("no command %s", tc.name)
+			continue
+		}
+		fs := c.flagSet()
+		for _, name := range tc.has {
+			if fs.Lookup(name) == nil {
+				t.Errorf("%s has no -%s flag", tc.name, name)
+			}
+		}
+		for _, name := range tc.hasNot {
+			if fs.Lookup(name) != nil {
+				t.Errorf("%s has the -%s flag", tc.name, name)
+			}
+		}
+		if f := fs.Lookup("format"); f != nil && f.DefValue != tc.formatD {
+			t.Errorf("%s -format defaults to %q, want %q", tc.name, f.DefValue, tc.formatD)
+		}
+	}
+}
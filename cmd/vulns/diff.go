@@ -0,0 +1,189 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/hyangah/vulns/internal/osvutil"
+	"github.com/hyangah/vulns/quickcheck"
+	"golang.org/x/tools/go/packages"
+)
+
+// diffFlags registers the flags of diff.
+func diffFlags(fs *flag.FlagSet) {
+	addFormatFlag(fs)
+	addDBFlags(fs)
+	addFilterFlags(fs)
+	addSuppressionFlags(fs)
+	addResultFlags(fs)
+	addLoadFlags(fs)
+	addAnalyzerFlags(fs)
+}
+
+// diff scans two revisions of the code, given as directories or git
+// revisions, and reports the findings introduced, removed, or changed
+// from the old one to the new one. Under -fail-on, only the
+// introduced findings fail, so that CI does not fail on the backlog
+// of the old revision.
+func diff(args []string) {
+	if len(args) < 2 {
+		exitUsage("diff")
+	}
+	switch flagFormat {
+	case "text", "json":
+	default:
+		exitf("diff supports only text and json formats\n")
+	}
+	patterns := args[2:]
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+	ignoreRules, ignoreAttrs, baseline := suppressions()
+
+	dbURLs := databases(&packages.Config{})
+	checkOffline(dbURLs, nil)
+	dbClient, err := osvutil.NewClient(dbURLs, dbOptions())
+	if err != nil {
+		exitf("failed to setup vulncheck client: %v", err)
+	}
+	dbClient.Ignore = ignoredIDs()
+	dbClient.Only = onlyIDs()
+	dbClient.Ecosystems = ecosystems()
+	dbClient.Concurrency = flagConcurrency
+	dbClient.SnapshotTime = dbSnapshotTime
+
+	ctx := context.Background()
+	var summaries [2]map[quickcheck.Key]quickcheck.Value
+	for i, rev := range args[:2] {
+		dir, err := checkout(ctx, rev)
+		if err != nil {
+			exitf("diff: %v\n", err)
+		}
+		summary, err := scanDir(ctx, dir, patterns, dbClient)
+		if err != nil {
+			exitf("diff: failed to analyze %s: %v\n", rev, err)
+		}
+		if len(ignoreRules) > 0 {
+			summary = quickcheck.Ignore(summary, ignoreRules)
+		}
+		if len(ignoreAttrs) > 0 {
+			summary = quickcheck.IgnoreAttrs(summary, ignoreAttrs)
+		}
+		if len(baseline) > 0 {
+			summary = quickcheck.Ignore(summary, baseline)
+		}
+		summaries[i] = summary
+	}
+	changes := quickcheck.Diff(summaries[0], summaries[1])
+
+	introduced := 0
+	for _, c := range changes {
+		if c.Kind == quickcheck.ChangeIntroduced {
+			introduced++
+		}
+	}
+	switch flagFormat {
+	case "text":
+		writeDiffText(os.Stdout, changes)
+	case "json":
+		if changes == nil {
+			changes = []*quickcheck.Change{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(changes); err != nil {
+			exitf("diff: %v\n", err)
+		}
+	}
+	exitFailOn(introduced > 0, introduced > 0)
+}
+
+// writeDiffText writes the changes, one per line marked with "+" for
+// the introduced findings, "-" for the removed ones, and "~" for the
+// changed ones, followed by the number of changes of each kind.
+func writeDiffText(w io.Writer, changes []*quickcheck.Change) {
+	counts := make(map[string]int)
+	for _, c := range changes {
+		counts[c.Kind]++
+		f := c.Finding()
+		subject := f.SymbolID().String()
+		switch c.Kind {
+		case quickcheck.ChangeIntroduced:
+			fmt.Fprintf(w, "+ %s %s (%s@%s)\n", f.ID, subject, f.ModulePath, f.Version)
+		case quickcheck.ChangeRemoved:
+			fmt.Fprintf(w, "- %s %s (%s@%s)\n", f.ID, subject, f.ModulePath, f.Version)
+		case quickcheck.ChangeChanged:
+			if c.Old.Version != c.New.Version {
+				fmt.Fprintf(w, "~ %s %s (%s %s => %s)\n", f.ID, subject, f.ModulePath, c.Old.Version, c.New.Version)
+			} else {
+				fmt.Fprintf(w, "~ %s %s (reached from %s)\n", f.ID, subject, strings.Join(c.New.Entries, ", "))
+			}
+		}
+	}
+	if len(changes) > 0 {
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "%d introduced, %d removed, %d changed\n",
+		counts[quickcheck.ChangeIntroduced], counts[quickcheck.ChangeRemoved], counts[quickcheck.ChangeChanged])
+}
+
+// checkout returns the directory of the revision: rev itself if it
+// is a directory, or else a git worktree of the commit rev names in
+// the repository of the current directory, in a temporary directory
+// removed at exit. The directory returned for a worktree is the one
+// corresponding to the current directory.
+func checkout(ctx context.Context, rev string) (string, error) {
+	if fi, err := os.Stat(rev); err == nil && fi.IsDir() {
+		return rev, nil
+	}
+	prefix, err := gitOutput(ctx, "", "rev-parse", "--show-prefix")
+	if err != nil {
+		return "", fmt.Errorf("%s is neither a directory nor a git revision: %v", rev, err)
+	}
+	commit, err := gitOutput(ctx, "", "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("%s is neither a directory nor a git revision", rev)
+	}
+	tmp, err := os.MkdirTemp("", "vulns-diff-")
+	if err != nil {
+		return "", err
+	}
+	worktree := filepath.Join(tmp, "src")
+	if err := runGit(ctx, "", "worktree", "add", "--detach", worktree, commit); err != nil {
+		os.RemoveAll(tmp)
+		return "", err
+	}
+	atExit(func() {
+		runGit(context.Background(), "", "worktree", "remove", "--force", worktree)
+		os.RemoveAll(tmp)
+	})
+	return filepath.Join(worktree, filepath.FromSlash(prefix)), nil
+}
+
+// gitOutput runs git in dir and returns its output, trimmed.
+func gitOutput(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %v\n%s", strings.Join(args, " "), err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
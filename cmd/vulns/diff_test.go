@@ -0,0 +1,64 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hyangah/vulns/quickcheck"
+)
+
+func TestWriteDiffText(t *testing.T) {
+	key := func(id, sym string) quickcheck.Key {
+		return quickcheck.Key{ID: id, Symbol: sym, PackagePath: "example.com/lib", ModulePath: "example.com/lib"}
+	}
+	value := func(version, entry string) quickcheck.Value {
+		return quickcheck.Value{Count: 1, Version: version, Entries: []string{entry}, Trace: []string{entry + " a.go:1:1", "example.com/lib.F lib.go:1:1"}}
+	}
+	old := map[quickcheck.Key]quickcheck.Value{
+		key("GO-1", "Parse"):  value("v1.0.0", "example.com/a.main"),
+		key("GO-2", "Decode"): value("v1.0.0", "example.com/a.main"),
+		key("GO-3", "Encode"): value("v1.0.0", "example.com/a.main"),
+		key("GO-4", "Read"):   value("v1.0.0", "example.com/a.main"),
+	}
+	new := map[quickcheck.Key]quickcheck.Value{
+		key("GO-1", "Parse"):  value("v1.1.0", "example.com/a.main"), // upgraded
+		key("GO-2", "Decode"): value("v1.0.0", "example.com/a.run"),  // reached from elsewhere
+		key("GO-4", "Read"):   value("v1.0.0", "example.com/a.main"), // unchanged
+		key("GO-5", "Write"):  value("v1.0.0", "example.com/a.main"),
+	}
+	for _, tc := range []struct {
+		name     string
+		old, new map[quickcheck.Key]quickcheck.Value
+		want     string
+	}{
+		{"none", old, old, "0 introduced, 0 removed, 0 changed\n"},
+		{"empty", nil, nil, "0 introduced, 0 removed, 0 changed\n"},
+		{"all", old, new, `~ GO-1 example.com/lib.Parse (example.com/lib v1.0.0 => v1.1.0)
+~ GO-2 example.com/lib.Decode (reached from example.com/a.run)
+- GO-3 example.com/lib.Encode (example.com/lib@v1.0.0)
++ GO-5 example.com/lib.Write (example.com/lib@v1.0.0)
+
+1 introduced, 1 removed, 2 changed
+`},
+		{"reversed", new, old, `~ GO-1 example.com/lib.Parse (example.com/lib v1.1.0 => v1.0.0)
+~ GO-2 example.com/lib.Decode (reached from example.com/a.main)
++ GO-3 example.com/lib.Encode (example.com/lib@v1.0.0)
+- GO-5 example.com/lib.Write (example.com/lib@v1.0.0)
+
+1 introduced, 1 removed, 2 changed
+`},
+	} {
+		var buf bytes.Buffer
+		writeDiffText(&buf, quickcheck.Diff(tc.old, tc.new))
+		if got := buf.String(); got != tc.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+
+	"github.com/hyangah/vulns/internal/osvutil"
+	"github.com/hyangah/vulns/quickcheck"
+	"github.com/hyangah/vulns/render"
+	"golang.org/x/tools/go/packages"
+)
+
+// dirFlags registers the flags of dir.
+func dirFlags(fs *flag.FlagSet) {
+	addFormatFlag(fs)
+	addDBFlags(fs)
+	addFilterFlags(fs)
+	addResultFlags(fs)
+}
+
+// dir scans the Go binaries found in the directory trees,
+// such as deployment artifacts, and reports the vulnerable
+// symbols each of them contains.
+func dir(roots []string) {
+	if len(roots) == 0 {
+		exitf("dir: no directories\n")
+	}
+	write := render.BinariesText
+	switch flagFormat {
+	case "text":
+	case "json":
+		write = render.BinariesJSON
+	default:
+		exitf("dir supports only text and json formats\n")
+	}
+	dbURLs := databases(&packages.Config{})
+	checkOffline(dbURLs, nil)
+	dbClient, err := osvutil.NewClient(dbURLs, dbOptions())
+	if err != nil {
+		exitf("failed to setup vulncheck client: %v", err)
+	}
+	dbClient.Ignore = ignoredIDs()
+	dbClient.Only = onlyIDs()
+	dbClient.Ecosystems = ecosystems()
+	dbClient.Concurrency = flagConcurrency
+	dbClient.SnapshotTime = dbSnapshotTime
+	var results []*quickcheck.BinaryResult
+	for _, root := range roots {
+		rs, err := quickcheck.ScanDir(context.Background(), root, dbClient)
+		if err != nil {
+			exitf("dir: %v\n", err)
+		}
+		results = append(results, rs...)
+	}
+	if err := write(os.Stdout, results); err != nil {
+		exitf("failed to render the report: %v\n", err)
+	}
+	found := false
+	for _, r := range results {
+		found = found || len(r.Findings) > 0
+	}
+	// Binaries record only the vulnerable symbols they contain.
+	exitFailOn(found, found)
+}
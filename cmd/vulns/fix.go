@@ -0,0 +1,121 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/hyangah/vulns/quickcheck"
+)
+
+// fixGoMod raises the requirements of the go.mod file of the main
+// module in the current directory to the minimum versions fixing the
+// findings in summary, and prints the diff. With -dry-run, it prints
+// the equivalent go commands instead.
+func fixGoMod(summary map[quickcheck.Key]quickcheck.Value) {
+	ups := quickcheck.Upgrades(summary)
+	if len(ups) == 0 {
+		fmt.Fprintln(os.Stderr, "fix: no upgrades to apply")
+		return
+	}
+	out, err := exec.Command("go", "env", "GOMOD").Output()
+	gomod := strings.TrimSpace(string(out))
+	if err != nil || gomod == "" || gomod == os.DevNull {
+		exitf("fix: no go.mod file in the current directory\n")
+	}
+	data, err := os.ReadFile(gomod)
+	if err != nil {
+		exitf("fix: %v\n", err)
+	}
+	fixed, skipped, err := quickcheck.EditGoMod(gomod, data, ups)
+	if err != nil {
+		exitf("fix: %v\n", err)
+	}
+	for _, u := range ups {
+		if reason, ok := skipped[u.Path]; ok {
+			fmt.Fprintf(os.Stderr, "fix: not upgrading %s to %s: %s\n", u.Path, u.To, reason)
+		}
+	}
+	dir := filepath.Dir(gomod)
+	if flagDryRun {
+		for _, u := range ups {
+			if _, ok := skipped[u.Path]; !ok {
+				fmt.Printf("go get %s@%s # %s\n", u.Path, u.To, strings.Join(u.IDs, ", "))
+			}
+		}
+		if flagTidy {
+			fmt.Println("go mod tidy")
+		}
+		return
+	}
+	if bytes.Equal(data, fixed) {
+		return
+	}
+	if err := os.WriteFile(gomod, fixed, 0666); err != nil {
+		exitf("fix: %v\n", err)
+	}
+	fmt.Print(lineDiff(gomod, string(data), string(fixed)))
+	if flagTidy {
+		cmd := exec.Command("go", "mod", "tidy")
+		cmd.Dir = dir
+		cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+		if err := cmd.Run(); err != nil {
+			exitf("fix: go mod tidy: %v\n", err)
+		}
+	}
+}
+
+// lineDiff returns the lines removed from and added to the file
+// between old and new, in the style of a unified diff without
+// context lines. It is meant for small files such as go.mod.
+func lineDiff(file, old, new string) string {
+	a, b := strings.SplitAfter(old, "\n"), strings.SplitAfter(new, "\n")
+	// lcs[i][j] is the length of the longest common
+	// subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "--- %s\n+++ %s\n", file, file)
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			if a[i] != "" {
+				fmt.Fprintf(&buf, "-%s", a[i])
+			}
+			i++
+		default:
+			if b[j] != "" {
+				fmt.Fprintf(&buf, "+%s", b[j])
+			}
+			j++
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,180 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import (
+	"flag"
+	"strings"
+
+	myanalysis "github.com/hyangah/vulns/analysis"
+	"github.com/hyangah/vulns/internal/xtools/analysisflags"
+	"github.com/hyangah/vulns/internal/xtools/checker"
+	"github.com/hyangah/vulns/quickcheck"
+	"github.com/hyangah/vulns/render"
+)
+
+// The flags of the commands, with their default values. Each command
+// registers the ones it takes in its own flag set (see command.flags),
+// with the add*Flags functions for the flags shared by several
+// commands.
+var (
+	flagFormat        = "text"
+	flagTemplateFile  = ""
+	flagID            = ""
+	flagIgnore        = ""
+	flagEcosystems    = ""
+	flagIgnoreFile    = ""
+	flagIgnoreSymbol  = ""
+	flagIgnoreAttr    = ""
+	flagLocal         = ""
+	flagGroupBy       = render.GroupByModule
+	flagSort          = render.SortByID
+	flagSeverities    = ""
+	flagMinSeverity   = ""
+	flagReport        = "findings"
+	flagSummary       = false
+	flagShowFiltered  = false
+	flagShow          = ""
+	flagOffline       = false
+	flagConcurrency   = 0
+	flagAnalysisCache = ""
+	flagDB            = ""
+	flagDBSnapshot    = ""
+	flagMirrors       = false
+	flagBaseline      = ""
+	flagBaselineWrite = ""
+	flagScan          = quickcheck.ScanSymbol
+	flagDBRate        = 0.0
+	flagCacheFallback = cacheFallbackTemp
+	flagDBBurst       = 10
+	flagNoColor       = false
+	flagShortTraces   = false
+	flagTags          = ""
+	flagGOOS          = ""
+	flagGOARCH        = ""
+	flagDryRun        = false
+	flagHistory       = ""
+	flagIssues        = ""
+	flagTidy          = false
+	flagWatch         = false
+	flagFailOn        = failOnNone
+	flagQuiet         = false
+	flagOnDBError     = onDBErrorFail
+)
+
+// scanFlags registers the flags of scan and module.
+func scanFlags(fs *flag.FlagSet) {
+	addFormatFlag(fs)
+	addDBFlags(fs)
+	addFilterFlags(fs)
+	addSuppressionFlags(fs)
+	addResultFlags(fs)
+	addOnDBErrorFlag(fs)
+	addLoadFlags(fs)
+	addAnalyzerFlags(fs)
+
+	fs.StringVar(&flagTemplateFile, "template-file", flagTemplateFile, "file with the text/template to render the text report with, such as a modified copy of render.DefaultTextTemplate")
+	fs.StringVar(&flagLocal, "local", flagLocal, "comma-separated list of module path prefixes of your code (default: the main modules)")
+	fs.StringVar(&flagGroupBy, "group-by", flagGroupBy, "group findings in the text and JSON output by module, vulnerability (vuln), affected package (package), or entry package in your code (entry)")
+	fs.StringVar(&flagSort, "sort", flagSort, "order of the findings and their groups: vulnerability ID (id), severity (from -severities), or module path (module)")
+	fs.StringVar(&flagSeverities, "severities", flagSeverities, "file of the severities of the vulnerabilities, one \"ID severity\" pair per line such as \"GHSA-xxxx-xxxx-xxxx High\", for -sort=severity and the severity labels of the output; IDs may be aliases")
+	fs.StringVar(&flagMinSeverity, "min-severity", flagMinSeverity, "leave out of the report the findings less severe than this bucket of -severities: "+strings.Join(render.Severities(), ", ")+" (the vulnerabilities not in the file)")
+	fs.StringVar(&flagReport, "report", flagReport, "what to report: reachable vulnerabilities (findings) or all known vulnerabilities of the dependencies (deps)")
+	fs.BoolVar(&flagSummary, "summary", flagSummary, "report only counts: the known vulnerabilities by module, how many are symbol-reachable or import-only, and the affected packages")
+	fs.BoolVar(&flagShowFiltered, "show-filtered", flagShowFiltered, "also show vulnerabilities excluded because they affect only other platforms (GOOS/GOARCH)")
+	fs.StringVar(&flagShow, "show", flagShow, "comma-separated list of extra sections to show: unreachable (known vulnerabilities of required modules without reachable vulnerable symbols, as informational), not-affected (the reason each known vulnerability of the import closure does not affect the packages, in JSON and YAML output)")
+	fs.StringVar(&flagAnalysisCache, "analysis-cache", flagAnalysisCache, "directory of a cache of the per-package analysis results, reused by later runs on unchanged packages with the same vulnerabilities (default: no caching)")
+	fs.BoolVar(&flagMirrors, "mirrors", flagMirrors, "treat multiple database URLs as mirrors of one database and fall back to the next on failure")
+	fs.StringVar(&flagBaselineWrite, "baseline-write", flagBaselineWrite, "write the reported findings to the baseline file")
+	fs.StringVar(&flagScan, "scan", flagScan, "scan level: gomod (go.mod, go.sum, and go.work files only, without the go command; the packages are ignored), module (go.mod data only; the packages are ignored), package (import graph), or symbol (reachability analysis)")
+	fs.BoolVar(&flagNoColor, "no-color", flagNoColor, "do not colorize the text output (default: colorized on terminals unless $NO_COLOR is set)")
+	fs.BoolVar(&flagShortTraces, "short-traces", flagShortTraces, "shorten the traces in text, markdown, and html output to the last element of the package paths and to file names relative to the current directory")
+	fs.BoolVar(&flagDryRun, "dry-run", flagDryRun, "with -fix, print the go commands upgrading the modules instead of editing go.mod; with -issues, print the changes to the issues instead of making them")
+	fs.StringVar(&flagHistory, "history", flagHistory, "record the findings in the history of scan runs in the directory, or in the SQLite database if the file name ends in .db, .sqlite, or .sqlite3 (in builds linking an SQLite driver)")
+	fs.StringVar(&flagIssues, "issues", flagIssues, "file an issue per vulnerability and module in github:owner/repo (token in $GITHUB_TOKEN) or gitlab:group/project (token in $GITLAB_TOKEN), and close them when resolved")
+	fs.BoolVar(&flagTidy, "tidy", flagTidy, "with -fix, run \"go mod tidy\" after editing go.mod")
+	fs.BoolVar(&flagWatch, "watch", flagWatch, "stay resident and analyze the packages again when the Go files of the main modules change, or on SIGHUP after querying the databases and reading the suppressions, -ignore-file, and -baseline again")
+	fs.BoolVar(&analysisflags.JSON, "json", analysisflags.JSON, "shorthand for -format=json")
+	fs.IntVar(&analysisflags.Context, "c", analysisflags.Context, "display offending line with this many lines of context")
+	fs.BoolVar(&checker.Fix, "fix", checker.Fix, "raise the requirements of go.mod to the minimum versions fixing the findings")
+	fs.StringVar(&checker.CPUProfile, "cpuprofile", checker.CPUProfile, "write CPU profile to this file")
+	fs.StringVar(&checker.MemProfile, "memprofile", checker.MemProfile, "write memory profile to this file")
+	fs.StringVar(&checker.Trace, "trace", checker.Trace, "write trace log to this file")
+}
+
+// addFormatFlag registers the -format flag, whose values the
+// commands other than scan restrict further.
+func addFormatFlag(fs *flag.FlagSet) {
+	fs.StringVar(&flagFormat, "format", flagFormat, "output format; one of "+strings.Join(render.Names(), ", "))
+}
+
+// addDBFlags registers the flags of the commands querying the
+// vulnerability databases, including -debug to log the queries.
+func addDBFlags(fs *flag.FlagSet) {
+	fs.StringVar(&flagDB, "db", flagDB, "comma-separated list of vulnerability database URLs (default: $GOVULNDB or https://vuln.go.dev); the credentials of the ones requiring authentication are read from $GOVULNDB_AUTH or .netrc")
+	fs.StringVar(&flagDBSnapshot, "db-snapshot-time", flagDBSnapshot, "RFC3339 time of an approved database snapshot; ignore the entries published after it, and mark the ones modified after it in their source")
+	fs.Float64Var(&flagDBRate, "db-rate", flagDBRate, "maximum average number of vulnerability database requests per second (0 means unlimited)")
+	fs.IntVar(&flagDBBurst, "db-burst", flagDBBurst, "maximum number of vulnerability database requests sent at once under -db-rate")
+	fs.StringVar(&flagCacheFallback, "cache-fallback", flagCacheFallback, "cache of the vulnerability database responses if the one in the module cache is not writable, as in hermetic sandboxes: temp (a temporary directory), memory, or none (fail)")
+	fs.BoolVar(&flagOffline, "offline", flagOffline, "forbid network access: the databases (-db or GOVULNDB) must be local file:// databases, and no modules are downloaded")
+	fs.IntVar(&flagConcurrency, "concurrency", flagConcurrency, "maximum number of packages analyzed and of modules looked up in the database at once (default: GOMAXPROCS)")
+	fs.StringVar(&flagEcosystems, "ecosystems", flagEcosystems, "comma-separated list of OSV ecosystems, besides Go, of the affected packages to consider, such as the ecosystem of internal advisories in the database")
+	fs.StringVar(&checker.Debug, "debug", checker.Debug, `debug flags, any subset of "fpstv"`)
+}
+
+// addFilterFlags registers the flags selecting the vulnerabilities
+// to look up in the databases.
+func addFilterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&flagID, "id", flagID, "comma-separated list of vulnerability IDs (GO-, CVE-, or GHSA-) to restrict the analysis to, fetching only their entries")
+	fs.StringVar(&flagIgnore, "ignore", flagIgnore, "comma-separated list of vulnerability IDs (GO-, CVE-, GHSA-, or the IDs of internal advisories) to leave out of the analysis")
+	fs.StringVar(&flagIgnoreFile, "ignore-file", flagIgnoreFile, "file listing vulnerability IDs to leave out of the analysis, one per line")
+}
+
+// addSuppressionFlags registers the flags suppressing findings
+// (see suppressions).
+func addSuppressionFlags(fs *flag.FlagSet) {
+	fs.StringVar(&flagIgnoreSymbol, "ignore-symbol", flagIgnoreSymbol, "comma-separated list of accepted findings to suppress, as ID or ID:pkgpath.Symbol")
+	fs.StringVar(&flagIgnoreAttr, "ignore-attr", flagIgnoreAttr, "comma-separated list of finding attributes whose findings are suppressed; any of "+strings.Join(quickcheck.Attrs(), ", "))
+	fs.StringVar(&flagBaseline, "baseline", flagBaseline, "baseline file of accepted findings (see -baseline-write) to leave out of the report")
+}
+
+// addResultFlags registers the flags selecting how the result of
+// the commands is reported through the exit status (see exitFailOn).
+func addResultFlags(fs *flag.FlagSet) {
+	fs.StringVar(&flagFailOn, "fail-on", flagFailOn, "exit with status 3 when vulnerabilities are found: none, any (a known vulnerability affects an imported package), symbol-reachable, or a severity bucket of -severities such as High (a finding at least that severe is symbol-reachable)")
+	fs.BoolVar(&flagQuiet, "q", flagQuiet, "print nothing on standard output and report the result with the exit status only: 0 if clean, 1 if vulnerabilities are found (as with -fail-on, symbol-reachable by default), 2 on errors")
+}
+
+// addOnDBErrorFlag registers the -on-db-error flag of the commands
+// able to report a scan as skipped (see skipReason).
+func addOnDBErrorFlag(fs *flag.FlagSet) {
+	fs.StringVar(&flagOnDBError, "on-db-error", flagOnDBError, "what to do when the vulnerability database cannot be queried, such as during an outage: fail, warn (report the scan as skipped and log the error), or skip (report the scan as skipped)")
+}
+
+// addPlatformFlags registers the flags selecting the target platform,
+// which both the go command and the filtering of the platform-specific
+// entries follow.
+func addPlatformFlags(fs *flag.FlagSet) {
+	fs.StringVar(&flagGOOS, "goos", flagGOOS, "target operating system of the analysis, such as js or plan9 (default: $GOOS or the host's)")
+	fs.StringVar(&flagGOARCH, "goarch", flagGOARCH, "target architecture of the analysis, such as wasm (default: $GOARCH or the host's)")
+}
+
+// addLoadFlags registers the flags of the commands loading packages.
+func addLoadFlags(fs *flag.FlagSet) {
+	addPlatformFlags(fs)
+	fs.StringVar(&flagTags, "tags", flagTags, "comma-separated list of build tags to consider satisfied when loading the packages, as in go build -tags")
+	fs.BoolVar(&checker.IncludeTests, "test", checker.IncludeTests, "indicates whether test files should be analyzed, too")
+}
+
+// addAnalyzerFlags registers the flags of the analyzer, such as
+// -roots, for the commands running it.
+func addAnalyzerFlags(fs *flag.FlagSet) {
+	myanalysis.Analyzer.Flags.VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+}
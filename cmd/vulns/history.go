@@ -0,0 +1,138 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hyangah/vulns/history"
+	"github.com/hyangah/vulns/quickcheck"
+	"golang.org/x/vuln/client"
+)
+
+// openHistory opens the store of scan runs named by the -history
+// flag: an SQLite database if the file name has a .db, .sqlite, or
+// .sqlite3 extension, and a directory of JSON files otherwise.
+func openHistory(name string) (history.Store, error) {
+	switch filepath.Ext(name) {
+	case ".db", ".sqlite", ".sqlite3":
+		return history.OpenSQLite(name)
+	}
+	return history.OpenDir(name)
+}
+
+// recordRun adds the run with the findings in all, of which the
+// ones missing in reported were suppressed, to the -history store.
+// The target of the run is the current directory.
+func recordRun(dbClient client.Client, all, reported map[quickcheck.Key]quickcheck.Value) {
+	ctx := context.Background()
+	store, err := openHistory(flagHistory)
+	if err != nil {
+		exitf("history: %v\n", err)
+	}
+	defer store.Close()
+	run := history.NewRun(time.Now().UTC(), all, reported)
+	run.Target, _ = os.Getwd()
+	if run.DBModified, err = dbClient.LastModifiedTime(ctx); err != nil {
+		exitf("history: %v\n", err)
+	}
+	if err := store.Add(ctx, run); err != nil {
+		exitf("history: %v\n", err)
+	}
+	if dbg('v') {
+		log.Printf("recorded run %s in %s", run.ID, flagHistory)
+	}
+}
+
+// The flags of history.
+var (
+	historyModule = ""
+	historyTarget = ""
+	historyJSON   = false
+)
+
+// historyFlags registers the flags of history.
+func historyFlags(fs *flag.FlagSet) {
+	fs.StringVar(&historyModule, "module", historyModule, "show only the findings in the module")
+	fs.StringVar(&historyTarget, "target", historyTarget, "show only the runs scanning the directory (default: all runs)")
+	fs.BoolVar(&historyJSON, "json", historyJSON, "print the history in JSON")
+}
+
+// showHistory prints the history of the findings recorded in the
+// store named by the argument, as by the -history flag of scan: when
+// each finding was first seen, fixed, or suppressed, and the number
+// of findings per month.
+func showHistory(args []string) {
+	if len(args) != 1 {
+		exitUsage("history")
+	}
+	store, err := openHistory(args[0])
+	if err != nil {
+		exitf("history: %v\n", err)
+	}
+	defer store.Close()
+	runs, err := store.Runs(context.Background(), history.Query{Module: historyModule, Target: historyTarget})
+	if err != nil {
+		exitf("history: %v\n", err)
+	}
+	timeline, trend := history.Timeline(runs), history.Trend(runs)
+	if historyJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(struct {
+			Findings []*history.FindingHistory
+			Trend    []*history.MonthTrend
+		}{timeline, trend})
+		return
+	}
+	if len(runs) == 0 {
+		fmt.Println("No runs recorded.")
+		return
+	}
+	date := func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return t.UTC().Format("2006-01-02")
+	}
+	fmt.Printf("%d runs from %s to %s\n\n", len(runs), date(runs[0].Time), date(runs[len(runs)-1].Time))
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	if len(timeline) > 0 {
+		fmt.Fprintln(tw, "Finding\tFirst seen\tFixed\tSuppressed")
+		for _, h := range timeline {
+			fmt.Fprintf(tw, "%s %s\t%s\t%s\t%s\n", h.ID, findingSubject(h.Finding), date(h.FirstSeen), date(h.Fixed), date(h.Suppressed))
+		}
+		fmt.Fprintln(tw)
+	}
+	fmt.Fprintln(tw, "Month\tReported\tSuppressed\tNew\tFixed")
+	for _, m := range trend {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\n", m.Month, m.Reported, m.Suppressed, m.New, m.Fixed)
+	}
+	tw.Flush()
+}
+
+// findingSubject returns what the recorded finding is about: the
+// vulnerable symbol, or the package or the module at the coarser
+// scan levels.
+func findingSubject(f *history.Finding) string {
+	switch {
+	case f.Symbol != "":
+		return f.Key().SymbolID().String()
+	case f.PackagePath != "":
+		return f.PackagePath
+	}
+	return f.ModulePath
+}
@@ -0,0 +1,59 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hyangah/vulns/internal/issues"
+	"github.com/hyangah/vulns/render"
+)
+
+// issueTracker returns the tracker selected with the -issues flag,
+// of the form github:owner/repo or gitlab:group/project. The tokens
+// and the API URLs are read from the environment variables also set
+// in GitHub Actions and GitLab CI/CD.
+func issueTracker(spec string) (issues.Tracker, error) {
+	kind, repo, ok := strings.Cut(spec, ":")
+	if !ok || repo == "" {
+		return nil, fmt.Errorf("invalid -issues flag %q (want github:owner/repo or gitlab:group/project)", spec)
+	}
+	env := func(name, def string) string {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+		return def
+	}
+	switch kind {
+	case "github":
+		return issues.NewGitHub(env("GITHUB_API_URL", issues.GitHubAPI), repo, os.Getenv("GITHUB_TOKEN")), nil
+	case "gitlab":
+		return issues.NewGitLab(env("CI_API_V4_URL", issues.GitLabAPI), repo, os.Getenv("GITLAB_TOKEN")), nil
+	}
+	return nil, fmt.Errorf("invalid -issues flag %q: unknown tracker %q", spec, kind)
+}
+
+// fileIssues files the findings in the report as issues, one per
+// vulnerability and module, and closes the issues of the findings
+// that were resolved. With -dry-run, it only prints what it would do.
+func fileIssues(report *render.Report) {
+	tracker, err := issueTracker(flagIssues)
+	if err != nil {
+		exitf("%v\n", err)
+	}
+	actions, err := issues.Sync(context.Background(), tracker, issues.FromReport(report), flagDryRun)
+	for _, a := range actions {
+		fmt.Fprintf(os.Stderr, "issues: %v\n", a)
+	}
+	if err != nil {
+		exitf("issues: %v\n", err)
+	}
+}
@@ -10,28 +10,65 @@
 package main
 
 import (
+	"bytes"
 	context "context"
 	"encoding/json"
 	"errors"
-	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"runtime"
 	"runtime/pprof"
 	"runtime/trace"
-	"sort"
 	"strings"
+	"time"
 
 	myanalysis "github.com/hyangah/vulns/analysis"
-	"github.com/hyangah/vulns/internal/analysisflags"
-	"github.com/hyangah/vulns/internal/checker"
-	"github.com/hyangah/vulns/internal/govulncheck"
+	"github.com/hyangah/vulns/internal/modproxy"
 	"github.com/hyangah/vulns/internal/osvutil"
+	"github.com/hyangah/vulns/internal/xtools/analysisflags"
+	"github.com/hyangah/vulns/internal/xtools/checker"
 	"github.com/hyangah/vulns/quickcheck"
+	"github.com/hyangah/vulns/render"
+	"github.com/hyangah/vulns/tracefmt"
+	"github.com/hyangah/vulns/vulncache"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/packages"
 	"golang.org/x/vuln/client"
+	"golang.org/x/vuln/osv"
+)
+
+// dbSnapshotTime is the parsed -db-snapshot-time, or zero.
+var dbSnapshotTime time.Time
+
+// Policies of the -fail-on flag.
+const (
+	failOnNone      = "none"
+	failOnAny       = "any"
+	failOnReachable = "symbol-reachable"
+)
+
+// Policies of the -on-db-error flag.
+const (
+	onDBErrorFail = "fail"
+	onDBErrorWarn = "warn"
+	onDBErrorSkip = "skip"
+)
+
+// Caches of the -cache-fallback flag.
+const (
+	cacheFallbackTemp   = "temp"
+	cacheFallbackMemory = "memory"
+	cacheFallbackNone   = "none"
+)
+
+// Sections of the -show flag.
+const (
+	showUnreachable = "unreachable"
+	showNotAffected = "not-affected"
 )
 
 func main() {
@@ -43,38 +80,220 @@ func main() {
 	analyzers := []*analysis.Analyzer{a}
 
 	if err := analysis.Validate(analyzers); err != nil {
-		log.Fatal(err)
+		fatalf("%v", err)
 	}
 
-	checker.RegisterFlags()
-
-	flag.Usage = func() {
-		paras := strings.Split(a.Doc, "\n\n")
-		fmt.Fprintf(os.Stderr, "%s: %s\n\n", a.Name, paras[0])
-		fmt.Fprintf(os.Stderr, "Usage: %s [-flag] [package]\n\n", a.Name)
-		if len(paras) > 1 {
-			fmt.Fprintln(os.Stderr, strings.Join(paras[1:], "\n\n"))
+	args := os.Args[1:]
+	if len(args) == 0 {
+		usage()
+		os.Exit(errorStatus())
+	}
+	// Only the exact name of a command runs it; the other arguments,
+	// including the flags of scan, are the arguments of scan.
+	c := lookupCommand(args[0])
+	if c != nil {
+		args = args[1:]
+	} else {
+		c = lookupCommand("scan")
+		// vulns -h prints the help of vulns, not of scan.
+		c.flagSet().Usage = usage
+	}
+	name := c.name
+	fs := c.flagSet()
+	fs.Parse(args)
+	args = fs.Args()
+	switch name {
+	case "help", "version":
+		c.run(args)
+		return
+	case "scan":
+		if len(args) == 0 {
+			help([]string{"scan"})
+			os.Exit(errorStatus())
+		}
+	}
+	defer runExitHooks()
+	switch flagReport {
+	case "findings", "deps":
+	default:
+		exitf("invalid -report flag %q\n", flagReport)
+	}
+	switch flagScan {
+	case quickcheck.ScanGoMod, quickcheck.ScanModule, quickcheck.ScanPackage, quickcheck.ScanSymbol:
+	default:
+		exitf("invalid -scan flag %q\n", flagScan)
+	}
+	if flagReport == "deps" && flagScan != quickcheck.ScanSymbol {
+		exitf("-report=deps requires -scan=symbol\n")
+	}
+	if flagSummary && (flagReport != "findings" || flagScan != quickcheck.ScanSymbol) {
+		exitf("-summary requires -report=findings and -scan=symbol\n")
+	}
+	for _, v := range showSections() {
+		switch v {
+		case showUnreachable:
+			if flagReport != "findings" || flagScan != quickcheck.ScanSymbol {
+				exitf("-show=unreachable requires -report=findings and -scan=symbol\n")
+			}
+		case showNotAffected:
+			if flagReport != "findings" || flagScan != quickcheck.ScanSymbol || (flagFormat != "json" && flagFormat != "yaml" && !analysisflags.JSON) {
+				exitf("-show=not-affected requires -report=findings, -scan=symbol, and -format=json or yaml\n")
+			}
+		default:
+			exitf("invalid -show flag %q\n", v)
+		}
+	}
+	// OpenVEX statements and CycloneDX analyses tell whether the
+	// vulnerable symbols are reachable, including for the
+	// vulnerabilities without findings.
+	if (flagFormat == "openvex" || flagFormat == "cyclonedx") && (flagReport != "findings" || flagScan != quickcheck.ScanSymbol) {
+		exitf("-format=%s requires -report=findings and -scan=symbol\n", flagFormat)
+	}
+	if flagWatch && (flagReport != "findings" || flagScan != quickcheck.ScanSymbol) {
+		exitf("-watch requires -report=findings and -scan=symbol\n")
+	}
+	// -fix raises the requirements of go.mod to the minimum versions
+	// fixing the findings (see fixGoMod).
+	if checker.Fix && (flagReport != "findings" || flagWatch) {
+		exitf("-fix requires -report=findings and conflicts with -watch\n")
+	}
+	if flagIssues != "" && (flagReport != "findings" || flagWatch) {
+		exitf("-issues requires -report=findings and conflicts with -watch\n")
+	}
+	if flagTidy && !checker.Fix {
+		exitf("-tidy requires -fix\n")
+	}
+	if flagDryRun && !checker.Fix && flagIssues == "" {
+		exitf("-dry-run requires -fix or -issues\n")
+	}
+	switch flagFailOn {
+	case failOnNone, failOnAny, failOnReachable:
+	default:
+		b, err := render.ParseSeverity(flagFailOn)
+		if err != nil {
+			exitf("invalid -fail-on flag %q\n", flagFailOn)
+		}
+		if flagSeverities == "" {
+			exitf("-fail-on=%s requires -severities\n", flagFailOn)
+		}
+		flagFailOn = b
+	}
+	if flagMinSeverity != "" {
+		b, err := render.ParseSeverity(flagMinSeverity)
+		if err != nil {
+			exitf("invalid -min-severity flag: %v\n", err)
+		}
+		if flagSeverities == "" {
+			exitf("-min-severity requires -severities\n")
+		}
+		flagMinSeverity = b
+	}
+	if flagQuiet {
+		if flagWatch {
+			exitf("-q conflicts with -watch\n")
+		}
+		if flagFailOn == failOnNone {
+			flagFailOn = failOnReachable
+		}
+		devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+		if err != nil {
+			exitf("-q: %v\n", err)
+		}
+		os.Stdout = devNull
+	}
+	switch flagOnDBError {
+	case onDBErrorFail, onDBErrorWarn, onDBErrorSkip:
+	default:
+		exitf("invalid -on-db-error flag %q\n", flagOnDBError)
+	}
+	switch flagCacheFallback {
+	case cacheFallbackTemp, cacheFallbackMemory, cacheFallbackNone:
+	default:
+		exitf("invalid -cache-fallback flag %q\n", flagCacheFallback)
+	}
+	if flagOffline {
+		if flagIssues != "" {
+			exitf("-issues conflicts with -offline\n")
+		}
+		if name == "warm" || name == "module" {
+			exitf("%s conflicts with -offline\n", name)
+		}
+		setOffline()
+	}
+	if flagConcurrency < 0 {
+		exitf("invalid -concurrency flag %d\n", flagConcurrency)
+	}
+	if flagConcurrency == 0 {
+		flagConcurrency = runtime.GOMAXPROCS(0)
+	}
+	checker.Concurrency = flagConcurrency
+	checker.CacheDir = flagAnalysisCache
+	if flagDBSnapshot != "" {
+		t, err := time.Parse(time.RFC3339, flagDBSnapshot)
+		if err != nil {
+			exitf("invalid -db-snapshot-time flag: %v\n", err)
+		}
+		dbSnapshotTime = t
+	}
+	// The go command loading the packages and the filtering of
+	// the platform-specific entries both follow GOOS and GOARCH.
+	if flagGOOS != "" {
+		os.Setenv("GOOS", flagGOOS)
+	}
+	if flagGOARCH != "" {
+		os.Setenv("GOARCH", flagGOARCH)
+	}
+	if analysisflags.JSON {
+		// -json is a shorthand for -format=json.
+		if flagFormat != "text" && flagFormat != "json" {
+			exitf("-json conflicts with -format=%s\n", flagFormat)
+		}
+		flagFormat = "json"
+	}
+	if flagTemplateFile != "" && flagFormat != "text" {
+		exitf("-template-file conflicts with -format=%s\n", flagFormat)
+	}
+	if flagFormat == "api" && fs.Lookup("roots") != nil {
+		// The API report is keyed by the exported functions and
+		// methods of the scanned packages: it implies -roots=exported.
+		if flagScan != quickcheck.ScanSymbol || flagReport != "findings" {
+			exitf("-format=api requires -scan=symbol and -report=findings\n")
+		}
+		roots := fs.Lookup("roots").Value
+		switch {
+		case roots.String() == myanalysis.RootsExported:
+		case roots.String() == myanalysis.RootsAll && fs.Lookup("root-symbols").Value.String() == "" && fs.Lookup("root-files").Value.String() == "":
+			roots.Set(myanalysis.RootsExported)
+		default:
+			exitf("-format=api conflicts with -roots other than exported\n")
 		}
-		fmt.Fprintln(os.Stderr, "\nFlags:")
-		flag.PrintDefaults()
 	}
 
-	// ASK(adonovan): DO WE NEED to export analysisflags.Parse too??
-	analyzers = analysisflags.Parse(analyzers, false)
+	// The findings of vulns are the diagnostics of the analyzer.
+	if f := fs.Lookup("facts-only"); f != nil && f.Value.String() == "true" {
+		exitf("-facts-only is for drivers analyzing builds in stages; use -report-packages to restrict the findings to packages\n")
+	}
 
-	args := flag.Args()
-	if len(args) == 0 {
-		flag.Usage()
-		os.Exit(1)
+	if c := lookupCommand(name); c.run != nil {
+		c.run(args)
+		return
 	}
+	if name == "module" {
+		if flagWatch || checker.Fix {
+			exitf("module conflicts with -watch and -fix\n")
+		}
+		args = downloadModule(args)
+	}
+
+	ignoreRules, ignoreAttrs, baseline := suppressions()
 
 	if checker.CPUProfile != "" {
 		f, err := os.Create(checker.CPUProfile)
 		if err != nil {
-			log.Fatal(err)
+			fatalf("%v", err)
 		}
 		if err := pprof.StartCPUProfile(f); err != nil {
-			log.Fatal(err)
+			fatalf("%v", err)
 		}
 		// NB: profile won't be written in case of error.
 		defer pprof.StopCPUProfile()
@@ -83,10 +302,10 @@ func main() {
 	if checker.Trace != "" {
 		f, err := os.Create(checker.Trace)
 		if err != nil {
-			log.Fatal(err)
+			fatalf("%v", err)
 		}
 		if err := trace.Start(f); err != nil {
-			log.Fatal(err)
+			fatalf("%v", err)
 		}
 		// NB: trace log won't be written in case of error.
 		defer func() {
@@ -98,13 +317,13 @@ func main() {
 	if checker.MemProfile != "" {
 		f, err := os.Create(checker.MemProfile)
 		if err != nil {
-			log.Fatal(err)
+			fatalf("%v", err)
 		}
 		// NB: memprofile won't be written in case of error.
 		defer func() {
 			runtime.GC() // get up-to-date statistics
 			if err := pprof.WriteHeapProfile(f); err != nil {
-				log.Fatalf("Writing memory profile: %v", err)
+				fatalf("Writing memory profile: %v", err)
 			}
 			f.Close()
 		}()
@@ -120,68 +339,672 @@ func main() {
 		Mode:  packages.LoadSyntax | packages.LoadAllSyntax | packages.NeedModule,
 		Tests: checker.IncludeTests,
 	}
-	pkgs, err := load(cfg, args)
-	if err != nil {
-		if _, ok := err.(typeParseError); !ok {
-			// Fail when some of the errors are not
-			// related to parsing nor typing.
-			log.Print(err)
-			os.Exit(1)
+	if flagScan == quickcheck.ScanPackage {
+		cfg.Mode = packages.NeedName | packages.NeedImports | packages.NeedDeps | packages.NeedModule
+	}
+	var pkgs []*packages.Package
+	var buildList []*packages.Module
+	var err error
+	switch flagScan {
+	case quickcheck.ScanGoMod:
+		if buildList, err = quickcheck.GoModBuildList("."); err != nil {
+			exitf("failed to read the go.mod files: %v\n", err)
+		}
+	case quickcheck.ScanModule:
+		if buildList, err = listModules(); err != nil {
+			exitf("failed to list modules: %v\n", err)
+		}
+	default:
+		if pkgs, err = load(cfg, args); err != nil {
+			if _, ok := err.(typeParseError); !ok {
+				// Fail when some of the errors are not
+				// related to parsing nor typing.
+				log.Print(err)
+				exit(errorStatus())
+			}
+			// TODO: filter analyzers based on RunDespiteError?
 		}
-		// TODO: filter analyzers based on RunDespiteError?
 	}
 
-	dbClient, err := client.NewClient(osvutil.FindGOVULNDB(cfg), client.Options{HTTPCache: govulncheck.DefaultCache()})
+	dbURLs := databases(cfg)
+	if flagOffline {
+		if buildList != nil {
+			checkOffline(dbURLs, osvutil.BuildListModules(buildList))
+		} else {
+			checkOffline(dbURLs, osvutil.Modules(pkgs))
+		}
+	}
+	dbClient, err := osvutil.NewClient(dbURLs, dbOptions())
 	if err != nil {
 		exitf("failed to setup vulncheck client: %v", err)
 	}
-	summary, _, err := quickcheck.Analyze(context.Background(), pkgs, dbClient)
+	dbClient.Mirrors = flagMirrors
+	dbClient.Ignore = ignoredIDs()
+	dbClient.Only = onlyIDs()
+	dbClient.Ecosystems = ecosystems()
+	dbClient.Concurrency = flagConcurrency
+	dbClient.SnapshotTime = dbSnapshotTime
+	if len(dbURLs) > 1 {
+		for _, h := range dbClient.Probe(context.Background()) {
+			if h.Err != nil {
+				log.Printf("database %s unavailable: %v", h.URL, h.Err)
+			} else if dbg('v') {
+				log.Printf("database %s available (%v)", h.URL, h.Latency)
+			}
+		}
+	}
+	show := func(pkgs []*packages.Package, summary map[quickcheck.Key]quickcheck.Value, pkg2vulns map[string][]*osv.Entry) (known, reachable bool) {
+		return present(pkgs, dbClient, summary, pkg2vulns, ignoreRules, ignoreAttrs, baseline)
+	}
+	if flagWatch {
+		reload := func() error {
+			rules, attrs, base, err := readSuppressions()
+			if err != nil {
+				return err
+			}
+			ignored, err := readIgnoredIDs()
+			if err != nil {
+				return err
+			}
+			ignoreRules, ignoreAttrs, baseline = rules, attrs, base
+			// Query the databases again for the entries published
+			// since, answered from the HTTP cache while it is fresh.
+			dbClient.Ignore = ignored
+			dbClient.Refresh()
+			myanalysis.ReloadCatalog()
+			return nil
+		}
+		watch(cfg, pkgs, dbClient, show, reload)
+		return
+	}
 
-	type entry struct {
-		Symbol string
-		Trace  []string
-		Count  int64
+	var summary map[quickcheck.Key]quickcheck.Value
+	var pkg2vulns map[string][]*osv.Entry
+	switch flagScan {
+	case quickcheck.ScanGoMod, quickcheck.ScanModule:
+		summary, pkg2vulns, err = quickcheck.AnalyzeModules(context.Background(), buildList, dbClient)
+	case quickcheck.ScanPackage:
+		summary, pkg2vulns, err = quickcheck.AnalyzePackages(context.Background(), pkgs, dbClient)
+	default:
+		summary, pkg2vulns, err = quickcheck.Analyze(context.Background(), pkgs, dbClient)
 	}
-	// id -> package -> entry
-	all := map[string]map[string][]entry{}
-	for k, v := range summary {
-		forID := all[k.ID]
-		if forID == nil {
-			forID = map[string][]entry{}
-			all[k.ID] = forID
+	if err != nil {
+		if reason := skipReason(err); reason != "" {
+			renderSkipped(outputRenderer(), reason)
+			return
 		}
-		forPkg := forID[k.PackagePath]
-		forPkg = append(forPkg, entry{k.Symbol, v.Trace, v.Count})
-		forID[k.PackagePath] = forPkg
+		exitf("failed to analyze: %v\n", err)
+	}
+	if dbg('v') {
+		if buildList != nil {
+			logModules(osvutil.BuildListModules(buildList))
+		} else {
+			logModules(osvutil.Modules(pkgs))
+		}
+	}
+	if len(dbURLs) > 1 {
+		log.Printf("used vulnerability databases: %s", strings.Join(dbClient.UsedSources(), ", "))
+	}
+	exitFailOn(show(pkgs, summary, pkg2vulns))
+}
+
+// present resolves the fixes of the findings in summary, filters
+// them, and reports them in the -report and -format requested. It
+// returns whether a known vulnerability affects an imported package
+// and whether a vulnerable symbol is reachable, for exitFailOn.
+func present(pkgs []*packages.Package, dbClient client.Client, summary map[quickcheck.Key]quickcheck.Value, pkg2vulns map[string][]*osv.Entry, ignoreRules []quickcheck.IgnoreRule, ignoreAttrs []string, baseline []quickcheck.IgnoreRule) (known, reachable bool) {
+	var lister quickcheck.VersionLister
+	if proxy := modproxy.FromEnv(); proxy != nil {
+		lister = proxy
+	}
+	summary = quickcheck.ResolveFixes(context.Background(), summary, pkg2vulns, lister)
+	all := summary
+	known = hasKnownVulns(pkg2vulns, ignoreRules)
+	severity := severityFunc(pkg2vulns)
+	if len(ignoreRules) > 0 {
+		summary = quickcheck.Ignore(summary, ignoreRules)
+	}
+	if len(ignoreAttrs) > 0 {
+		summary = quickcheck.IgnoreAttrs(summary, ignoreAttrs)
+	}
+	if flagMinSeverity != "" {
+		summary = filterSeverity(summary, pkg2vulns, severity, flagMinSeverity)
+	}
+	if flagBaselineWrite != "" {
+		writeBaseline(flagBaselineWrite, summary)
+	}
+	if len(baseline) > 0 {
+		summary = quickcheck.Ignore(summary, baseline)
+	}
+	if flagHistory != "" {
+		recordRun(dbClient, all, summary)
+	}
+	if flagReport == "deps" {
+		// The verdicts follow the suppressions.
+		reportDeps(pkgs, dbClient, summary)
+		return known, reached(summary, pkg2vulns, severity)
+	}
+	if flagSummary {
+		reportSummary(quickcheck.Summarize(summary, withoutIgnored(pkg2vulns, ignoreRules)))
+		return known, reached(summary, pkg2vulns, severity)
+	}
+
+	renderer := outputRenderer()
+	report := render.NewReport(summary, pkg2vulns)
+	report.SnippetContext = analysisflags.Context
+	if flagShortTraces {
+		report.TraceFormat.ShortSymbols = true
+		report.TraceFormat.Dir, _ = os.Getwd()
+	}
+	if flagFormat == "gitlab-sast" {
+		// GitLab expects the files relative to the project directory.
+		if report.TraceFormat.Dir = os.Getenv("CI_PROJECT_DIR"); report.TraceFormat.Dir == "" {
+			report.TraceFormat.Dir, _ = os.Getwd()
+		}
+	}
+	if flagFormat == "lines" || flagFormat == "lines-verbose" {
+		// Editors open the files relative to the directory they run the command in.
+		report.TraceFormat.Dir, _ = os.Getwd()
+	}
+	if flagFormat == "text" {
+		report.TraceFormat.Width = tracefmt.TerminalWidth(os.Stdout)
+		report.Color = !flagNoColor && os.Getenv("NO_COLOR") == "" && tracefmt.IsTerminal(os.Stdout)
+	}
+	report.Boundary = quickcheck.ParseBoundary(flagLocal)
+	if len(report.Boundary) == 0 {
+		report.Boundary = quickcheck.MainModules(pkgs)
+	}
+	if flagScan != quickcheck.ScanModule && flagScan != quickcheck.ScanGoMod {
+		report.Coverage = quickcheck.AnalysisCoverage(pkgs, flagScan)
+		if dbg('v') {
+			for _, p := range report.Coverage.Skipped {
+				log.Printf("not analyzed for symbol reachability: %s (%s)", p.Path, p.Reason)
+			}
+		}
+	}
+	if flagShowFiltered {
+		var err error
+		report.Filtered, err = quickcheck.Filtered(context.Background(), pkgs, dbClient)
+		if err != nil {
+			exitf("failed to fetch OSV entries: %v\n", err)
+		}
+	}
+	if showing(showUnreachable) {
+		var err error
+		report.Unreachable, err = quickcheck.Unreachable(context.Background(), pkgs, dbClient, all)
+		if err != nil {
+			exitf("failed to fetch OSV entries: %v\n", err)
+		}
+	}
+	if showing(showNotAffected) || flagFormat == "openvex" || flagFormat == "cyclonedx" {
+		notAffected, err := quickcheck.NotAffectedBy(context.Background(), pkgs, dbClient, all)
+		if err != nil {
+			exitf("failed to fetch OSV entries: %v\n", err)
+		}
+		report.NotAffected = append([]*quickcheck.NotAffected{}, notAffected...)
+	}
+	if flagFormat == "cyclonedx" {
+		for _, q := range osvutil.Modules(pkgs) {
+			report.Modules = append(report.Modules, q.Module)
+		}
+	}
+	switch report.GroupBy = flagGroupBy; report.GroupBy {
+	case render.GroupByModule, render.GroupByVuln, render.GroupByPackage, render.GroupByEntry:
+	default:
+		exitf("invalid -group-by flag %q\n", flagGroupBy)
+	}
+	report.Severity = severity
+	if err := report.Sort(flagSort); err != nil {
+		exitf("invalid -sort flag: %v\n", err)
+	}
+	if err := renderer.Render(os.Stdout, report); err != nil {
+		exitf("failed to render the report: %v\n", err)
+	}
+	if checker.Fix {
+		fixGoMod(summary)
+	}
+	if flagIssues != "" {
+		fileIssues(report)
+	}
+	return known, reached(summary, pkg2vulns, severity)
+}
+
+// logModules logs the modules looked up in the vulnerability
+// database and the ones skipped, with the reason.
+func logModules(queries []*osvutil.ModuleQuery) {
+	var queried, skipped int
+	for _, q := range queries {
+		m := q.Module
+		if m.Replace != nil {
+			m = m.Replace
+		}
+		mod := m.Path
+		if m.Version != "" {
+			mod += "@" + m.Version
+		}
+		if q.Skipped != "" {
+			skipped++
+			log.Printf("skipped module %s: %s", mod, q.Skipped)
+			continue
+		}
+		queried++
+		log.Printf("queried module %s", mod)
+	}
+	log.Printf("%d modules queried, %d skipped", queried, skipped)
+}
+
+// databases returns the URLs of the vulnerability databases listed
+// with -db, or else with GOVULNDB in the environment of cfg.
+func databases(cfg *packages.Config) []string {
+	if flagDB != "" {
+		return strings.Split(flagDB, ",")
+	}
+	return osvutil.FindGOVULNDB(cfg)
+}
+
+// dbOptions returns the options of the vulnerability database
+// clients, which share the HTTP cache and the -db-rate limit, and
+// send the credentials of the databases (see osvutil.LoadCredentials).
+func dbOptions() client.Options {
+	opts := client.Options{HTTPCache: httpCache()}
+	if flagOffline {
+		opts.HTTPClient = &http.Client{Transport: offlineTransport{}}
+		return opts
+	}
+	creds, err := osvutil.LoadCredentials()
+	if err != nil {
+		exitf("failed to load the credentials of the vulnerability databases: %v\n", err)
 	}
+	var transport http.RoundTripper
+	if flagDBRate > 0 {
+		limiter := osvutil.NewLimiter(flagDBRate, flagDBBurst)
+		transport = limiter.Transport(nil)
+	}
+	opts.HTTPClient = &http.Client{Transport: creds.Transport(transport)}
+	return opts
+}
+
+// httpCache returns the cache of the vulnerability database clients:
+// the default disk cache, or the one selected by -cache-fallback from
+// its first failure on, such as when the module cache is read-only.
+func httpCache() vulncache.Cache {
+	if flagCacheFallback == cacheFallbackNone {
+		return vulncache.Default()
+	}
+	return vulncache.Fallback(vulncache.Default(), func(err error) vulncache.Cache {
+		if flagCacheFallback == cacheFallbackTemp {
+			dir, terr := os.MkdirTemp("", "vulns-cache-")
+			if terr == nil {
+				atExit(func() { os.RemoveAll(dir) })
+				log.Printf("vulnerability database cache unusable (%v); caching in %s", err, dir)
+				return vulncache.Disk(dir)
+			}
+		}
+		log.Printf("vulnerability database cache unusable (%v); caching in memory", err)
+		return vulncache.Memory()
+	})
+}
+
+// listModules returns the build list of the main module
+// in the current directory, as reported by "go list -m all".
+func listModules() ([]*packages.Module, error) {
+	cmd := exec.Command("go", "list", "-m", "-json", "all")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list -m: %v\n%s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	var mods []*packages.Module
+	for dec := json.NewDecoder(bytes.NewReader(out)); dec.More(); {
+		m := new(packages.Module)
+		if err := dec.Decode(m); err != nil {
+			return nil, fmt.Errorf("go list -m: %v", err)
+		}
+		mods = append(mods, m)
+	}
+	return mods, nil
+}
+
+// suppressions returns the findings suppressed with the -ignore-symbol,
+// -ignore-attr, and -baseline flags.
+func suppressions() (ignoreRules []quickcheck.IgnoreRule, ignoreAttrs []string, baseline []quickcheck.IgnoreRule) {
+	ignoreRules, ignoreAttrs, baseline, err := readSuppressions()
+	if err != nil {
+		exitf("%v\n", err)
+	}
+	return ignoreRules, ignoreAttrs, baseline
+}
+
+// readSuppressions is like suppressions, but returns an error if a
+// flag is invalid, such as when the -baseline file cannot be read.
+func readSuppressions() (ignoreRules []quickcheck.IgnoreRule, ignoreAttrs []string, baseline []quickcheck.IgnoreRule, err error) {
+	if ignoreRules, err = quickcheck.ParseIgnoreRules(flagIgnoreSymbol); err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid -ignore-symbol flag: %v", err)
+	}
+	if ignoreAttrs, err = quickcheck.ParseAttrs(flagIgnoreAttr); err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid -ignore-attr flag: %v", err)
+	}
+	if flagBaseline != "" {
+		if baseline, err = quickcheck.ReadBaseline(flagBaseline); err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid -baseline flag: %v", err)
+		}
+	}
+	return ignoreRules, ignoreAttrs, baseline, nil
+}
+
+// ecosystems returns the ecosystems listed by -ecosystems.
+func ecosystems() []string {
+	var ecos []string
+	for _, e := range strings.Split(flagEcosystems, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			ecos = append(ecos, e)
+		}
+	}
+	return ecos
+}
+
+// showSections returns the sections listed by -show.
+func showSections() []string {
+	var sections []string
+	for _, v := range strings.Split(flagShow, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			sections = append(sections, v)
+		}
+	}
+	return sections
+}
+
+// showing reports whether -show lists the section.
+func showing(section string) bool {
+	for _, v := range showSections() {
+		if v == section {
+			return true
+		}
+	}
+	return false
+}
+
+// onlyIDs returns the vulnerability IDs listed in the -id flag.
+func onlyIDs() []string {
 	var ids []string
-	for id := range all {
-		ids = append(ids, id)
-	}
-	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
-	count := 0
-	for _, id := range ids {
-		for pkg, entries := range all[id] {
-			count++
-			fmt.Printf("Vulnerability #%d: %v (%v)\n", count, id, pkg)
-			fmt.Println("\nCall stacks in your code:")
-			for _, p := range entries[0].Trace {
-				fmt.Printf("\t%v\n", p)
+	for _, id := range strings.Split(flagID, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// ignoredIDs returns the vulnerability IDs listed
+// with the -ignore and -ignore-file flags.
+func ignoredIDs() []string {
+	ids, err := readIgnoredIDs()
+	if err != nil {
+		exitf("%v\n", err)
+	}
+	return ids
+}
+
+// readIgnoredIDs is like ignoredIDs, but returns the error
+// reading the -ignore-file file.
+func readIgnoredIDs() ([]string, error) {
+	var ids []string
+	for _, id := range strings.Split(flagIgnore, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	if flagIgnoreFile != "" {
+		more, err := osvutil.ReadIgnoreFile(flagIgnoreFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -ignore-file flag: %v", err)
+		}
+		ids = append(ids, more...)
+	}
+	return ids, nil
+}
+
+// readSeverities reads the severities of the vulnerabilities in the
+// -severities file, by ID. Blank lines and the text after # are
+// ignored.
+func readSeverities(file string) (map[string]string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	severities := make(map[string]string)
+	for i, line := range strings.Split(string(data), "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		f := strings.Fields(line)
+		if len(f) == 0 {
+			continue
+		}
+		if len(f) != 2 {
+			return nil, fmt.Errorf("%s:%d: want \"ID severity\"", file, i+1)
+		}
+		b, err := render.ParseSeverity(f[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", file, i+1, err)
+		}
+		severities[f[0]] = b
+	}
+	return severities, nil
+}
+
+// severityFunc returns the function returning the severity of a
+// vulnerability in the -severities file, by its ID or an alias of its
+// entry in pkg2vulns, or nil without the flag.
+func severityFunc(pkg2vulns map[string][]*osv.Entry) func(id string) string {
+	if flagSeverities == "" {
+		return nil
+	}
+	severities, err := readSeverities(flagSeverities)
+	if err != nil {
+		exitf("invalid -severities flag: %v\n", err)
+	}
+	entries := render.NewReport(nil, pkg2vulns).Entries
+	return func(id string) string {
+		if s, ok := severities[id]; ok {
+			return s
+		}
+		if e := entries[id]; e != nil {
+			for _, alias := range e.Aliases {
+				if s, ok := severities[alias]; ok {
+					return s
+				}
+			}
+		}
+		return ""
+	}
+}
+
+// filterSeverity returns the findings of summary at least as severe
+// as the bucket min according to severity (see severityFunc).
+func filterSeverity(summary map[quickcheck.Key]quickcheck.Value, pkg2vulns map[string][]*osv.Entry, severity func(string) string, min string) map[quickcheck.Key]quickcheck.Value {
+	report := render.NewReport(summary, pkg2vulns)
+	report.Severity = severity
+	report.FilterSeverity(min)
+	filtered := make(map[quickcheck.Key]quickcheck.Value)
+	for _, f := range report.Findings {
+		filtered[f.Key] = summary[f.Key]
+	}
+	return filtered
+}
+
+// reached reports whether summary has a finding, or, if -fail-on is a
+// severity bucket, a finding at least that severe according to
+// severity, as the reachable result of the scan for failOnViolated.
+func reached(summary map[quickcheck.Key]quickcheck.Value, pkg2vulns map[string][]*osv.Entry, severity func(string) string) bool {
+	switch flagFailOn {
+	case failOnNone, failOnAny, failOnReachable:
+		return len(summary) > 0
+	}
+	report := render.NewReport(summary, pkg2vulns)
+	report.Severity = severity
+	for _, f := range report.Findings {
+		if render.AtLeast(report.SeverityOf(f.ID), flagFailOn) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeBaseline writes the findings of summary to the baseline file.
+func writeBaseline(file string, summary map[quickcheck.Key]quickcheck.Value) {
+	f, err := os.Create(file)
+	if err != nil {
+		exitf("failed to write the baseline: %v\n", err)
+	}
+	err = quickcheck.WriteBaseline(f, summary)
+	if err2 := f.Close(); err == nil {
+		err = err2
+	}
+	if err != nil {
+		exitf("failed to write the baseline: %v\n", err)
+	}
+}
+
+// hasKnownVulns reports whether pkg2vulns has a vulnerability
+// not suppressed as a whole by the rules.
+func hasKnownVulns(pkg2vulns map[string][]*osv.Entry, rules []quickcheck.IgnoreRule) bool {
+	return len(withoutIgnored(pkg2vulns, rules)) > 0
+}
+
+// withoutIgnored returns pkg2vulns without the vulnerabilities
+// that the rules ignore everywhere.
+func withoutIgnored(pkg2vulns map[string][]*osv.Entry, rules []quickcheck.IgnoreRule) map[string][]*osv.Entry {
+	ignored := make(map[string]bool)
+	for _, r := range rules {
+		if r.PackagePath == "" {
+			ignored[r.ID] = true
+		}
+	}
+	res := make(map[string][]*osv.Entry)
+	for pkg, vulns := range pkg2vulns {
+		for _, v := range vulns {
+			if !ignored[v.ID] {
+				res[pkg] = append(res[pkg], v)
 			}
-			fmt.Println()
 		}
 	}
+	return res
 }
 
-func jsonString(v any) string {
-	s, _ := json.MarshalIndent(v, " ", " ")
-	return string(s)
+// outputRenderer returns the renderer of the -format, or of the
+// -template-file if set.
+func outputRenderer() render.Renderer {
+	renderer := render.Lookup(flagFormat)
+	if renderer == nil {
+		exitf("unknown output format %q (available: %s)\n", flagFormat, strings.Join(render.Names(), ", "))
+	}
+	if flagTemplateFile != "" {
+		data, err := os.ReadFile(flagTemplateFile)
+		if err != nil {
+			exitf("failed to read the template: %v\n", err)
+		}
+		if renderer, err = render.ParseTemplate(filepath.Base(flagTemplateFile), string(data)); err != nil {
+			exitf("invalid template: %v\n", err)
+		}
+	}
+	return renderer
+}
+
+// skipReason returns the reason to report the scan as skipped if
+// err is a failed vulnerability database query and -on-db-error
+// allows skipping, logging err under -on-db-error=warn. Otherwise,
+// it returns "" and the caller fails.
+func skipReason(err error) string {
+	var dbErr *osvutil.DBError
+	if flagOnDBError == onDBErrorFail || !errors.As(err, &dbErr) {
+		return ""
+	}
+	if flagOnDBError == onDBErrorWarn {
+		log.Printf("warning: scan skipped: %v", err)
+	}
+	return fmt.Sprintf("vulnerability database unavailable: %v", err)
+}
+
+// renderSkipped writes a report of a scan skipped for the reason.
+func renderSkipped(renderer render.Renderer, reason string) {
+	report := render.NewReport(nil, nil)
+	report.Skipped = reason
+	if err := renderer.Render(os.Stdout, report); err != nil {
+		exitf("failed to render the report: %v\n", err)
+	}
+}
+
+// exitFailOn exits with status 3, or 1 under -q, if the scan result
+// violates the -fail-on policy. known reports whether a known vulnerability affects
+// an imported package, and reachable whether a vulnerable symbol is
+// reachable.
+func exitFailOn(known, reachable bool) {
+	status := 3
+	if flagQuiet {
+		status = 1
+	}
+	if failOnViolated(known, reachable) {
+		exit(status)
+	}
+}
+
+// failOnViolated reports whether the scan result violates the -fail-on
+// policy, with known and reachable as for exitFailOn. With a severity
+// bucket, reachable reports only the findings at least that severe
+// (see reached); the bucket requires -severities, which only the scans
+// reporting their findings through present take.
+func failOnViolated(known, reachable bool) bool {
+	switch flagFailOn {
+	case failOnNone:
+		return false
+	case failOnAny:
+		return known || reachable
+	}
+	return reachable
+}
+
+// reportDeps writes the inventory of all known vulnerabilities
+// of the dependencies of pkgs with their reachability verdicts.
+func reportDeps(pkgs []*packages.Package, dbClient client.Client, summary map[quickcheck.Key]quickcheck.Value) {
+	mods, err := quickcheck.Dependencies(context.Background(), pkgs, dbClient, summary)
+	if err != nil {
+		exitf("failed to fetch OSV entries: %v\n", err)
+	}
+	switch flagFormat {
+	case "text":
+		err = render.DepsText(os.Stdout, mods)
+	case "json":
+		err = render.DepsJSON(os.Stdout, mods)
+	default:
+		exitf("-report=deps supports only text and json formats\n")
+	}
+	if err != nil {
+		exitf("failed to render the report: %v\n", err)
+	}
+}
+
+// reportSummary writes the counts of the -summary.
+func reportSummary(s *quickcheck.Summary) {
+	var err error
+	switch flagFormat {
+	case "text":
+		err = render.SummaryText(os.Stdout, s)
+	case "json":
+		err = render.SummaryJSON(os.Stdout, s)
+	default:
+		exitf("-summary supports only text and json formats\n")
+	}
+	if err != nil {
+		exitf("failed to render the report: %v\n", err)
+	}
 }
 
 func dbg(b byte) bool { return strings.IndexByte(checker.Debug, b) >= 0 }
 
 func load(cfg *packages.Config, patterns []string) ([]*packages.Package, error) {
-
+	if flagTags != "" && cfg.BuildFlags == nil {
+		cfg.BuildFlags = []string{"-tags=" + flagTags}
+	}
 	initial, err := packages.Load(cfg, patterns...)
 	if err == nil {
 		if len(initial) == 0 {
@@ -229,27 +1052,44 @@ type typeParseError struct {
 
 func exitf(format string, args ...interface{}) {
 	fmt.Fprintf(os.Stderr, format, args...)
-	os.Exit(1)
+	exit(errorStatus())
 }
 
-func populateVulnsCatalog(pkgs []*packages.Package) {
-	cfg := &packages.Config{
-		// We need module for analysis.
-		Mode:  packages.NeedModule | packages.NeedImports,
-		Tests: true,
-	}
+// fatalf logs the message and exits with the error status, as
+// exitf does, for the errors reported by the log package.
+func fatalf(format string, args ...interface{}) {
+	log.Printf(format, args...)
+	exit(errorStatus())
+}
 
-	dbClient, err := client.NewClient(osvutil.FindGOVULNDB(cfg), client.Options{HTTPCache: govulncheck.DefaultCache()})
-	if err != nil {
-		exitf("failed to setup vulncheck client: %v", err)
-	}
-	modvulns, err := osvutil.FetchOSVEntries(context.Background(), dbClient, pkgs)
-	if err != nil {
-		exitf("failed to fetch OSV entries: %v", err)
+// exitHooks are run before the program exits.
+var exitHooks []func()
+
+// atExit registers f to be run before the program exits through
+// exit or exitf, or returns from main, such as to remove a
+// temporary directory.
+func atExit(f func()) { exitHooks = append(exitHooks, f) }
+
+func runExitHooks() {
+	for _, f := range exitHooks {
+		f()
 	}
-	if err := json.NewEncoder(os.Stdout).Encode(modvulns); err != nil {
-		exitf("failed to encode module vulnerability info: %v", err)
+	exitHooks = nil
+}
+
+// exit runs the exit hooks and exits with the status code.
+func exit(code int) {
+	runExitHooks()
+	os.Exit(code)
+}
+
+// errorStatus returns the exit status of failures: 1, or 2 under -q,
+// where 1 means that vulnerabilities were found.
+func errorStatus() int {
+	if flagQuiet {
+		return 2
 	}
+	return 1
 }
 
 /*
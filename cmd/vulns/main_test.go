@@ -0,0 +1,91 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hyangah/vulns/quickcheck"
+	"github.com/hyangah/vulns/testutils"
+	"golang.org/x/vuln/osv"
+)
+
+// severityFixture sets -severities to a file of the severities of
+// GO-1 and of GO-2 by its alias, and returns the summary of a finding
+// of each of GO-1, GO-2, and GO-3 with their entries.
+func severityFixture(t *testing.T) (map[quickcheck.Key]quickcheck.Value, map[string][]*osv.Entry) {
+	file := filepath.Join(t.TempDir(), "severities")
+	if err := os.WriteFile(file, []byte("GO-1 critical\nCVE-2 Low # by alias\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	old := flagSeverities
+	flagSeverities = file
+	t.Cleanup(func() { flagSeverities = old })
+
+	summary := make(map[quickcheck.Key]quickcheck.Value)
+	var entries []*osv.Entry
+	for _, id := range []string{"GO-1", "GO-2", "GO-3"} {
+		e := testutils.Entry(id, "example.com/m", "", "example.com/m/p")
+		entries = append(entries, e)
+		summary[quickcheck.Key{ID: id, PackagePath: "example.com/m/p", Symbol: "F"}] = quickcheck.Value{Count: 1}
+	}
+	entries[1].Aliases = []string{"CVE-2"}
+	return summary, map[string][]*osv.Entry{"example.com/m/p": entries}
+}
+
+func TestFilterSeverity(t *testing.T) {
+	summary, pkg2vulns := severityFixture(t)
+	severity := severityFunc(pkg2vulns)
+	for _, tc := range []struct {
+		min  string
+		want string
+	}{
+		{"Critical", "GO-1"},
+		{"High", "GO-1"},
+		{"Low", "GO-1 GO-2"},
+		{"Unknown", "GO-1 GO-2 GO-3"},
+	} {
+		var ids []string
+		for k := range filterSeverity(summary, pkg2vulns, severity, tc.min) {
+			ids = append(ids, k.ID)
+		}
+		sort.Strings(ids)
+		if got := strings.Join(ids, " "); got != tc.want {
+			t.Errorf("filterSeverity(%s) = %q, want %q", tc.min, got, tc.want)
+		}
+	}
+}
+
+func TestFailOnSeverity(t *testing.T) {
+	summary, pkg2vulns := severityFixture(t)
+	severity := severityFunc(pkg2vulns)
+	low := filterSeverity(summary, pkg2vulns, severity, "Low")
+	delete(low, quickcheck.Key{ID: "GO-1", PackagePath: "example.com/m/p", Symbol: "F"})
+	defer func(v string) { flagFailOn = v }(flagFailOn)
+	for _, tc := range []struct {
+		failOn  string
+		summary map[quickcheck.Key]quickcheck.Value
+		want    bool
+	}{
+		{failOnNone, summary, false},
+		{failOnReachable, low, true},
+		{"Critical", summary, true},
+		{"High", low, false},
+		{"Low", low, true},
+		{"Unknown", nil, false},
+	} {
+		flagFailOn = tc.failOn
+		if got := failOnViolated(true, reached(tc.summary, pkg2vulns, severity)); got != tc.want {
+			t.Errorf("-fail-on=%s with %d findings: violated = %v, want %v", tc.failOn, len(tc.summary), got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hyangah/vulns/internal/modproxy"
+	"golang.org/x/mod/module"
+)
+
+// downloadModule downloads the module version named by the first
+// argument, path@version, from the module proxy into a temporary
+// directory, which is removed on exit, and changes to it, so that
+// the packages are then loaded and scanned as in the main module.
+// The version may be "latest". It returns the package patterns to
+// scan: the remaining arguments, or ./... by default.
+func downloadModule(args []string) []string {
+	if len(args) == 0 {
+		exitUsage("module")
+	}
+	modPath, version, err := parseModuleVersion(args[0])
+	if err != nil {
+		exitf("module: %v\n", err)
+	}
+	proxy := modproxy.FromEnv()
+	if proxy == nil {
+		exitf("module: GOPROXY lists no module proxy\n")
+	}
+	ctx := context.Background()
+	if version == "latest" {
+		versions, err := proxy.Versions(ctx, modPath)
+		if err != nil {
+			exitf("module: %v\n", err)
+		}
+		if len(versions) == 0 {
+			exitf("module: no released versions of %s\n", modPath)
+		}
+		version = versions[len(versions)-1]
+	}
+
+	tmp, err := os.MkdirTemp("", "vulns-module-")
+	if err != nil {
+		exitf("module: %v\n", err)
+	}
+	atExit(func() { os.RemoveAll(tmp) })
+	dir := filepath.Join(tmp, "m")
+	if dbg('v') {
+		log.Printf("download %s@%s to %s", modPath, version, dir)
+	}
+	if err := proxy.Download(ctx, modPath, version, dir); err != nil {
+		exitf("module: %v\n", err)
+	}
+	// The go command may need to add missing requirements and
+	// checksums while loading the packages.
+	for _, name := range []string{"go.mod", "go.sum"} {
+		os.Chmod(filepath.Join(dir, name), 0666)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "go.mod")); os.IsNotExist(err) {
+		// A module predating modules.
+		if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(fmt.Sprintf("module %s\n", modPath)), 0666); err != nil {
+			exitf("module: %v\n", err)
+		}
+	}
+	if err := os.Chdir(dir); err != nil {
+		exitf("module: %v\n", err)
+	}
+	os.Setenv("GOFLAGS", strings.TrimSpace(os.Getenv("GOFLAGS")+" -mod=mod"))
+
+	if len(args) > 1 {
+		return args[1:]
+	}
+	return []string{"./..."}
+}
+
+// parseModuleVersion returns the module path and the version
+// of the argument of downloadModule, path@version.
+func parseModuleVersion(arg string) (modPath, version string, err error) {
+	modPath, version, ok := strings.Cut(arg, "@")
+	if !ok || version == "" {
+		return "", "", fmt.Errorf("missing version in %q", arg)
+	}
+	if err := module.CheckPath(modPath); err != nil {
+		return "", "", err
+	}
+	return modPath, version, nil
+}
@@ -0,0 +1,36 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import "testing"
+
+func TestParseModuleVersion(t *testing.T) {
+	for _, tc := range []struct {
+		arg              string
+		modPath, version string // empty if the argument is invalid
+	}{
+		{"golang.org/x/text@v0.3.5", "golang.org/x/text", "v0.3.5"},
+		{"github.com/gin-gonic/gin@latest", "github.com/gin-gonic/gin", "latest"},
+		{"example.com/m/v2@v2.0.0-20220101000000-abcdefabcdef", "example.com/m/v2", "v2.0.0-20220101000000-abcdefabcdef"},
+		{"golang.org/x/text", "", ""},
+		{"golang.org/x/text@", "", ""},
+		{"@v1.0.0", "", ""},
+		{"Not A Path@v1.0.0", "", ""},
+	} {
+		modPath, version, err := parseModuleVersion(tc.arg)
+		if tc.modPath == "" {
+			if err == nil {
+				t.Errorf("parseModuleVersion(%q) = %q, %q, want an error", tc.arg, modPath, version)
+			}
+			continue
+		}
+		if err != nil || modPath != tc.modPath || version != tc.version {
+			t.Errorf("parseModuleVersion(%q) = %q, %q, %v, want %q, %q", tc.arg, modPath, version, err, tc.modPath, tc.version)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/hyangah/vulns/internal/osvutil"
+	"github.com/hyangah/vulns/internal/xtools/checker"
+	"github.com/hyangah/vulns/quickcheck"
+	"github.com/hyangah/vulns/render"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/vuln/client"
+	"gopkg.in/yaml.v3"
+)
+
+// A manifest lists the repositories scanned by multi.
+//
+//	workdir: repos # where the repositories are cloned, relative to the manifest
+//	repos:
+//	  - url: https://github.com/example/app
+//	    ref: main       # branch or tag (default: the default branch)
+//	    dir: server     # module directory in the repository (default: root)
+//	    patterns: [./cmd/...] # packages to scan (default: ./...)
+type manifest struct {
+	Workdir string         `yaml:"workdir"`
+	Repos   []manifestRepo `yaml:"repos"`
+}
+
+type manifestRepo struct {
+	Name     string   `yaml:"name"` // default: the last element of the URL
+	URL      string   `yaml:"url"`
+	Ref      string   `yaml:"ref"`
+	Dir      string   `yaml:"dir"`
+	Patterns []string `yaml:"patterns"`
+}
+
+// The flags of multi.
+var (
+	multiManifest = ""
+	multiNoSync   = false
+)
+
+// multiFlags registers the flags of multi.
+func multiFlags(fs *flag.FlagSet) {
+	fs.StringVar(&multiManifest, "manifest", multiManifest, "manifest file listing the repositories to scan")
+	fs.BoolVar(&multiNoSync, "nosync", multiNoSync, "scan the repositories already in the work directory without cloning or updating them")
+	addFormatFlag(fs)
+	addDBFlags(fs)
+	addFilterFlags(fs)
+	addResultFlags(fs)
+	addLoadFlags(fs)
+	addAnalyzerFlags(fs)
+}
+
+// multi clones or updates the repositories listed in a manifest,
+// scans each, and reports the per-repository results along with
+// the modules affecting the most repositories.
+func multi(args []string) {
+	if multiManifest == "" || len(args) > 0 {
+		exitUsage("multi")
+	}
+	write := render.MultiText
+	switch flagFormat {
+	case "text":
+	case "json":
+		write = render.MultiJSON
+	default:
+		exitf("multi supports only text and json formats\n")
+	}
+
+	m, err := readManifest(multiManifest)
+	if err != nil {
+		exitf("multi: %v\n", err)
+	}
+	dbURLs := databases(&packages.Config{})
+	checkOffline(dbURLs, nil)
+	dbClient, err := osvutil.NewClient(dbURLs, dbOptions())
+	if err != nil {
+		exitf("failed to setup vulncheck client: %v", err)
+	}
+	dbClient.Ignore = ignoredIDs()
+	dbClient.Only = onlyIDs()
+	dbClient.Ecosystems = ecosystems()
+	dbClient.Concurrency = flagConcurrency
+	dbClient.SnapshotTime = dbSnapshotTime
+
+	ctx := context.Background()
+	var results []*render.RepoResult
+	for _, r := range m.Repos {
+		res := &render.RepoResult{Name: r.Name, URL: r.URL}
+		dst := filepath.Join(m.Workdir, r.Name)
+		if !multiNoSync {
+			res.Err = syncRepo(ctx, dst, r)
+		}
+		if res.Err == nil {
+			res.Findings, res.Err = scanRepo(ctx, filepath.Join(dst, r.Dir), r.Patterns, dbClient)
+		}
+		results = append(results, res)
+	}
+	if err := write(os.Stdout, results); err != nil {
+		exitf("failed to render the report: %v\n", err)
+	}
+	found := false
+	for _, r := range results {
+		found = found || len(r.Findings) > 0
+	}
+	exitFailOn(found, found)
+}
+
+// readManifest reads the manifest file and fills in the defaults.
+func readManifest(file string) (*manifest, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var m manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %v", file, err)
+	}
+	if m.Workdir == "" {
+		m.Workdir = "repos"
+	}
+	if !filepath.IsAbs(m.Workdir) {
+		m.Workdir = filepath.Join(filepath.Dir(file), m.Workdir)
+	}
+	seen := make(map[string]bool)
+	for i := range m.Repos {
+		r := &m.Repos[i]
+		if r.URL == "" {
+			return nil, fmt.Errorf("invalid manifest %s: repository #%d has no url", file, i+1)
+		}
+		if r.Name == "" {
+			r.Name = strings.TrimSuffix(path.Base(strings.TrimRight(r.URL, "/")), ".git")
+		}
+		if seen[r.Name] {
+			return nil, fmt.Errorf("invalid manifest %s: duplicate repository name %q", file, r.Name)
+		}
+		seen[r.Name] = true
+		if len(r.Patterns) == 0 {
+			r.Patterns = []string{"./..."}
+		}
+	}
+	return &m, nil
+}
+
+// syncRepo clones the repository into dst,
+// or updates the clone if dst already has one.
+func syncRepo(ctx context.Context, dst string, r manifestRepo) error {
+	if _, err := os.Stat(filepath.Join(dst, ".git")); err == nil {
+		ref := r.Ref
+		if ref == "" {
+			ref = "HEAD"
+		}
+		if err := runGit(ctx, dst, "fetch", "--depth=1", "origin", ref); err != nil {
+			return err
+		}
+		return runGit(ctx, dst, "reset", "--hard", "FETCH_HEAD")
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0777); err != nil {
+		return err
+	}
+	args := []string{"clone", "--depth=1"}
+	if r.Ref != "" {
+		args = append(args, "--branch", r.Ref)
+	}
+	return runGit(ctx, "", append(args, r.URL, dst)...)
+}
+
+func runGit(ctx context.Context, dir string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git %s: %v\n%s", strings.Join(args, " "), err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// scanRepo analyzes the packages matching the patterns in dir.
+func scanRepo(ctx context.Context, dir string, patterns []string, dbClient client.Client) ([]*quickcheck.Finding, error) {
+	summary, err := scanDir(ctx, dir, patterns, dbClient)
+	if err != nil {
+		return nil, err
+	}
+	return quickcheck.Findings(summary), nil
+}
+
+// scanDir analyzes the packages matching the patterns in dir
+// and returns the summary of the findings.
+func scanDir(ctx context.Context, dir string, patterns []string, dbClient client.Client) (map[quickcheck.Key]quickcheck.Value, error) {
+	if dbg('v') {
+		log.Printf("load %s in %s", patterns, dir)
+	}
+	cfg := &packages.Config{
+		Context: ctx,
+		Dir:     dir,
+		Mode:    packages.LoadSyntax | packages.LoadAllSyntax | packages.NeedModule,
+		Tests:   checker.IncludeTests,
+	}
+	pkgs, err := load(cfg, patterns)
+	if err != nil {
+		if _, ok := err.(typeParseError); !ok {
+			return nil, err
+		}
+	}
+	summary, _, err := quickcheck.Analyze(ctx, pkgs, dbClient)
+	if err != nil {
+		return nil, err
+	}
+	if dbg('v') {
+		logModules(osvutil.Modules(pkgs))
+	}
+	return summary, nil
+}
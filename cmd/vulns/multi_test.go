@@ -0,0 +1,103 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadManifest(t *testing.T) {
+	dir := t.TempDir()
+	abs := filepath.Join(dir, "elsewhere")
+	for _, tc := range []struct {
+		name, manifest string
+		want           *manifest // nil if invalid
+		wantErr        string
+	}{
+		{
+			name: "defaults",
+			manifest: `repos:
+  - url: https://github.com/example/app
+  - url: https://github.com/example/lib.git/
+`,
+			want: &manifest{
+				Workdir: filepath.Join(dir, "repos"),
+				Repos: []manifestRepo{
+					{Name: "app", URL: "https://github.com/example/app", Patterns: []string{"./..."}},
+					{Name: "lib", URL: "https://github.com/example/lib.git/", Patterns: []string{"./..."}},
+				},
+			},
+		},
+		{
+			name: "explicit",
+			manifest: `workdir: ` + abs + `
+repos:
+  - name: server
+    url: https://github.com/example/app
+    ref: v1.0.0
+    dir: server
+    patterns: [./cmd/..., ./internal/...]
+  - url: https://github.com/example/app.git
+`,
+			want: &manifest{
+				Workdir: abs,
+				Repos: []manifestRepo{
+					{Name: "server", URL: "https://github.com/example/app", Ref: "v1.0.0", Dir: "server", Patterns: []string{"./cmd/...", "./internal/..."}},
+					{Name: "app", URL: "https://github.com/example/app.git", Patterns: []string{"./..."}},
+				},
+			},
+		},
+		{
+			name:     "relative workdir",
+			manifest: "workdir: ../clones\n",
+			want:     &manifest{Workdir: filepath.Join(dir, "..", "clones")},
+		},
+		{
+			name:     "no url",
+			manifest: "repos:\n  - url: https://github.com/example/app\n  - name: lib\n",
+			wantErr:  "repository #2 has no url",
+		},
+		{
+			name:     "duplicate name",
+			manifest: "repos:\n  - url: https://github.com/example/app\n  - url: https://github.com/other/app.git\n",
+			wantErr:  `duplicate repository name "app"`,
+		},
+		{
+			name:     "invalid yaml",
+			manifest: "repos: [\n",
+			wantErr:  "invalid manifest",
+		},
+	} {
+		file := filepath.Join(dir, "repos.yaml")
+		if err := os.WriteFile(file, []byte(tc.manifest), 0666); err != nil {
+			t.Fatal(err)
+		}
+		m, err := readManifest(file)
+		if tc.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("%s: readManifest error = %v, want one containing %q", tc.name, err, tc.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(m, tc.want) {
+			t.Errorf("%s: readManifest =\n%+v\nwant\n%+v", tc.name, m, tc.want)
+		}
+	}
+
+	if _, err := readManifest(filepath.Join(dir, "nosuch.yaml")); err == nil {
+		t.Errorf("readManifest succeeded with a missing file")
+	}
+}
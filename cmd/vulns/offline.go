@@ -0,0 +1,70 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/hyangah/vulns/internal/osvutil"
+)
+
+// offlineTransport fails every request, so that nothing reaches the
+// network under -offline even if a code path misses the flag.
+type offlineTransport struct{}
+
+func (offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, fmt.Errorf("network access to %s disabled by -offline", req.URL.Host)
+}
+
+// setOffline prepares the process for -offline: the go command
+// loading the packages and the module proxy client must not download
+// anything, so that missing modules fail fast instead of hanging.
+func setOffline() {
+	os.Setenv("GOPROXY", "off")
+}
+
+// checkOffline exits under -offline if any of the database urls is
+// not a local file:// database, listing the modules of the queries
+// that could therefore not be checked.
+func checkOffline(urls []string, queries []*osvutil.ModuleQuery) {
+	if !flagOffline {
+		return
+	}
+	var remote []string
+	for _, u := range urls {
+		if !strings.HasPrefix(u, "file://") {
+			remote = append(remote, u)
+		}
+	}
+	if len(remote) == 0 {
+		return
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "-offline requires local file:// vulnerability databases, but the databases include %s\n", strings.Join(remote, ", "))
+	var mods []string
+	for _, q := range queries {
+		if q.Skipped != "" {
+			continue
+		}
+		m := q.Module
+		if m.Replace != nil {
+			m = m.Replace
+		}
+		mods = append(mods, m.Path+"@"+m.Version)
+	}
+	if len(mods) > 0 {
+		fmt.Fprintf(&b, "could not check %d modules:\n", len(mods))
+		for _, m := range mods {
+			fmt.Fprintf(&b, "\t%s\n", m)
+		}
+	}
+	exitf("%s", b.String())
+}
@@ -0,0 +1,103 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/hyangah/vulns/internal/modproxy"
+	"github.com/hyangah/vulns/internal/osvutil"
+	"github.com/hyangah/vulns/quickcheck"
+	"github.com/hyangah/vulns/render"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/vuln/client"
+	"golang.org/x/vuln/osv"
+)
+
+// sbom reports the vulnerabilities affecting the Go modules listed in
+// an SPDX or CycloneDX SBOM, such as the one of an artifact delivered
+// by a third party, without its source or its binary. Like binary, it
+// reports vulnerable modules, not reachable symbols.
+func sbom(args []string) {
+	if len(args) != 1 {
+		exitf("sbom: want exactly one SBOM file\n")
+	}
+	renderer := render.Lookup(flagFormat)
+	if renderer == nil {
+		exitf("unknown output format %q (available: %s)\n", flagFormat, strings.Join(render.Names(), ", "))
+	}
+	mods, err := quickcheck.SBOMModules(args[0])
+	if err != nil {
+		exitf("sbom: %v\n", err)
+	}
+	if len(mods) == 0 {
+		exitf("sbom: no Go modules (pkg:golang package URLs) in %s\n", args[0])
+	}
+	known := reportModules(renderer, mods)
+	exitFailOn(known, known)
+}
+
+// reportModulesFlags registers the flags of binary and sbom,
+// which report the vulnerabilities of modules with reportModules.
+func reportModulesFlags(fs *flag.FlagSet) {
+	addFormatFlag(fs)
+	addDBFlags(fs)
+	addFilterFlags(fs)
+	addResultFlags(fs)
+	addOnDBErrorFlag(fs)
+	addPlatformFlags(fs)
+}
+
+// reportModules renders the report of the vulnerabilities affecting
+// the modules, looked up in the databases without loading packages,
+// and reports whether there are any.
+func reportModules(renderer render.Renderer, mods []*packages.Module) bool {
+	dbURLs := databases(&packages.Config{})
+	if flagOffline {
+		checkOffline(dbURLs, osvutil.BuildListModules(mods))
+	}
+	dbClient, err := osvutil.NewClient(dbURLs, dbOptions())
+	if err != nil {
+		exitf("failed to setup vulncheck client: %v", err)
+	}
+	dbClient.Ignore = ignoredIDs()
+	dbClient.Only = onlyIDs()
+	dbClient.Ecosystems = ecosystems()
+	dbClient.Concurrency = flagConcurrency
+	dbClient.SnapshotTime = dbSnapshotTime
+	summary, mod2vulns, err := analyzeModules(context.Background(), mods, dbClient)
+	if err != nil {
+		if reason := skipReason(err); reason != "" {
+			renderSkipped(renderer, reason)
+			return false
+		}
+		exitf("failed to analyze: %v\n", err)
+	}
+	if err := renderer.Render(os.Stdout, render.NewReport(summary, mod2vulns)); err != nil {
+		exitf("failed to render the report: %v\n", err)
+	}
+	return len(summary) > 0
+}
+
+// analyzeModules returns the findings of the vulnerabilities affecting
+// the modules, with their fixes resolved, and the entries affecting
+// each module.
+func analyzeModules(ctx context.Context, mods []*packages.Module, dbClient client.Client) (map[quickcheck.Key]quickcheck.Value, map[string][]*osv.Entry, error) {
+	summary, mod2vulns, err := quickcheck.AnalyzeModules(ctx, mods, dbClient)
+	if err != nil {
+		return nil, nil, err
+	}
+	if dbg('v') {
+		logModules(osvutil.BuildListModules(mods))
+	}
+	var lister quickcheck.VersionLister
+	if proxy := modproxy.FromEnv(); proxy != nil {
+		lister = proxy
+	}
+	return quickcheck.ResolveFixes(ctx, summary, mod2vulns, lister), mod2vulns, nil
+}
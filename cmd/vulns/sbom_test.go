@@ -0,0 +1,98 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hyangah/vulns/quickcheck"
+	"github.com/hyangah/vulns/testutils"
+	"golang.org/x/vuln/osv"
+)
+
+func TestAnalyzeModules(t *testing.T) {
+	t.Setenv("GOPROXY", "off") // resolve the fixes from the entries only
+
+	dbClient := testutils.MapClient(map[string][]*osv.Entry{
+		"github.com/gin-gonic/gin": {
+			testutils.Entry("GO-1", "github.com/gin-gonic/gin", "v1.7.7"),
+			testutils.Entry("GO-2", "github.com/gin-gonic/gin", "v1.5.0"), // fixed before the version in use
+		},
+		"golang.org/x/text": {testutils.Entry("GO-3", "golang.org/x/text", "v0.3.7", "golang.org/x/text/language")},
+		"example.com/other": {testutils.Entry("GO-4", "example.com/other", "")},
+	})
+	for _, tc := range []struct {
+		name, sbom string
+		want       []string
+	}{
+		{
+			name: "cyclonedx",
+			sbom: `{
+  "bomFormat": "CycloneDX",
+  "metadata": {"component": {"purl": "pkg:golang/example.com/app@v0.1.0"}},
+  "components": [
+    {"purl": "pkg:golang/github.com/gin-gonic/gin@v1.6.0"},
+    {"purl": "pkg:golang/golang.org/x/text@v0.3.5"}
+  ]
+}`,
+			want: []string{
+				"GO-1 github.com/gin-gonic/gin@v1.6.0 fix v1.7.7",
+				"GO-3 golang.org/x/text@v0.3.5 fix v0.3.7",
+			},
+		},
+		{
+			name: "spdx tag-value",
+			sbom: `SPDXVersion: SPDX-2.3
+PackageName: golang.org/x/text
+ExternalRef: PACKAGE-MANAGER purl pkg:golang/golang.org/x/text@v0.3.7
+PackageName: gin
+ExternalRef: PACKAGE-MANAGER purl pkg:golang/github.com/gin-gonic/gin@v1.4.0
+`,
+			want: []string{
+				"GO-1 github.com/gin-gonic/gin@v1.4.0 fix v1.7.7",
+				"GO-2 github.com/gin-gonic/gin@v1.4.0 fix v1.5.0",
+			},
+		},
+		{
+			name: "unaffected",
+			sbom: `{"spdxVersion": "SPDX-2.3", "packages": [{"externalRefs": [{"referenceType": "purl", "referenceLocator": "pkg:golang/golang.org/x/text@v0.3.8"}]}]}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "sbom")
+			if err := os.WriteFile(file, []byte(tc.sbom), 0666); err != nil {
+				t.Fatal(err)
+			}
+			mods, err := quickcheck.SBOMModules(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			summary, mod2vulns, err := analyzeModules(context.Background(), mods, dbClient)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for k, v := range summary {
+				got = append(got, fmt.Sprintf("%s %s@%s fix %s", k.ID, k.ModulePath, v.Version, v.Fix))
+				if len(mod2vulns[k.ModulePath]) == 0 {
+					t.Errorf("no entries for %s, with the finding of %s", k.ModulePath, k.ID)
+				}
+			}
+			sort.Strings(got)
+			if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+				t.Errorf("analyzeModules =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tc.want, "\n"))
+			}
+		})
+	}
+}
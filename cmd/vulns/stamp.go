@@ -0,0 +1,141 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/hyangah/vulns/internal/osvutil"
+	"github.com/hyangah/vulns/internal/xtools/checker"
+	"github.com/hyangah/vulns/quickcheck"
+	"github.com/hyangah/vulns/stamp"
+	"golang.org/x/tools/go/packages"
+)
+
+// The flags of stamp.
+var (
+	stampOut    = ""
+	stampFormat = "json"
+	stampVar    = "main.vulnsStamp"
+)
+
+// stampFlags registers the flags of stamp, whose -format selects
+// the format of the stamp, not of a report.
+func stampFlags(fs *flag.FlagSet) {
+	fs.StringVar(&stampOut, "o", stampOut, "output file (default: standard output)")
+	fs.StringVar(&stampFormat, "format", stampFormat, "output format: json, to embed with go:embed, or ldflags, to pass to go build -ldflags")
+	fs.StringVar(&stampVar, "var", stampVar, "with -format=ldflags, the qualified name of the string variable to set")
+	addDBFlags(fs)
+	addFilterFlags(fs)
+	addSuppressionFlags(fs)
+	addLoadFlags(fs)
+	addAnalyzerFlags(fs)
+}
+
+// writeStamp scans the packages matching the patterns and writes
+// the summary of the result, to be built into the binary of the
+// packages (see package stamp). The findings suppressed with the
+// -ignore-symbol, -ignore-attr, and -baseline flags are recorded as
+// suppressed.
+func writeStamp(args []string) {
+	if len(args) == 0 {
+		exitUsage("stamp")
+	}
+	if stampFormat != "json" && stampFormat != "ldflags" {
+		exitf("stamp: invalid -format flag %q\n", stampFormat)
+	}
+	ignoreRules, ignoreAttrs, baseline := suppressions()
+
+	cfg := &packages.Config{
+		Mode:  packages.LoadSyntax | packages.LoadAllSyntax | packages.NeedModule,
+		Tests: checker.IncludeTests,
+	}
+	pkgs, err := load(cfg, args)
+	if err != nil {
+		if _, ok := err.(typeParseError); !ok {
+			exitf("stamp: %v\n", err)
+		}
+	}
+	dbURLs := databases(cfg)
+	if flagOffline {
+		checkOffline(dbURLs, osvutil.Modules(pkgs))
+	}
+	dbClient, err := osvutil.NewClient(dbURLs, dbOptions())
+	if err != nil {
+		exitf("failed to setup vulncheck client: %v", err)
+	}
+	dbClient.Ignore = ignoredIDs()
+	dbClient.Only = onlyIDs()
+	dbClient.Ecosystems = ecosystems()
+	dbClient.Concurrency = flagConcurrency
+	dbClient.SnapshotTime = dbSnapshotTime
+	ctx := context.Background()
+	s := &stamp.Stamp{Scanned: time.Now().UTC()}
+	if s.DBModified, err = dbClient.LastModifiedTime(ctx); err != nil {
+		exitf("stamp: %v\n", err)
+	}
+	all, _, err := quickcheck.Analyze(ctx, pkgs, dbClient)
+	if err != nil {
+		exitf("failed to analyze: %v\n", err)
+	}
+	reported := all
+	if len(ignoreRules) > 0 {
+		reported = quickcheck.Ignore(reported, ignoreRules)
+	}
+	if len(ignoreAttrs) > 0 {
+		reported = quickcheck.IgnoreAttrs(reported, ignoreAttrs)
+	}
+	if len(baseline) > 0 {
+		reported = quickcheck.Ignore(reported, baseline)
+	}
+	s.Found = vulnIDs(reported, nil)
+	s.Suppressed = vulnIDs(all, reported)
+
+	var data []byte
+	if stampFormat == "json" {
+		data, err = s.Marshal()
+		data = append(data, '\n')
+	} else {
+		var flags string
+		flags, err = s.LDFlags(stampVar)
+		data = []byte(flags + "\n")
+	}
+	if err != nil {
+		exitf("stamp: %v\n", err)
+	}
+	if stampOut == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(stampOut, data, 0666); err != nil {
+		exitf("stamp: %v\n", err)
+	}
+}
+
+// vulnIDs returns the sorted IDs of the vulnerabilities
+// with findings in summary but none in except.
+func vulnIDs(summary, except map[quickcheck.Key]quickcheck.Value) []string {
+	excluded := make(map[string]bool)
+	for k := range except {
+		excluded[k.ID] = true
+	}
+	seen := make(map[string]bool)
+	var ids []string
+	for k := range summary {
+		if !excluded[k.ID] && !seen[k.ID] {
+			seen[k.ID] = true
+			ids = append(ids, k.ID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,41 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyangah/vulns/quickcheck"
+)
+
+func TestVulnIDs(t *testing.T) {
+	summary := func(ids ...string) map[quickcheck.Key]quickcheck.Value {
+		s := make(map[quickcheck.Key]quickcheck.Value)
+		for i, id := range ids {
+			// Several findings of the same vulnerability.
+			s[quickcheck.Key{ID: id, Symbol: "F", PackagePath: "example.com/p"}] = quickcheck.Value{Count: 1}
+			s[quickcheck.Key{ID: id, Symbol: "G", PackagePath: "example.com/p"}] = quickcheck.Value{Count: int64(i)}
+		}
+		return s
+	}
+	for _, tc := range []struct {
+		summary, except map[quickcheck.Key]quickcheck.Value
+		want            string
+	}{
+		{nil, nil, ""},
+		{summary("GO-2", "GO-1"), nil, "GO-1 GO-2"},
+		{summary("GO-2", "GO-1", "GO-3"), summary("GO-2"), "GO-1 GO-3"},
+		{summary("GO-1"), summary("GO-1", "GO-2"), ""},
+		{nil, summary("GO-1"), ""},
+	} {
+		if got := strings.Join(vulnIDs(tc.summary, tc.except), " "); got != tc.want {
+			t.Errorf("vulnIDs(%v, %v) = %q, want %q", tc.summary, tc.except, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/hyangah/vulns/internal/osvutil"
+	"github.com/hyangah/vulns/internal/xtools/checker"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/vuln/client"
+)
+
+// warmFlags registers the flags of cache and warm.
+func warmFlags(fs *flag.FlagSet) {
+	addDBFlags(fs)
+	addLoadFlags(fs)
+}
+
+// warm pre-fetches the database index and the OSV entries of all
+// modules in the import graph of the packages matching patterns
+// into the HTTP cache, so a later scan can run without network access
+// while the cached index is fresh.
+//
+// Only the import graph is loaded; packages are not type checked.
+func warm(patterns []string) {
+	if len(patterns) == 0 {
+		exitf("warm: no package patterns\n")
+	}
+	cfg := &packages.Config{
+		Mode:  packages.NeedName | packages.NeedModule | packages.NeedImports | packages.NeedDeps,
+		Tests: checker.IncludeTests,
+	}
+	pkgs, err := load(cfg, patterns)
+	if err != nil {
+		if _, ok := err.(typeParseError); !ok {
+			exitf("warm: %v\n", err)
+		}
+	}
+	dbClient, err := client.NewClient(databases(cfg), dbOptions())
+	if err != nil {
+		exitf("failed to setup vulncheck client: %v", err)
+	}
+	// Querying each module caches the database index as well.
+	pkg2vulns, err := osvutil.FetchOSVEntries(context.Background(), dbClient, pkgs)
+	if err != nil {
+		exitf("warm: failed to fetch OSV entries: %v\n", err)
+	}
+	fmt.Fprintf(os.Stderr, "warmed the vulnerability database cache; %d packages have known vulnerabilities\n", len(pkg2vulns))
+}
@@ -0,0 +1,289 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hyangah/vulns/quickcheck"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/vuln/client"
+	"golang.org/x/vuln/osv"
+)
+
+// watchInterval is how often watch looks for modified files.
+const watchInterval = 500 * time.Millisecond
+
+// watch analyzes pkgs, reports the findings with show, and then stays
+// resident: whenever a Go file in the directory of a package of the
+// main modules changes, it loads and analyzes again only the initial
+// packages importing a changed package, directly or indirectly, and
+// reports the findings merged with the previous ones of the other
+// initial packages. A change of a go.mod file of the main modules
+// may change every dependency, so all the packages are analyzed again.
+//
+// On SIGHUP, watch calls reload to query the databases for the
+// catalog, and to read the suppressions, the -ignore-file file, and
+// the -baseline file again, and analyzes all the packages again,
+// keeping the HTTP cache of dbClient. After each analysis, it logs whether
+// the findings violate the -fail-on policy.
+//
+// Packages added after the start of watch are not watched.
+func watch(cfg *packages.Config, pkgs []*packages.Package, dbClient client.Client, show func([]*packages.Package, map[quickcheck.Key]quickcheck.Value, map[string][]*osv.Entry) (bool, bool), reload func() error) {
+	ctx := context.Background()
+	w := &watcher{
+		cfg:       cfg,
+		dbClient:  dbClient,
+		byPkg:     make(map[string]map[quickcheck.Key]quickcheck.Value),
+		pkg2vulns: make(map[string][]*osv.Entry),
+	}
+	if err := w.analyze(ctx, pkgs); err != nil {
+		exitf("failed to analyze: %v\n", err)
+	}
+	w.show(show)
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	w.run(ctx, ticker.C, hup, show, reload)
+}
+
+// run analyzes the packages again, when their files changed at a tick
+// or after calling reload on a hangup signal, until ctx is done.
+func (w *watcher) run(ctx context.Context, tick <-chan time.Time, hup <-chan os.Signal, show func([]*packages.Package, map[quickcheck.Key]quickcheck.Value, map[string][]*osv.Entry) (bool, bool), reload func() error) {
+	files := w.files()
+	for {
+		var roots []string
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			if err := reload(); err != nil {
+				log.Printf("failed to reload: %v", err)
+				continue
+			}
+			roots = w.rootPaths()
+			if dbg('v') {
+				log.Printf("reloaded; analyzing %d packages again", len(roots))
+			}
+		case <-tick:
+			next := w.files()
+			changed := changedDirs(files, next)
+			files = next
+			if len(changed) == 0 {
+				continue
+			}
+			roots = w.affected(changed)
+			if dbg('v') {
+				log.Printf("changed: %s; analyzing %d packages again", strings.Join(sortedKeys(changed), ", "), len(roots))
+			}
+		}
+		if len(roots) == 0 {
+			continue
+		}
+		pkgs, err := load(w.cfg, roots)
+		if err != nil {
+			if _, ok := err.(typeParseError); !ok {
+				log.Printf("failed to load packages: %v", err)
+				continue
+			}
+		}
+		if err := w.analyze(ctx, pkgs); err != nil {
+			log.Printf("failed to analyze: %v", err)
+			continue
+		}
+		files = w.files() // the loaded packages may have other files
+		fmt.Fprintf(os.Stdout, "\n=== %s ===\n\n", time.Now().Format("15:04:05"))
+		w.show(show)
+	}
+}
+
+// A watcher holds the findings of each initial package
+// between the analyses of watch.
+type watcher struct {
+	cfg       *packages.Config
+	dbClient  client.Client
+	roots     []*packages.Package                            // initial packages, in load order
+	byPkg     map[string]map[quickcheck.Key]quickcheck.Value // by package ID
+	pkg2vulns map[string][]*osv.Entry
+}
+
+// analyze analyzes the initial packages pkgs, which replace
+// the previous initial packages with the same package path.
+func (w *watcher) analyze(ctx context.Context, pkgs []*packages.Package) error {
+	byPkg, pkg2vulns, err := quickcheck.AnalyzeByPackage(ctx, pkgs, w.dbClient)
+	if err != nil {
+		return err
+	}
+	reloaded := make(map[string]bool)
+	for _, p := range pkgs {
+		reloaded[p.PkgPath] = true
+	}
+	roots := make([]*packages.Package, 0, len(w.roots)+len(pkgs))
+	for _, p := range w.roots {
+		if reloaded[p.PkgPath] {
+			delete(w.byPkg, p.ID)
+			continue
+		}
+		roots = append(roots, p)
+	}
+	w.roots = append(roots, pkgs...)
+	for _, p := range pkgs {
+		w.byPkg[p.ID] = byPkg[p.ID]
+	}
+	for path, vulns := range pkg2vulns {
+		w.pkg2vulns[path] = vulns
+	}
+	// Forget the vulnerabilities of the packages no longer imported.
+	imported := make(map[string]bool)
+	packages.Visit(w.roots, func(p *packages.Package) bool {
+		imported[p.PkgPath] = true
+		return true
+	}, nil)
+	for path := range w.pkg2vulns {
+		if !imported[path] {
+			delete(w.pkg2vulns, path)
+		}
+	}
+	return nil
+}
+
+// show reports the merged findings of all initial packages.
+func (w *watcher) show(show func([]*packages.Package, map[quickcheck.Key]quickcheck.Value, map[string][]*osv.Entry) (bool, bool)) {
+	summaries := make([]map[quickcheck.Key]quickcheck.Value, 0, len(w.roots))
+	for _, p := range w.roots {
+		summaries = append(summaries, w.byPkg[p.ID])
+	}
+	if failOnViolated(show(w.roots, quickcheck.Merge(summaries...), w.pkg2vulns)) {
+		log.Printf("the findings violate -fail-on=%s", flagFailOn)
+	}
+}
+
+// files returns the modification time and size of the Go files in the
+// directories of the packages of the main modules, and of the go.mod
+// files of the main modules, keyed by file name.
+func (w *watcher) files() map[string]string {
+	dirs, gomods := make(map[string]bool), make(map[string]bool)
+	packages.Visit(w.roots, nil, func(p *packages.Package) {
+		if p.Module == nil || !p.Module.Main {
+			return
+		}
+		if p.Module.GoMod != "" {
+			gomods[p.Module.GoMod] = true
+		}
+		for _, f := range p.GoFiles {
+			dirs[filepath.Dir(f)] = true
+		}
+	})
+	files := make(map[string]string)
+	stat := func(file string) {
+		if fi, err := os.Stat(file); err == nil {
+			files[file] = fmt.Sprint(fi.ModTime().UnixNano(), fi.Size())
+		}
+	}
+	for gomod := range gomods {
+		stat(gomod)
+	}
+	for dir := range dirs {
+		matches, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+		for _, file := range matches {
+			stat(file)
+		}
+	}
+	return files
+}
+
+// changedDirs returns the directories of the files added, removed,
+// or modified between the before and after snapshots of files.
+// A changed go.mod file itself is returned instead of its directory.
+func changedDirs(before, after map[string]string) map[string]bool {
+	changed := make(map[string]bool)
+	note := func(file string) {
+		if filepath.Ext(file) == ".mod" {
+			changed[file] = true
+		} else {
+			changed[filepath.Dir(file)] = true
+		}
+	}
+	for file, v := range after {
+		if before[file] != v {
+			note(file)
+		}
+	}
+	for file := range before {
+		if _, ok := after[file]; !ok {
+			note(file)
+		}
+	}
+	return changed
+}
+
+// affected returns the package paths of the initial packages
+// importing, directly or indirectly, a package in one of the changed
+// directories, or all of them if a go.mod file changed.
+func (w *watcher) affected(changed map[string]bool) []string {
+	hit := make(map[*packages.Package]bool)
+	var visit func(p *packages.Package) bool
+	visit = func(p *packages.Package) bool {
+		if v, ok := hit[p]; ok {
+			return v
+		}
+		hit[p] = false // break import cycles
+		v := false
+		if p.Module != nil && p.Module.Main && changed[p.Module.GoMod] {
+			v = true
+		}
+		for _, f := range p.GoFiles {
+			v = v || changed[filepath.Dir(f)]
+		}
+		for _, imp := range p.Imports {
+			v = visit(imp) || v
+		}
+		hit[p] = v
+		return v
+	}
+	paths := make(map[string]bool)
+	for _, p := range w.roots {
+		if strings.HasSuffix(p.ID, ".test") {
+			continue // generated test main; loaded again with its package
+		}
+		if visit(p) {
+			paths[p.PkgPath] = true
+		}
+	}
+	return sortedKeys(paths)
+}
+
+// rootPaths returns the package paths of the initial packages.
+func (w *watcher) rootPaths() []string {
+	paths := make(map[string]bool)
+	for _, p := range w.roots {
+		if !strings.HasSuffix(p.ID, ".test") {
+			paths[p.PkgPath] = true
+		}
+	}
+	return sortedKeys(paths)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,180 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/hyangah/vulns/quickcheck"
+	"github.com/hyangah/vulns/testutils"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/vuln/osv"
+)
+
+func TestWatchReload(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"go.mod": "module example.com/w\n\ngo 1.18\n",
+		"w.go":   "package w\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := &packages.Config{Mode: packages.LoadAllSyntax | packages.NeedModule, Dir: dir}
+	pkgs, err := load(cfg, []string{"./..."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &watcher{
+		cfg:       cfg,
+		dbClient:  testutils.MapClient(nil),
+		byPkg:     make(map[string]map[quickcheck.Key]quickcheck.Value),
+		pkg2vulns: make(map[string][]*osv.Entry),
+	}
+	if err := w.analyze(context.Background(), pkgs); err != nil {
+		t.Fatal(err)
+	}
+
+	reloads := make(chan error) // the results of the reloads
+	reload := func() error { return <-reloads }
+	shown := make(chan []string, 2)
+	show := func(pkgs []*packages.Package, _ map[quickcheck.Key]quickcheck.Value, _ map[string][]*osv.Entry) (bool, bool) {
+		var paths []string
+		for _, p := range pkgs {
+			paths = append(paths, p.PkgPath)
+		}
+		shown <- paths
+		return false, false
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	hup := make(chan os.Signal)
+	done := make(chan bool)
+	go func() {
+		w.run(ctx, nil, hup, show, reload)
+		close(done)
+	}()
+
+	// A failed reload analyzes nothing again.
+	hup <- syscall.SIGHUP
+	reloads <- errors.New("invalid -baseline flag")
+	hup <- syscall.SIGHUP
+	reloads <- nil
+	select {
+	case paths := <-shown:
+		if len(paths) != 1 || paths[0] != "example.com/w" {
+			t.Errorf("analyzed %v again after reloading, want [example.com/w]", paths)
+		}
+	case <-time.After(time.Minute):
+		t.Fatal("no analysis after reloading")
+	}
+	if len(shown) > 0 {
+		t.Errorf("analyzed the packages again after a failed reload")
+	}
+	cancel()
+	<-done
+}
+
+func TestChangedDirs(t *testing.T) {
+	fp := filepath.FromSlash
+	before := map[string]string{
+		fp("/w/go.mod"):   "1 10",
+		fp("/w/a/a.go"):   "1 10",
+		fp("/w/a/a2.go"):  "1 10",
+		fp("/w/lib/l.go"): "1 10",
+	}
+	for _, tc := range []struct {
+		name   string
+		change func(files map[string]string)
+		want   []string
+	}{
+		{"unchanged", func(map[string]string) {}, nil},
+		{"modified", func(files map[string]string) { files[fp("/w/a/a.go")] = "2 10" }, []string{"/w/a"}},
+		{"resized", func(files map[string]string) { files[fp("/w/lib/l.go")] = "1 11" }, []string{"/w/lib"}},
+		{"added", func(files map[string]string) { files[fp("/w/b/b.go")] = "1 10" }, []string{"/w/b"}},
+		{"removed", func(files map[string]string) { delete(files, fp("/w/a/a2.go")) }, []string{"/w/a"}},
+		{"go.mod", func(files map[string]string) { files[fp("/w/go.mod")] = "2 12" }, []string{"/w/go.mod"}},
+		{"several", func(files map[string]string) {
+			files[fp("/w/a/a.go")] = "2 10"
+			files[fp("/w/a/a2.go")] = "2 10"
+			delete(files, fp("/w/lib/l.go"))
+		}, []string{"/w/a", "/w/lib"}},
+	} {
+		after := make(map[string]string)
+		for f, v := range before {
+			after[f] = v
+		}
+		tc.change(after)
+		var want []string
+		for _, d := range tc.want {
+			want = append(want, fp(d))
+		}
+		if got := sortedKeys(changedDirs(before, after)); strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("%s: changedDirs = %q, want %q", tc.name, got, want)
+		}
+	}
+}
+
+func TestWatcherAffected(t *testing.T) {
+	fp := filepath.FromSlash
+	main := &packages.Module{Path: "example.com/w", Main: true, GoMod: fp("/w/go.mod")}
+	dep := &packages.Module{Path: "example.com/dep", GoMod: fp("/mod/dep/go.mod")}
+	pkg := func(path string, mod *packages.Module, file string, imports ...*packages.Package) *packages.Package {
+		p := &packages.Package{ID: path, PkgPath: path, Module: mod, GoFiles: []string{fp(file)}, Imports: make(map[string]*packages.Package)}
+		for _, imp := range imports {
+			p.Imports[imp.PkgPath] = imp
+		}
+		return p
+	}
+	d := pkg("example.com/dep", dep, "/mod/dep/dep.go")
+	lib := pkg("example.com/w/lib", main, "/w/lib/lib.go", d)
+	a := pkg("example.com/w/a", main, "/w/a/a.go", lib)
+	b := pkg("example.com/w/b", main, "/w/b/b.go")
+	// The generated test main of a imports a, and is loaded again with it.
+	test := pkg("example.com/w/a.test", main, "/cache/a.test/testmain.go", a)
+	w := &watcher{roots: []*packages.Package{a, b, test}}
+
+	for _, tc := range []struct {
+		changed []string
+		want    []string
+	}{
+		{nil, nil},
+		{[]string{"/w/a"}, []string{"example.com/w/a"}},
+		{[]string{"/w/lib"}, []string{"example.com/w/a"}},
+		{[]string{"/mod/dep"}, []string{"example.com/w/a"}},
+		{[]string{"/w/b"}, []string{"example.com/w/b"}},
+		{[]string{"/w/lib", "/w/b"}, []string{"example.com/w/a", "example.com/w/b"}},
+		{[]string{"/w/go.mod"}, []string{"example.com/w/a", "example.com/w/b"}},
+		{[]string{"/mod/dep/go.mod"}, nil}, // not a main module
+		{[]string{"/w/other"}, nil},
+	} {
+		changed := make(map[string]bool)
+		for _, c := range tc.changed {
+			changed[fp(c)] = true
+		}
+		if got := w.affected(changed); strings.Join(got, " ") != strings.Join(tc.want, " ") {
+			t.Errorf("affected(%q) = %q, want %q", tc.changed, got, tc.want)
+		}
+	}
+}
+
+func TestWatcherRootPaths(t *testing.T) {
+	pkg := func(path string) *packages.Package { return &packages.Package{ID: path, PkgPath: path} }
+	// The generated test main is not a root to report on reload.
+	w := &watcher{roots: []*packages.Package{pkg("example.com/w/b"), pkg("example.com/w/a"), pkg("example.com/w/a.test")}}
+	if got, want := strings.Join(w.rootPaths(), " "), "example.com/w/a example.com/w/b"; got != want {
+		t.Errorf("rootPaths() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package vulns documents the layout of this module for the tools
+// depending on it, such as gopls plugins and CI wrappers. It has no
+// API of its own.
+//
+// The supported API is in the following packages:
+//
+//   - analysis: the vulnerability analyzer for go/analysis drivers,
+//     and the vet-compatible JSON encoding of its diagnostics.
+//   - quickcheck: the scanner of package graphs, build lists, and
+//     binaries, and the classification of its findings.
+//   - vulndb: the client of the vulnerability databases.
+//   - vulncache: the caches of database responses.
+//   - render: the reports of the findings, in every output format.
+//   - tracefmt: the formatting of the traces of the findings.
+//   - stamp, history, and stdlib: the build stamps, the history of
+//     the scans, and the versions of the standard library.
+//   - testutils: fake databases and clients for tests, and
+//     testutils/report: the parsing and linting of the YAML reports
+//     of golang.org/x/vulndb the fake databases are written in.
+//   - schema: the versioned JSON Schema documents of the JSON outputs.
+//
+// Within a major version of the module, the exported identifiers of
+// these packages are not removed, and their behavior only changes
+// compatibly, by adding fields, functions, and options. Identifiers
+// documented as experimental are exempt, as are the output formats
+// of render, which may add fields. The outputs documented by the
+// schema package only change compatibly within a version of their
+// schemas.
+//
+// Everything under internal is private to the module: internal/xtools
+// and internal/xvuln hold modified copies of golang.org/x/tools and
+// golang.org/x/vuln code, and the other internal packages implement
+// the supported ones. The commands under cmd are not libraries.
+package vulns
@@ -0,0 +1,105 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// idFormat is the time layout of the IDs of the runs in a Dir,
+// which sort like the times of the runs.
+const idFormat = "20060102T150405.000000000Z"
+
+// A Dir is a Store writing a JSON file per run in a directory.
+// The files can be checked in, or kept as CI artifacts.
+type Dir struct {
+	dir string
+}
+
+// OpenDir returns the store in the directory, creating it if needed.
+func OpenDir(dir string) (*Dir, error) {
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, err
+	}
+	return &Dir{dir: dir}, nil
+}
+
+// Add implements Store.
+func (d *Dir) Add(ctx context.Context, r *Run) error {
+	data, err := json.MarshalIndent(r, "", "\t")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	base := r.Time.UTC().Format(idFormat)
+	for i := 0; ; i++ {
+		id := base
+		if i > 0 {
+			id = fmt.Sprintf("%s-%d", base, i)
+		}
+		// O_EXCL, so that concurrent scans do not overwrite each other.
+		f, err := os.OpenFile(filepath.Join(d.dir, id+".json"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		r.ID = id
+		// The ID is the file name; it is not stored in the file.
+		_, err = f.Write(data)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		return err
+	}
+}
+
+// Runs implements Store.
+func (d *Dir) Runs(ctx context.Context, q Query) ([]*Run, error) {
+	files, err := filepath.Glob(filepath.Join(d.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var runs []*Run
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var r Run
+		if err := json.Unmarshal(data, &r); err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		if !q.match(&r) {
+			continue
+		}
+		r.ID = strings.TrimSuffix(filepath.Base(file), ".json")
+		r.Findings = q.filter(r.Findings)
+		runs = append(runs, &r)
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		a, b := runs[i], runs[j]
+		if !a.Time.Equal(b.Time) {
+			return a.Time.Before(b.Time)
+		}
+		// Order the runs at the same time as they were added:
+		// ID, ID-1, ID-2, ...
+		if len(a.ID) != len(b.ID) {
+			return len(a.ID) < len(b.ID)
+		}
+		return a.ID < b.ID
+	})
+	return runs, nil
+}
+
+// Close implements Store.
+func (d *Dir) Close() error { return nil }
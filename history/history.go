@@ -0,0 +1,190 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package history persists the results of vulnerability scans over
+// time, so that the findings of a run can be compared with the ones
+// of earlier runs: which findings are new, which were resolved, and
+// when each was first seen.
+//
+// A Store keeps the runs. The package provides a store writing a JSON
+// file per run in a directory (OpenDir), and a store in an SQL
+// database, such as an SQLite file (OpenSQLite).
+package history
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/hyangah/vulns/quickcheck"
+)
+
+// A Run is the result of a scan.
+type Run struct {
+	// ID identifies the run in its store. It is assigned by Store.Add.
+	ID string `json:",omitempty"`
+	// Time is when the scan ran.
+	Time time.Time
+	// DBModified is when the vulnerability database
+	// used by the scan was last modified.
+	DBModified time.Time
+	// Target describes what was scanned, such as the directory
+	// of the main module.
+	Target string `json:",omitempty"`
+	// Findings lists the findings of the scan, including the
+	// suppressed ones, sorted.
+	Findings []*Finding `json:",omitempty"`
+}
+
+// A Finding is a vulnerable symbol, package, or module found by a scan,
+// depending on the scan level.
+type Finding struct {
+	ID          string // the OSV ID of the vulnerability
+	ModulePath  string
+	PackagePath string `json:",omitempty"`
+	Symbol      string `json:",omitempty"`
+	Version     string `json:",omitempty"` // the version of the module in use
+	Fix         string `json:",omitempty"` // the version fixing the vulnerability
+	// Suppressed reports whether the finding was left out of the
+	// report, for example because it was accepted in a baseline.
+	Suppressed bool `json:",omitempty"`
+}
+
+// Key identifies the finding across runs. It does not include the
+// versions, so that a finding persists while the module is upgraded
+// to versions that are still affected.
+func (f *Finding) Key() quickcheck.Key {
+	return quickcheck.Key{ID: f.ID, ModulePath: f.ModulePath, PackagePath: f.PackagePath, Symbol: f.Symbol}
+}
+
+// NewRun returns the run of a scan at time t, with the findings in
+// all, of which the ones missing in reported were suppressed.
+func NewRun(t time.Time, all, reported map[quickcheck.Key]quickcheck.Value) *Run {
+	r := &Run{Time: t}
+	for k, v := range all {
+		_, ok := reported[k]
+		r.Findings = append(r.Findings, &Finding{
+			ID:          k.ID,
+			ModulePath:  k.ModulePath,
+			PackagePath: k.PackagePath,
+			Symbol:      k.Symbol,
+			Version:     v.Version,
+			Fix:         v.Fix,
+			Suppressed:  !ok,
+		})
+	}
+	sortFindings(r.Findings)
+	return r
+}
+
+func sortFindings(fs []*Finding) {
+	sort.Slice(fs, func(i, j int) bool {
+		a, b := fs[i], fs[j]
+		if a.ID != b.ID {
+			return a.ID < b.ID
+		}
+		if a.ModulePath != b.ModulePath {
+			return a.ModulePath < b.ModulePath
+		}
+		if a.PackagePath != b.PackagePath {
+			return a.PackagePath < b.PackagePath
+		}
+		return a.Symbol < b.Symbol
+	})
+}
+
+// A Query selects runs in a Store.
+type Query struct {
+	// Target, if set, selects the runs of the target.
+	Target string
+	// Since and Until, if set, select the runs
+	// at or after Since and before Until.
+	Since, Until time.Time
+	// Module, if set, selects the findings in the module.
+	// The runs are returned even if they have no such findings.
+	Module string
+}
+
+// match reports whether the run is selected by the query,
+// ignoring the Module.
+func (q *Query) match(r *Run) bool {
+	return (q.Target == "" || r.Target == q.Target) &&
+		(q.Since.IsZero() || !r.Time.Before(q.Since)) &&
+		(q.Until.IsZero() || r.Time.Before(q.Until))
+}
+
+// filter returns the findings selected by the query.
+func (q *Query) filter(fs []*Finding) []*Finding {
+	if q.Module == "" {
+		return fs
+	}
+	var kept []*Finding
+	for _, f := range fs {
+		if f.ModulePath == q.Module {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// A Store keeps scan runs.
+type Store interface {
+	// Add stores the run and sets its ID.
+	Add(ctx context.Context, r *Run) error
+	// Runs returns the runs selected by the query, sorted by time.
+	Runs(ctx context.Context, q Query) ([]*Run, error)
+	// Close releases the resources of the store.
+	Close() error
+}
+
+// Diff returns the findings of new that are not in old, and the
+// findings of old that are not in new. Suppressed findings count
+// as findings; compare Finding.Suppressed to tell when a finding
+// was suppressed. A nil old run has no findings.
+func Diff(old, new *Run) (introduced, resolved []*Finding) {
+	keys := func(r *Run) map[quickcheck.Key]bool {
+		m := make(map[quickcheck.Key]bool)
+		if r != nil {
+			for _, f := range r.Findings {
+				m[f.Key()] = true
+			}
+		}
+		return m
+	}
+	oldKeys, newKeys := keys(old), keys(new)
+	for _, f := range new.Findings {
+		if !oldKeys[f.Key()] {
+			introduced = append(introduced, f)
+		}
+	}
+	if old != nil {
+		for _, f := range old.Findings {
+			if !newKeys[f.Key()] {
+				resolved = append(resolved, f)
+			}
+		}
+	}
+	return introduced, resolved
+}
+
+// FirstSeen returns the time of the earliest of the runs, sorted by
+// time, since which each finding of the last run has been found in
+// every run. A finding resolved and found again is thus first seen
+// when it was found again.
+func FirstSeen(runs []*Run) map[quickcheck.Key]time.Time {
+	seen := make(map[quickcheck.Key]time.Time)
+	for _, r := range runs {
+		next := make(map[quickcheck.Key]time.Time)
+		for _, f := range r.Findings {
+			k := f.Key()
+			if t, ok := seen[k]; ok {
+				next[k] = t
+			} else {
+				next[k] = r.Time
+			}
+		}
+		seen = next
+	}
+	return seen
+}
@@ -0,0 +1,162 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package history
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hyangah/vulns/quickcheck"
+)
+
+var (
+	keyA = quickcheck.Key{ID: "GO-2022-0001", ModulePath: "a.com/m", PackagePath: "a.com/m/vuln", Symbol: "Vuln"}
+	keyB = quickcheck.Key{ID: "GO-2022-0002", ModulePath: "b.com/m", PackagePath: "b.com/m/vuln", Symbol: "Vuln"}
+	t0   = time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+)
+
+func TestNewRun(t *testing.T) {
+	all := map[quickcheck.Key]quickcheck.Value{
+		keyB: {Version: "v1.0.0"},
+		keyA: {Version: "v0.1.0", Fix: "v0.2.0"},
+	}
+	reported := map[quickcheck.Key]quickcheck.Value{keyA: all[keyA]}
+	got := NewRun(t0, all, reported)
+	want := &Run{Time: t0, Findings: []*Finding{
+		{ID: "GO-2022-0001", ModulePath: "a.com/m", PackagePath: "a.com/m/vuln", Symbol: "Vuln", Version: "v0.1.0", Fix: "v0.2.0"},
+		{ID: "GO-2022-0002", ModulePath: "b.com/m", PackagePath: "b.com/m/vuln", Symbol: "Vuln", Version: "v1.0.0", Suppressed: true},
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("NewRun mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// testRuns returns three daily runs: A is found in the first and the
+// last, and B, in the last two.
+func testRuns() []*Run {
+	a := map[quickcheck.Key]quickcheck.Value{keyA: {}}
+	b := map[quickcheck.Key]quickcheck.Value{keyB: {}}
+	ab := map[quickcheck.Key]quickcheck.Value{keyA: {}, keyB: {}}
+	return []*Run{
+		NewRun(t0, a, a),
+		NewRun(t0.Add(24*time.Hour), b, b),
+		NewRun(t0.Add(48*time.Hour), ab, ab),
+	}
+}
+
+func TestDiff(t *testing.T) {
+	runs := testRuns()
+	introduced, resolved := Diff(runs[0], runs[1])
+	if len(introduced) != 1 || introduced[0].Key() != keyB || len(resolved) != 1 || resolved[0].Key() != keyA {
+		t.Errorf("Diff = %v, %v; want B introduced and A resolved", introduced, resolved)
+	}
+	introduced, resolved = Diff(nil, runs[2])
+	if len(introduced) != 2 || len(resolved) != 0 {
+		t.Errorf("Diff(nil, run) = %v, %v; want all introduced", introduced, resolved)
+	}
+}
+
+func TestFirstSeen(t *testing.T) {
+	got := FirstSeen(testRuns())
+	want := map[quickcheck.Key]time.Time{
+		keyA: t0.Add(48 * time.Hour), // resolved and found again
+		keyB: t0.Add(24 * time.Hour),
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("FirstSeen mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDir(t *testing.T) {
+	ctx := context.Background()
+	s, err := OpenDir(t.TempDir() + "/history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	runs := testRuns()
+	runs[0].Target = "other"
+	// Add them out of order, and twice at the same time.
+	dup := *runs[2]
+	for _, r := range []*Run{runs[2], runs[0], runs[1], &dup} {
+		if err := s.Add(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if dup.ID == runs[2].ID || !strings.HasPrefix(dup.ID, runs[2].ID) {
+		t.Errorf("IDs of runs at the same time: %q, %q", runs[2].ID, dup.ID)
+	}
+	got, err := s.Runs(ctx, Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]*Run{runs[0], runs[1], runs[2], &dup}, got); diff != "" {
+		t.Errorf("Runs mismatch (-want +got):\n%s", diff)
+	}
+
+	got, err = s.Runs(ctx, Query{Since: t0.Add(time.Hour), Until: t0.Add(48 * time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ID != runs[1].ID {
+		t.Errorf("Runs(Since, Until) = %v, want the second run", got)
+	}
+	got, err = s.Runs(ctx, Query{Module: "a.com/m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 4 || len(got[1].Findings) != 0 || len(got[2].Findings) != 1 || got[2].Findings[0].Key() != keyA {
+		t.Errorf("Runs(Module) = %v, want the findings in a.com/m", got)
+	}
+	got, err = s.Runs(ctx, Query{Target: "other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ID != runs[0].ID {
+		t.Errorf("Runs(Target) = %v, want the first run", got)
+	}
+}
+
+func TestOpenSQLiteWithoutDriver(t *testing.T) {
+	if _, err := OpenSQLite(t.TempDir() + "/history.db"); err == nil || !strings.Contains(err.Error(), "no SQLite driver") {
+		t.Errorf("OpenSQLite without a driver: got error %v", err)
+	}
+}
+
+func TestTimeline(t *testing.T) {
+	runs := testRuns()
+	// A is suppressed in the last run, and a fourth run
+	// next month finds nothing.
+	runs[2].Findings[0].Suppressed = true
+	runs = append(runs, &Run{Time: t0.Add(31 * 24 * time.Hour)})
+
+	got := Timeline(runs)
+	if len(got) != 2 {
+		t.Fatalf("got %d histories, want 2", len(got))
+	}
+	a, b := got[0], got[1]
+	if a.Key() != keyA || b.Key() != keyB {
+		t.Fatalf("Timeline returned %v, %v; want A, B", a.Key(), b.Key())
+	}
+	day := func(n int) time.Time { return t0.Add(time.Duration(n) * 24 * time.Hour) }
+	if !a.FirstSeen.Equal(day(0)) || !a.LastSeen.Equal(day(2)) || !a.Suppressed.Equal(day(2)) || !a.Fixed.Equal(day(31)) {
+		t.Errorf("history of A = %+v", a)
+	}
+	if !b.FirstSeen.Equal(day(1)) || !b.LastSeen.Equal(day(2)) || !b.Suppressed.IsZero() || !b.Fixed.Equal(day(31)) {
+		t.Errorf("history of B = %+v", b)
+	}
+
+	trend := Trend(runs)
+	want := []*MonthTrend{
+		{Month: "2022-10", Reported: 1, Suppressed: 1, New: 2},
+		{Month: "2022-11", Fixed: 2},
+	}
+	if diff := cmp.Diff(want, trend); diff != "" {
+		t.Errorf("Trend mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package history
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// sqliteDrivers are the names the common SQLite drivers register
+// with database/sql: modernc.org/sqlite and github.com/mattn/go-sqlite3.
+var sqliteDrivers = []string{"sqlite", "sqlite3"}
+
+// schema creates the tables of an SQL store. The times are stored
+// as RFC 3339 strings with nanoseconds, which sort like the times.
+const schema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id INTEGER PRIMARY KEY,
+	time TEXT NOT NULL,
+	db_modified TEXT NOT NULL,
+	target TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS runs_time ON runs (time);
+CREATE TABLE IF NOT EXISTS findings (
+	run INTEGER NOT NULL REFERENCES runs (id),
+	id TEXT NOT NULL,
+	module TEXT NOT NULL,
+	package TEXT NOT NULL,
+	symbol TEXT NOT NULL,
+	version TEXT NOT NULL,
+	fix TEXT NOT NULL,
+	suppressed INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS findings_run ON findings (run);
+`
+
+// An SQL is a Store in an SQL database.
+type SQL struct {
+	db *sql.DB
+}
+
+// OpenSQLite returns the store in the SQLite database file, creating
+// it if needed. The package does not depend on an SQLite driver:
+// programs link one in, such as modernc.org/sqlite.
+func OpenSQLite(file string) (*SQL, error) {
+	for _, name := range sqliteDrivers {
+		for _, d := range sql.Drivers() {
+			if d != name {
+				continue
+			}
+			db, err := sql.Open(name, file)
+			if err != nil {
+				return nil, err
+			}
+			s, err := NewSQL(db)
+			if err != nil {
+				db.Close()
+				return nil, err
+			}
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot open %s: no SQLite driver (%v) is linked in", file, sqliteDrivers)
+}
+
+// NewSQL returns the store in the database, creating its tables
+// if needed. The statements are written for SQLite, but are portable
+// to other databases with the same placeholder syntax.
+func NewSQL(db *sql.DB) (*SQL, error) {
+	if _, err := db.Exec(schema); err != nil {
+		return nil, fmt.Errorf("creating the history tables: %v", err)
+	}
+	return &SQL{db: db}, nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+// Add implements Store.
+func (s *SQL) Add(ctx context.Context, r *Run) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	res, err := tx.ExecContext(ctx, `INSERT INTO runs (time, db_modified, target) VALUES (?, ?, ?)`,
+		formatTime(r.Time), formatTime(r.DBModified), r.Target)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	for _, f := range r.Findings {
+		_, err := tx.ExecContext(ctx, `INSERT INTO findings (run, id, module, package, symbol, version, fix, suppressed) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			id, f.ID, f.ModulePath, f.PackagePath, f.Symbol, f.Version, f.Fix, f.Suppressed)
+		if err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	r.ID = strconv.FormatInt(id, 10)
+	return nil
+}
+
+// Runs implements Store.
+func (s *SQL) Runs(ctx context.Context, q Query) ([]*Run, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, time, db_modified, target FROM runs ORDER BY time, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var runs []*Run
+	byID := make(map[int64]*Run)
+	for rows.Next() {
+		var (
+			id                int64
+			t, dbModified, tg string
+		)
+		if err := rows.Scan(&id, &t, &dbModified, &tg); err != nil {
+			return nil, err
+		}
+		r := &Run{ID: strconv.FormatInt(id, 10), Target: tg}
+		if r.Time, err = parseTime(t); err != nil {
+			return nil, err
+		}
+		if r.DBModified, err = parseTime(dbModified); err != nil {
+			return nil, err
+		}
+		if q.match(r) {
+			runs = append(runs, r)
+			byID[id] = r
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	frows, err := s.db.QueryContext(ctx, `SELECT run, id, module, package, symbol, version, fix, suppressed FROM findings`)
+	if err != nil {
+		return nil, err
+	}
+	defer frows.Close()
+	for frows.Next() {
+		var (
+			run int64
+			f   Finding
+		)
+		if err := frows.Scan(&run, &f.ID, &f.ModulePath, &f.PackagePath, &f.Symbol, &f.Version, &f.Fix, &f.Suppressed); err != nil {
+			return nil, err
+		}
+		if r := byID[run]; r != nil && (q.Module == "" || f.ModulePath == q.Module) {
+			r.Findings = append(r.Findings, &f)
+		}
+	}
+	if err := frows.Err(); err != nil {
+		return nil, err
+	}
+	for _, r := range runs {
+		sortFindings(r.Findings)
+	}
+	return runs, nil
+}
+
+// Close implements Store.
+func (s *SQL) Close() error { return s.db.Close() }
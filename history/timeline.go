@@ -0,0 +1,108 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package history
+
+import (
+	"sort"
+	"time"
+
+	"github.com/hyangah/vulns/quickcheck"
+)
+
+// A FindingHistory is the history of a finding in a list of runs.
+type FindingHistory struct {
+	// Finding is the finding as of the last run with it.
+	*Finding
+	// FirstSeen is the time of the first run with the finding.
+	FirstSeen time.Time
+	// LastSeen is the time of the last run with the finding.
+	LastSeen time.Time
+	// Fixed is the time of the first run without the finding
+	// after LastSeen, or zero if the last run has the finding.
+	Fixed time.Time
+	// Suppressed is the time since which the finding has been
+	// suppressed, as of LastSeen, or zero if it was reported then.
+	Suppressed time.Time
+}
+
+// Timeline returns the history of each finding in the runs, which
+// are sorted by time, sorted by the time the findings were first seen.
+func Timeline(runs []*Run) []*FindingHistory {
+	byKey := make(map[quickcheck.Key]*FindingHistory)
+	var hs []*FindingHistory
+	for _, r := range runs {
+		found := make(map[quickcheck.Key]bool)
+		for _, f := range r.Findings {
+			k := f.Key()
+			found[k] = true
+			h := byKey[k]
+			if h == nil {
+				h = &FindingHistory{FirstSeen: r.Time}
+				byKey[k] = h
+				hs = append(hs, h)
+			}
+			wasSuppressed := h.Finding != nil && h.Fixed.IsZero() && h.Finding.Suppressed
+			if !f.Suppressed {
+				h.Suppressed = time.Time{}
+			} else if !wasSuppressed {
+				h.Suppressed = r.Time
+			}
+			h.Finding = f
+			h.LastSeen = r.Time
+			h.Fixed = time.Time{}
+		}
+		for k, h := range byKey {
+			if !found[k] && h.Fixed.IsZero() {
+				h.Fixed = r.Time
+			}
+		}
+	}
+	sort.SliceStable(hs, func(i, j int) bool { return hs[i].FirstSeen.Before(hs[j].FirstSeen) })
+	return hs
+}
+
+// A MonthTrend counts the findings in the runs of a month.
+type MonthTrend struct {
+	Month string // in the form 2006-01
+	// Reported and Suppressed are the numbers of findings
+	// reported and suppressed by the last run of the month.
+	Reported, Suppressed int
+	// New and Fixed are the numbers of findings first seen and
+	// fixed in the month.
+	New, Fixed int
+}
+
+// Trend returns the trend of the findings in the runs, which are
+// sorted by time, per month in UTC with runs, sorted by month.
+func Trend(runs []*Run) []*MonthTrend {
+	const layout = "2006-01"
+	var months []*MonthTrend
+	byMonth := make(map[string]*MonthTrend)
+	for _, r := range runs {
+		month := r.Time.UTC().Format(layout)
+		m := byMonth[month]
+		if m == nil {
+			m = &MonthTrend{Month: month}
+			byMonth[month] = m
+			months = append(months, m)
+		}
+		// The last run of the month wins.
+		m.Reported, m.Suppressed = 0, 0
+		for _, f := range r.Findings {
+			if f.Suppressed {
+				m.Suppressed++
+			} else {
+				m.Reported++
+			}
+		}
+	}
+	for _, h := range Timeline(runs) {
+		byMonth[h.FirstSeen.UTC().Format(layout)].New++
+		if !h.Fixed.IsZero() {
+			byMonth[h.Fixed.UTC().Format(layout)].Fixed++
+		}
+	}
+	return months
+}
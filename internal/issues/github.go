@@ -0,0 +1,91 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package issues
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// GitHubAPI is the URL of the GitHub REST API.
+const GitHubAPI = "https://api.github.com"
+
+// A GitHub tracks the issues of a GitHub repository.
+type GitHub struct {
+	c    restClient
+	repo string
+}
+
+// NewGitHub returns the tracker of the repository, such as
+// "owner/repo", using the REST API at the URL, such as GitHubAPI,
+// authenticated with the token.
+func NewGitHub(api, repo, token string) *GitHub {
+	h := http.Header{}
+	if token != "" {
+		h.Set("Authorization", "Bearer "+token)
+	}
+	return &GitHub{
+		c:    restClient{base: strings.TrimRight(api, "/"), header: h, http: http.DefaultClient},
+		repo: repo,
+	}
+}
+
+type githubIssue struct {
+	Number      int       `json:"number,omitempty"`
+	Title       string    `json:"title"`
+	Body        string    `json:"body"`
+	State       string    `json:"state"`
+	Labels      []string  `json:"labels,omitempty"`
+	PullRequest *struct{} `json:"pull_request,omitempty"`
+}
+
+// List implements Tracker.
+func (g *GitHub) List(ctx context.Context) ([]*Issue, error) {
+	var issues []*Issue
+	for page := 1; ; page++ {
+		var resp []githubIssue
+		path := fmt.Sprintf("/repos/%s/issues?labels=%s&state=all&per_page=%d&page=%d", g.repo, Label, perPage, page)
+		if err := g.c.do(ctx, "GET", path, nil, &resp); err != nil {
+			return nil, err
+		}
+		for _, gi := range resp {
+			if gi.PullRequest != nil {
+				continue // the issues API lists pull requests too
+			}
+			issues = append(issues, &Issue{
+				Number: gi.Number,
+				Key:    parseKey(gi.Body),
+				Title:  gi.Title,
+				Body:   gi.Body,
+				Open:   gi.State == "open",
+			})
+		}
+		if len(resp) < perPage {
+			return issues, nil
+		}
+	}
+}
+
+// Create implements Tracker.
+func (g *GitHub) Create(ctx context.Context, issue *Issue) error {
+	req := githubIssue{Title: issue.Title, Body: issue.Body, Labels: []string{Label}}
+	var resp githubIssue
+	if err := g.c.do(ctx, "POST", "/repos/"+g.repo+"/issues", &req, &resp); err != nil {
+		return err
+	}
+	issue.Number = resp.Number
+	return nil
+}
+
+// Update implements Tracker.
+func (g *GitHub) Update(ctx context.Context, issue *Issue) error {
+	req := githubIssue{Title: issue.Title, Body: issue.Body, State: "closed"}
+	if issue.Open {
+		req.State = "open"
+	}
+	return g.c.do(ctx, "PATCH", fmt.Sprintf("/repos/%s/issues/%d", g.repo, issue.Number), &req, nil)
+}
@@ -0,0 +1,89 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package issues
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// GitLabAPI is the URL of the REST API of gitlab.com.
+const GitLabAPI = "https://gitlab.com/api/v4"
+
+// A GitLab tracks the issues of a GitLab project.
+type GitLab struct {
+	c       restClient
+	project string // escaped path or ID of the project
+}
+
+// NewGitLab returns the tracker of the project, such as
+// "group/project", using the REST API at the URL, such as
+// GitLabAPI, authenticated with the token.
+func NewGitLab(api, project, token string) *GitLab {
+	h := http.Header{}
+	if token != "" {
+		h.Set("PRIVATE-TOKEN", token)
+	}
+	return &GitLab{
+		c:       restClient{base: strings.TrimRight(api, "/"), header: h, http: http.DefaultClient},
+		project: url.PathEscape(project),
+	}
+}
+
+type gitlabIssue struct {
+	IID         int    `json:"iid,omitempty"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	State       string `json:"state,omitempty"`
+	StateEvent  string `json:"state_event,omitempty"`
+	Labels      string `json:"labels,omitempty"`
+}
+
+// List implements Tracker.
+func (g *GitLab) List(ctx context.Context) ([]*Issue, error) {
+	var issues []*Issue
+	for page := 1; ; page++ {
+		var resp []gitlabIssue
+		path := fmt.Sprintf("/projects/%s/issues?labels=%s&per_page=%d&page=%d", g.project, Label, perPage, page)
+		if err := g.c.do(ctx, "GET", path, nil, &resp); err != nil {
+			return nil, err
+		}
+		for _, gi := range resp {
+			issues = append(issues, &Issue{
+				Number: gi.IID,
+				Key:    parseKey(gi.Description),
+				Title:  gi.Title,
+				Body:   gi.Description,
+				Open:   gi.State == "opened",
+			})
+		}
+		if len(resp) < perPage {
+			return issues, nil
+		}
+	}
+}
+
+// Create implements Tracker.
+func (g *GitLab) Create(ctx context.Context, issue *Issue) error {
+	req := gitlabIssue{Title: issue.Title, Description: issue.Body, Labels: Label}
+	var resp gitlabIssue
+	if err := g.c.do(ctx, "POST", "/projects/"+g.project+"/issues", &req, &resp); err != nil {
+		return err
+	}
+	issue.Number = resp.IID
+	return nil
+}
+
+// Update implements Tracker.
+func (g *GitLab) Update(ctx context.Context, issue *Issue) error {
+	req := gitlabIssue{Title: issue.Title, Description: issue.Body, StateEvent: "close"}
+	if issue.Open {
+		req.StateEvent = "reopen"
+	}
+	return g.c.do(ctx, "PUT", fmt.Sprintf("/projects/%s/issues/%d", g.project, issue.Number), &req, nil)
+}
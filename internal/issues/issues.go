@@ -0,0 +1,224 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package issues files the findings of a scan as issues in an
+// issue tracker, such as GitHub or GitLab, one per vulnerability
+// and module, and closes them when the findings are resolved.
+//
+// The issues are labeled with Label, and their descriptions end
+// with a marker identifying the vulnerability and the module, so
+// that later scans find the issues they filed.
+package issues
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hyangah/vulns/render"
+)
+
+// Label is the label of the issues filed by Sync.
+const Label = "vulnerability"
+
+// An Issue is the issue of a vulnerability in a module.
+type Issue struct {
+	Number int    // assigned by the tracker
+	Key    string // the vulnerability ID and the module path
+	Title  string
+	Body   string
+	Open   bool
+}
+
+// A Tracker creates and updates issues in an issue tracker.
+type Tracker interface {
+	// List returns the issues labeled with Label, open or closed.
+	List(ctx context.Context) ([]*Issue, error)
+	// Create files the issue, labeled with Label,
+	// and sets its Number.
+	Create(ctx context.Context, issue *Issue) error
+	// Update sets the title, the body, and the state of the issue.
+	Update(ctx context.Context, issue *Issue) error
+}
+
+// Kinds of Actions.
+const (
+	Created  = "created"
+	Updated  = "updated"
+	Reopened = "reopened"
+	Closed   = "closed"
+)
+
+// An Action is a change made to an issue by Sync.
+type Action struct {
+	Kind  string
+	Issue *Issue
+}
+
+func (a *Action) String() string {
+	if a.Issue.Number == 0 {
+		return fmt.Sprintf("%s: %s", a.Kind, a.Issue.Title)
+	}
+	return fmt.Sprintf("%s #%d: %s", a.Kind, a.Issue.Number, a.Issue.Title)
+}
+
+// Sync makes the issues in the tracker match the wanted issues,
+// which are all open: it creates the missing issues, updates and
+// reopens the existing ones, and closes the issues that are not
+// wanted anymore, because their findings were resolved. With dryRun,
+// it only returns the actions it would take.
+func Sync(ctx context.Context, t Tracker, wanted []*Issue, dryRun bool) ([]*Action, error) {
+	existing, err := t.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byKey := make(map[string]*Issue)
+	for _, is := range existing {
+		if is.Key == "" {
+			continue // not filed by Sync
+		}
+		// Prefer the open issue if there are several.
+		if old := byKey[is.Key]; old == nil || !old.Open {
+			byKey[is.Key] = is
+		}
+	}
+	var actions []*Action
+	do := func(kind string, is *Issue) error {
+		actions = append(actions, &Action{Kind: kind, Issue: is})
+		switch {
+		case dryRun:
+			return nil
+		case kind == Created:
+			return t.Create(ctx, is)
+		default:
+			return t.Update(ctx, is)
+		}
+	}
+	for _, w := range wanted {
+		old := byKey[w.Key]
+		delete(byKey, w.Key)
+		var kind string
+		switch {
+		case old == nil:
+			kind = Created
+		case !old.Open:
+			kind = Reopened
+		case old.Title != w.Title || !sameText(old.Body, w.Body):
+			kind = Updated
+		default:
+			continue
+		}
+		is := *w
+		is.Open = true
+		if old != nil {
+			is.Number = old.Number
+		}
+		if err := do(kind, &is); err != nil {
+			return actions, err
+		}
+	}
+	var resolved []*Issue
+	for _, is := range byKey {
+		if is.Open {
+			resolved = append(resolved, is)
+		}
+	}
+	sort.Slice(resolved, func(i, j int) bool { return resolved[i].Number < resolved[j].Number })
+	for _, old := range resolved {
+		is := *old
+		is.Open = false
+		if err := do(Closed, &is); err != nil {
+			return actions, err
+		}
+	}
+	return actions, nil
+}
+
+// sameText reports whether the issue bodies are the same,
+// ignoring the line endings, which trackers may change.
+func sameText(a, b string) bool {
+	normalize := func(s string) string {
+		return strings.TrimSpace(strings.ReplaceAll(s, "\r\n", "\n"))
+	}
+	return normalize(a) == normalize(b)
+}
+
+var markerRE = regexp.MustCompile(`<!-- vulns-issue: (\S+ \S+) -->\s*$`)
+
+// marker returns the marker identifying the issue with the key.
+func marker(key string) string {
+	return fmt.Sprintf("<!-- vulns-issue: %s -->", key)
+}
+
+// parseKey returns the key in the marker at the end of
+// the body, or the empty string if there is none.
+func parseKey(body string) string {
+	if m := markerRE.FindStringSubmatch(body); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// FromReport returns the issues of the findings in the report,
+// one per vulnerability and module, with the representative trace
+// of the vulnerability and the recommended upgrade.
+func FromReport(r *render.Report) []*Issue {
+	var issues []*Issue
+	for _, m := range r.ModuleGroups() {
+		for _, v := range m.Vulns {
+			f := v.Finding
+			var b strings.Builder
+			if u := r.URL(v.ID); u != "" {
+				fmt.Fprintf(&b, "[%s](%s)", v.ID, u)
+			} else {
+				b.WriteString(v.ID)
+			}
+			fmt.Fprintf(&b, " affects `%s`", moduleVersion(m))
+			if len(v.Packages) > 0 {
+				fmt.Fprintf(&b, " (packages `%s`)", strings.Join(v.Packages, "`, `"))
+			}
+			b.WriteString(".\n\n")
+			if e := r.Entries[v.ID]; e != nil && e.Details != "" {
+				fmt.Fprintf(&b, "%s\n\n", strings.TrimSpace(e.Details))
+			}
+			if f.Fix != "" {
+				fmt.Fprintf(&b, "Upgrade to `%s@%s`", m.Path, f.Fix)
+				if f.MinFix != "" && f.MinFix != f.Fix {
+					fmt.Fprintf(&b, " (minimum fixed version: `%s`)", f.MinFix)
+				}
+				b.WriteString(".\n\n")
+			} else {
+				b.WriteString("No fixed version is known.\n\n")
+			}
+			if len(f.Trace) > 0 {
+				fmt.Fprintf(&b, "Call stack in your code:\n\n```\n")
+				for _, l := range r.TraceFormat.Lines(f.Trace) {
+					fmt.Fprintf(&b, "%s\n", l)
+				}
+				b.WriteString("```\n\n")
+			}
+			if len(f.Attrs) > 0 {
+				fmt.Fprintf(&b, "Notes: %s\n\n", strings.Join(f.Attrs, ", "))
+			}
+			key := v.ID + " " + m.Path
+			b.WriteString(marker(key))
+			issues = append(issues, &Issue{
+				Key:   key,
+				Title: fmt.Sprintf("%s: vulnerability in %s", v.ID, m.Path),
+				Body:  b.String(),
+				Open:  true,
+			})
+		}
+	}
+	return issues
+}
+
+func moduleVersion(m *render.ModuleGroup) string {
+	if m.Version == "" {
+		return m.Path
+	}
+	return m.Path + "@" + m.Version
+}
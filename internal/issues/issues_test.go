@@ -0,0 +1,245 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package issues
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hyangah/vulns/quickcheck"
+	"github.com/hyangah/vulns/render"
+	"golang.org/x/vuln/osv"
+)
+
+// fakeTracker is an in-memory Tracker.
+type fakeTracker struct {
+	issues []*Issue
+}
+
+func (t *fakeTracker) List(ctx context.Context) ([]*Issue, error) {
+	var list []*Issue
+	for _, is := range t.issues {
+		c := *is
+		list = append(list, &c)
+	}
+	return list, nil
+}
+
+func (t *fakeTracker) Create(ctx context.Context, issue *Issue) error {
+	issue.Number = len(t.issues) + 1
+	c := *issue
+	t.issues = append(t.issues, &c)
+	return nil
+}
+
+func (t *fakeTracker) Update(ctx context.Context, issue *Issue) error {
+	c := *issue
+	t.issues[issue.Number-1] = &c
+	return nil
+}
+
+func testReport() *render.Report {
+	summary := map[quickcheck.Key]quickcheck.Value{
+		{ID: "GO-2022-0001", PackagePath: "a.com/m/vuln", ModulePath: "a.com/m", Symbol: "Vuln"}: {
+			Trace:   []string{"work/y.Y /tmp/y/y.go:3:9", "a.com/m/vuln.Vuln /tmp/a/vuln.go:2:9"},
+			Version: "v1.0.0",
+			Fix:     "v1.2.0",
+		},
+		{ID: "GO-2022-0002", PackagePath: "b.com/m/vuln", ModulePath: "b.com/m", Symbol: "Vuln"}: {
+			Trace: []string{"work/x.X /tmp/x/x.go:4:9", "b.com/m/vuln.Vuln /tmp/b/vuln.go:2:9"},
+		},
+	}
+	pkg2vulns := map[string][]*osv.Entry{
+		"a.com/m/vuln": {{ID: "GO-2022-0001", Details: "A bug in a.com/m."}},
+	}
+	return render.NewReport(summary, pkg2vulns)
+}
+
+func TestFromReport(t *testing.T) {
+	issues := FromReport(testReport())
+	if len(issues) != 2 {
+		t.Fatalf("got %d issues, want 2", len(issues))
+	}
+	is := issues[0]
+	if is.Key != "GO-2022-0001 a.com/m" || is.Title != "GO-2022-0001: vulnerability in a.com/m" {
+		t.Errorf("unexpected issue %+v", is)
+	}
+	for _, want := range []string{
+		"affects `a.com/m@v1.0.0` (packages `a.com/m/vuln`)",
+		"A bug in a.com/m.",
+		"Upgrade to `a.com/m@v1.2.0`.",
+		"```\nwork/y.Y /tmp/y/y.go:3:9\na.com/m/vuln.Vuln /tmp/a/vuln.go:2:9\n```",
+	} {
+		if !strings.Contains(is.Body, want) {
+			t.Errorf("issue body does not contain %q:\n%s", want, is.Body)
+		}
+	}
+	if got := parseKey(is.Body); got != is.Key {
+		t.Errorf("parseKey = %q, want %q", got, is.Key)
+	}
+	if !strings.Contains(issues[1].Body, "No fixed version is known.") {
+		t.Errorf("issue body does not say there is no fix:\n%s", issues[1].Body)
+	}
+}
+
+func TestSync(t *testing.T) {
+	ctx := context.Background()
+	tr := &fakeTracker{issues: []*Issue{{Number: 1, Title: "unrelated", Body: "filed by hand", Open: true}}}
+	wanted := FromReport(testReport())
+
+	kinds := func(actions []*Action) []string {
+		var s []string
+		for _, a := range actions {
+			s = append(s, fmt.Sprintf("%s %d", a.Kind, a.Issue.Number))
+		}
+		return s
+	}
+	check := func(actions []*Action, err error, want ...string) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := kinds(actions); !reflect.DeepEqual(got, want) {
+			t.Errorf("actions = %v, want %v", got, want)
+		}
+	}
+
+	actions, err := Sync(ctx, tr, wanted, true)
+	check(actions, err, "created 0", "created 0")
+	if len(tr.issues) != 1 {
+		t.Fatalf("dry run filed issues: %+v", tr.issues)
+	}
+	actions, err = Sync(ctx, tr, wanted, false)
+	check(actions, err, "created 2", "created 3")
+	actions, err = Sync(ctx, tr, wanted, false)
+	check(actions, err)
+
+	// GO-2022-0002 is resolved, and the issue of GO-2022-0001 changes.
+	r := testReport()
+	r.Findings = r.Findings[:1]
+	r.Findings[0].Fix = "v1.3.0"
+	actions, err = Sync(ctx, tr, FromReport(r), false)
+	check(actions, err, "updated 2", "closed 3")
+	if tr.issues[2].Open || !tr.issues[0].Open {
+		t.Errorf("unexpected issue states after closing: %+v", tr.issues)
+	}
+
+	// GO-2022-0002 is back.
+	tr.issues[2].Body = strings.ReplaceAll(tr.issues[2].Body, "\n", "\r\n")
+	actions, err = Sync(ctx, tr, wanted, false)
+	check(actions, err, "updated 2", "reopened 3")
+	actions, err = Sync(ctx, tr, wanted, false)
+	check(actions, err)
+}
+
+// fakeServer serves the issues API of GitHub or GitLab in memory.
+type fakeServer struct {
+	mu       sync.Mutex
+	issues   []map[string]interface{}
+	requests []string
+}
+
+func (s *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path+" "+r.Header.Get("Authorization")+r.Header.Get("PRIVATE-TOKEN"))
+	var in map[string]interface{}
+	if r.Method != "GET" {
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	switch {
+	case r.Method == "GET":
+		if r.URL.Query().Get("page") != "1" {
+			w.Write([]byte("[]"))
+			return
+		}
+		json.NewEncoder(w).Encode(s.issues)
+	case r.Method == "POST":
+		in["number"] = len(s.issues) + 1
+		in["iid"] = len(s.issues) + 1
+		in["state"] = "open"
+		s.issues = append(s.issues, in)
+		json.NewEncoder(w).Encode(in)
+	default:
+		var n int
+		fmt.Sscanf(r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:], "%d", &n)
+		for k, v := range in {
+			s.issues[n-1][k] = v
+		}
+		w.Write([]byte("{}"))
+	}
+}
+
+func TestGitHub(t *testing.T) {
+	s := &fakeServer{}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	tr := NewGitHub(srv.URL, "owner/repo", "secret")
+	ctx := context.Background()
+	is := &Issue{Title: "t", Body: "b\n" + marker("GO-1 m")}
+	if err := tr.Create(ctx, is); err != nil {
+		t.Fatal(err)
+	}
+	s.issues = append(s.issues, map[string]interface{}{"number": 2, "title": "pr", "state": "open", "pull_request": map[string]interface{}{}})
+	is.Open = false
+	if err := tr.Update(ctx, is); err != nil {
+		t.Fatal(err)
+	}
+	list, err := tr.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*Issue{{Number: 1, Key: "GO-1 m", Title: "t", Body: is.Body, Open: false}}
+	if !reflect.DeepEqual(list, want) {
+		t.Errorf("List = %+v, want %+v", list[0], want[0])
+	}
+	if got, want := s.requests[0], "POST /repos/owner/repo/issues Bearer secret"; got != want {
+		t.Errorf("request = %q, want %q", got, want)
+	}
+	if got := s.issues[0]["labels"]; !reflect.DeepEqual(got, []interface{}{Label}) {
+		t.Errorf("labels = %v, want [%s]", got, Label)
+	}
+}
+
+func TestGitLab(t *testing.T) {
+	s := &fakeServer{}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	tr := NewGitLab(srv.URL, "group/project", "secret")
+	ctx := context.Background()
+	is := &Issue{Title: "t", Body: "b\n" + marker("GO-1 m")}
+	if err := tr.Create(ctx, is); err != nil {
+		t.Fatal(err)
+	}
+	s.issues[0]["state"] = "opened"
+	list, err := tr.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*Issue{{Number: 1, Key: "GO-1 m", Title: "t", Body: is.Body, Open: true}}
+	if !reflect.DeepEqual(list, want) {
+		t.Errorf("List = %+v, want %+v", list[0], want[0])
+	}
+	is.Open = false
+	if err := tr.Update(ctx, is); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.issues[0]["state_event"]; got != "close" {
+		t.Errorf("state_event = %v, want close", got)
+	}
+	if got, want := s.requests[0], "POST /projects/group/project/issues secret"; got != want {
+		t.Errorf("request = %q, want %q", got, want)
+	}
+}
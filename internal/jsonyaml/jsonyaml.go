@@ -0,0 +1,43 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package jsonyaml converts JSON documents to YAML, so that the YAML
+// output of the commands has the same schema as their JSON output:
+// the same field names, in the same order, and the same omitted fields.
+package jsonyaml
+
+import (
+	"bytes"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Convert returns the YAML form of the JSON document data.
+func Convert(data []byte) ([]byte, error) {
+	// JSON is a subset of YAML, in the flow style.
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	blockStyle(&doc)
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// blockStyle resets the styles of the nodes, so that the
+// encoder uses the block style and quotes only when needed.
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
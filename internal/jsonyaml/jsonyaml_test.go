@@ -0,0 +1,35 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jsonyaml
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestConvert(t *testing.T) {
+	in := `[{"ID":"GO-2022-0001","Count":2,"Fix":"","Trace":["a.F x.go:1:2","b.G"],"Empty":[],"Version":"1.0","Flag":"true","Details":"line one\nline two"}]`
+	want := `- ID: GO-2022-0001
+  Count: 2
+  Fix: ""
+  Trace:
+    - a.F x.go:1:2
+    - b.G
+  Empty: []
+  Version: "1.0"
+  Flag: "true"
+  Details: |-
+    line one
+    line two
+`
+	got, err := Convert([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("Convert mismatch (-want +got):\n%s", diff)
+	}
+}
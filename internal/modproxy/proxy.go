@@ -0,0 +1,240 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package modproxy queries a Go module proxy for the available
+// and retracted versions of modules, and downloads their contents.
+package modproxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyangah/vulns/vulncache"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+	modzip "golang.org/x/mod/zip"
+)
+
+// listTTL is how long a cached version list is used
+// before it is fetched again.
+const listTTL = time.Hour
+
+// DefaultCacheDir returns the directory where proxy responses are
+// cached, next to the vulnerability database cache.
+func DefaultCacheDir() string {
+	return filepath.Join(vulncache.DefaultDir(), "_proxy")
+}
+
+// A Client queries a module proxy, caching the responses
+// in a directory.
+type Client struct {
+	url      string
+	cacheDir string // no caching if empty
+	http     *http.Client
+
+	mu sync.Mutex // serializes cache access
+}
+
+// New returns a client of the first proxy in the GOPROXY list,
+// or nil if the list has no proxy (e.g. "off" or "direct").
+func New(goproxy, cacheDir string) *Client {
+	for _, p := range strings.FieldsFunc(goproxy, func(r rune) bool { return r == ',' || r == '|' }) {
+		if p == "off" || p == "direct" || p == "noproxy" {
+			continue
+		}
+		return &Client{url: strings.TrimRight(p, "/"), cacheDir: cacheDir, http: http.DefaultClient}
+	}
+	return nil
+}
+
+// FromEnv returns a client of the proxy the go command uses
+// according to its GOPROXY setting, caching the responses
+// in DefaultCacheDir.
+func FromEnv() *Client {
+	goproxy := os.Getenv("GOPROXY")
+	if out, err := exec.Command("go", "env", "GOPROXY").Output(); err == nil {
+		goproxy = string(bytes.TrimSpace(out))
+	}
+	if goproxy == "" {
+		goproxy = "https://proxy.golang.org,direct"
+	}
+	return New(goproxy, DefaultCacheDir())
+}
+
+type cachedList struct {
+	Retrieved time.Time
+	Versions  []string
+}
+
+// Versions returns the released versions of the module
+// known to the proxy, in semver order.
+func (c *Client) Versions(ctx context.Context, modPath string) ([]string, error) {
+	ep, err := module.EscapePath(modPath)
+	if err != nil {
+		return nil, err
+	}
+	cacheFile := filepath.Join(ep, "list.json")
+	var cached cachedList
+	if c.readCache(cacheFile, &cached) && time.Since(cached.Retrieved) < listTTL {
+		return cached.Versions, nil
+	}
+	data, err := c.get(ctx, ep+"/@v/list")
+	if err != nil {
+		return nil, err
+	}
+	var versions []string
+	for _, v := range strings.Fields(string(data)) {
+		if semver.IsValid(v) {
+			versions = append(versions, v)
+		}
+	}
+	semver.Sort(versions)
+	c.writeCache(cacheFile, cachedList{Retrieved: time.Now(), Versions: versions})
+	return versions, nil
+}
+
+// Retractions returns the version intervals retracted by the go.mod
+// file of the latest version of the module.
+func (c *Client) Retractions(ctx context.Context, modPath string) ([]modfile.VersionInterval, error) {
+	versions, err := c.Versions(ctx, modPath)
+	if err != nil || len(versions) == 0 {
+		return nil, err
+	}
+	latest := versions[len(versions)-1]
+	data, err := c.goMod(ctx, modPath, latest)
+	if err != nil {
+		return nil, err
+	}
+	f, err := modfile.ParseLax("go.mod", data, nil)
+	if err != nil {
+		return nil, err
+	}
+	var intervals []modfile.VersionInterval
+	for _, r := range f.Retract {
+		intervals = append(intervals, r.VersionInterval)
+	}
+	return intervals, nil
+}
+
+// goMod returns the go.mod file of the module version.
+// go.mod files of released versions never change,
+// so they are cached indefinitely.
+func (c *Client) goMod(ctx context.Context, modPath, version string) ([]byte, error) {
+	ep, err := module.EscapePath(modPath)
+	if err != nil {
+		return nil, err
+	}
+	ev, err := module.EscapeVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	cacheFile := filepath.Join(ep, ev+".mod.json")
+	var data []byte
+	if c.readCache(cacheFile, &data) {
+		return data, nil
+	}
+	data, err = c.get(ctx, ep+"/@v/"+ev+".mod")
+	if err != nil {
+		return nil, err
+	}
+	c.writeCache(cacheFile, data)
+	return data, nil
+}
+
+// Download extracts the zip file of the module version from the
+// proxy into dir, which must not exist or be empty. The contents are
+// checked against the restrictions on module zip files, but not
+// against the checksum database. The extracted files are read-only.
+func (c *Client) Download(ctx context.Context, modPath, version, dir string) error {
+	ep, err := module.EscapePath(modPath)
+	if err != nil {
+		return err
+	}
+	ev, err := module.EscapeVersion(version)
+	if err != nil {
+		return err
+	}
+	data, err := c.get(ctx, ep+"/@v/"+ev+".zip")
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp("", "vulns-module-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return modzip.Unzip(dir, module.Version{Path: modPath, Version: version}, f.Name())
+}
+
+func (c *Client) get(ctx context.Context, path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.url+"/"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s/%s: unexpected status %s", c.url, path, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// readCache decodes the cached file into v and reports whether it succeeded.
+func (c *Client) readCache(name string, v interface{}) bool {
+	if c.cacheDir == "" {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, err := os.ReadFile(filepath.Join(c.cacheDir, c.host(), name))
+	return err == nil && json.Unmarshal(data, v) == nil
+}
+
+// writeCache caches v. Caching is best effort; errors are ignored.
+func (c *Client) writeCache(name string, v interface{}) {
+	if c.cacheDir == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	path := filepath.Join(c.cacheDir, c.host(), name)
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return
+	}
+	os.WriteFile(path, data, 0666)
+}
+
+// host returns the directory name of the proxy in the cache.
+func (c *Client) host() string {
+	h := c.url
+	if i := strings.Index(h, "://"); i >= 0 {
+		h = h[i+3:]
+	}
+	return strings.NewReplacer("/", "_", ":", "_").Replace(h)
+}
@@ -0,0 +1,102 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package modproxy
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/mod/modfile"
+)
+
+func TestClient(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/example.com/!m/@v/list":
+			w.Write([]byte("v1.1.0\nv1.0.0\nv1.2.0\n"))
+		case "/example.com/!m/@v/v1.2.0.mod":
+			w.Write([]byte("module example.com/M\n\nretract v1.1.0 // broken\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	c := New("off,"+srv.URL+",direct", t.TempDir())
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		versions, err := c.Versions(ctx, "example.com/M")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"v1.0.0", "v1.1.0", "v1.2.0"}; !reflect.DeepEqual(versions, want) {
+			t.Errorf("Versions = %v, want %v", versions, want)
+		}
+		retractions, err := c.Retractions(ctx, "example.com/M")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []modfile.VersionInterval{{Low: "v1.1.0", High: "v1.1.0"}}; !reflect.DeepEqual(retractions, want) {
+			t.Errorf("Retractions = %v, want %v", retractions, want)
+		}
+	}
+	if requests != 2 {
+		t.Errorf("got %d requests, want 2 (responses should be cached)", requests)
+	}
+	if _, err := c.Versions(ctx, "example.com/unknown"); err == nil {
+		t.Error("Versions of an unknown module succeeded")
+	}
+	if New("off", "") != nil || New("direct", "") != nil {
+		t.Error("New returned a client without a proxy")
+	}
+}
+
+func TestDownload(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"go.mod": "module example.com/M\n",
+		"p/p.go": "package p\n",
+	} {
+		w, err := zw.Create("example.com/M@v1.2.0/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/example.com/!m/@v/v1.2.0.zip" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(buf.Bytes())
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, "")
+	dir := filepath.Join(t.TempDir(), "m")
+	if err := c.Download(context.Background(), "example.com/M", "v1.2.0", dir); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "p", "p.go"))
+	if err != nil || string(data) != "package p\n" {
+		t.Errorf("p/p.go = %q, %v; want %q", data, err, "package p\n")
+	}
+	if err := c.Download(context.Background(), "example.com/M", "v1.3.0", t.TempDir()); err == nil {
+		t.Error("Download of an unknown version succeeded")
+	}
+}
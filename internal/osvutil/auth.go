@@ -0,0 +1,216 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package osvutil
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Credentials are the credentials of the vulnerability databases
+// requiring authentication, such as enterprise mirrors behind SSO.
+// They are sent in the Authorization header of the requests to the
+// databases, never in their URLs, so that the URLs can be logged and
+// recorded in the provenance of the entries.
+type Credentials struct {
+	prefixes []prefixCredential // longest prefix first
+	machines []netrcLine
+}
+
+// A prefixCredential is the Authorization header
+// of the requests to the URLs with the prefix.
+type prefixCredential struct {
+	prefix *url.URL
+	header string
+}
+
+// A netrcLine is a machine of a .netrc file.
+type netrcLine struct {
+	machine  string
+	login    string
+	password string
+}
+
+// LoadCredentials returns the credentials in $GOVULNDB_AUTH and in
+// the .netrc file of the user, $NETRC if set, the way the go command
+// finds the credentials of GOPROXY.
+//
+// GOVULNDB_AUTH is a comma-separated list of url=credentials, where
+// the url is a database URL or a prefix of it, and the credentials
+// are either "user:password", sent with basic authentication, or the
+// value of the Authorization header, such as "Bearer token". The
+// longest matching prefix applies, before the .netrc machines, whose
+// credentials are sent with basic authentication over https only.
+func LoadCredentials() (*Credentials, error) {
+	c := &Credentials{}
+	if env := os.Getenv("GOVULNDB_AUTH"); env != "" {
+		prefixes, err := parseAuth(env)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GOVULNDB_AUTH: %v", err)
+		}
+		c.prefixes = prefixes
+	}
+	file := os.Getenv("NETRC")
+	if file == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return c, nil
+		}
+		name := ".netrc"
+		if runtime.GOOS == "windows" {
+			name = "_netrc"
+		}
+		file = filepath.Join(home, name)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	c.machines = parseNetrc(string(data))
+	return c, nil
+}
+
+// parseAuth parses the credentials in the GOVULNDB_AUTH format.
+func parseAuth(s string) ([]prefixCredential, error) {
+	var res []prefixCredential
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		rawURL, cred, ok := strings.Cut(item, "=")
+		if !ok || cred == "" {
+			return nil, fmt.Errorf("%q: want url=credentials", rawURL)
+		}
+		u, err := url.Parse(strings.TrimRight(rawURL, "/"))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("%q: not an http or https URL", rawURL)
+		}
+		u.Host = strings.ToLower(u.Host)
+		var header string
+		switch user, password, ok := strings.Cut(cred, ":"); {
+		case strings.Contains(cred, " "):
+			header = cred
+		case ok:
+			header = basicAuth(user, password)
+		default:
+			return nil, fmt.Errorf("credentials of %s: want user:password or an Authorization header value", rawURL)
+		}
+		res = append(res, prefixCredential{prefix: u, header: header})
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return len(res[i].prefix.Path) > len(res[j].prefix.Path)
+	})
+	return res, nil
+}
+
+// parseNetrc parses the machines of a .netrc file
+// the way the go command does.
+func parseNetrc(data string) []netrcLine {
+	var nrc []netrcLine
+	var l netrcLine
+	inMacro := false
+	for _, line := range strings.Split(data, "\n") {
+		if inMacro {
+			if line == "" {
+				inMacro = false
+			}
+			continue
+		}
+		f := strings.Fields(line)
+		i := 0
+		for ; i < len(f)-1; i += 2 {
+			// Reset at each "machine" token.
+			// “The auto-login process searches the .netrc file for a machine token
+			// that matches […]. Once a match is made, the subsequent .netrc tokens
+			// are processed, stopping when the end of file is reached or another
+			// machine or a default token is encountered.”
+			switch f[i] {
+			case "machine":
+				l = netrcLine{machine: f[i+1]}
+			case "default":
+				break
+			case "login":
+				l.login = f[i+1]
+			case "password":
+				l.password = f[i+1]
+			case "macdef":
+				// “A macro is defined with the specified name; its contents begin with
+				// the next .netrc line and continue until a null line (consecutive
+				// new-line characters) is encountered.”
+				inMacro = true
+			}
+			if l.machine != "" && l.login != "" && l.password != "" {
+				nrc = append(nrc, l)
+				l = netrcLine{}
+			}
+		}
+		if i < len(f) && f[i] == "default" {
+			// “There can be only one default token, and it must be after all machine tokens.”
+			break
+		}
+	}
+	return nrc
+}
+
+func basicAuth(user, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))
+}
+
+// authorization returns the Authorization header
+// of the request to the URL, or "" if there is none.
+func (c *Credentials) authorization(u *url.URL) string {
+	if c == nil {
+		return ""
+	}
+	host := strings.ToLower(u.Host)
+	for _, p := range c.prefixes {
+		if p.prefix.Scheme == u.Scheme && p.prefix.Host == host &&
+			(u.Path == p.prefix.Path || strings.HasPrefix(u.Path, p.prefix.Path+"/")) {
+			return p.header
+		}
+	}
+	if u.Scheme == "https" {
+		for _, l := range c.machines {
+			if strings.EqualFold(l.machine, u.Hostname()) {
+				return basicAuth(l.login, l.password)
+			}
+		}
+	}
+	return ""
+}
+
+// Transport returns an http.RoundTripper that adds the credentials to
+// the requests sent with base, or http.DefaultTransport if base is
+// nil, that have no Authorization header yet. Use it in the
+// client.Options of the database clients.
+func (c *Credentials) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &authTransport{c: c, base: base}
+}
+
+type authTransport struct {
+	c    *Credentials
+	base http.RoundTripper
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") == "" {
+		if h := t.c.authorization(req.URL); h != "" {
+			req = req.Clone(req.Context())
+			req.Header.Set("Authorization", h)
+		}
+	}
+	return t.base.RoundTrip(req)
+}
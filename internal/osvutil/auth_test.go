@@ -0,0 +1,90 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package osvutil
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/vuln/client"
+)
+
+func TestCredentials(t *testing.T) {
+	netrc := filepath.Join(t.TempDir(), "netrc")
+	if err := os.WriteFile(netrc, []byte(`machine vulndb.example.com login netrc password secret
+machine other.example.com
+	login carol
+	password pass
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("NETRC", netrc)
+	t.Setenv("GOVULNDB_AUTH", "https://vulndb.example.com=Bearer root, https://vulndb.example.com/mirror/=alice:pw,http://localhost:8080/db=Bearer local")
+	c, err := LoadCredentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		url, want string
+	}{
+		{"https://vulndb.example.com/index.json", "Bearer root"},
+		{"https://VULNDB.example.com/mirror/index.json", basicAuth("alice", "pw")},
+		{"https://vulndb.example.com/mirror2/index.json", "Bearer root"},
+		{"http://localhost:8080/db/ID/GO-2022-0001.json", "Bearer local"},
+		{"http://localhost:8080/dbx/index.json", ""},
+		{"https://other.example.com/index.json", basicAuth("carol", "pass")},
+		// .netrc credentials are not sent in clear text.
+		{"http://other.example.com/index.json", ""},
+		{"https://vuln.go.dev/index.json", ""},
+	} {
+		req, err := http.NewRequest("GET", tc.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := c.authorization(req.URL); got != tc.want {
+			t.Errorf("authorization(%s) = %q, want %q", tc.url, got, tc.want)
+		}
+	}
+
+	for _, bad := range []string{"https://vulndb.example.com", "vulndb.example.com=Bearer x", "https://vulndb.example.com=token"} {
+		t.Setenv("GOVULNDB_AUTH", bad)
+		if _, err := LoadCredentials(); err == nil {
+			t.Errorf("LoadCredentials succeeded with GOVULNDB_AUTH=%q", bad)
+		}
+	}
+}
+
+func TestCredentialsTransport(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got = r.Header.Get("Authorization"); got == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("{}")) // an empty index.json
+	}))
+	defer srv.Close()
+
+	t.Setenv("NETRC", filepath.Join(t.TempDir(), "missing"))
+	t.Setenv("GOVULNDB_AUTH", srv.URL+"=Bearer token")
+	creds, err := LoadCredentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli, err := NewClient([]string{srv.URL}, client.Options{HTTPClient: &http.Client{Transport: creds.Transport(nil)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.GetByModule(context.Background(), "example.com/m"); err != nil {
+		t.Fatal(err)
+	}
+	if got != "Bearer token" {
+		t.Errorf("Authorization = %q, want %q", got, "Bearer token")
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package osvutil
+
+import (
+	"context"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/vuln/client"
+	"golang.org/x/vuln/osv"
+)
+
+// FetchCatalog returns the OSV entries affecting the given modules,
+// keyed by the affected package paths, in the form the analyzer reads
+// with its -vulns-json flag. Modules with a version only get the
+// entries affecting that version. If mods is empty, the catalog
+// holds all entries of the database.
+func FetchCatalog(ctx context.Context, cli client.Client, mods []module.Version) (map[string][]*osv.Entry, error) {
+	var entries []*osv.Entry
+	if len(mods) == 0 {
+		ids, err := cli.ListIDs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			e, err := cli.GetByID(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			if e != nil {
+				entries = append(entries, e)
+			}
+		}
+		entries = normalizeOSVEntries(nil, entries)
+	}
+	for _, mv := range mods {
+		vulns, err := cli.GetByModule(ctx, mv.Path)
+		if err != nil {
+			return nil, err
+		}
+		m := &packages.Module{Path: mv.Path, Version: mv.Version}
+		if mv.Version != "" {
+			vulns, _ = filterOSVEntries(m, vulns, ecosystemFilter(cli))
+		}
+		entries = append(entries, normalizeOSVEntries(m, vulns)...)
+	}
+
+	pkg2vulns := make(map[string][]*osv.Entry)
+	for _, e := range entries {
+		seen := map[string]bool{}
+		for _, a := range e.Affected {
+			for _, p := range a.EcosystemSpecific.Imports {
+				if !seen[p.Path] {
+					seen[p.Path] = true
+					pkg2vulns[p.Path] = append(pkg2vulns[p.Path], e)
+				}
+			}
+		}
+	}
+	return pkg2vulns, nil
+}
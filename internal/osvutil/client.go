@@ -0,0 +1,624 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package osvutil
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/vuln/client"
+	"golang.org/x/vuln/osv"
+)
+
+// Provenance records where an OSV entry came from.
+type Provenance struct {
+	// Source is the URL of the database the entry was read from.
+	Source string
+	// Fetched is the time the data was retrieved from the source.
+	// For entries served from the HTTP cache, this is the time the
+	// cached index was last refreshed, which may be well in the past.
+	Fetched time.Time
+	// Modified is the last modified time of the entry itself.
+	Modified time.Time
+	// AfterSnapshot reports that the entry was modified after the
+	// Client.SnapshotTime, such as to revise the affected versions.
+	// The source no longer has the entry as it was in the snapshot,
+	// so it is the newer entry.
+	AfterSnapshot bool `json:",omitempty"`
+}
+
+// Client is a client.Client that queries each database separately
+// so it can record the provenance of the entries it returns.
+// Methods that do not return entries for a module are delegated
+// to a client over the union of all sources.
+//
+// A Client queries each source for a module once, and splits the
+// entries of a module version once, in its lifetime or until Refresh,
+// so that the scans sharing it share the queries. The split entries
+// do not reflect later changes of its fields.
+type Client struct {
+	client.Client
+
+	// Mirrors indicates that the sources are mirrors of the same
+	// database rather than independent databases. Instead of taking
+	// the union of all sources, queries go to the first source and
+	// fall back to the next ones in order on failure.
+	Mirrors bool
+
+	// Ignore lists vulnerability IDs, such as GO-, CVE-, or GHSA-
+	// identifiers, whose entries the client drops. An entry is
+	// dropped if its ID or any of its aliases is listed. As the
+	// entries are dropped before FetchOSVEntries sees them, the
+	// analysis does not search paths to their symbols at all.
+	Ignore []string
+
+	// Only, if set, lists the vulnerability IDs or aliases of the
+	// only entries the client returns, to answer whether specific
+	// advisories affect the packages. The entries are fetched by ID
+	// on the first query, and GetByModule and ListIDs answer from
+	// them without fetching the other entries of the modules. It is
+	// an error if one of the IDs is in none of the sources. Only must
+	// not change after the first query.
+	Only []string
+
+	// Ecosystems lists the ecosystems, besides Go, of the affected
+	// packages that FetchOSVEntries and FetchCatalog accept, such as
+	// the ecosystem of a private database of internal advisories.
+	// The affected packages must still be named by Go import paths.
+	Ecosystems []string
+
+	// Concurrency is the maximum number of modules that
+	// FetchOSVEntries queries at once. Zero means one.
+	Concurrency int
+
+	// SnapshotTime, if set, pins the queries to a database snapshot
+	// approved at that time. The entries of a module are used as is
+	// if the index of the source reports them modified at or before
+	// it. Otherwise, the entries published after it are left out, as
+	// they were not in the snapshot, and the ones published before
+	// it but modified after it are used as the source has them now,
+	// with the AfterSnapshot field of their provenance set, as the
+	// source no longer has them as they were.
+	SnapshotTime time.Time
+
+	sources []*dbSource
+
+	onlyOnce    sync.Once
+	onlyEntries []*osv.Entry // entries of c.Only
+	onlyErr     error
+
+	// modules holds the entries of each module path@version split
+	// by FetchBuildListOSVEntries, shared by all its calls.
+	modules memo
+
+	mu   sync.Mutex
+	prov map[string]Provenance // entry ID -> provenance
+	used map[string]bool       // URLs of sources that answered queries
+}
+
+// SourceHealth is the result of probing a database source.
+type SourceHealth struct {
+	URL     string
+	Latency time.Duration
+	Err     error // nil if the source is available
+}
+
+// A DBError reports a failed query to a database source, such as
+// when the source is unreachable, as opposed to a problem with the
+// data it returned.
+type DBError struct {
+	URL string
+	Err error
+}
+
+func (e *DBError) Error() string { return e.URL + ": " + e.Err.Error() }
+
+func (e *DBError) Unwrap() error { return e.Err }
+
+type dbSource struct {
+	url    string
+	dbName string // cache key used by the client for http sources
+	cli    client.Client
+	cache  client.Cache
+
+	queries memo // entries by module path
+}
+
+// NewClient returns a provenance-tracking client for the
+// given database URLs.
+func NewClient(urls []string, opts client.Options) (*Client, error) {
+	union, err := client.NewClient(urls, opts)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{Client: union, prov: make(map[string]Provenance), used: make(map[string]bool)}
+	for _, u := range urls {
+		u = strings.TrimRight(u, "/")
+		cli, err := client.NewClient([]string{u}, opts)
+		if err != nil {
+			return nil, err
+		}
+		s := &dbSource{url: u, cli: cli}
+		if strings.HasPrefix(u, "http://") || strings.HasPrefix(u, "https://") {
+			if parsed, err := url.Parse(u); err == nil {
+				s.dbName = parsed.Hostname()
+				s.cache = opts.HTTPCache
+			}
+		}
+		c.sources = append(c.sources, s)
+	}
+	return c, nil
+}
+
+// getByModule returns the entries of the source for the module.
+// Each module is queried once in the lifetime of the client, as
+// long as the query succeeds, so that scans of several modules of
+// a workspace or of several repositories in one invocation share
+// the queries of their common dependencies.
+func (s *dbSource) getByModule(ctx context.Context, modulePath string) ([]*osv.Entry, error) {
+	v, err := s.queries.do(modulePath, func() (interface{}, error) {
+		return s.cli.GetByModule(ctx, modulePath)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]*osv.Entry), nil
+}
+
+// Refresh forgets the results of the queries, so that the next ones
+// query the sources again, such as to pick up the entries added to
+// the databases since in a long-running program. The sources still
+// answer from the HTTP cache while the cached index is fresh.
+// Refresh must not be called concurrently with queries.
+func (c *Client) Refresh() {
+	for _, s := range c.sources {
+		s.queries.reset()
+	}
+	c.modules.reset()
+	c.onlyOnce = sync.Once{}
+	c.onlyEntries, c.onlyErr = nil, nil
+}
+
+// Probe checks the availability and latency of each source by
+// querying its last modified time, and reorders the sources so
+// that available sources come first, fastest first.
+// The order matters when c.Mirrors is set.
+func (c *Client) Probe(ctx context.Context) []SourceHealth {
+	health := make(map[*dbSource]SourceHealth)
+	var res []SourceHealth
+	for _, s := range c.sources {
+		start := time.Now()
+		_, err := s.cli.LastModifiedTime(ctx)
+		h := SourceHealth{URL: s.url, Latency: time.Since(start), Err: err}
+		health[s] = h
+		res = append(res, h)
+	}
+	sort.SliceStable(c.sources, func(i, j int) bool {
+		hi, hj := health[c.sources[i]], health[c.sources[j]]
+		if (hi.Err == nil) != (hj.Err == nil) {
+			return hi.Err == nil
+		}
+		return hi.Latency < hj.Latency
+	})
+	return res
+}
+
+// UsedSources returns the URLs of the sources that answered
+// queries, in the current order of the sources.
+func (c *Client) UsedSources() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var urls []string
+	for _, s := range c.sources {
+		if c.used[s.url] {
+			urls = append(urls, s.url)
+		}
+	}
+	return urls
+}
+
+// GetByModule returns the union of the entries for the module
+// from all sources. When several sources have an entry with the
+// same ID, the entry from the first source wins.
+// If c.Mirrors is set, it returns the entries from the first
+// source that answers the query successfully.
+func (c *Client) GetByModule(ctx context.Context, modulePath string) ([]*osv.Entry, error) {
+	if len(c.Only) > 0 {
+		return c.onlyByModule(ctx, modulePath)
+	}
+	var entries []*osv.Entry
+	seen := map[string]bool{}
+	var lastErr error
+	for _, s := range c.sources {
+		es, err := s.getByModule(ctx, modulePath)
+		if err != nil {
+			err = &DBError{URL: s.url, Err: err}
+		} else if !c.SnapshotTime.IsZero() {
+			if modified, ok := s.indexModified(modulePath); !ok || modified.After(c.SnapshotTime) {
+				es = c.atSnapshot(es)
+			}
+		}
+		if err != nil {
+			if c.Mirrors {
+				lastErr = err
+				continue
+			}
+			return nil, err
+		}
+		c.markUsed(s)
+		fetched := s.fetchTime()
+		for _, e := range es {
+			if seen[e.ID] || c.ignored(e) {
+				continue
+			}
+			seen[e.ID] = true
+			entries = append(entries, e)
+			c.record(e, c.provenance(s, fetched, e))
+		}
+		if c.Mirrors {
+			return entries, nil
+		}
+	}
+	return entries, lastErr
+}
+
+// GetByID returns the entry with the given ID from the first
+// source that has it. If c.Mirrors is set, sources that fail
+// are skipped.
+func (c *Client) GetByID(ctx context.Context, id string) (*osv.Entry, error) {
+	var lastErr error
+	for _, s := range c.sources {
+		e, err := s.cli.GetByID(ctx, id)
+		if err != nil {
+			err = &DBError{URL: s.url, Err: err}
+		} else if e != nil && len(c.atSnapshot([]*osv.Entry{e})) == 0 {
+			e = nil
+		}
+		if err != nil {
+			if c.Mirrors {
+				lastErr = err
+				continue
+			}
+			return nil, err
+		}
+		c.markUsed(s)
+		if e != nil && c.ignored(e) {
+			return nil, nil
+		}
+		if e != nil {
+			c.record(e, c.provenance(s, s.fetchTime(), e))
+			return e, nil
+		}
+		if c.Mirrors {
+			return nil, nil
+		}
+	}
+	return nil, lastErr
+}
+
+// GetByAlias returns the entries with the given alias from the
+// first source that has them. If c.Mirrors is set, sources that
+// fail are skipped.
+func (c *Client) GetByAlias(ctx context.Context, alias string) ([]*osv.Entry, error) {
+	var lastErr error
+	for _, s := range c.sources {
+		es, err := s.cli.GetByAlias(ctx, alias)
+		if err != nil {
+			err = &DBError{URL: s.url, Err: err}
+		} else {
+			es = c.atSnapshot(es)
+		}
+		if err != nil {
+			if c.Mirrors {
+				lastErr = err
+				continue
+			}
+			return nil, err
+		}
+		c.markUsed(s)
+		var entries []*osv.Entry
+		fetched := s.fetchTime()
+		for _, e := range es {
+			if !c.ignored(e) {
+				entries = append(entries, e)
+				c.record(e, c.provenance(s, fetched, e))
+			}
+		}
+		if len(es) > 0 || c.Mirrors {
+			return entries, nil
+		}
+	}
+	return nil, lastErr
+}
+
+// ListIDs returns the IDs of all entries in the sources, or those
+// of the entries of c.Only if it is set.
+func (c *Client) ListIDs(ctx context.Context) ([]string, error) {
+	if len(c.Only) == 0 {
+		return c.Client.ListIDs(ctx)
+	}
+	entries, err := c.fetchOnly(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, e := range entries {
+		ids = append(ids, e.ID)
+	}
+	return ids, nil
+}
+
+// fetchOnly returns the entries of c.Only, fetched once,
+// by ID or, for the IDs that are not, by alias.
+func (c *Client) fetchOnly(ctx context.Context) ([]*osv.Entry, error) {
+	c.onlyOnce.Do(func() {
+		seen := map[string]bool{}
+		for _, id := range c.Only {
+			e, err := c.GetByID(ctx, id)
+			if err != nil {
+				c.onlyErr = err
+				return
+			}
+			es := []*osv.Entry{e}
+			if e == nil {
+				if es, err = c.GetByAlias(ctx, id); err != nil {
+					c.onlyErr = err
+					return
+				}
+			}
+			if len(es) == 0 && !c.ignoredID(id) {
+				c.onlyErr = fmt.Errorf("%s: not found in the vulnerability database", id)
+				return
+			}
+			for _, e := range es {
+				if e != nil && !seen[e.ID] {
+					seen[e.ID] = true
+					c.onlyEntries = append(c.onlyEntries, e)
+				}
+			}
+		}
+	})
+	return c.onlyEntries, c.onlyErr
+}
+
+// onlyByModule returns the entries of c.Only affecting the module.
+func (c *Client) onlyByModule(ctx context.Context, modulePath string) ([]*osv.Entry, error) {
+	entries, err := c.fetchOnly(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var res []*osv.Entry
+	for _, e := range entries {
+		for _, a := range e.Affected {
+			if a.Package.Name == modulePath {
+				res = append(res, e)
+				break
+			}
+		}
+	}
+	return res, nil
+}
+
+// AcceptsEcosystem reports whether the affected packages in the
+// ecosystem are considered: those of the Go ecosystem, and those
+// of the ecosystems in c.Ecosystems.
+func (c *Client) AcceptsEcosystem(eco osv.Ecosystem) bool {
+	if eco == osv.GoEcosystem {
+		return true
+	}
+	for _, e := range c.Ecosystems {
+		if osv.Ecosystem(e) == eco {
+			return true
+		}
+	}
+	return false
+}
+
+// atSnapshot returns the entries that were in the database at
+// c.SnapshotTime, if it is set: those published at or before it,
+// including the ones modified after it (see Provenance.AfterSnapshot).
+func (c *Client) atSnapshot(entries []*osv.Entry) []*osv.Entry {
+	if c.SnapshotTime.IsZero() {
+		return entries
+	}
+	var res []*osv.Entry
+	for _, e := range entries {
+		if !e.Modified.After(c.SnapshotTime) || e.Published.IsZero() || !e.Published.After(c.SnapshotTime) {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// provenance returns the provenance of the entry of the source
+// retrieved at the fetched time.
+func (c *Client) provenance(s *dbSource, fetched time.Time, e *osv.Entry) Provenance {
+	return Provenance{
+		Source:        s.url,
+		Fetched:       fetched,
+		Modified:      e.Modified,
+		AfterSnapshot: !c.SnapshotTime.IsZero() && e.Modified.After(c.SnapshotTime),
+	}
+}
+
+// ignored reports whether the entry is listed in c.Ignore.
+func (c *Client) ignored(e *osv.Entry) bool {
+	for _, id := range c.Ignore {
+		if e.ID == id {
+			return true
+		}
+		for _, a := range e.Aliases {
+			if a == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ignoredID reports whether the ID is listed in c.Ignore.
+func (c *Client) ignoredID(id string) bool {
+	for _, ig := range c.Ignore {
+		if ig == id {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadIgnoreFile reads a file listing vulnerability IDs to ignore,
+// one per line. Blank lines and text after # are skipped.
+func ReadIgnoreFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		if line = strings.TrimSpace(line); line != "" {
+			ids = append(ids, line)
+		}
+	}
+	return ids, nil
+}
+
+func (c *Client) markUsed(s *dbSource) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.used[s.url] = true
+}
+
+func (c *Client) record(e *osv.Entry, p Provenance) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.prov[e.ID] = p
+}
+
+// Provenance returns the provenance of the entry with the given ID,
+// if it was returned by this client.
+func (c *Client) Provenance(id string) (Provenance, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p, ok := c.prov[id]
+	return p, ok
+}
+
+// Provenances returns the provenance of the entries of pkg2vulns,
+// keyed by entry ID, if cli records it, as Client does.
+func Provenances(cli client.Client, pkg2vulns map[string][]*osv.Entry) map[string]Provenance {
+	pt, ok := cli.(interface {
+		Provenance(id string) (Provenance, bool)
+	})
+	if !ok {
+		return nil
+	}
+	prov := make(map[string]Provenance)
+	for _, vulns := range pkg2vulns {
+		for _, e := range vulns {
+			if p, ok := pt.Provenance(e.ID); ok {
+				prov[e.ID] = p
+			}
+		}
+	}
+	return prov
+}
+
+// fetchTime returns the time the source data was retrieved.
+// For http sources backed by a cache, the index retrieval time
+// tells when the data was last confirmed up to date.
+// indexModified returns the last modified time of the entries of the
+// module in the index of the source, read from the HTTP cache after
+// a query or from the index.json file of a file:// database, and
+// reports whether it is known.
+func (s *dbSource) indexModified(modulePath string) (time.Time, bool) {
+	if s.cache != nil {
+		index, _, err := s.cache.ReadIndex(s.dbName)
+		if err != nil || index == nil {
+			return time.Time{}, false
+		}
+		modified, ok := index[modulePath]
+		return modified, ok
+	}
+	if !strings.HasPrefix(s.url, "file://") {
+		return time.Time{}, false
+	}
+	f, err := os.Open(filepath.Join(strings.TrimPrefix(s.url, "file://"), "index.json"))
+	if err != nil {
+		return time.Time{}, false
+	}
+	defer f.Close()
+	var modified time.Time
+	if ok, err := LookupIndex(f, modulePath, &modified); err != nil || !ok {
+		return time.Time{}, false
+	}
+	return modified, true
+}
+
+func (s *dbSource) fetchTime() time.Time {
+	if s.cache != nil {
+		if _, retrieved, err := s.cache.ReadIndex(s.dbName); err == nil && !retrieved.IsZero() {
+			return retrieved
+		}
+	}
+	return time.Now()
+}
+
+// A memo holds the results of calls by key, so that each call is
+// made once while it succeeds. Concurrent calls with the same key
+// wait for the first one. The zero value is ready to use.
+type memo struct {
+	mu    sync.Mutex
+	calls map[string]*memoCall
+}
+
+type memoCall struct {
+	done chan struct{}
+	v    interface{}
+	err  error
+}
+
+// reset forgets the results of all the calls.
+func (m *memo) reset() {
+	m.mu.Lock()
+	m.calls = nil
+	m.mu.Unlock()
+}
+
+// do returns the result of f for the key, calling it if there is no
+// result yet. Failed calls are forgotten, so the next one calls f again.
+func (m *memo) do(key string, f func() (interface{}, error)) (interface{}, error) {
+	m.mu.Lock()
+	if call := m.calls[key]; call != nil {
+		m.mu.Unlock()
+		<-call.done
+		return call.v, call.err
+	}
+	if m.calls == nil {
+		m.calls = make(map[string]*memoCall)
+	}
+	call := &memoCall{done: make(chan struct{})}
+	m.calls[key] = call
+	m.mu.Unlock()
+
+	call.v, call.err = f()
+	if call.err != nil {
+		m.mu.Lock()
+		delete(m.calls, key)
+		m.mu.Unlock()
+	}
+	close(call.done)
+	return call.v, call.err
+}
@@ -0,0 +1,535 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package osvutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/hyangah/vulns/testutils"
+	"golang.org/x/mod/module"
+	"golang.org/x/vuln/client"
+	"golang.org/x/vuln/osv"
+)
+
+func TestClientProvenance(t *testing.T) {
+	ctx := context.Background()
+	db1, err := testutils.NewDatabase(ctx, []byte(`
+-- GO-2020-0001.yaml --
+modules:
+  - module: example.com/m
+    versions:
+      - fixed: 1.1.0
+    packages:
+      - package: example.com/m/p
+description: |
+    Something.
+published: 2021-04-14T20:04:52Z
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db1.Clean()
+	db2, err := testutils.NewDatabase(ctx, []byte(`
+-- GO-2020-0001.yaml --
+modules:
+  - module: example.com/m
+    versions:
+      - fixed: 1.1.0
+    packages:
+      - package: example.com/m/p
+description: |
+    Duplicate.
+published: 2021-04-14T20:04:52Z
+-- GO-2020-0002.yaml --
+modules:
+  - module: example.com/m
+    versions:
+      - fixed: 1.2.0
+    packages:
+      - package: example.com/m/q
+description: |
+    Something else.
+published: 2021-04-14T20:04:52Z
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db2.Clean()
+
+	cli, err := NewClient([]string{db1.URI(), db2.URI()}, client.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := cli.GetByModule(ctx, "example.com/m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	for id, want := range map[string]string{
+		"GO-2020-0001": db1.URI(),
+		"GO-2020-0002": db2.URI(),
+	} {
+		p, ok := cli.Provenance(id)
+		if !ok {
+			t.Errorf("Provenance(%q) not found", id)
+			continue
+		}
+		if p.Source != want {
+			t.Errorf("Provenance(%q).Source = %q, want %q", id, p.Source, want)
+		}
+		if p.Fetched.IsZero() {
+			t.Errorf("Provenance(%q).Fetched is not set", id)
+		}
+	}
+	if _, ok := cli.Provenance("GO-2020-0003"); ok {
+		t.Errorf("Provenance(GO-2020-0003) found unexpectedly")
+	}
+}
+
+// indexCache is a cache holding only an index retrieved at a time.
+type indexCache struct {
+	client.Cache
+	index     client.DBIndex
+	retrieved time.Time
+}
+
+func (c indexCache) ReadIndex(string) (client.DBIndex, time.Time, error) {
+	return c.index, c.retrieved, nil
+}
+
+func TestClientFetchTime(t *testing.T) {
+	ctx := context.Background()
+	retrieved := time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC)
+	e := testutils.Entry("GO-2022-0001", "example.com/m", "")
+	e.Aliases = []string{"CVE-2022-0001"}
+	cli := testutils.MapClient(map[string][]*osv.Entry{"example.com/m": {e}})
+	for name, query := range map[string]func(*Client) error{
+		"GetByModule": func(c *Client) error { _, err := c.GetByModule(ctx, "example.com/m"); return err },
+		"GetByID":     func(c *Client) error { _, err := c.GetByID(ctx, "GO-2022-0001"); return err },
+		"GetByAlias":  func(c *Client) error { _, err := c.GetByAlias(ctx, "CVE-2022-0001"); return err },
+	} {
+		// An http source whose cached index was retrieved earlier.
+		c := &Client{prov: make(map[string]Provenance), used: make(map[string]bool)}
+		c.sources = []*dbSource{{url: "https://vuln.example.com", dbName: "vuln.example.com", cli: cli, cache: indexCache{retrieved: retrieved}}}
+		if err := query(c); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if p, ok := c.Provenance("GO-2022-0001"); !ok || !p.Fetched.Equal(retrieved) {
+			t.Errorf("%s: provenance fetched at %v, want the retrieval time of the cached index %v", name, p.Fetched, retrieved)
+		}
+	}
+}
+
+func TestClientRefresh(t *testing.T) {
+	ctx := context.Background()
+	byModule := map[string][]*osv.Entry{
+		"example.com/m": {testutils.Entry("GO-2022-0001", "example.com/m", "")},
+	}
+	c := &Client{prov: make(map[string]Provenance), used: make(map[string]bool)}
+	c.sources = []*dbSource{{url: "https://vuln.example.com", dbName: "vuln.example.com", cli: testutils.MapClient(byModule)}}
+	count := func() int {
+		entries, err := c.GetByModule(ctx, "example.com/m")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(entries)
+	}
+	if got := count(); got != 1 {
+		t.Fatalf("got %d entries, want 1", got)
+	}
+	// An entry published since.
+	byModule["example.com/m"] = append(byModule["example.com/m"], testutils.Entry("GO-2022-0002", "example.com/m", ""))
+	if got := count(); got != 1 {
+		t.Errorf("before Refresh: got %d entries, want the 1 queried already", got)
+	}
+	c.Refresh()
+	if got := count(); got != 2 {
+		t.Errorf("after Refresh: got %d entries, want 2", got)
+	}
+}
+
+func TestClientMirrors(t *testing.T) {
+	ctx := context.Background()
+	db, err := testutils.NewDatabase(ctx, []byte(`
+-- GO-2020-0001.yaml --
+modules:
+  - module: example.com/m
+    versions:
+      - fixed: 1.1.0
+    packages:
+      - package: example.com/m/p
+description: |
+    Something.
+published: 2021-04-14T20:04:52Z
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Clean()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer broken.Close()
+
+	cli, err := NewClient([]string{broken.URL, db.URI()}, client.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.GetByModule(ctx, "example.com/m"); err == nil {
+		t.Fatal("GetByModule succeeded unexpectedly without Mirrors")
+	}
+
+	cli.Mirrors = true
+	entries, err := cli.GetByModule(ctx, "example.com/m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	if got := cli.UsedSources(); len(got) != 1 || got[0] != db.URI() {
+		t.Errorf("UsedSources() = %v, want [%s]", got, db.URI())
+	}
+
+	health := cli.Probe(ctx)
+	if len(health) != 2 {
+		t.Fatalf("Probe returned %d results, want 2", len(health))
+	}
+	if health[0].URL != broken.URL || health[0].Err == nil {
+		t.Errorf("Probe()[0] = %+v, want an error for %s", health[0], broken.URL)
+	}
+	if health[1].URL != db.URI() || health[1].Err != nil {
+		t.Errorf("Probe()[1] = %+v, want no error for %s", health[1], db.URI())
+	}
+	if got := cli.sources[0].url; got != db.URI() {
+		t.Errorf("after Probe, first source = %s, want %s", got, db.URI())
+	}
+}
+
+func TestClientIgnore(t *testing.T) {
+	ctx := context.Background()
+	db, err := testutils.NewDatabase(ctx, []byte(`
+-- GO-2020-0001.yaml --
+modules:
+  - module: example.com/m
+    versions:
+      - fixed: 1.1.0
+    packages:
+      - package: example.com/m/p
+description: |
+    Something.
+cves:
+  - CVE-2020-0001
+published: 2021-04-14T20:04:52Z
+-- GO-2020-0002.yaml --
+modules:
+  - module: example.com/m
+    versions:
+      - fixed: 1.2.0
+    packages:
+      - package: example.com/m/p
+description: |
+    Something else.
+ghsas:
+  - GHSA-aaaa-bbbb-cccc
+published: 2021-04-14T20:04:52Z
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Clean()
+	cli, err := NewClient([]string{db.URI()}, client.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		ignore []string
+		want   int
+	}{
+		{nil, 2},
+		{[]string{"GO-2020-0001"}, 1},
+		{[]string{"CVE-2020-0001", "GHSA-aaaa-bbbb-cccc"}, 0},
+	} {
+		cli.Ignore = tc.ignore
+		entries, err := cli.GetByModule(ctx, "example.com/m")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != tc.want {
+			t.Errorf("Ignore = %v: got %d entries, want %d", tc.ignore, len(entries), tc.want)
+		}
+	}
+	if e, err := cli.GetByID(ctx, "GO-2020-0002"); err != nil || e != nil {
+		t.Errorf("GetByID of an ignored entry = %v, %v; want nil, nil", e, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "ignore")
+	if err := os.WriteFile(path, []byte("# accepted\nGO-2020-0001\n\nCVE-2020-0002 # not affected\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	ids, err := ReadIgnoreFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || ids[0] != "GO-2020-0001" || ids[1] != "CVE-2020-0002" {
+		t.Errorf("ReadIgnoreFile = %q", ids)
+	}
+}
+
+func TestClientOnly(t *testing.T) {
+	ctx := context.Background()
+	db, err := testutils.NewDatabase(ctx, []byte(`
+-- GO-2020-0001.yaml --
+modules:
+  - module: example.com/m
+    versions:
+      - fixed: 1.1.0
+    packages:
+      - package: example.com/m/p
+description: |
+    Something.
+published: 2021-04-14T20:04:52Z
+-- GO-2020-0002.yaml --
+modules:
+  - module: example.com/m
+    versions:
+      - fixed: 1.2.0
+    packages:
+      - package: example.com/m/p
+description: |
+    Something else.
+cves:
+  - CVE-2020-0002
+published: 2021-04-14T20:04:52Z
+-- GO-2020-0003.yaml --
+modules:
+  - module: example.com/n
+    versions:
+      - fixed: 1.2.0
+    packages:
+      - package: example.com/n/p
+description: |
+    Something else.
+published: 2021-04-14T20:04:52Z
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Clean()
+	for _, tc := range []struct {
+		only []string
+		m, n []string // IDs of the entries of example.com/m and example.com/n
+	}{
+		{[]string{"GO-2020-0001"}, []string{"GO-2020-0001"}, nil},
+		{[]string{"CVE-2020-0002", "GO-2020-0003"}, []string{"GO-2020-0002"}, []string{"GO-2020-0003"}},
+	} {
+		cli, err := NewClient([]string{db.URI()}, client.Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cli.Only = tc.only
+		for _, mod := range []struct {
+			path string
+			want []string
+		}{{"example.com/m", tc.m}, {"example.com/n", tc.n}} {
+			entries, err := cli.GetByModule(ctx, mod.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, e := range entries {
+				got = append(got, e.ID)
+			}
+			if !reflect.DeepEqual(got, mod.want) {
+				t.Errorf("Only = %v: GetByModule(%s) = %v, want %v", tc.only, mod.path, got, mod.want)
+			}
+		}
+		ids, err := cli.ListIDs(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := append(tc.m, tc.n...); !reflect.DeepEqual(ids, want) {
+			t.Errorf("Only = %v: ListIDs = %v, want %v", tc.only, ids, want)
+		}
+	}
+
+	cli, err := NewClient([]string{db.URI()}, client.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli.Only = []string{"GO-2020-0009"}
+	if _, err := cli.GetByModule(ctx, "example.com/m"); err == nil {
+		t.Error("GetByModule with an unknown ID in Only succeeded, want an error")
+	}
+}
+
+func TestFetchCatalog(t *testing.T) {
+	ctx := context.Background()
+	db, err := testutils.NewDatabase(ctx, []byte(`
+-- GO-2020-0001.yaml --
+modules:
+  - module: example.com/m
+    versions:
+      - fixed: 1.1.0
+    packages:
+      - package: example.com/m/p
+description: |
+    Something.
+published: 2021-04-14T20:04:52Z
+-- GO-2020-0002.yaml --
+modules:
+  - module: example.com/n
+    versions:
+      - fixed: 1.2.0
+    packages:
+      - package: example.com/n/q
+description: |
+    Something else.
+published: 2021-04-14T20:04:52Z
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Clean()
+	cli, err := client.NewClient([]string{db.URI()}, client.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		mods []module.Version
+		want []string // package paths
+	}{
+		{nil, []string{"example.com/m/p", "example.com/n/q"}},
+		{[]module.Version{{Path: "example.com/m"}}, []string{"example.com/m/p"}},
+		{[]module.Version{{Path: "example.com/m", Version: "v1.0.0"}}, []string{"example.com/m/p"}},
+		{[]module.Version{{Path: "example.com/m", Version: "v1.1.0"}}, nil},
+	} {
+		got, err := FetchCatalog(ctx, cli, tc.mods)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var pkgs []string
+		for p := range got {
+			pkgs = append(pkgs, p)
+		}
+		sort.Strings(pkgs)
+		if !reflect.DeepEqual(pkgs, tc.want) {
+			t.Errorf("FetchCatalog(%v) has packages %v, want %v", tc.mods, pkgs, tc.want)
+		}
+	}
+}
+
+func TestClientSnapshotTime(t *testing.T) {
+	ctx := context.Background()
+	day := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	e1 := testutils.Entry("GO-2022-0001", "example.com/m", "")
+	e1.Published, e1.Modified = day("2022-01-01"), day("2022-01-01")
+	e2 := testutils.Entry("GO-2022-0002", "example.com/m", "")
+	e2.Published, e2.Modified = day("2022-06-01"), day("2022-06-01")
+	e1Revised := *e1
+	e1Revised.Modified = day("2022-06-01")
+	approved := testutils.MapClient(map[string][]*osv.Entry{"example.com/m": {e1}})
+	// The index of updated is newer than the snapshot, with an
+	// entry published after it.
+	updated := testutils.MapClient(map[string][]*osv.Entry{"example.com/m": {e1, e2}})
+	// revised modified an entry of the snapshot after it.
+	revised := testutils.MapClient(map[string][]*osv.Entry{"example.com/m": {&e1Revised}})
+	newClient := func(mirrors bool, clis ...client.Client) *Client {
+		c := &Client{Mirrors: mirrors, SnapshotTime: day("2022-03-01"), prov: make(map[string]Provenance), used: make(map[string]bool)}
+		for i, cli := range clis {
+			c.sources = append(c.sources, &dbSource{url: fmt.Sprintf("file:///db%d", i), cli: cli})
+		}
+		return c
+	}
+	ids := func(es []*osv.Entry) []string {
+		var ids []string
+		for _, e := range es {
+			ids = append(ids, e.ID)
+		}
+		return ids
+	}
+
+	for _, tc := range []struct {
+		name    string
+		mirrors bool
+		clis    []client.Client
+		want    []string
+		revised bool // whether GO-2022-0001 is the one modified after the snapshot
+	}{
+		{"approved", false, []client.Client{approved}, []string{"GO-2022-0001"}, false},
+		{"updated", false, []client.Client{updated}, []string{"GO-2022-0001"}, false},
+		{"revised", false, []client.Client{revised}, []string{"GO-2022-0001"}, true},
+		{"mirrors", true, []client.Client{revised, updated}, []string{"GO-2022-0001"}, true},
+	} {
+		c := newClient(tc.mirrors, tc.clis...)
+		es, err := c.GetByModule(ctx, "example.com/m")
+		if err != nil {
+			t.Errorf("%s: GetByModule: %v", tc.name, err)
+			continue
+		}
+		if got := ids(es); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: GetByModule = %v, want %v", tc.name, got, tc.want)
+		}
+		if p, _ := c.Provenance("GO-2022-0001"); p.AfterSnapshot != tc.revised {
+			t.Errorf("%s: Provenance(GO-2022-0001).AfterSnapshot = %v, want %v", tc.name, p.AfterSnapshot, tc.revised)
+		}
+	}
+
+	// The index of the source reports the entries of the module
+	// modified before the snapshot, so they are used as is.
+	c := newClient(false, updated)
+	c.sources[0].cache = indexCache{index: client.DBIndex{"example.com/m": day("2022-02-01")}}
+	if es, err := c.GetByModule(ctx, "example.com/m"); err != nil || len(es) != 2 {
+		t.Errorf("GetByModule with the module indexed before the snapshot = %v, %v, want both entries", ids(es), err)
+	}
+
+	if e, err := newClient(false, updated).GetByID(ctx, "GO-2022-0002"); err != nil || e != nil {
+		t.Errorf("GetByID of an entry published after the snapshot = %v, %v, want nil, nil", e, err)
+	}
+	c = newClient(false, revised)
+	if e, err := c.GetByID(ctx, "GO-2022-0001"); err != nil || e == nil {
+		t.Errorf("GetByID of an entry modified after the snapshot = %v, %v, want the entry", e, err)
+	} else if p, _ := c.Provenance("GO-2022-0001"); !p.AfterSnapshot {
+		t.Errorf("GetByID of an entry modified after the snapshot: provenance %+v, want AfterSnapshot", p)
+	}
+}
+
+// unreachableClient fails every query, like a database during an outage.
+type unreachableClient struct{ client.Client }
+
+func (unreachableClient) GetByModule(context.Context, string) ([]*osv.Entry, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestClientDBError(t *testing.T) {
+	c := &Client{
+		sources: []*dbSource{{url: "https://db.example", cli: unreachableClient{}}},
+		prov:    make(map[string]Provenance),
+		used:    make(map[string]bool),
+	}
+	_, err := c.GetByModule(context.Background(), "example.com/m")
+	var dbErr *DBError
+	if !errors.As(err, &dbErr) || dbErr.URL != "https://db.example" {
+		t.Errorf("GetByModule error = %v, want a DBError of https://db.example", err)
+	}
+}
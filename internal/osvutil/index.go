@@ -0,0 +1,59 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package osvutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// LookupIndex decodes into v the value of the key in the JSON object
+// read from r, such as the index.json or aliases.json file of a
+// database, and reports whether the key was found. It stops reading
+// at the key and does not decode the values of the other keys, so
+// that single lookups in the indexes of large databases need little
+// memory.
+func LookupIndex(r io.Reader, key string, v interface{}) (bool, error) {
+	dec := json.NewDecoder(r)
+	if t, err := dec.Token(); err != nil {
+		return false, err
+	} else if t != json.Delim('{') {
+		return false, fmt.Errorf("index is not a JSON object")
+	}
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return false, err
+		}
+		if t == key {
+			return true, dec.Decode(v)
+		}
+		if err := skipValue(dec); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// skipValue reads the next JSON value from dec, token by token.
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		t, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch t {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
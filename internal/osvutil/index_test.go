@@ -0,0 +1,37 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package osvutil
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLookupIndex(t *testing.T) {
+	const index = `{
+		"CVE-2022-0001": ["GO-2022-0001"],
+		"GHSA-xxxx-yyyy-zzzz": ["GO-2022-0002", {"nested": [1, {"a": []}]}],
+		"CVE-2022-0003": ["GO-2022-0003", "GO-2022-0004"]
+	} trailing garbage`
+	for _, tc := range []struct {
+		key   string
+		found bool
+		want  []string
+	}{
+		{"CVE-2022-0001", true, []string{"GO-2022-0001"}},
+		{"CVE-2022-0003", true, []string{"GO-2022-0003", "GO-2022-0004"}},
+		{"CVE-2022-0002", false, nil},
+	} {
+		var got []string
+		found, err := LookupIndex(strings.NewReader(index), tc.key, &got)
+		if tc.found && err != nil || found != tc.found || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("LookupIndex(%q) = %v, %v, %v; want %v, %v", tc.key, got, found, err, tc.want, tc.found)
+		}
+	}
+	if _, err := LookupIndex(strings.NewReader(`["GO-2022-0001"]`), "x", new([]string)); err == nil {
+		t.Errorf("LookupIndex of an array succeeded")
+	}
+}
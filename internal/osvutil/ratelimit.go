@@ -0,0 +1,95 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package osvutil
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// A Limiter limits the rate of requests to the vulnerability
+// databases, so that large scans do not overload the servers.
+// It is a token bucket holding up to burst tokens, refilled at
+// rate tokens per second. Each request takes a token.
+type Limiter struct {
+	rate  float64
+	burst int
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	now    func() time.Time // for testing
+}
+
+// NewLimiter returns a limiter allowing rate requests per second
+// on average, and bursts of up to burst requests. The rate must be
+// positive. A burst less than 1 is treated as 1.
+func NewLimiter(rate float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{rate: rate, burst: burst, tokens: float64(burst), now: time.Now}
+}
+
+// reserve takes a token and returns how long to wait
+// before the request it accounts for may be sent.
+func (l *Limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > float64(l.burst) {
+			l.tokens = float64(l.burst)
+		}
+	}
+	l.last = now
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// Wait blocks until a request may be sent or ctx is done.
+func (l *Limiter) Wait(ctx context.Context) error {
+	d := l.reserve()
+	if d == 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Transport returns an http.RoundTripper that waits for the limiter
+// before each request sent with base, or http.DefaultTransport if
+// base is nil. Use it in the client.Options of the database clients.
+// Requests answered by the HTTP cache are not limited.
+func (l *Limiter) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &limitedTransport{l: l, base: base}
+}
+
+type limitedTransport struct {
+	l    *Limiter
+	base http.RoundTripper
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.l.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
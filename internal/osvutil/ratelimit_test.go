@@ -0,0 +1,62 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package osvutil
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLimiterReserve(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := NewLimiter(2, 3) // 2 requests/sec, bursts of 3
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if d := l.reserve(); d != 0 {
+			t.Fatalf("request %d in burst: wait %v, want 0", i, d)
+		}
+	}
+	if d := l.reserve(); d != 500*time.Millisecond {
+		t.Errorf("request after burst: wait %v, want 500ms", d)
+	}
+	if d := l.reserve(); d != time.Second {
+		t.Errorf("second request after burst: wait %v, want 1s", d)
+	}
+	// After a long pause, the bucket is full again, but not fuller.
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		if d := l.reserve(); d != 0 {
+			t.Fatalf("request %d after pause: wait %v, want 0", i, d)
+		}
+	}
+	if d := l.reserve(); d == 0 {
+		t.Error("request beyond burst after pause was not limited")
+	}
+}
+
+func TestLimiterTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	l := NewLimiter(1, 1)
+	hc := &http.Client{Transport: l.Transport(nil)}
+	if _, err := hc.Get(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	// The next request must wait about a second; give up before that.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := hc.Do(req); err == nil {
+		t.Error("second request was not limited")
+	}
+}
@@ -8,69 +8,21 @@
 package osvutil
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
-	"regexp"
 	"runtime"
+	"sort"
 	"strings"
+	"sync"
 
+	"github.com/hyangah/vulns/stdlib"
 	"golang.org/x/mod/module"
 	"golang.org/x/tools/go/packages"
 	"golang.org/x/vuln/client"
 	"golang.org/x/vuln/osv"
 )
 
-var stdlibModule = &packages.Module{
-	Path: "stdlib",
-}
-
-var (
-	// Regexp for matching go tags. The groups are:
-	// 1  the major.minor version
-	// 2  the patch version, or empty if none
-	// 3  the entire prerelease, if present
-	// 4  the prerelease type ("beta" or "rc")
-	// 5  the prerelease number
-	tagRegexp = regexp.MustCompile(`^go(\d+\.\d+)(\.\d+|)((beta|rc|-pre)(\d+))?$`)
-)
-
-// GoTagToSemver replaces go version to semver style version string.
-// This is a modified copy of pkgsite/internal/stdlib:VersionForTag.
-func GoTagToSemver(tag string) string {
-	if tag == "" {
-		return ""
-	}
-
-	tag = strings.Fields(tag)[0]
-	// Special cases for go1.
-	if tag == "go1" {
-		return "v1.0.0"
-	}
-	if tag == "go1.0" {
-		return ""
-	}
-	m := tagRegexp.FindStringSubmatch(tag)
-	if m == nil {
-		return ""
-	}
-	version := "v" + m[1]
-	if m[2] != "" {
-		version += m[2]
-	} else {
-		version += ".0"
-	}
-	if m[3] != "" {
-		if !strings.HasPrefix(m[4], "-") {
-			version += "-"
-		}
-		version += m[4] + "." + m[5]
-	}
-	return version
-}
-
 func walk(pkgs []*packages.Package, fn func(pkg *packages.Package) error) error {
 	seen := map[*packages.Package]bool{}
 	var visit func(*packages.Package) error
@@ -98,44 +50,208 @@ func walk(pkgs []*packages.Package, fn func(pkg *packages.Package) error) error
 	return nil
 }
 
-func FetchOSVEntries(ctx context.Context, cli client.Client, pkgs []*packages.Package) (map[string][]*osv.Entry, error) {
-	// fetch osv entries, and organize based on the module.
-	modules := extractModules(pkgs)
-	stdlibModule := &packages.Module{
-		Path:    "stdlib",
-		Version: GoTagToSemver(goVersion()),
+// ModuleEntries holds the OSV entries affecting a module
+// in the import closure of the analyzed packages.
+type ModuleEntries struct {
+	// Module is the module as it appears in the build list.
+	// For the standard library, it is stdlib.Module.
+	Module  *packages.Module
+	Entries []*osv.Entry
+	// Excluded lists the vulnerable packages of the module
+	// excluded from Entries because they are vulnerable only
+	// on platforms other than the target GOOS/GOARCH.
+	Excluded []ExcludedImport
+	// Unaffected lists the entries of the module that do not
+	// affect its version.
+	Unaffected []*osv.Entry
+}
+
+// An ExcludedImport is a vulnerable package of an entry that
+// does not affect the target platform.
+type ExcludedImport struct {
+	Entry  *osv.Entry
+	Import osv.EcosystemSpecificImport
+}
+
+// Reasons a module is not looked up in the vulnerability database.
+const (
+	SkipInvalidPath = "invalid module path"
+	SkipMain        = "main module"
+	SkipNoVersion   = "unknown version"
+)
+
+// A ModuleQuery reports whether a module in the import closure
+// of the analyzed packages is looked up in the vulnerability database.
+type ModuleQuery struct {
+	// Module is the module as it appears in the build list.
+	// For the standard library, it is stdlib.Module.
+	Module *packages.Module
+	// Skipped is the reason the module is not looked up,
+	// or empty if it is.
+	Skipped string
+}
+
+// Modules returns the modules in the import closure of pkgs, including
+// the standard library, sorted by path and version in the build list.
+// The modules that FetchModuleOSVEntries does not look up have a
+// non-empty Skipped. No vulnerability can be reported for such modules.
+func Modules(pkgs []*packages.Package) []*ModuleQuery {
+	return BuildListModules(extractModules(pkgs))
+}
+
+// BuildListModules is like Modules, but for the given modules,
+// such as the build list reported by "go list -m all". The standard
+// library is at the version of the go command, unless modules
+// includes it (see stdlib.Module), as for a binary's build list.
+func BuildListModules(modules []*packages.Module) []*ModuleQuery {
+	hasStdlib := false
+	for _, m := range modules {
+		hasStdlib = hasStdlib || m.Path == stdlib.ModulePath
+	}
+	if !hasStdlib {
+		goVersion, err := stdlib.GoVersion()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v; skipping stdlib scanning\n", err)
+		}
+		modules = append(modules[:len(modules):len(modules)], stdlib.Module(goVersion))
 	}
-	modules = append(modules, stdlibModule)
 
-	mod2OSV := make(map[string][]*osv.Entry)
-	// TODO(hyangah): run multiple cli.GetByModule calls in parallel
-	// unless batch API can be offered from upstream.
+	var res []*ModuleQuery
 	for _, mod := range modules {
 		m := effectiveModule(mod)
 		if m == nil {
 			continue
 		}
-		modPath := m.Path
-		// If module path is not a valid, exportable module path (e.g. contains dot!)
-		// we don't need to lookup module.
-		if err := module.CheckPath(modPath); err != nil {
+		q := &ModuleQuery{Module: mod}
+		switch {
+		case m.Main:
+			q.Skipped = SkipMain
+		case m.Version == "":
+			// Includes directory replacements. The entries
+			// would be dropped by filterOSVEntries anyway.
+			q.Skipped = SkipNoVersion
+		case m.Path != stdlib.ModulePath && module.CheckPath(m.Path) != nil:
+			// Not a valid, exportable module path (e.g. contains dot!),
+			// so the database cannot have it.
+			q.Skipped = SkipInvalidPath
+		}
+		res = append(res, q)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		mi, mj := res[i].Module, res[j].Module
+		if mi.Path != mj.Path {
+			return mi.Path < mj.Path
+		}
+		return mi.Version < mj.Version
+	})
+	return res
+}
+
+// FetchModuleOSVEntries returns the OSV entries affecting each module
+// in the import closure of pkgs, including the standard library.
+// Modules without known vulnerabilities, and modules that are not
+// looked up for lack of a version, are included with no entries.
+// Modules with invalid paths are omitted. See Modules.
+func FetchModuleOSVEntries(ctx context.Context, cli client.Client, pkgs []*packages.Package) ([]*ModuleEntries, error) {
+	return FetchBuildListOSVEntries(ctx, cli, extractModules(pkgs))
+}
+
+// FetchBuildListOSVEntries is like FetchModuleOSVEntries, but for the
+// given modules. It needs no package information.
+// The modules are queried in parallel, at most Client.Concurrency at
+// once if cli is a Client, and one at a time otherwise.
+func FetchBuildListOSVEntries(ctx context.Context, cli client.Client, modules []*packages.Module) ([]*ModuleEntries, error) {
+	var res, queried []*ModuleEntries
+	for _, q := range BuildListModules(modules) {
+		if q.Skipped == SkipInvalidPath {
 			continue
 		}
-		vulns, err := cli.GetByModule(ctx, modPath)
+		me := &ModuleEntries{Module: q.Module}
+		res = append(res, me)
+		if q.Skipped == "" {
+			queried = append(queried, me)
+		}
+	}
+	accepts := ecosystemFilter(cli)
+	fetch := func(me *ModuleEntries) error {
+		m := effectiveModule(me.Module)
+		split := func() (interface{}, error) {
+			vulns, err := cli.GetByModule(ctx, m.Path)
+			if err != nil {
+				return nil, err
+			}
+			res := &ModuleEntries{Unaffected: unaffectedOSVEntries(m, vulns, accepts)}
+			vulns, res.Excluded = filterOSVEntries(m, vulns, accepts)
+			res.Entries = normalizeOSVEntries(m, vulns)
+			return res, nil
+		}
+		// A Client splits the entries of each module@version once
+		// per process, however many scans share the module.
+		var v interface{}
+		var err error
+		if c, ok := cli.(*Client); ok {
+			v, err = c.modules.do(modKey(m), split)
+		} else {
+			v, err = split()
+		}
+		if err != nil {
+			return err
+		}
+		res := v.(*ModuleEntries)
+		me.Entries, me.Excluded, me.Unaffected = res.Entries, res.Excluded, res.Unaffected
+		return nil
+	}
+
+	n := 1
+	if c, ok := cli.(*Client); ok && c.Concurrency > 0 {
+		n = c.Concurrency
+	}
+	sem := make(chan struct{}, n)
+	errs := make([]error, len(queried))
+	var wg sync.WaitGroup
+	for i, me := range queried {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, me *ModuleEntries) {
+			defer wg.Done()
+			errs[i] = fetch(me)
+			<-sem
+		}(i, me)
+	}
+	wg.Wait()
+	for _, err := range errs {
 		if err != nil {
 			return nil, err
 		}
-		vulns = normalizeOSVEntries(m, filterOSVEntries(m, vulns))
-		if len(vulns) > 0 {
-			mod2OSV[modKey(mod)] = vulns
+	}
+	return res, nil
+}
+
+func FetchOSVEntries(ctx context.Context, cli client.Client, pkgs []*packages.Package) (map[string][]*osv.Entry, error) {
+	// fetch osv entries, and organize based on the module.
+	modEntries, err := FetchModuleOSVEntries(ctx, cli, pkgs)
+	if err != nil {
+		return nil, err
+	}
+	var stdlibModule *packages.Module
+	mod2OSV := make(map[string][]*osv.Entry)
+	for _, me := range modEntries {
+		if me.Module.Path == stdlib.ModulePath {
+			stdlibModule = me.Module
+		}
+		if len(me.Entries) > 0 {
+			mod2OSV[modKey(me.Module)] = me.Entries
 		}
 	}
 	pkg2OSV := make(map[string][]*osv.Entry)
 	walk(pkgs, func(pkg *packages.Package) error {
 		m := pkg.Module
-		if m == nil && isStdPackage(pkg.PkgPath) {
+		if m == nil && stdlib.Contains(pkg.PkgPath) {
 			m = stdlibModule
 		}
+		if m == nil {
+			return nil
+		}
 		var vulns []*osv.Entry
 		for _, v := range mod2OSV[modKey(m)] {
 			for _, a := range v.Affected {
@@ -148,13 +264,23 @@ func FetchOSVEntries(ctx context.Context, cli client.Client, pkgs []*packages.Pa
 		}
 		if len(vulns) > 0 {
 			pkg2OSV[pkg.PkgPath] = vulns
-		} else {
 		}
 		return nil
 	})
 	return pkg2OSV, nil
 }
 
+// ImportedPackages returns the set of the import paths
+// of the packages in the import closure of pkgs.
+func ImportedPackages(pkgs []*packages.Package) map[string]bool {
+	imported := make(map[string]bool)
+	walk(pkgs, func(pkg *packages.Package) error {
+		imported[pkg.PkgPath] = true
+		return nil
+	})
+	return imported
+}
+
 func effectiveModule(mod *packages.Module) *packages.Module {
 	m := mod
 	for ; m != nil; m = m.Replace {
@@ -165,10 +291,27 @@ func effectiveModule(mod *packages.Module) *packages.Module {
 	return m
 }
 
-func filterOSVEntries(module *packages.Module, vulns []*osv.Entry) []*osv.Entry {
-	goos, goarch := lookupEnv("GOOS", runtime.GOOS), lookupEnv("GOARCH", runtime.GOARCH)
-	// TODO: add OS/Arch check - see the use of matchesPlatform
-	// https://github.com/golang/vuln/blob/4bd4888cc0609c2fdddc1eb4e66fa070397d921e/vulncheck/vulncheck.go#L299
+// ecosystemAccepter is implemented by clients that accept
+// ecosystems other than Go (see Client.Ecosystems).
+type ecosystemAccepter interface {
+	AcceptsEcosystem(eco osv.Ecosystem) bool
+}
+
+// ecosystemFilter returns the function reporting whether the entries
+// of cli for packages in an ecosystem are considered.
+func ecosystemFilter(cli client.Client) func(osv.Ecosystem) bool {
+	if ea, ok := cli.(ecosystemAccepter); ok {
+		return ea.AcceptsEcosystem
+	}
+	return func(eco osv.Ecosystem) bool { return eco == osv.GoEcosystem }
+}
+
+// filterOSVEntries returns the entries affecting the module version on
+// the target platform, considering the affected packages of the
+// ecosystems accepted by accepts. It also returns the vulnerable packages of the
+// affected version excluded because they do not affect the platform.
+func filterOSVEntries(module *packages.Module, vulns []*osv.Entry, accepts func(osv.Ecosystem) bool) (_ []*osv.Entry, excluded []ExcludedImport) {
+	goos, goarch := TargetPlatform()
 	modVersion := module.Version
 	if module.Replace != nil {
 		modVersion = module.Replace.Version
@@ -179,13 +322,13 @@ func filterOSVEntries(module *packages.Module, vulns []*osv.Entry) []*osv.Entry
 		var filteredAffected []osv.Affected
 		// leave only the entries that correspond to the module.
 		for _, a := range v.Affected {
-			if a.Package.Ecosystem != osv.GoEcosystem {
+			if !accepts(a.Package.Ecosystem) {
 				continue
 			}
-			if module.Path == "stdlib" && !isStdPackage(a.Package.Name) {
+			if module.Path == stdlib.ModulePath && !stdlib.Contains(a.Package.Name) {
 				continue
 			}
-			if module.Path != "stdlib" && !strings.HasPrefix(a.Package.Name, module.Path) {
+			if module.Path != stdlib.ModulePath && !strings.HasPrefix(a.Package.Name, module.Path) {
 				continue
 			}
 			// A module version is affected if
@@ -204,6 +347,8 @@ func filterOSVEntries(module *packages.Module, vulns []*osv.Entry) []*osv.Entry
 			for _, p := range a.EcosystemSpecific.Imports {
 				if matchesPlatform(goos, goarch, p) {
 					filteredImports = append(filteredImports, p)
+				} else {
+					excluded = append(excluded, ExcludedImport{Entry: v, Import: p})
 				}
 			}
 			if len(a.EcosystemSpecific.Imports) != 0 && len(filteredImports) == 0 {
@@ -222,11 +367,56 @@ func filterOSVEntries(module *packages.Module, vulns []*osv.Entry) []*osv.Entry
 		newV.Affected = filteredAffected
 		filteredVulns = append(filteredVulns, &newV)
 	}
-	return filteredVulns
+	return filteredVulns, excluded
+}
+
+// unaffectedOSVEntries returns the entries of the module, considering
+// the affected packages of the ecosystems accepted by accepts, whose
+// affected ranges do not include the version of the module, if known.
+func unaffectedOSVEntries(module *packages.Module, vulns []*osv.Entry, accepts func(osv.Ecosystem) bool) []*osv.Entry {
+	modVersion := module.Version
+	if module.Replace != nil {
+		modVersion = module.Replace.Version
+	}
+	if modVersion == "" {
+		return nil
+	}
+	var unaffected []*osv.Entry
+	for _, v := range vulns {
+		matched, affected := false, false
+		for _, a := range v.Affected {
+			if !accepts(a.Package.Ecosystem) {
+				continue
+			}
+			if module.Path == stdlib.ModulePath && !stdlib.Contains(a.Package.Name) {
+				continue
+			}
+			if module.Path != stdlib.ModulePath && !strings.HasPrefix(a.Package.Name, module.Path) {
+				continue
+			}
+			matched = true
+			if a.Ranges.AffectsSemver(modVersion) {
+				affected = true
+				break
+			}
+		}
+		if matched && !affected {
+			unaffected = append(unaffected, v)
+		}
+	}
+	return unaffected
+}
+
+// TargetPlatform returns the GOOS and GOARCH the analyzed packages are
+// built for, such as js and wasm for WebAssembly: the values of the
+// GOOS and GOARCH environment variables, or the host platform.
+// Entries affecting only other platforms are excluded.
+func TargetPlatform() (goos, goarch string) {
+	return lookupEnv("GOOS", runtime.GOOS), lookupEnv("GOARCH", runtime.GOARCH)
 }
 
 func lookupEnv(key, defaultValue string) string {
-	if v, ok := os.LookupEnv(key); ok {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
 		return v
 	}
 	return defaultValue
@@ -250,18 +440,6 @@ func matchesPlatform(os, arch string, e osv.EcosystemSpecificImport) bool {
 	return matchesOS && matchesArch
 }
 
-func isStdPackage(pkg string) bool {
-	if pkg == "" {
-		return false
-	}
-	// std packages do not have a "." in their path. For instance, see
-	// Contains in pkgsite/+/refs/heads/master/internal/stdlbib/stdlib.go.
-	if i := strings.IndexByte(pkg, '/'); i != -1 {
-		pkg = pkg[:i]
-	}
-	return !strings.Contains(pkg, ".")
-}
-
 func normalizeOSVEntries(_ *packages.Module, vulns []*osv.Entry) []*osv.Entry {
 	for _, v := range vulns {
 		// osv entry's details has many arbitrarilily place new line breaks. Remove them.
@@ -296,9 +474,6 @@ func modKey(mod *packages.Module) string {
 func extractModules(pkgs []*packages.Package) []*packages.Module {
 	modMap := map[string]*packages.Module{}
 
-	stdlibModule.Version = GoTagToSemver(goVersion())
-	modMap[stdlibModule.Path] = stdlibModule
-
 	seen := map[*packages.Package]bool{}
 	var extract func(*packages.Package, map[string]*packages.Module)
 	extract = func(pkg *packages.Package, modMap map[string]*packages.Module) {
@@ -327,16 +502,3 @@ func extractModules(pkgs []*packages.Package) []*packages.Module {
 	}
 	return modules
 }
-
-func goVersion() string {
-	if v := os.Getenv("GOVERSION"); v != "" {
-		// Unlikely to happen in practice, mostly used for testing.
-		return v
-	}
-	out, err := exec.Command("go", "env", "GOVERSION").Output()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "failed to determine go version; skipping stdlib scanning: %v\n", err)
-		return ""
-	}
-	return string(bytes.TrimSpace(out))
-}
@@ -0,0 +1,254 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package osvutil
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hyangah/vulns/stdlib"
+	"github.com/hyangah/vulns/testutils"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/vuln/client"
+	"golang.org/x/vuln/osv"
+)
+
+func TestModules(t *testing.T) {
+	t.Setenv("GOVERSION", "go1.19.1")
+	dep := func(path string, mod *packages.Module, imports ...*packages.Package) *packages.Package {
+		p := &packages.Package{PkgPath: path, Module: mod, Imports: map[string]*packages.Package{}}
+		for _, imp := range imports {
+			p.Imports[imp.PkgPath] = imp
+		}
+		return p
+	}
+	a := dep("example.com/a", &packages.Module{Path: "example.com/a", Version: "v1.0.0"})
+	b := dep("example.com/b", &packages.Module{Path: "example.com/b", Replace: &packages.Module{Path: "../b"}})
+	c := dep("c/d", &packages.Module{Path: "c", Version: "v1.0.0"})
+	fmt := dep("fmt", nil)
+	main := dep("example.com/m", &packages.Module{Path: "example.com/m", Main: true}, a, b, c, fmt)
+
+	type query struct{ Path, Skipped string }
+	var got []query
+	for _, q := range Modules([]*packages.Package{main}) {
+		got = append(got, query{q.Module.Path, q.Skipped})
+	}
+	want := []query{
+		{"c", SkipInvalidPath},
+		{"example.com/a", ""},
+		{"example.com/b", SkipNoVersion},
+		{"example.com/m", SkipMain},
+		{"stdlib", ""},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Modules mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestFilterOSVEntriesPlatform(t *testing.T) {
+	t.Setenv("GOVERSION", "go1.19.1")
+	var std []*osv.Entry
+	for _, e := range []struct {
+		id, pkg      string
+		goos, goarch []string
+	}{
+		{"GO-2022-0001", "syscall", []string{"windows"}, nil},
+		{"GO-2022-0002", "syscall/js", []string{"js"}, []string{"wasm"}},
+		{"GO-2022-0003", "os", []string{"plan9"}, nil},
+		{"GO-2022-0004", "net/http", nil, nil},
+	} {
+		v := testutils.Entry(e.id, e.pkg, "", e.pkg)
+		imp := &v.Affected[0].EcosystemSpecific.Imports[0]
+		imp.GOOS, imp.GOARCH = e.goos, e.goarch
+		std = append(std, v)
+	}
+	for _, tc := range []struct {
+		goos, goarch string
+		want         []string
+	}{
+		{"linux", "amd64", []string{"GO-2022-0004"}},
+		{"windows", "amd64", []string{"GO-2022-0001", "GO-2022-0004"}},
+		{"js", "wasm", []string{"GO-2022-0002", "GO-2022-0004"}},
+		{"js", "amd64", []string{"GO-2022-0004"}},
+		{"plan9", "386", []string{"GO-2022-0003", "GO-2022-0004"}},
+	} {
+		t.Setenv("GOOS", tc.goos)
+		t.Setenv("GOARCH", tc.goarch)
+		vulns, excluded := filterOSVEntries(stdlib.Module("go1.19.1"), std, ecosystemFilter(nil))
+		var got []string
+		for _, v := range vulns {
+			got = append(got, v.ID)
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("%s/%s: entries mismatch (-want +got):\n%s", tc.goos, tc.goarch, diff)
+		}
+		if len(got)+len(excluded) != len(std) {
+			t.Errorf("%s/%s: %d entries kept and %d excluded, want %d in total", tc.goos, tc.goarch, len(got), len(excluded), len(std))
+		}
+	}
+}
+
+func TestFilterOSVEntriesEcosystem(t *testing.T) {
+	vulns := []*osv.Entry{
+		testutils.Entry("GO-2022-0001", "example.com/a", ""),
+		testutils.Entry("CORP-2024-001", "example.com/a", ""),
+		testutils.Entry("PYSEC-2022-0001", "example.com/a", ""),
+	}
+	vulns[1].Affected[0].Package.Ecosystem = "corp"
+	vulns[2].Affected[0].Package.Ecosystem = "PyPI"
+	m := &packages.Module{Path: "example.com/a", Version: "v1.0.0"}
+	for _, tc := range []struct {
+		ecosystems []string
+		want       []string
+	}{
+		{nil, []string{"GO-2022-0001"}},
+		{[]string{"corp"}, []string{"GO-2022-0001", "CORP-2024-001"}},
+	} {
+		c := &Client{Ecosystems: tc.ecosystems}
+		got, _ := filterOSVEntries(m, vulns, ecosystemFilter(c))
+		var ids []string
+		for _, v := range got {
+			ids = append(ids, v.ID)
+		}
+		if diff := cmp.Diff(tc.want, ids); diff != "" {
+			t.Errorf("Ecosystems %q: entries mismatch (-want +got):\n%s", tc.ecosystems, diff)
+		}
+	}
+}
+
+func TestUnaffectedOSVEntries(t *testing.T) {
+	vulns := []*osv.Entry{
+		testutils.Entry("GO-2022-0001", "example.com/a", "1.0.0"),
+		testutils.Entry("GO-2022-0002", "example.com/a", "1.1.0"),
+		testutils.Entry("GO-2022-0003", "example.com/other", "1.0.0"),
+	}
+	m := &packages.Module{Path: "example.com/a", Version: "v1.0.0"}
+	var got []string
+	for _, v := range unaffectedOSVEntries(m, vulns, ecosystemFilter(nil)) {
+		got = append(got, v.ID)
+	}
+	if diff := cmp.Diff([]string{"GO-2022-0001"}, got); diff != "" {
+		t.Errorf("unaffected entries mismatch (-want +got):\n%s", diff)
+	}
+	if got := unaffectedOSVEntries(&packages.Module{Path: "example.com/a"}, vulns, ecosystemFilter(nil)); len(got) != 0 {
+		t.Errorf("unaffected entries of a module without version = %v, want none", got)
+	}
+}
+
+// countingClient answers every module with one entry named after
+// it and records the number of queries of each module and the
+// largest number of queries in flight.
+type countingClient struct {
+	client.Client
+
+	mu                  sync.Mutex
+	inFlight, maxFlight int
+	queries             map[string]int
+}
+
+func (c *countingClient) GetByModule(ctx context.Context, modulePath string) ([]*osv.Entry, error) {
+	c.mu.Lock()
+	if c.queries == nil {
+		c.queries = make(map[string]int)
+	}
+	c.queries[modulePath]++
+	c.inFlight++
+	if c.inFlight > c.maxFlight {
+		c.maxFlight = c.inFlight
+	}
+	c.mu.Unlock()
+	time.Sleep(10 * time.Millisecond)
+	c.mu.Lock()
+	c.inFlight--
+	c.mu.Unlock()
+	return []*osv.Entry{{
+		ID:       "GO-" + modulePath,
+		Affected: []osv.Affected{{Package: osv.Package{Name: modulePath, Ecosystem: osv.GoEcosystem}}},
+	}}, nil
+}
+
+func TestFetchBuildListConcurrency(t *testing.T) {
+	var modules []*packages.Module
+	for _, m := range []string{"example.com/a", "example.com/b", "example.com/c", "example.com/d", "example.com/e"} {
+		modules = append(modules, &packages.Module{Path: m, Version: "v1.0.0"})
+	}
+	fake := &countingClient{}
+	cli := &Client{
+		Concurrency: 2,
+		sources:     []*dbSource{{url: "file:///fake", cli: fake}},
+		prov:        make(map[string]Provenance),
+		used:        make(map[string]bool),
+	}
+	res, err := FetchBuildListOSVEntries(context.Background(), cli, modules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, me := range res {
+		for _, e := range me.Entries {
+			got = append(got, me.Module.Path+": "+e.ID)
+		}
+	}
+	want := []string{
+		"example.com/a: GO-example.com/a",
+		"example.com/b: GO-example.com/b",
+		"example.com/c: GO-example.com/c",
+		"example.com/d: GO-example.com/d",
+		"example.com/e: GO-example.com/e",
+		"stdlib: GO-stdlib",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("entries mismatch (-want +got):\n%s", diff)
+	}
+	if fake.maxFlight > 2 {
+		t.Errorf("%d queries in flight, want at most 2", fake.maxFlight)
+	}
+}
+
+func TestFetchBuildListShared(t *testing.T) {
+	fake := &countingClient{}
+	cli := &Client{
+		Concurrency: 4,
+		sources:     []*dbSource{{url: "file:///fake", cli: fake}},
+		prov:        make(map[string]Provenance),
+		used:        make(map[string]bool),
+	}
+	// Two members of a workspace sharing a dependency,
+	// scanned one after the other and concurrently.
+	members := [][]*packages.Module{
+		{{Path: "example.com/a", Version: "v1.0.0"}, {Path: "example.com/shared", Version: "v1.0.0"}},
+		{{Path: "example.com/b", Version: "v1.0.0"}, {Path: "example.com/shared", Version: "v1.0.0"}},
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		for _, modules := range members {
+			wg.Add(1)
+			go func(modules []*packages.Module) {
+				defer wg.Done()
+				res, err := FetchBuildListOSVEntries(context.Background(), cli, modules)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				for _, me := range res {
+					if len(me.Entries) != 1 {
+						t.Errorf("%s: got %d entries, want 1", me.Module.Path, len(me.Entries))
+					}
+				}
+			}(modules)
+		}
+		wg.Wait()
+	}
+	want := map[string]int{"example.com/a": 1, "example.com/b": 1, "example.com/shared": 1, "stdlib": 1}
+	if diff := cmp.Diff(want, fake.queries); diff != "" {
+		t.Errorf("queries mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -81,7 +81,10 @@ func Parse(analyzers []*analysis.Analyzer, multi bool) []*analysis.Analyzer {
 	_ = flag.Bool("source", false, "no effect (deprecated)")
 	_ = flag.Bool("v", false, "no effect (deprecated)")
 	_ = flag.Bool("all", false, "no effect (deprecated)")
-	_ = flag.String("tags", "", "no effect (deprecated)")
+	if flag.Lookup("tags") == nil {
+		// Drivers loading packages themselves may define -tags.
+		_ = flag.String("tags", "", "no effect (deprecated)")
+	}
 	for old, new := range vetLegacyFlags {
 		newFlag := flag.Lookup(new)
 		if newFlag != nil && flag.Lookup(old) == nil {
@@ -320,6 +323,9 @@ var vetLegacyFlags = map[string]string{
 func PrintPlain(fset *token.FileSet, diag analysis.Diagnostic) {
 	posn := fset.Position(diag.Pos)
 	fmt.Fprintf(os.Stderr, "%s: %s\n", posn, diag.Message)
+	for _, r := range diag.Related {
+		fmt.Fprintf(os.Stderr, "\t%s: %s\n", fset.Position(r.Pos), r.Message)
+	}
 
 	// -c=N: show offending line plus N lines of context.
 	if Context >= 0 {
@@ -339,32 +345,55 @@ func PrintPlain(fset *token.FileSet, diag analysis.Diagnostic) {
 }
 
 // A JSONTree is a mapping from package ID to analysis name to result.
-// Each result is either a jsonError or a list of jsonDiagnostic.
+// Each result is either a JSONError or a list of JSONDiagnostic.
+// It is the object printed by vet and the analysis drivers under -json.
 type JSONTree map[string]map[string]interface{}
 
+// A JSONError is the result of an analysis that failed in a JSONTree.
+type JSONError struct {
+	Err string `json:"error"`
+}
+
+// A JSONDiagnostic is a diagnostic in a JSONTree.
+type JSONDiagnostic struct {
+	Category string `json:"category,omitempty"`
+	Posn     string `json:"posn"`
+	Message  string `json:"message"`
+	// Related is the related information of the diagnostic,
+	// such as the near-duplicates folded into it.
+	Related []JSONRelatedInformation `json:"related,omitempty"`
+}
+
+// A JSONRelatedInformation is the related information of a
+// JSONDiagnostic.
+type JSONRelatedInformation struct {
+	Posn    string `json:"posn"`
+	Message string `json:"message"`
+}
+
 // Add adds the result of analysis 'name' on package 'id'.
 // The result is either a list of diagnostics or an error.
 func (tree JSONTree) Add(fset *token.FileSet, id, name string, diags []analysis.Diagnostic, err error) {
 	var v interface{}
 	if err != nil {
-		type jsonError struct {
-			Err string `json:"error"`
-		}
-		v = jsonError{err.Error()}
+		v = JSONError{err.Error()}
 	} else if len(diags) > 0 {
-		type jsonDiagnostic struct {
-			Category string `json:"category,omitempty"`
-			Posn     string `json:"posn"`
-			Message  string `json:"message"`
-		}
-		var diagnostics []jsonDiagnostic
+		var diagnostics []JSONDiagnostic
 		// TODO(matloob): Should the JSON diagnostics contain ranges?
 		// If so, how should they be formatted?
 		for _, f := range diags {
-			diagnostics = append(diagnostics, jsonDiagnostic{
+			var related []JSONRelatedInformation
+			for _, r := range f.Related {
+				related = append(related, JSONRelatedInformation{
+					Posn:    fset.Position(r.Pos).String(),
+					Message: r.Message,
+				})
+			}
+			diagnostics = append(diagnostics, JSONDiagnostic{
 				Category: f.Category,
 				Posn:     fset.Position(f.Pos).String(),
 				Message:  f.Message,
+				Related:  related,
 			})
 		}
 		v = diagnostics
@@ -379,6 +408,16 @@ func (tree JSONTree) Add(fset *token.FileSet, id, name string, diags []analysis.
 	}
 }
 
+// Write writes the tree to w as indented JSON.
+func (tree JSONTree) Write(w io.Writer) error {
+	data, err := json.MarshalIndent(tree, "", "\t")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
 func (tree JSONTree) Print() {
 	data, err := json.MarshalIndent(tree, "", "\t")
 	if err != nil {
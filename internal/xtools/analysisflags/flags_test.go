@@ -0,0 +1,108 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysisflags_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/token"
+	"os"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/hyangah/vulns/internal/xtools/analysisflags"
+	"golang.org/x/tools/go/analysis"
+)
+
+func main() {
+	fmt.Println(analysisflags.Parse([]*analysis.Analyzer{
+		{Name: "a1", Doc: "a1"},
+		{Name: "a2", Doc: "a2"},
+		{Name: "a3", Doc: "a3"},
+	}, true))
+	os.Exit(0)
+}
+
+// This test fork/execs the main function above.
+func TestExec(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skipf("skipping fork/exec test on this platform")
+	}
+
+	progname := os.Args[0]
+
+	if os.Getenv("ANALYSISFLAGS_CHILD") == "1" {
+		// child process
+		os.Args = strings.Fields(progname + " " + os.Getenv("FLAGS"))
+		main()
+		panic("unreachable")
+	}
+
+	for _, test := range []struct {
+		flags string
+		want  string
+	}{
+		{"", "[a1 a2 a3]"},
+		{"-a1=0", "[a2 a3]"},
+		{"-a1=1", "[a1]"},
+		{"-a1", "[a1]"},
+		{"-a1=1 -a3=1", "[a1 a3]"},
+		{"-a1=1 -a3=0", "[a1]"},
+	} {
+		cmd := exec.Command(progname, "-test.run=TestExec")
+		cmd.Env = append(os.Environ(), "ANALYSISFLAGS_CHILD=1", "FLAGS="+test.flags)
+
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("exec failed: %v; output=<<%s>>", err, output)
+		}
+
+		got := strings.TrimSpace(string(output))
+		if got != test.want {
+			t.Errorf("got %s, want %s", got, test.want)
+		}
+	}
+}
+
+func TestJSONTree(t *testing.T) {
+	fset := token.NewFileSet()
+	f := fset.AddFile("a.go", -1, 100)
+	f.SetLines([]int{0, 10, 20})
+	tree := make(analysisflags.JSONTree)
+	tree.Add(fset, "example.com/a", "vulns", []analysis.Diagnostic{
+		{Pos: f.Pos(12), Category: "GO-2022-0001:fmt Println", Message: "vulnerable"},
+	}, nil)
+	tree.Add(fset, "example.com/b", "vulns", nil, errors.New("failed"))
+	tree.Add(fset, "example.com/c", "vulns", nil, nil)
+
+	var buf bytes.Buffer
+	if err := tree.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.Bytes())
+	}
+	if len(got) != 2 {
+		t.Errorf("got results for %d packages, want 2 (no entry without diagnostics):\n%s", len(got), buf.Bytes())
+	}
+	var diags []analysisflags.JSONDiagnostic
+	if err := json.Unmarshal(got["example.com/a"]["vulns"], &diags); err != nil {
+		t.Fatal(err)
+	}
+	want := []analysisflags.JSONDiagnostic{{Category: "GO-2022-0001:fmt Println", Posn: "a.go:2:3", Message: "vulnerable"}}
+	if !reflect.DeepEqual(diags, want) {
+		t.Errorf("diagnostics = %+v, want %+v", diags, want)
+	}
+	var jerr analysisflags.JSONError
+	if err := json.Unmarshal(got["example.com/b"]["vulns"], &jerr); err != nil || jerr.Err != "failed" {
+		t.Errorf("error = %+v, %v; want %q", jerr, err, "failed")
+	}
+}
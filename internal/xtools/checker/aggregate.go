@@ -0,0 +1,101 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package checker
+
+import (
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// aggregate returns the diagnostics of the root actions to print,
+// with the near-duplicates reported by several root packages for the
+// same finding folded together.
+//
+// A package importing a vulnerable package reports the findings it
+// inherits through facts again, with a trace through the symbols the
+// imported package reports. Among the diagnostics of an analyzer with
+// the same category, one whose first frame appears in the trace of
+// the diagnostic of another package is demoted to related information
+// of that diagnostic. What remains is the finding of the package
+// closest to the user code, the importer, with the trace from there.
+//
+// The message of a diagnostic is expected in the form the vulns
+// analyzer reports, "id|frame\tframe...", where the first frame is
+// the symbol of the reporting package. Diagnostics without a
+// category or in other forms are kept as they are.
+func aggregate(roots []*action) map[*action][]analysis.Diagnostic {
+	type entry struct {
+		act    *action
+		diag   *analysis.Diagnostic
+		frames []string
+		demote bool
+	}
+	type key struct {
+		a        *analysis.Analyzer
+		category string
+	}
+	var entries []*entry
+	groups := make(map[key][]*entry)
+	for _, act := range roots {
+		for i := range act.diagnostics {
+			e := &entry{act: act, diag: &act.diagnostics[i]}
+			entries = append(entries, e)
+			d := e.diag
+			if d.Category == "" {
+				continue
+			}
+			_, trace, ok := strings.Cut(d.Message, "|")
+			if !ok || trace == "" {
+				continue
+			}
+			e.frames = strings.Split(trace, "\t")
+			k := key{act.a, d.Category}
+			groups[k] = append(groups[k], e)
+		}
+	}
+
+	// The diagnostics are copied before they get related information,
+	// so that the results of the actions stay as the analyzers
+	// reported them.
+	related := make(map[*analysis.Diagnostic][]analysis.RelatedInformation)
+	for _, g := range groups {
+		for _, e := range g {
+			for _, other := range g {
+				if other.act.pkg == e.act.pkg || !contains(other.frames[1:], e.frames[0]) {
+					continue
+				}
+				e.demote = true
+				related[other.diag] = append(related[other.diag], analysis.RelatedInformation{
+					Pos:     e.diag.Pos,
+					End:     e.diag.End,
+					Message: "also reported in package " + e.act.pkg.PkgPath,
+				})
+			}
+		}
+	}
+
+	res := make(map[*action][]analysis.Diagnostic)
+	for _, e := range entries {
+		if e.demote {
+			continue
+		}
+		d := *e.diag
+		if r := related[e.diag]; len(r) > 0 {
+			d.Related = append(d.Related[:len(d.Related):len(d.Related)], r...)
+		}
+		res[e.act] = append(res[e.act], d)
+	}
+	return res
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
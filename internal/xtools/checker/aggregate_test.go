@@ -0,0 +1,64 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package checker
+
+import (
+	"go/token"
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestAggregate(t *testing.T) {
+	a := &analysis.Analyzer{Name: "vulns"}
+	other := &analysis.Analyzer{Name: "other"}
+	mk := func(a *analysis.Analyzer, path string, diags ...analysis.Diagnostic) *action {
+		return &action{a: a, pkg: &packages.Package{PkgPath: path}, isroot: true, diagnostics: diags}
+	}
+	diag := func(pos token.Pos, category, message string) analysis.Diagnostic {
+		return analysis.Diagnostic{Pos: pos, Category: category, Message: message}
+	}
+
+	const (
+		vuln = "GO-2022-0001:example.com/lib"
+		lib  = "example.com/lib.F lib.go:3:6"
+		mid  = "example.com/mid.G mid.go:5:6"
+		app  = "example.com/app.main main.go:7:6"
+	)
+	libAct := mk(a, "example.com/lib", diag(1, vuln, "GO-2022-0001|"+lib))
+	midAct := mk(a, "example.com/mid", diag(2, vuln, "GO-2022-0001|"+mid+"\t"+lib))
+	appAct := mk(a, "example.com/app",
+		diag(3, vuln, "GO-2022-0001|"+app+"\t"+mid+"\t"+lib),
+		diag(4, "GO-2022-0002:example.com/lib", "GO-2022-0002|"+app+"\t"+lib),
+		diag(5, "", "unrelated"))
+	// The same finding of another analyzer is not folded.
+	otherAct := mk(other, "example.com/lib", diag(1, vuln, "GO-2022-0001|"+lib))
+
+	got := aggregate([]*action{libAct, midAct, appAct, otherAct})
+
+	if len(got[libAct]) != 0 || len(got[midAct]) != 0 {
+		t.Errorf("aggregate kept the diagnostics of the imported packages: lib %v, mid %v", got[libAct], got[midAct])
+	}
+	want := []analysis.Diagnostic{
+		{Pos: 3, Category: vuln, Message: "GO-2022-0001|" + app + "\t" + mid + "\t" + lib,
+			Related: []analysis.RelatedInformation{
+				{Pos: 1, Message: "also reported in package example.com/lib"},
+				{Pos: 2, Message: "also reported in package example.com/mid"},
+			}},
+		appAct.diagnostics[1],
+		appAct.diagnostics[2],
+	}
+	if !reflect.DeepEqual(got[appAct], want) {
+		t.Errorf("aggregate(app) = %+v\nwant %+v", got[appAct], want)
+	}
+	if !reflect.DeepEqual(got[otherAct], otherAct.diagnostics) {
+		t.Errorf("aggregate(other) = %+v, want %+v", got[otherAct], otherAct.diagnostics)
+	}
+	if len(appAct.diagnostics[0].Related) != 0 {
+		t.Errorf("aggregate modified the diagnostics of the action: %+v", appAct.diagnostics[0])
+	}
+}
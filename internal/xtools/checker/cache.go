@@ -0,0 +1,363 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package checker
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"go/token"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/types/objectpath"
+)
+
+// The on-disk cache of analysis results (see CacheDir) stores, for
+// each action, the diagnostics and the facts it produced, keyed by a
+// hash of everything they derive from: the analyzer, its flags, the
+// source files of the package, and the keys of the actions on its
+// dependencies. A later run finding the key in the cache restores
+// them instead of running the analyzer on the package, which only
+// requires the facts of the dependencies, themselves often restored.
+//
+// Only analyzers without results are cached, as results are not
+// serializable, and only actions whose facts are on package-level
+// objects and whose diagnostics are in the files of the package,
+// without suggested fixes.
+
+// cacheVersion is the version of the encoding of the cache entries.
+const cacheVersion = 1
+
+// A cacheEntry is the cached outcome of an action.
+type cacheEntry struct {
+	ObjectFacts  []cachedFact
+	PackageFacts []cachedFact
+	Diagnostics  []cachedDiagnostic
+}
+
+// A cachedFact is a gob-encoded fact of the type with the index in
+// the FactTypes of the analyzer, on the object with the path, if any.
+type cachedFact struct {
+	Path string
+	Type int
+	Data []byte
+}
+
+// A cachedDiagnostic is a diagnostic whose positions are recorded
+// as offsets in the file. End is -1 if the diagnostic has none.
+type cachedDiagnostic struct {
+	File     string
+	Pos, End int
+	Category string
+	Message  string
+}
+
+var (
+	executableOnce sync.Once
+	executableHash string // empty if the executable cannot be read
+)
+
+// executableID returns the hash of the running executable, so that
+// a new version of the analyzers invalidates the cache.
+func executableID() string {
+	executableOnce.Do(func() {
+		exe, err := os.Executable()
+		if err != nil {
+			return
+		}
+		f, err := os.Open(exe)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+		h := sha256.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return
+		}
+		executableHash = hex.EncodeToString(h.Sum(nil))
+	})
+	return executableHash
+}
+
+// analyzerID returns the hash of the analyzer and of the values of its
+// flags. Flags naming files, such as the catalog of the vulns analyzer,
+// which each run writes to a new temporary file, contribute the content
+// of the files instead of their names.
+func analyzerID(a *analysis.Analyzer) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "analyzer %s\n", a.Name)
+	a.Flags.VisitAll(func(f *flag.Flag) {
+		v := f.Value.String()
+		if fi, err := os.Stat(v); err == nil && fi.Mode().IsRegular() {
+			if data, err := os.ReadFile(v); err == nil {
+				fmt.Fprintf(h, "flag %s file %x\n", f.Name, sha256.Sum256(data))
+				return
+			}
+		}
+		fmt.Fprintf(h, "flag %s %q\n", f.Name, v)
+	})
+	for _, req := range a.Requires {
+		fmt.Fprintf(h, "requires %s\n", analyzerID(req))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cacheKey returns the key of the action in the cache, or the empty
+// string if the action is not cacheable. analyzerIDs memoizes
+// analyzerID.
+func (act *action) cacheKey(analyzerIDs func(*analysis.Analyzer) string) string {
+	act.keyOnce.Do(func() {
+		if act.a.ResultType != nil || executableID() == "" {
+			return
+		}
+		h := sha256.New()
+		fmt.Fprintf(h, "cache %d\nexecutable %s\n", cacheVersion, executableID())
+		fmt.Fprintf(h, "analyzer %s\n", analyzerIDs(act.a))
+		fmt.Fprintf(h, "GOOS=%s GOARCH=%s GOFLAGS=%s\n", os.Getenv("GOOS"), os.Getenv("GOARCH"), os.Getenv("GOFLAGS"))
+		pkg := act.pkg
+		fmt.Fprintf(h, "package %s %s illtyped=%v sizes=%v\n", pkg.ID, pkg.PkgPath, pkg.IllTyped, pkg.TypesSizes)
+		for _, files := range [][]string{pkg.CompiledGoFiles, pkg.OtherFiles} {
+			for _, file := range files {
+				data, err := os.ReadFile(file)
+				if err != nil {
+					return
+				}
+				fmt.Fprintf(h, "file %s %x\n", file, sha256.Sum256(data))
+			}
+		}
+		for _, dep := range act.deps {
+			if dep.pkg == act.pkg {
+				continue // the required analyzers are covered by analyzerID.
+			}
+			k := dep.cacheKey(analyzerIDs)
+			if k == "" {
+				return
+			}
+			fmt.Fprintf(h, "dep %s\n", k)
+		}
+		act.key = hex.EncodeToString(h.Sum(nil))
+	})
+	return act.key
+}
+
+// cacheFile returns the file of the cache entry with the key.
+func cacheFile(key string) string {
+	return filepath.Join(CacheDir, key[:2], key)
+}
+
+// readCache returns the cache entry of the action, or nil if there is none.
+func (act *action) readCache() *cacheEntry {
+	if act.key == "" {
+		return nil
+	}
+	data, err := os.ReadFile(cacheFile(act.key))
+	if err != nil {
+		return nil
+	}
+	entry := new(cacheEntry)
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(entry); err != nil {
+		if dbg('v') {
+			log.Printf("%s: ignoring invalid cache entry: %v", act, err)
+		}
+		return nil
+	}
+	return entry
+}
+
+// restore applies the cache entry to the action, whose dependencies
+// have run and whose inherited facts are set, and reports whether it
+// succeeded. On failure, the action must run.
+func (act *action) restore(entry *cacheEntry) bool {
+	objectFacts := make(map[objectFactKey]analysis.Fact)
+	for _, cf := range entry.ObjectFacts {
+		obj, err := objectpath.Object(act.pkg.Types, objectpath.Path(cf.Path))
+		if err != nil {
+			return false
+		}
+		fact, ok := act.decodeFact(cf)
+		if !ok {
+			return false
+		}
+		objectFacts[objectFactKey{obj, factType(fact)}] = fact
+	}
+	packageFacts := make(map[packageFactKey]analysis.Fact)
+	for _, cf := range entry.PackageFacts {
+		fact, ok := act.decodeFact(cf)
+		if !ok {
+			return false
+		}
+		packageFacts[packageFactKey{act.pkg.Types, factType(fact)}] = fact
+	}
+	files := make(map[string]*token.File)
+	for _, f := range act.pkg.Syntax {
+		if tf := act.pkg.Fset.File(f.Pos()); tf != nil {
+			files[tf.Name()] = tf
+		}
+	}
+	var diags []analysis.Diagnostic
+	for _, cd := range entry.Diagnostics {
+		tf := files[cd.File]
+		if tf == nil || cd.Pos > tf.Size() || cd.End > tf.Size() {
+			return false
+		}
+		d := analysis.Diagnostic{Pos: tf.Pos(cd.Pos), Category: cd.Category, Message: cd.Message}
+		if cd.End >= 0 {
+			d.End = tf.Pos(cd.End)
+		}
+		diags = append(diags, d)
+	}
+
+	for k, f := range objectFacts {
+		act.objectFacts[k] = f
+	}
+	for k, f := range packageFacts {
+		act.packageFacts[k] = f
+	}
+	act.diagnostics = diags
+	return true
+}
+
+func (act *action) decodeFact(cf cachedFact) (analysis.Fact, bool) {
+	if cf.Type < 0 || cf.Type >= len(act.a.FactTypes) {
+		return nil, false
+	}
+	t := reflect.TypeOf(act.a.FactTypes[cf.Type])
+	fact := reflect.New(t.Elem()).Interface().(analysis.Fact)
+	if err := gob.NewDecoder(bytes.NewReader(cf.Data)).Decode(fact); err != nil {
+		return nil, false
+	}
+	return fact, true
+}
+
+// writeCache stores the facts and the diagnostics of the action,
+// which ran successfully, in the cache. Caching is best effort;
+// errors are ignored.
+func (act *action) writeCache() {
+	if act.key == "" {
+		return
+	}
+	entry, ok := act.cacheEntry()
+	if !ok {
+		return
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(entry); err != nil {
+		return
+	}
+	file := cacheFile(act.key)
+	if err := os.MkdirAll(filepath.Dir(file), 0777); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), "tmp-")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(buf.Bytes())
+	if err2 := tmp.Close(); err == nil {
+		err = err2
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), file)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		if dbg('v') {
+			log.Printf("%s: failed to write the cache entry: %v", act, err)
+		}
+	}
+}
+
+// cacheEntry returns the cache entry of the action, and false if the
+// facts or the diagnostics cannot be cached.
+func (act *action) cacheEntry() (*cacheEntry, bool) {
+	entry := new(cacheEntry)
+	for k, fact := range act.objectFacts {
+		if k.obj.Pkg() != act.pkg.Types {
+			continue // inherited
+		}
+		path, err := objectpath.For(k.obj)
+		if err != nil {
+			return nil, false
+		}
+		cf, ok := act.encodeFact(fact)
+		if !ok {
+			return nil, false
+		}
+		cf.Path = string(path)
+		entry.ObjectFacts = append(entry.ObjectFacts, cf)
+	}
+	for k, fact := range act.packageFacts {
+		if k.pkg != act.pkg.Types {
+			continue // inherited
+		}
+		cf, ok := act.encodeFact(fact)
+		if !ok {
+			return nil, false
+		}
+		entry.PackageFacts = append(entry.PackageFacts, cf)
+	}
+	sort.Slice(entry.ObjectFacts, func(i, j int) bool {
+		x, y := entry.ObjectFacts[i], entry.ObjectFacts[j]
+		if x.Path != y.Path {
+			return x.Path < y.Path
+		}
+		return x.Type < y.Type
+	})
+	sort.Slice(entry.PackageFacts, func(i, j int) bool { return entry.PackageFacts[i].Type < entry.PackageFacts[j].Type })
+
+	for _, d := range act.diagnostics {
+		if len(d.SuggestedFixes) > 0 || len(d.Related) > 0 {
+			return nil, false
+		}
+		tf := act.pkg.Fset.File(d.Pos)
+		if tf == nil || !act.ownsFile(tf) {
+			return nil, false
+		}
+		cd := cachedDiagnostic{File: tf.Name(), Pos: tf.Offset(d.Pos), End: -1, Category: d.Category, Message: d.Message}
+		if d.End.IsValid() {
+			if act.pkg.Fset.File(d.End) != tf {
+				return nil, false
+			}
+			cd.End = tf.Offset(d.End)
+		}
+		entry.Diagnostics = append(entry.Diagnostics, cd)
+	}
+	return entry, true
+}
+
+// ownsFile reports whether the file is one of the parsed files of the package.
+func (act *action) ownsFile(tf *token.File) bool {
+	for _, f := range act.pkg.Syntax {
+		if act.pkg.Fset.File(f.Pos()) == tf {
+			return true
+		}
+	}
+	return false
+}
+
+func (act *action) encodeFact(fact analysis.Fact) (cachedFact, bool) {
+	t := reflect.TypeOf(fact)
+	for i, ft := range act.a.FactTypes {
+		if reflect.TypeOf(ft) != t {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(fact); err != nil {
+			return cachedFact{}, false
+		}
+		return cachedFact{Type: i, Data: buf.Bytes()}, true
+	}
+	return cachedFact{}, false
+}
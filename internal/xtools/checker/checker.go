@@ -30,7 +30,7 @@ import (
 	"sync"
 	"time"
 
-	"github.com/hyangah/vulns/internal/analysisflags"
+	"github.com/hyangah/vulns/internal/xtools/analysisflags"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/packages"
 )
@@ -54,6 +54,15 @@ var (
 
 	// Fix determines whether to apply all suggested fixes.
 	Fix bool
+
+	// Concurrency limits the number of actions, that is, analyzers
+	// applied to packages, that run at once. Zero means no limit.
+	Concurrency int
+
+	// CacheDir, if set, is the directory of the on-disk cache of the
+	// diagnostics and facts of the actions, reused by later runs on
+	// unchanged packages instead of running the analyzers again.
+	CacheDir string
 )
 
 // RegisterFlags registers command-line flags used by the analysis driver.
@@ -218,12 +227,12 @@ func TestAnalyzer(a *analysis.Analyzer, pkgs []*packages.Package) []*TestAnalyze
 	for _, act := range analyze(pkgs, []*analysis.Analyzer{a}) {
 		facts := make(map[types.Object][]analysis.Fact)
 		for key, fact := range act.objectFacts {
-			if key.obj.Pkg() == act.pass.Pkg {
+			if key.obj.Pkg() == act.pkg.Types {
 				facts[key.obj] = append(facts[key.obj], fact)
 			}
 		}
 		for key, fact := range act.packageFacts {
-			if key.pkg == act.pass.Pkg {
+			if key.pkg == act.pkg.Types {
 				facts[nil] = append(facts[nil], fact)
 			}
 		}
@@ -247,6 +256,11 @@ func analyze(pkgs []*packages.Package, analyzers []*analysis.Analyzer) []*action
 		log.Printf("building graph of analysis passes")
 	}
 
+	var sem chan struct{} // limits the running actions
+	if Concurrency > 0 {
+		sem = make(chan struct{}, Concurrency)
+	}
+
 	// Each graph node (action) is one unit of analysis.
 	// Edges express package-to-package (vertical) dependencies,
 	// and analysis-to-analysis (horizontal) dependencies.
@@ -261,7 +275,7 @@ func analyze(pkgs []*packages.Package, analyzers []*analysis.Analyzer) []*action
 		k := key{a, pkg}
 		act, ok := actions[k]
 		if !ok {
-			act = &action{a: a, pkg: pkg}
+			act = &action{a: a, pkg: pkg, sem: sem}
 
 			// Add a dependency on each required analyzers.
 			for _, req := range a.Requires {
@@ -297,6 +311,19 @@ func analyze(pkgs []*packages.Package, analyzers []*analysis.Analyzer) []*action
 		}
 	}
 
+	if CacheDir != "" {
+		ids := make(map[*analysis.Analyzer]string)
+		analyzerIDs := func(a *analysis.Analyzer) string {
+			if _, ok := ids[a]; !ok {
+				ids[a] = analyzerID(a)
+			}
+			return ids[a]
+		}
+		for _, act := range actions {
+			act.cacheKey(analyzerIDs)
+		}
+	}
+
 	// Execute the graph in parallel.
 	execAll(roots)
 
@@ -434,7 +461,8 @@ func applyFixes(roots []*action) {
 
 // printDiagnostics prints the diagnostics for the root packages in either
 // plain text or JSON format. JSON format also includes errors for any
-// dependencies.
+// dependencies. The diagnostics several root packages report for the
+// same finding are aggregated, see aggregate.
 //
 // It returns the exitcode: in plain mode, 0 for success, 1 for analysis
 // errors, and 3 for diagnostics. We avoid 2 since the flag package uses
@@ -446,6 +474,7 @@ func printDiagnostics(roots []*action) (exitcode int) {
 	// Print diagnostics only for root packages,
 	// but errors for all packages.
 	printed := make(map[*action]bool)
+	diagnostics := aggregate(roots)
 	var print func(*action)
 	var visitAll func(actions []*action)
 	visitAll = func(actions []*action) {
@@ -464,7 +493,7 @@ func printDiagnostics(roots []*action) (exitcode int) {
 		print = func(act *action) {
 			var diags []analysis.Diagnostic
 			if act.isroot {
-				diags = act.diagnostics
+				diags = diagnostics[act]
 			}
 			tree.Add(act.pkg.Fset, act.pkg.ID, act.a.Name, diags, act.err)
 		}
@@ -491,7 +520,7 @@ func printDiagnostics(roots []*action) (exitcode int) {
 				return
 			}
 			if act.isroot {
-				for _, diag := range act.diagnostics {
+				for _, diag := range diagnostics[act] {
 					// We don't display a.Name/f.Category
 					// as most users don't care.
 
@@ -573,6 +602,9 @@ type action struct {
 	pkg          *packages.Package
 	pass         *analysis.Pass
 	isroot       bool
+	sem          chan struct{} // if non-nil, held while running
+	keyOnce      sync.Once
+	key          string // the key in the cache (see CacheDir), if cacheable
 	deps         []*action
 	objectFacts  map[objectFactKey]analysis.Fact
 	packageFacts map[packageFactKey]analysis.Fact
@@ -617,8 +649,27 @@ func execAll(actions []*action) {
 func (act *action) exec() { act.once.Do(act.execOnce) }
 
 func (act *action) execOnce() {
-	// Analyze dependencies.
-	execAll(act.deps)
+	// Analyze dependencies. Restoring the outcome of the action from
+	// the cache only needs the facts of the other packages.
+	cached := act.readCache()
+	if cached != nil {
+		var deps []*action
+		for _, dep := range act.deps {
+			if dep.pkg != act.pkg {
+				deps = append(deps, dep)
+			}
+		}
+		execAll(deps)
+	} else {
+		execAll(act.deps)
+	}
+
+	// Hold a slot only after the dependencies are done,
+	// so that waiting actions do not starve them.
+	if act.sem != nil {
+		act.sem <- struct{}{}
+		defer func() { <-act.sem }()
+	}
 
 	// TODO(adonovan): uncomment this during profiling.
 	// It won't build pre-go1.11 but conditional compilation
@@ -671,6 +722,23 @@ func (act *action) execOnce() {
 		}
 	}
 
+	if cached != nil {
+		if act.restore(cached) {
+			return
+		}
+		// Run the analysis after all.
+		execAll(act.deps)
+		for _, dep := range act.deps {
+			if dep.pkg == act.pkg {
+				if dep.err != nil {
+					act.err = fmt.Errorf("failed prerequisites: %s", dep)
+					return
+				}
+				inputs[dep.a] = dep.result
+			}
+		}
+	}
+
 	// Run the analysis.
 	pass := &analysis.Pass{
 		Analyzer:          act.a,
@@ -745,6 +813,9 @@ func (act *action) execOnce() {
 		}
 	}
 	act.err = err
+	if err == nil {
+		act.writeCache()
+	}
 
 	// disallow calls after Run
 	pass.ExportObjectFact = nil
@@ -11,8 +11,8 @@ import (
 	"path/filepath"
 	"testing"
 
-	"github.com/hyangah/vulns/internal/checker"
-	"github.com/hyangah/vulns/internal/testenv"
+	"github.com/hyangah/vulns/internal/xtools/checker"
+	"github.com/hyangah/vulns/internal/xtools/testenv"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/analysistest"
 	"golang.org/x/tools/go/analysis/passes/inspect"
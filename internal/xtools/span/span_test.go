@@ -11,7 +11,7 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/hyangah/vulns/internal/span"
+	"github.com/hyangah/vulns/internal/xtools/span"
 )
 
 var (
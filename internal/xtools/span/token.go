@@ -8,7 +8,7 @@ import (
 	"fmt"
 	"go/token"
 
-	"github.com/hyangah/vulns/internal/bug"
+	"github.com/hyangah/vulns/internal/xtools/bug"
 )
 
 // Range represents a source code range in token.Pos form.
@@ -10,7 +10,7 @@ import (
 	"path"
 	"testing"
 
-	"github.com/hyangah/vulns/internal/span"
+	"github.com/hyangah/vulns/internal/xtools/span"
 )
 
 var testdata = []struct {
@@ -10,7 +10,7 @@ package span_test
 import (
 	"testing"
 
-	"github.com/hyangah/vulns/internal/span"
+	"github.com/hyangah/vulns/internal/xtools/span"
 )
 
 // TestURI tests the conversion between URIs and filenames. The test cases
@@ -8,7 +8,7 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/hyangah/vulns/internal/span"
+	"github.com/hyangah/vulns/internal/xtools/span"
 )
 
 // The funny character below is 4 bytes long in UTF-8; two UTF-16 code points
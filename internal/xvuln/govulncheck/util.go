@@ -8,7 +8,7 @@ import (
 	"fmt"
 	"strings"
 
-	isem "github.com/hyangah/vulns/internal/semver"
+	isem "github.com/hyangah/vulns/internal/xvuln/semver"
 	"golang.org/x/mod/semver"
 	"golang.org/x/vuln/osv"
 	"golang.org/x/vuln/vulncheck"
@@ -0,0 +1,46 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// WriteBaseline writes the findings of summary as a baseline file,
+// a JSON array of objects with the ID, PackagePath, and Symbol of
+// each finding. Traces are left out, so that the baseline keeps
+// matching when unrelated changes alter them.
+func WriteBaseline(w io.Writer, summary map[Key]Value) error {
+	rules := []IgnoreRule{}
+	for _, f := range Findings(summary) {
+		rules = append(rules, IgnoreRule{ID: f.ID, PackagePath: f.PackagePath, Symbol: f.Symbol})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rules)
+}
+
+// ReadBaseline reads a baseline file and returns the rules
+// suppressing its findings (see Ignore). Besides the files written
+// by WriteBaseline, it accepts the JSON output of a previous scan.
+func ReadBaseline(path string) ([]IgnoreRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules []IgnoreRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("invalid baseline file %s: %v", path, err)
+	}
+	for i, r := range rules {
+		if r.ID == "" || r.PackagePath == "" || r.Symbol == "" {
+			return nil, fmt.Errorf("invalid baseline file %s: entry #%d lacks ID, PackagePath, or Symbol", path, i+1)
+		}
+	}
+	return rules, nil
+}
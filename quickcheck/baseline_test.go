@@ -0,0 +1,50 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBaseline(t *testing.T) {
+	old := map[Key]Value{
+		{ID: "GO-2022-0001", PackagePath: "example.com/m/p", Symbol: "F", ModulePath: "example.com/m"}: {Trace: []string{"work.A /work/a.go:3:1"}},
+	}
+	var buf bytes.Buffer
+	if err := WriteBaseline(&buf, old); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	if err := os.WriteFile(path, buf.Bytes(), 0666); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := ReadBaseline(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	accepted := Key{ID: "GO-2022-0001", PackagePath: "example.com/m/p", Symbol: "F", ModulePath: "example.com/m"}
+	otherSymbol := Key{ID: "GO-2022-0001", PackagePath: "example.com/m/p", Symbol: "G", ModulePath: "example.com/m"}
+	current := map[Key]Value{
+		accepted:    {Trace: []string{"work.B /work/b.go:10:1"}}, // trace changed
+		otherSymbol: {Trace: []string{"work.B /work/b.go:12:1"}},
+	}
+	got := Ignore(current, rules)
+	if _, ok := got[accepted]; ok || len(got) != 1 {
+		t.Errorf("findings after baseline = %v, want only %v", got, otherSymbol)
+	}
+
+	// A baseline entry without a symbol would suppress
+	// the whole vulnerability, so it is rejected.
+	if err := os.WriteFile(path, []byte(`[{"ID": "GO-2022-0001"}]`), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadBaseline(path); err == nil {
+		t.Errorf("ReadBaseline accepted an entry without a symbol")
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import (
+	"context"
+	"debug/buildinfo"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hyangah/vulns/stdlib"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/vuln/client"
+	"golang.org/x/vuln/vulncheck"
+)
+
+// A BinaryResult is the result of scanning a Go binary.
+type BinaryResult struct {
+	Path string
+	// Findings lists the vulnerable symbols present in the binary,
+	// sorted by ID, package path, and symbol.
+	Findings []Key
+	// Err is the error that prevented scanning the binary, if any.
+	Err error `json:"-"`
+}
+
+// ScanBinary reports the vulnerable symbols present in the Go binary.
+// Unlike Analyze, it does not compute reachability; it relies on the
+// symbol table of the binary, which includes only reachable code.
+func ScanBinary(ctx context.Context, path string, dbClient client.Client) ([]Key, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	res, err := vulncheck.Binary(ctx, f, &vulncheck.Config{Client: dbClient})
+	if err != nil {
+		return nil, err
+	}
+	seen := map[Key]bool{}
+	var keys []Key
+	for _, v := range res.Vulns {
+		k := Key{ID: v.OSV.ID, Symbol: v.Symbol, PackagePath: v.PkgPath, ModulePath: v.ModPath}
+		if !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		ki, kj := keys[i], keys[j]
+		if ki.ID != kj.ID {
+			return ki.ID < kj.ID
+		}
+		if ki.PackagePath != kj.PackagePath {
+			return ki.PackagePath < kj.PackagePath
+		}
+		return ki.Symbol < kj.Symbol
+	})
+	return keys, nil
+}
+
+// ScanDir scans every Go binary in the directory tree rooted at root.
+// Files that are not Go binaries are skipped. A failure to scan a
+// binary, or to read a file or a directory of the tree, is recorded
+// in the result of its path rather than aborting the walk. Only an
+// unreadable root is an error. The results are in lexical order of
+// the paths.
+func ScanDir(ctx context.Context, root string, dbClient client.Client) ([]*BinaryResult, error) {
+	var results []*BinaryResult
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if d == nil { // the root
+				return err
+			}
+			results = append(results, &BinaryResult{Path: path, Err: err})
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			results = append(results, &BinaryResult{Path: path, Err: err})
+			return nil
+		}
+		f.Close()
+		if !isGoBinary(path) {
+			return nil
+		}
+		r := &BinaryResult{Path: path}
+		r.Findings, r.Err = ScanBinary(ctx, path, dbClient)
+		results = append(results, r)
+		return ctx.Err()
+	})
+	return results, err
+}
+
+// BinaryModules returns the build list recorded in the Go binary,
+// including the standard library at the Go version the binary was
+// built with, for use with AnalyzeModules.
+func BinaryModules(path string) ([]*packages.Module, error) {
+	bi, err := buildinfo.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	mods := []*packages.Module{
+		{Path: bi.Main.Path, Version: bi.Main.Version, Main: true},
+		stdlib.Module(bi.GoVersion),
+	}
+	for _, d := range bi.Deps {
+		m := &packages.Module{Path: d.Path, Version: d.Version}
+		if r := d.Replace; r != nil {
+			m.Replace = &packages.Module{Path: r.Path, Version: r.Version}
+		}
+		mods = append(mods, m)
+	}
+	return mods, nil
+}
+
+// BinaryPlatform returns the GOOS and GOARCH
+// the Go binary was built for.
+func BinaryPlatform(path string) (goos, goarch string, err error) {
+	bi, err := buildinfo.ReadFile(path)
+	if err != nil {
+		return "", "", err
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "GOOS":
+			goos = s.Value
+		case "GOARCH":
+			goarch = s.Value
+		}
+	}
+	if goos == "" || goarch == "" {
+		return "", "", fmt.Errorf("%s: no GOOS/GOARCH in the build information", path)
+	}
+	return goos, goarch, nil
+}
+
+// isGoBinary reports whether the file is an executable built by Go.
+func isGoBinary(path string) bool {
+	_, err := buildinfo.ReadFile(path)
+	return err == nil
+}
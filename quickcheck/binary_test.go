@@ -0,0 +1,142 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/hyangah/vulns/stdlib"
+	"github.com/hyangah/vulns/testutils"
+	"golang.org/x/vuln/client"
+)
+
+func TestScanDir(t *testing.T) {
+	ctx := context.Background()
+	db, err := testutils.NewDatabase(ctx, []byte(`
+-- GO-2020-0001.yaml --
+modules:
+  - module: example.com/m
+    versions:
+      - fixed: 1.1.0
+    packages:
+      - package: example.com/m/p
+description: |
+    Something.
+published: 2021-04-14T20:04:52Z
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Clean()
+	cli, err := client.NewClient([]string{db.URI()}, client.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The test binary itself is a Go binary.
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skip(err)
+	}
+	data, err := os.ReadFile(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	bin := filepath.Join(root, "sub", "prog")
+	if err := os.MkdirAll(filepath.Dir(bin), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(bin, data, 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "README"), []byte("not a binary"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := ScanDir(ctx, root, cli)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Path != bin {
+		t.Fatalf("ScanDir found %v, want only %s", results, bin)
+	}
+	if len(results[0].Findings) != 0 {
+		t.Errorf("unexpected findings: %v", results[0].Findings)
+	}
+
+	// Unreadable files and directories are reported,
+	// and the walk goes on.
+	if os.Getuid() == 0 {
+		t.Skip("permissions are not enforced for root")
+	}
+	locked := filepath.Join(root, "a-locked")
+	if err := os.Mkdir(locked, 0000); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(locked, 0777)
+	secret := filepath.Join(root, "secret")
+	if err := os.WriteFile(secret, data, 0000); err != nil {
+		t.Fatal(err)
+	}
+	results, err = ScanDir(ctx, root, cli)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, r := range results {
+		paths = append(paths, r.Path)
+		if r.Path != bin && r.Err == nil {
+			t.Errorf("result of unreadable %s has no error", r.Path)
+		}
+	}
+	if want := []string{locked, secret, bin}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("ScanDir found %v, want %v", paths, want)
+	}
+	if _, err := ScanDir(ctx, filepath.Join(root, "missing"), cli); err == nil {
+		t.Error("ScanDir succeeded with a missing root")
+	}
+}
+
+func TestBinaryModules(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skip(err)
+	}
+	mods, err := BinaryModules(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := map[string]string{}
+	for _, m := range mods {
+		found[m.Path] = m.Version
+	}
+	if v, want := found[stdlib.ModulePath], stdlib.GoTagToSemver(runtime.Version()); v != want {
+		t.Errorf("stdlib version = %q, want %q", v, want)
+	}
+	// The test binary links this package's dependencies.
+	if v := found["golang.org/x/vuln"]; v == "" {
+		t.Errorf("golang.org/x/vuln not found in %v", found)
+	}
+}
+
+func TestBinaryPlatform(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skip(err)
+	}
+	goos, goarch, err := BinaryPlatform(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if goos != runtime.GOOS || goarch != runtime.GOARCH {
+		t.Errorf("BinaryPlatform = %s/%s, want %s/%s", goos, goarch, runtime.GOOS, runtime.GOARCH)
+	}
+}
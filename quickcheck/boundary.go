@@ -0,0 +1,84 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import (
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// A Boundary is a list of module path prefixes that identify
+// first-party code ("my code"). Packages outside the boundary
+// are dependencies.
+type Boundary []string
+
+// ParseBoundary parses a comma-separated list of module path prefixes.
+func ParseBoundary(s string) Boundary {
+	var b Boundary
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			b = append(b, p)
+		}
+	}
+	return b
+}
+
+// MainModules returns the boundary consisting of
+// the main modules of the packages.
+func MainModules(pkgs []*packages.Package) Boundary {
+	var b Boundary
+	seen := map[string]bool{}
+	for _, p := range pkgs {
+		if m := p.Module; m != nil && m.Main && !seen[m.Path] {
+			seen[m.Path] = true
+			b = append(b, m.Path)
+		}
+	}
+	return b
+}
+
+// Contains reports whether the package belongs to first-party code.
+// An empty boundary contains nothing.
+func (b Boundary) Contains(pkgpath string) bool {
+	for _, prefix := range b {
+		if pkgpath == prefix || strings.HasPrefix(pkgpath, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Split splits a trace into the leading frames in first-party code
+// and the remaining frames in dependencies.
+func (b Boundary) Split(trace []string) (local, deps []string) {
+	i := 0
+	for i < len(trace) && b.Contains(FramePackage(trace[i])) {
+		i++
+	}
+	return trace[:i], trace[i:]
+}
+
+// EntryPackage returns the package of the first frame of the trace
+// that is in first-party code, or the empty string if there is none.
+func (b Boundary) EntryPackage(trace []string) string {
+	for _, fr := range trace {
+		if pkg := FramePackage(fr); b.Contains(pkg) {
+			return pkg
+		}
+	}
+	return ""
+}
+
+// FramePackage returns the package path of a trace frame
+// of the form "pkgpath.Symbol file:line:col" or "pkgpath".
+func FramePackage(frame string) string {
+	name, _, _ := strings.Cut(frame, " ")
+	sym, ok := ParseSymbolID(name)
+	if !ok {
+		return name // import path without symbol
+	}
+	return sym.PkgPath
+}
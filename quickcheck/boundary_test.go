@@ -0,0 +1,33 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBoundary(t *testing.T) {
+	b := ParseBoundary("example.com/app, example.com/lib")
+	trace := []string{
+		"example.com/app/cmd.main /app/cmd/main.go:10:6",
+		"example.com/lib.Load /lib/load.go:3:6",
+		"golang.org/x/text/language.Parse /mod/language.go:12:6",
+		"fmt.Sprintf /goroot/fmt/print.go:1:1",
+	}
+	local, deps := b.Split(trace)
+	if !reflect.DeepEqual(local, trace[:2]) || !reflect.DeepEqual(deps, trace[2:]) {
+		t.Errorf("Split = %q, %q", local, deps)
+	}
+	if got, want := b.EntryPackage(trace), "example.com/app/cmd"; got != want {
+		t.Errorf("EntryPackage = %q, want %q", got, want)
+	}
+	if b.Contains("example.com/application") {
+		t.Errorf("Contains matched a path sharing only a string prefix")
+	}
+	if got := Boundary(nil).EntryPackage(trace); got != "" {
+		t.Errorf("EntryPackage with empty boundary = %q, want empty", got)
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build/constraint"
+	"go/token"
+	"strings"
+
+	vulnsanalysis "github.com/hyangah/vulns/analysis"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/vuln/osv"
+)
+
+// Attributes describing the static context of a finding.
+// They hint at how likely the vulnerable symbol is exercised
+// in production builds.
+const (
+	// AttrBuildConstrained marks findings whose every occurrence
+	// starts in a file guarded by a build constraint.
+	AttrBuildConstrained = "build-constrained"
+	// AttrTestOnly marks findings whose every occurrence
+	// starts in a _test.go file.
+	AttrTestOnly = "test-only"
+	// AttrTestPackagesOnly marks findings only reachable
+	// from test packages.
+	AttrTestPackagesOnly = "test-packages-only"
+	// AttrTypeUsage marks findings whose every occurrence reaches
+	// the vulnerable method only through the use of its type, e.g.
+	// a composite literal, so the method may not be called at all
+	// (see analysis.ViaType). They are of lower confidence, but are
+	// reported since the method is often called through an interface
+	// the analysis cannot track.
+	AttrTypeUsage = "type-usage"
+	// AttrLinkname marks findings whose every occurrence reaches
+	// the vulnerable symbol through a go:linkname directive or
+	// assembly (see analysis.ViaLinkname). The reference graph does
+	// not see these references, so they are detected on a best
+	// effort basis and are of low confidence.
+	AttrLinkname = "linkname"
+	// AttrRenamedReceiver marks findings of methods that the entry
+	// lists under another receiver type, matched by package and
+	// method name because the listed type no longer exists, e.g. it
+	// was renamed (see analysis.RenamedReceiver). They are of lower
+	// confidence, as another type may have had the method.
+	AttrRenamedReceiver = "renamed-receiver"
+)
+
+// Attrs returns the list of known finding attributes.
+func Attrs() []string {
+	return []string{AttrBuildConstrained, AttrTestOnly, AttrTestPackagesOnly, AttrTypeUsage, AttrLinkname, AttrRenamedReceiver, AttrDirect, AttrIndirect}
+}
+
+// ParseAttrs parses a comma-separated list of finding attributes.
+func ParseAttrs(s string) ([]string, error) {
+	var attrs []string
+next:
+	for _, a := range strings.Split(s, ",") {
+		if a = strings.TrimSpace(a); a == "" {
+			continue
+		}
+		for _, known := range Attrs() {
+			if a == known {
+				attrs = append(attrs, a)
+				continue next
+			}
+		}
+		return nil, fmt.Errorf("unknown finding attribute %q", a)
+	}
+	return attrs, nil
+}
+
+// callContext accumulates the static context of the occurrences
+// of a finding. Each field holds only if it holds for all of them.
+type callContext struct {
+	buildConstrained bool
+	testOnly         bool
+	testPackagesOnly bool
+	typeUsage        bool
+	linkname         bool
+	renamedReceiver  bool
+}
+
+// occurrenceContext computes the context of a diagnostic
+// reported at pos in pkg.
+func occurrenceContext(pkg *packages.Package, pos token.Pos) callContext {
+	c := callContext{testPackagesOnly: isTestPackage(pkg)}
+	f := pkg.Fset.File(pos)
+	if f == nil {
+		return c
+	}
+	c.testOnly = strings.HasSuffix(f.Name(), "_test.go")
+	for _, file := range pkg.Syntax {
+		if pkg.Fset.File(file.Pos()) == f {
+			c.buildConstrained = hasBuildConstraint(file)
+			break
+		}
+	}
+	return c
+}
+
+// merge returns the context holding for both c and o.
+func (c callContext) merge(o callContext) callContext {
+	return callContext{
+		buildConstrained: c.buildConstrained && o.buildConstrained,
+		testOnly:         c.testOnly && o.testOnly,
+		testPackagesOnly: c.testPackagesOnly && o.testPackagesOnly,
+		typeUsage:        c.typeUsage && o.typeUsage,
+		linkname:         c.linkname && o.linkname,
+		renamedReceiver:  c.renamedReceiver && o.renamedReceiver,
+	}
+}
+
+func (c callContext) attrs() []string {
+	var attrs []string
+	if c.buildConstrained {
+		attrs = append(attrs, AttrBuildConstrained)
+	}
+	if c.testOnly {
+		attrs = append(attrs, AttrTestOnly)
+	}
+	if c.testPackagesOnly {
+		attrs = append(attrs, AttrTestPackagesOnly)
+	}
+	if c.typeUsage {
+		attrs = append(attrs, AttrTypeUsage)
+	}
+	if c.linkname {
+		attrs = append(attrs, AttrLinkname)
+	}
+	if c.renamedReceiver {
+		attrs = append(attrs, AttrRenamedReceiver)
+	}
+	return attrs
+}
+
+// renamedReceiver reports whether the entry with the ID among vulns
+// affects the method sym only by its name (see AttrRenamedReceiver).
+func renamedReceiver(vulns []*osv.Entry, id string, sym vulnsanalysis.SymbolID) bool {
+	for _, e := range vulns {
+		if e.ID == id {
+			return vulnsanalysis.RenamedReceiver(e, sym)
+		}
+	}
+	return false
+}
+
+// isTestPackage reports whether pkg is a test variant of a package,
+// an external test package, or a generated test main package.
+func isTestPackage(pkg *packages.Package) bool {
+	return strings.HasSuffix(pkg.ID, "]") || strings.HasSuffix(pkg.PkgPath, "_test") || strings.HasSuffix(pkg.PkgPath, ".test")
+}
+
+// hasBuildConstraint reports whether the file has a
+// //go:build or // +build line before its package clause.
+func hasBuildConstraint(f *ast.File) bool {
+	for _, cg := range f.Comments {
+		if cg.Pos() >= f.Package {
+			break
+		}
+		for _, c := range cg.List {
+			if constraint.IsGoBuild(c.Text) || constraint.IsPlusBuild(c.Text) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasAttr reports whether the finding value has the attribute.
+func (v Value) HasAttr(attr string) bool {
+	for _, a := range v.Attrs {
+		if a == attr {
+			return true
+		}
+	}
+	return false
+}
+
+// IgnoreAttrs returns a copy of summary without the findings
+// that have any of the attributes.
+func IgnoreAttrs(summary map[Key]Value, attrs []string) map[Key]Value {
+	res := make(map[Key]Value, len(summary))
+next:
+	for k, v := range summary {
+		for _, a := range attrs {
+			if v.HasAttr(a) {
+				continue next
+			}
+		}
+		res[k] = v
+	}
+	return res
+}
@@ -0,0 +1,82 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestHasBuildConstraint(t *testing.T) {
+	for _, tc := range []struct {
+		src  string
+		want bool
+	}{
+		{"package p\n", false},
+		{"// Package p does things.\npackage p\n", false},
+		{"//go:build windows\n\npackage p\n", true},
+		{"// +build linux\n\npackage p\n", true},
+		{"package p\n\n//go:build windows\n", false},
+	} {
+		f, err := parser.ParseFile(token.NewFileSet(), "p.go", tc.src, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hasBuildConstraint(f); got != tc.want {
+			t.Errorf("hasBuildConstraint(%q) = %v, want %v", tc.src, got, tc.want)
+		}
+	}
+}
+
+func TestCallContextMerge(t *testing.T) {
+	test := callContext{testOnly: true, testPackagesOnly: true}
+	prod := callContext{buildConstrained: true}
+	if got := test.attrs(); len(got) != 2 || got[0] != AttrTestOnly || got[1] != AttrTestPackagesOnly {
+		t.Errorf("attrs() = %v", got)
+	}
+	if got := test.merge(prod).attrs(); len(got) != 0 {
+		t.Errorf("merged attrs() = %v, want none", got)
+	}
+	construct := callContext{typeUsage: true}
+	if got := construct.attrs(); len(got) != 1 || got[0] != AttrTypeUsage {
+		t.Errorf("attrs() = %v, want [%v]", got, AttrTypeUsage)
+	}
+	if got := construct.merge(prod).attrs(); len(got) != 0 {
+		t.Errorf("merged attrs() = %v, want none", got)
+	}
+	linkname := callContext{linkname: true}
+	if got := linkname.attrs(); len(got) != 1 || got[0] != AttrLinkname {
+		t.Errorf("attrs() = %v, want [%v]", got, AttrLinkname)
+	}
+	if got := linkname.merge(prod).attrs(); len(got) != 0 {
+		t.Errorf("merged attrs() = %v, want none", got)
+	}
+	renamed := callContext{renamedReceiver: true}
+	if got := renamed.attrs(); len(got) != 1 || got[0] != AttrRenamedReceiver {
+		t.Errorf("attrs() = %v, want [%v]", got, AttrRenamedReceiver)
+	}
+}
+
+func TestIgnoreAttrs(t *testing.T) {
+	test := Key{ID: "GO-2022-0001", PackagePath: "example.com/foo", Symbol: "ParseConfig"}
+	prod := Key{ID: "GO-2022-0002", PackagePath: "example.com/foo", Symbol: "ParseConfig"}
+	summary := map[Key]Value{test: {Attrs: []string{AttrTestOnly}}, prod: {}}
+
+	attrs, err := ParseAttrs("test-only")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := IgnoreAttrs(summary, attrs)
+	if _, ok := got[test]; ok {
+		t.Errorf("test-only finding %v was kept", test)
+	}
+	if _, ok := got[prod]; !ok {
+		t.Errorf("finding %v was dropped", prod)
+	}
+	if _, err := ParseAttrs("test-only,bogus"); err == nil {
+		t.Error("ParseAttrs accepted an unknown attribute")
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package corpus runs quickcheck against a corpus of module fixtures
+// and compares the findings with the expected ones. It is meant to be
+// used as a regression suite when changing the reference graph analysis:
+//
+//	func TestCorpus(t *testing.T) {
+//		corpus.Run(t, "testdata")
+//	}
+//
+// Each subdirectory of the corpus directory is a fixture, which is the
+// root of a Go module and contains, in addition to the module's files:
+//
+//   - vulndb.txtar: the vulnerability reports to check against, in the
+//     txtar format accepted by testutils.NewDatabase.
+//   - want.json: the expected findings, a JSON list of quickcheck.Key
+//     sorted by ID, package path, and symbol.
+//
+// All packages of the module ("./...") are analyzed. The dependencies of
+// a fixture with a vendor directory are loaded from it. Otherwise they
+// are fetched on demand by the go command, so such fixtures are skipped
+// in -short mode.
+//
+// Running the tests with the -corpus.update flag rewrites the want.json
+// files with the actual findings.
+package corpus
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hyangah/vulns/quickcheck"
+	"github.com/hyangah/vulns/testutils"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/vuln/client"
+)
+
+var update = flag.Bool("corpus.update", false, "update the expected findings of the corpus fixtures")
+
+// Fixture file names.
+const (
+	dbFile   = "vulndb.txtar"
+	wantFile = "want.json"
+)
+
+// Run runs a subtest for each fixture in the corpus directory.
+func Run(t *testing.T, dir string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		n++
+		fixture := filepath.Join(dir, e.Name())
+		t.Run(e.Name(), func(t *testing.T) { runFixture(t, fixture) })
+	}
+	if n == 0 {
+		t.Fatalf("no fixtures found in %s", dir)
+	}
+}
+
+func runFixture(t *testing.T, dir string) {
+	if !isVendored(dir) && testing.Short() {
+		t.Skip("skipping fixture without vendored dependencies in -short mode")
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Check(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantPath := filepath.Join(dir, wantFile)
+	if *update {
+		data, err := json.MarshalIndent(got, "", "\t")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(wantPath, append(data, '\n'), 0666); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	data, err := os.ReadFile(wantPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []quickcheck.Key
+	if err := json.Unmarshal(data, &want); err != nil {
+		t.Fatalf("invalid %s: %v", wantPath, err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("findings mismatch (-want +got):\n%s\nrerun with -corpus.update if the change is intended", diff)
+	}
+}
+
+// Check analyzes all packages of the fixture in dir against the
+// vulnerability reports of the fixture and returns the findings.
+func Check(ctx context.Context, dir string) ([]quickcheck.Key, error) {
+	reports, err := os.ReadFile(filepath.Join(dir, dbFile))
+	if err != nil {
+		return nil, err
+	}
+	db, err := testutils.NewDatabase(ctx, reports)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Clean()
+	cli, err := client.NewClient([]string{db.URI()}, client.Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &packages.Config{
+		Context: ctx,
+		Dir:     dir,
+		Mode:    packages.LoadSyntax | packages.LoadAllSyntax | packages.NeedModule,
+		Tests:   true,
+	}
+	if isVendored(dir) {
+		cfg.Env = append(os.Environ(), "GOFLAGS=-mod=vendor")
+	}
+	pkgs, err := packages.Load(cfg, "./...")
+	if err != nil {
+		return nil, err
+	}
+	var errs []string
+	packages.Visit(pkgs, nil, func(p *packages.Package) {
+		for _, e := range p.Errors {
+			errs = append(errs, e.Error())
+		}
+	})
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("loading %s failed:\n%s", dir, strings.Join(errs, "\n"))
+	}
+
+	summary, _, err := quickcheck.Analyze(ctx, pkgs, cli)
+	if err != nil {
+		return nil, err
+	}
+	keys := []quickcheck.Key{}
+	for _, f := range quickcheck.Findings(summary) {
+		keys = append(keys, f.Key)
+	}
+	return keys, nil
+}
+
+// isVendored reports whether the fixture in dir has
+// a vendor directory.
+func isVendored(dir string) bool {
+	fi, err := os.Stat(filepath.Join(dir, "vendor"))
+	return err == nil && fi.IsDir()
+}
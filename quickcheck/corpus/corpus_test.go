@@ -0,0 +1,11 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package corpus
+
+import "testing"
+
+func TestCorpus(t *testing.T) {
+	Run(t, "testdata")
+}
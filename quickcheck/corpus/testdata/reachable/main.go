@@ -0,0 +1,7 @@
+package main
+
+import "example.com/vuln"
+
+func main() {
+	vuln.Vuln()
+}
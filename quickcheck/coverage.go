@@ -0,0 +1,87 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import (
+	"sort"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Reasons a package is not fully analyzed for symbol reachability.
+const (
+	// CoverageLoadErrors means the package failed to load or type
+	// check. It is analyzed anyway, but references from code with
+	// errors may be missed.
+	CoverageLoadErrors = "load errors"
+	// CoverageNoSyntax means the package was loaded without syntax,
+	// so its references are unknown.
+	CoverageNoSyntax = "no syntax"
+	// CoverageScanLevel means the scan level does not analyze
+	// symbols (see ScanPackage); the package is only matched
+	// against the vulnerable packages of its module.
+	CoverageScanLevel = "scan level"
+)
+
+// Coverage describes how much of the import closure of the analyzed
+// packages was analyzed for symbol reachability, which tells how
+// complete the absence of findings is.
+type Coverage struct {
+	// Packages is the number of packages in the import closure.
+	Packages int
+	// Analyzed is the number of packages fully analyzed.
+	Analyzed int
+	// Skipped lists the other packages, sorted by path.
+	Skipped []*SkippedPackage `json:",omitempty"`
+}
+
+// A SkippedPackage is a package not fully analyzed
+// for symbol reachability.
+type SkippedPackage struct {
+	Path   string
+	Reason string // such as CoverageLoadErrors
+}
+
+// Percent returns the percentage of the packages fully analyzed,
+// or 100 if there are no packages.
+func (c *Coverage) Percent() float64 {
+	if c.Packages == 0 {
+		return 100
+	}
+	return 100 * float64(c.Analyzed) / float64(c.Packages)
+}
+
+// AnalysisCoverage returns the coverage of the analysis of pkgs at
+// the scan level, either ScanPackage or ScanSymbol. pkgs are the
+// packages passed to Analyze or AnalyzePackages.
+func AnalysisCoverage(pkgs []*packages.Package, level string) *Coverage {
+	c := &Coverage{}
+	seen := make(map[string]bool)
+	packages.Visit(pkgs, nil, func(p *packages.Package) {
+		// Test variants are analyzed separately,
+		// but count once, like in the findings.
+		if seen[p.PkgPath] {
+			return
+		}
+		seen[p.PkgPath] = true
+		c.Packages++
+		reason := ""
+		switch {
+		case level != ScanSymbol:
+			reason = CoverageScanLevel
+		case len(p.Errors) > 0 || p.IllTyped:
+			reason = CoverageLoadErrors
+		case p.Types == nil || (len(p.Syntax) == 0 && len(p.GoFiles) > 0):
+			reason = CoverageNoSyntax
+		}
+		if reason == "" {
+			c.Analyzed++
+		} else {
+			c.Skipped = append(c.Skipped, &SkippedPackage{Path: p.PkgPath, Reason: reason})
+		}
+	})
+	sort.Slice(c.Skipped, func(i, j int) bool { return c.Skipped[i].Path < c.Skipped[j].Path })
+	return c
+}
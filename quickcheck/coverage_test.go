@@ -0,0 +1,58 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import (
+	"go/ast"
+	"go/types"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestAnalysisCoverage(t *testing.T) {
+	pkg := func(path string, imports ...*packages.Package) *packages.Package {
+		p := &packages.Package{
+			PkgPath: path,
+			GoFiles: []string{path + ".go"},
+			Syntax:  []*ast.File{{}},
+			Types:   types.NewPackage(path, "p"),
+			Imports: map[string]*packages.Package{},
+		}
+		for _, imp := range imports {
+			p.Imports[imp.PkgPath] = imp
+		}
+		return p
+	}
+	broken := pkg("example.com/broken")
+	broken.IllTyped = true
+	exported := pkg("example.com/exported")
+	exported.Syntax = nil
+	ok := pkg("example.com/ok")
+	root := pkg("example.com/app", broken, exported, ok)
+	test := pkg("example.com/app", ok) // test variant
+
+	got := AnalysisCoverage([]*packages.Package{root, test}, ScanSymbol)
+	want := &Coverage{
+		Packages: 4,
+		Analyzed: 2,
+		Skipped: []*SkippedPackage{
+			{Path: "example.com/broken", Reason: CoverageLoadErrors},
+			{Path: "example.com/exported", Reason: CoverageNoSyntax},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("AnalysisCoverage mismatch (-want +got):\n%s", diff)
+	}
+	if got, want := got.Percent(), 50.0; got != want {
+		t.Errorf("Percent() = %v, want %v", got, want)
+	}
+
+	got = AnalysisCoverage([]*packages.Package{root}, ScanPackage)
+	if got.Packages != 4 || got.Analyzed != 0 || len(got.Skipped) != 4 || got.Skipped[0].Reason != CoverageScanLevel {
+		t.Errorf("AnalysisCoverage(ScanPackage) = %+v, want no package analyzed", got)
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import (
+	"context"
+	"sort"
+
+	"github.com/hyangah/vulns/internal/osvutil"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/vuln/client"
+	"golang.org/x/vuln/osv"
+)
+
+// Reachability verdicts of a known vulnerability of a dependency.
+const (
+	// VerdictReachable means a vulnerable symbol is reachable
+	// from the analyzed packages.
+	VerdictReachable = "reachable"
+	// VerdictImported means a vulnerable package is imported,
+	// but no vulnerable symbol was found reachable.
+	VerdictImported = "imported"
+	// VerdictNotImported means none of the vulnerable packages
+	// is in the import closure of the analyzed packages.
+	VerdictNotImported = "not-imported"
+)
+
+// A ModuleVulns lists the known vulnerabilities of a module
+// in the import closure of the analyzed packages.
+type ModuleVulns struct {
+	Path    string
+	Version string
+	Vulns   []*DepVuln
+}
+
+// A DepVuln is a known vulnerability of a dependency
+// along with its reachability verdict.
+type DepVuln struct {
+	ID      string
+	Verdict string
+	Entry   *osv.Entry `json:"-"`
+}
+
+// Dependencies returns the inventory of all known vulnerabilities of
+// the modules in the import closure of pkgs, including modules without
+// any, regardless of reachability. summary is the result of Analyze for
+// the same packages and determines the reachability verdicts.
+func Dependencies(ctx context.Context, pkgs []*packages.Package, dbClient client.Client, summary map[Key]Value) ([]*ModuleVulns, error) {
+	modEntries, err := osvutil.FetchModuleOSVEntries(ctx, dbClient, pkgs)
+	if err != nil {
+		return nil, err
+	}
+	return dependencies(modEntries, osvutil.ImportedPackages(pkgs), summary), nil
+}
+
+// dependencies computes the inventory of Dependencies from the
+// entries of the modules and the imported packages.
+func dependencies(modEntries []*osvutil.ModuleEntries, imported map[string]bool, summary map[Key]Value) []*ModuleVulns {
+	reachable := make(map[string]bool)
+	for k := range summary {
+		reachable[k.ID] = true
+	}
+
+	var mods []*ModuleVulns
+	for _, me := range modEntries {
+		m := me.Module
+		if m.Replace != nil {
+			m = m.Replace
+		}
+		mv := &ModuleVulns{Path: m.Path, Version: m.Version}
+		for _, e := range me.Entries {
+			v := &DepVuln{ID: e.ID, Verdict: VerdictNotImported, Entry: e}
+			switch {
+			case reachable[e.ID]:
+				v.Verdict = VerdictReachable
+			case importsAffected(imported, e):
+				v.Verdict = VerdictImported
+			}
+			mv.Vulns = append(mv.Vulns, v)
+		}
+		mods = append(mods, mv)
+	}
+	return mods
+}
+
+// importsAffected reports whether any of the packages affected
+// by the entry is imported.
+func importsAffected(imported map[string]bool, e *osv.Entry) bool {
+	for _, a := range e.Affected {
+		if len(a.EcosystemSpecific.Imports) == 0 {
+			return true // the whole module, which is in the closure, is affected.
+		}
+		for _, p := range a.EcosystemSpecific.Imports {
+			if imported[p.Path] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// An UnreachableVuln is a known vulnerability of a required module
+// without any reachable vulnerable symbol. govulncheck mentions such
+// vulnerabilities at module level as informational.
+type UnreachableVuln struct {
+	ID         string
+	ModulePath string
+	Version    string
+	Fix        string `json:",omitempty"` // the minimum fixed version
+	Verdict    string // VerdictImported or VerdictNotImported
+}
+
+// Unreachable returns the known vulnerabilities of the modules in the
+// import closure of pkgs that no vulnerable symbol is reachable for,
+// according to summary, sorted by module path and ID.
+func Unreachable(ctx context.Context, pkgs []*packages.Package, dbClient client.Client, summary map[Key]Value) ([]*UnreachableVuln, error) {
+	mods, err := Dependencies(ctx, pkgs, dbClient, summary)
+	if err != nil {
+		return nil, err
+	}
+	return unreachable(mods), nil
+}
+
+func unreachable(mods []*ModuleVulns) []*UnreachableVuln {
+	var res []*UnreachableVuln
+	for _, m := range mods {
+		for _, v := range m.Vulns {
+			if v.Verdict == VerdictReachable {
+				continue
+			}
+			u := &UnreachableVuln{ID: v.ID, ModulePath: m.Path, Version: m.Version, Verdict: v.Verdict}
+			if v.Entry != nil {
+				u.Fix = minFixVersion(affectedModule(m.Path, v.Entry), m.Version)
+			}
+			res = append(res, u)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].ModulePath != res[j].ModulePath {
+			return res[i].ModulePath < res[j].ModulePath
+		}
+		return res[i].ID < res[j].ID
+	})
+	return res
+}
@@ -0,0 +1,118 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hyangah/vulns/internal/osvutil"
+	"github.com/hyangah/vulns/testutils"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/vuln/osv"
+)
+
+func TestImportsAffected(t *testing.T) {
+	imported := map[string]bool{"example.com/m/p": true}
+	for _, tc := range []struct {
+		pkgs []string
+		want bool
+	}{
+		{[]string{"example.com/m/p"}, true},
+		{[]string{"example.com/m/q", "example.com/m/p"}, true},
+		{[]string{"example.com/m/q"}, false},
+		{nil, true},
+	} {
+		e := testutils.Entry("GO-2022-0001", "example.com/m", "", tc.pkgs...)
+		if got := importsAffected(imported, e); got != tc.want {
+			t.Errorf("importsAffected(%v) = %v, want %v", tc.pkgs, got, tc.want)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	e1 := testutils.Entry("GO-2022-0001", "example.com/m", "", "example.com/m/p", "example.com/m/q")
+	e2 := testutils.Entry("GO-2022-0002", "example.com/m", "", "example.com/m/q")
+	e3 := testutils.Entry("GO-2022-0003", "example.com/n", "", "example.com/n")
+	pkg2vulns := map[string][]*osv.Entry{
+		"example.com/m/p": {e1},
+		"example.com/m/q": {e1, e2},
+		"example.com/n":   {e3},
+	}
+	summary := map[Key]Value{
+		{ID: "GO-2022-0001", PackagePath: "example.com/m/p", Symbol: "F"}: {},
+		{ID: "GO-2022-0001", PackagePath: "example.com/m/q", Symbol: "G"}: {},
+	}
+	want := &Summary{
+		Modules: []*ModuleSummary{
+			{Path: "example.com/m", Vulns: 2, Reachable: 1, ImportOnly: 1},
+			{Path: "example.com/n", Vulns: 1, ImportOnly: 1},
+		},
+		Vulns:      3,
+		Reachable:  1,
+		ImportOnly: 2,
+		Packages:   3,
+	}
+	if diff := cmp.Diff(want, Summarize(summary, pkg2vulns)); diff != "" {
+		t.Errorf("Summarize mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestUnreachable(t *testing.T) {
+	entry := &osv.Entry{
+		ID: "GO-2022-0002",
+		Affected: []osv.Affected{{
+			Package: osv.Package{Name: "example.com/m"},
+			Ranges: osv.Affects{{
+				Type:   osv.TypeSemver,
+				Events: []osv.RangeEvent{{Introduced: "0"}, {Fixed: "1.1.0"}, {Introduced: "1.3.0"}, {Fixed: "1.3.1"}},
+			}},
+		}},
+	}
+	mods := []*ModuleVulns{
+		{Path: "example.com/n", Version: "v0.1.0", Vulns: []*DepVuln{
+			{ID: "GO-2022-0003", Verdict: VerdictNotImported},
+		}},
+		{Path: "example.com/m", Version: "v1.3.0", Vulns: []*DepVuln{
+			{ID: "GO-2022-0002", Verdict: VerdictImported, Entry: entry},
+			{ID: "GO-2022-0001", Verdict: VerdictReachable},
+		}},
+		{Path: "example.com/o", Version: "v1.0.0"},
+	}
+	want := []*UnreachableVuln{
+		{ID: "GO-2022-0002", ModulePath: "example.com/m", Version: "v1.3.0", Fix: "v1.3.1", Verdict: VerdictImported},
+		{ID: "GO-2022-0003", ModulePath: "example.com/n", Version: "v0.1.0", Verdict: VerdictNotImported},
+	}
+	if diff := cmp.Diff(want, unreachable(mods)); diff != "" {
+		t.Errorf("unreachable mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNotAffected(t *testing.T) {
+	old := testutils.Entry("GO-2022-0001", "example.com/m", "1.0.0", "example.com/m/p")
+	windows := testutils.Entry("GO-2022-0002", "example.com/m", "2.0.0", "example.com/m/w")
+	windows.Affected[0].EcosystemSpecific.Imports[0].GOOS = []string{"windows"}
+	unreachable := testutils.Entry("GO-2022-0003", "example.com/m", "2.0.0", "example.com/m/p")
+	notImported := testutils.Entry("GO-2022-0004", "example.com/m", "2.0.0", "example.com/m/q")
+	reachable := testutils.Entry("GO-2022-0005", "example.com/m", "2.0.0", "example.com/m/p")
+	modEntries := []*osvutil.ModuleEntries{{
+		Module:     &packages.Module{Path: "example.com/m", Version: "v1.2.0"},
+		Entries:    []*osv.Entry{unreachable, notImported, reachable},
+		Excluded:   []osvutil.ExcludedImport{{Entry: windows, Import: windows.Affected[0].EcosystemSpecific.Imports[0]}},
+		Unaffected: []*osv.Entry{old},
+	}}
+	imported := map[string]bool{"example.com/m/p": true, "example.com/m/w": true}
+	summary := map[Key]Value{{ID: "GO-2022-0005", PackagePath: "example.com/m/p", Symbol: "F"}: {}}
+
+	want := []*NotAffected{
+		{ID: "GO-2022-0001", ModulePath: "example.com/m", Version: "v1.2.0", Reason: ReasonVersion, Detail: "affected versions: [0, v1.0.0)", Fixed: "v1.0.0"},
+		{ID: "GO-2022-0002", ModulePath: "example.com/m", Version: "v1.2.0", Reason: ReasonPlatform, Detail: "vulnerable only on other platforms: example.com/m/w (GOOS=windows)"},
+		{ID: "GO-2022-0003", ModulePath: "example.com/m", Version: "v1.2.0", Reason: ReasonUnreachable},
+		{ID: "GO-2022-0004", ModulePath: "example.com/m", Version: "v1.2.0", Reason: ReasonNotImported},
+	}
+	if diff := cmp.Diff(want, notAffected(modEntries, imported, summary)); diff != "" {
+		t.Errorf("notAffected mismatch (-want +got):\n%s", diff)
+	}
+}
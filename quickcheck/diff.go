@@ -0,0 +1,96 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import (
+	"sort"
+	"strings"
+)
+
+// Kinds of the changes between the findings of two scans.
+const (
+	ChangeIntroduced = "introduced"
+	ChangeRemoved    = "removed"
+	ChangeChanged    = "changed"
+)
+
+// A Change is a difference between the findings of two scans,
+// such as of two revisions of the same code.
+type Change struct {
+	Kind string
+	// Old and New are the finding in each scan. Old is nil for
+	// the introduced findings and New for the removed ones.
+	Old *Finding `json:",omitempty"`
+	New *Finding `json:",omitempty"`
+}
+
+// Diff returns the changes from the findings in old to the ones in
+// new, sorted as by Findings. The findings are matched by Key. A
+// finding of both scans changed if the version of the module in use
+// or the entry points reaching it differ; the positions of the
+// frames are left out, so that edits only moving code around do not
+// count as changes.
+func Diff(old, new map[Key]Value) []*Change {
+	var changes []*Change
+	for _, f := range Findings(new) {
+		v, ok := old[f.Key]
+		switch {
+		case !ok:
+			changes = append(changes, &Change{Kind: ChangeIntroduced, New: f})
+		case v.Version != f.Version || !equalStrings(entryPoints(v), entryPoints(f.Value)):
+			changes = append(changes, &Change{Kind: ChangeChanged, Old: &Finding{Key: f.Key, Value: v}, New: f})
+		}
+	}
+	for _, f := range Findings(old) {
+		if _, ok := new[f.Key]; !ok {
+			changes = append(changes, &Change{Kind: ChangeRemoved, Old: f})
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		ki, kj := changes[i].Finding().Key, changes[j].Finding().Key
+		if ki.ID != kj.ID {
+			return ki.ID < kj.ID
+		}
+		if ki.PackagePath != kj.PackagePath {
+			return ki.PackagePath < kj.PackagePath
+		}
+		return ki.Symbol < kj.Symbol
+	})
+	return changes
+}
+
+// Finding returns the finding of the change in the new scan,
+// or in the old one for removed findings.
+func (c *Change) Finding() *Finding {
+	if c.New != nil {
+		return c.New
+	}
+	return c.Old
+}
+
+// entryPoints returns the symbols of the entry points of the traces
+// to the finding, without their positions.
+func entryPoints(v Value) []string {
+	if len(v.Entries) > 0 {
+		return v.Entries
+	}
+	if len(v.Trace) == 0 {
+		return nil
+	}
+	entry, _, _ := strings.Cut(v.Trace[0], " ")
+	return []string{entry}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
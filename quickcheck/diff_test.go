@@ -0,0 +1,45 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import "testing"
+
+func TestDiff(t *testing.T) {
+	key := func(id, sym string) Key {
+		return Key{ID: id, ModulePath: "example.com/lib", PackagePath: "example.com/lib", Symbol: sym}
+	}
+	old := map[Key]Value{
+		key("GO-2022-0001", "Parse"):  {Version: "v1.0.0", Trace: []string{"example.com/app.Run /app/run.go:1:1", "example.com/lib.Parse"}},
+		key("GO-2022-0002", "Decode"): {Version: "v1.0.0", Entries: []string{"example.com/app.Run"}},
+		key("GO-2022-0003", "Encode"): {Version: "v1.0.0", Entries: []string{"example.com/app.Run"}},
+		key("GO-2022-0004", "Close"):  {Version: "v1.0.0", Entries: []string{"example.com/app.Run"}},
+	}
+	new := map[Key]Value{
+		// The code moved: not a change.
+		key("GO-2022-0001", "Parse"):  {Version: "v1.0.0", Trace: []string{"example.com/app.Run /app/run.go:9:1", "example.com/lib.Parse"}},
+		key("GO-2022-0002", "Decode"): {Version: "v1.0.1", Entries: []string{"example.com/app.Run"}},
+		key("GO-2022-0003", "Encode"): {Version: "v1.0.0", Entries: []string{"example.com/app.Main", "example.com/app.Run"}},
+		key("GO-2022-0005", "Open"):   {Version: "v1.0.0", Entries: []string{"example.com/app.Run"}},
+	}
+	var got []string
+	for _, c := range Diff(old, new) {
+		got = append(got, c.Kind+" "+c.Finding().ID)
+		if (c.Old == nil) != (c.Kind == ChangeIntroduced) || (c.New == nil) != (c.Kind == ChangeRemoved) {
+			t.Errorf("%s %s: Old = %v, New = %v", c.Kind, c.Finding().ID, c.Old, c.New)
+		}
+	}
+	want := []string{
+		"changed GO-2022-0002",
+		"changed GO-2022-0003",
+		"removed GO-2022-0004",
+		"introduced GO-2022-0005",
+	}
+	if !equalStrings(got, want) {
+		t.Errorf("Diff = %q, want %q", got, want)
+	}
+	if changes := Diff(old, old); len(changes) != 0 {
+		t.Errorf("Diff(old, old) = %v, want none", changes)
+	}
+}
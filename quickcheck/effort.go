@@ -0,0 +1,73 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import "golang.org/x/mod/semver"
+
+// Kinds of version bumps of an Effort.
+const (
+	BumpPatch = "patch"
+	BumpMinor = "minor"
+	BumpMajor = "major"
+)
+
+// An Effort estimates the scope of the remediation of a finding,
+// for prioritization.
+type Effort struct {
+	// Bump is the kind of the version bump from the version in use
+	// to the smallest fixed version: BumpPatch, BumpMinor, or
+	// BumpMajor. It is empty if either version is unknown.
+	Bump string `json:",omitempty"`
+	// EntryPoints is the number of distinct entry points in
+	// first-party code that reach the vulnerable symbol.
+	EntryPoints int
+}
+
+// Effort returns the estimated remediation effort of the finding.
+// The fixed version is MinFix, or Fix if MinFix is unknown (see
+// ResolveFixes). The entry points are the Entries of the finding,
+// or the first frame of its trace if there are none, in the boundary;
+// if the boundary is empty or contains none of them, all of them count.
+func (f *Finding) Effort(b Boundary) Effort {
+	fix := f.MinFix
+	if fix == "" {
+		fix = f.Fix
+	}
+	e := Effort{Bump: bump(f.Version, fix)}
+	entries := f.Entries
+	if len(entries) == 0 && len(f.Trace) > 0 {
+		entries = f.Trace[:1]
+	}
+	for _, fr := range entries {
+		if b.Contains(FramePackage(fr)) {
+			e.EntryPoints++
+		}
+	}
+	if e.EntryPoints == 0 {
+		e.EntryPoints = len(entries)
+	}
+	return e
+}
+
+// bump returns the kind of the version bump from one semantic version
+// to another, or the empty string if either is invalid or to is not
+// higher than from. Major versions 0 and 1 are both treated as
+// compatible by Go modules, but a bump of the minor version of a
+// v0 module may break its users, so it counts as a major bump.
+func bump(from, to string) string {
+	if !semver.IsValid(from) || !semver.IsValid(to) || semver.Compare(from, to) >= 0 {
+		return ""
+	}
+	switch {
+	case semver.Major(from) != semver.Major(to):
+		return BumpMajor
+	case semver.MajorMinor(from) != semver.MajorMinor(to):
+		if semver.Major(from) == "v0" {
+			return BumpMajor
+		}
+		return BumpMinor
+	}
+	return BumpPatch
+}
@@ -0,0 +1,35 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import "testing"
+
+func TestEffort(t *testing.T) {
+	b := ParseBoundary("example.com/app")
+	for _, tc := range []struct {
+		name  string
+		value Value
+		want  Effort
+	}{
+		{"patch", Value{Version: "v1.2.3", Fix: "v1.2.5"}, Effort{Bump: BumpPatch}},
+		{"minor", Value{Version: "v1.2.3", Fix: "v1.4.0"}, Effort{Bump: BumpMinor}},
+		{"minfix", Value{Version: "v1.2.3", Fix: "v1.4.0", MinFix: "v1.2.4"}, Effort{Bump: BumpPatch}},
+		{"v0 minor", Value{Version: "v0.2.3", Fix: "v0.3.0"}, Effort{Bump: BumpMajor}},
+		{"major", Value{Version: "v1.2.3", Fix: "v2.0.0"}, Effort{Bump: BumpMajor}},
+		{"unknown", Value{Version: "v1.2.3"}, Effort{}},
+		{"trace", Value{Trace: []string{"example.com/app.Run /app/run.go:1:1", "example.com/lib.Parse"}}, Effort{EntryPoints: 1}},
+		{"entries", Value{Entries: []string{
+			"example.com/app.Run /app/run.go:1:1",
+			"example.com/app/cmd.Main /app/cmd/main.go:1:1",
+			"example.com/dep.Init /dep/init.go:1:1",
+		}}, Effort{EntryPoints: 2}},
+		{"no first-party entries", Value{Entries: []string{"example.com/dep.A", "example.com/dep.B"}}, Effort{EntryPoints: 2}},
+	} {
+		f := &Finding{Key: Key{ID: "GO-2022-0001", PackagePath: "example.com/lib", Symbol: "Parse"}, Value: tc.value}
+		if got := f.Effort(b); got != tc.want {
+			t.Errorf("%s: Effort = %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+}
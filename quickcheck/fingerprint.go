@@ -0,0 +1,37 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Fingerprint returns an identifier of the finding that is stable
+// across runs. It is computed from the vulnerability ID, the vulnerable
+// symbol, and the symbol of the first frame of the trace in first-party
+// code (or the first frame if the boundary contains none of them).
+// Frame positions are left out, so edits that only move code around
+// do not change the fingerprint.
+func (f *Finding) Fingerprint(b Boundary) string {
+	entry := ""
+	if len(f.Trace) > 0 {
+		entry = f.Trace[0]
+	}
+	for _, fr := range f.Trace {
+		if b.Contains(FramePackage(fr)) {
+			entry = fr
+			break
+		}
+	}
+	entry, _, _ = strings.Cut(entry, " ") // drop the position
+	h := sha256.New()
+	for _, s := range []string{f.ID, f.PackagePath + "." + f.Symbol, entry} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
@@ -0,0 +1,35 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import "testing"
+
+func TestFingerprint(t *testing.T) {
+	b := ParseBoundary("example.com/app")
+	finding := func(trace ...string) *Finding {
+		return &Finding{
+			Key:   Key{ID: "GO-2022-0001", PackagePath: "example.com/lib", Symbol: "Parse"},
+			Value: Value{Trace: trace},
+		}
+	}
+	base := finding("example.com/app.Run /app/run.go:10:2", "example.com/lib.Parse /lib/parse.go:3:6")
+	fp := base.Fingerprint(b)
+
+	if got := finding("example.com/app.Run /app/run.go:42:7", "example.com/lib.Parse /lib/parse.go:9:6").Fingerprint(b); got != fp {
+		t.Errorf("fingerprint changed when only positions moved: %s != %s", got, fp)
+	}
+	if got := finding("example.com/app.Other /app/run.go:10:2", "example.com/lib.Parse /lib/parse.go:3:6").Fingerprint(b); got == fp {
+		t.Errorf("fingerprint did not change with the entry frame")
+	}
+	other := finding("example.com/app.Run /app/run.go:10:2", "example.com/lib.Parse /lib/parse.go:3:6")
+	other.ID = "GO-2022-0002"
+	if got := other.Fingerprint(b); got == fp {
+		t.Errorf("fingerprint did not change with the vulnerability ID")
+	}
+	// Without first-party frames, the first frame is used.
+	if got := base.Fingerprint(nil); got != fp {
+		t.Errorf("fingerprint without boundary = %s, want %s", got, fp)
+	}
+}
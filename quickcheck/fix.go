@@ -0,0 +1,172 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import (
+	"context"
+
+	"github.com/hyangah/vulns/internal/osvutil"
+	"github.com/hyangah/vulns/internal/xvuln/govulncheck"
+	isem "github.com/hyangah/vulns/internal/xvuln/semver"
+	"github.com/hyangah/vulns/stdlib"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+	"golang.org/x/vuln/osv"
+)
+
+// A VersionLister lists the available and retracted versions
+// of modules, such as a module proxy client.
+type VersionLister interface {
+	// Versions returns the available versions in semver order.
+	Versions(ctx context.Context, modPath string) ([]string, error)
+	// Retractions returns the retracted version intervals.
+	Retractions(ctx context.Context, modPath string) ([]modfile.VersionInterval, error)
+}
+
+// ResolveFixes sets the Fix field of the findings in summary to the
+// module version to upgrade to. The version is the latest fixed version
+// recorded in the OSV entry. If lister is not nil, the version is checked
+// against it, and if it is not available or is retracted, the next
+// available version that is neither retracted nor affected is used
+// instead. If lister fails, the recorded version is used unverified.
+//
+// ResolveFixes also sets the MinFix field to the smallest fixed version
+// recorded in the OSV entry that is above the version in use and not
+// affected, which may be older than Fix if the vulnerability is fixed
+// in several release branches.
+func ResolveFixes(ctx context.Context, summary map[Key]Value, pkg2vulns map[string][]*osv.Entry, lister VersionLister) map[Key]Value {
+	entries := make(map[string]*osv.Entry)
+	for _, vulns := range pkg2vulns {
+		for _, v := range vulns {
+			entries[v.ID] = v
+		}
+	}
+	type modVuln struct{ mod, id string }
+	fixes := make(map[modVuln]string)
+	res := make(map[Key]Value, len(summary))
+	for k, v := range summary {
+		mv := modVuln{k.ModulePath, k.ID}
+		fix, ok := fixes[mv]
+		e := entries[k.ID]
+		if !ok {
+			if e != nil {
+				fix = fixVersion(ctx, k.ModulePath, e, lister)
+			}
+			fixes[mv] = fix
+		}
+		v.Fix = fix
+		if e != nil {
+			v.MinFix = minFixVersion(affectedModule(k.ModulePath, e), v.Version)
+		}
+		res[k] = v
+	}
+	return res
+}
+
+// affectedModule returns the affected entries of e for the module.
+func affectedModule(modPath string, e *osv.Entry) []osv.Affected {
+	var affected []osv.Affected
+	for _, a := range e.Affected {
+		if a.Package.Name == modPath || (modPath == stdlib.ModulePath && stdlib.Contains(a.Package.Name)) {
+			affected = append(affected, a)
+		}
+	}
+	return affected
+}
+
+// minFixVersion returns the smallest fixed version recorded in the
+// affected ranges that is above the version, if known, and is not
+// affected itself, or the empty string if there is none.
+func minFixVersion(affected []osv.Affected, version string) string {
+	min := ""
+	for _, a := range affected {
+		for _, r := range a.Ranges {
+			if r.Type != osv.TypeSemver {
+				continue
+			}
+			for _, ev := range r.Events {
+				if ev.Fixed == "" {
+					continue
+				}
+				fixed := isem.CanonicalizeSemverPrefix(ev.Fixed)
+				if version != "" && semver.Compare(fixed, version) <= 0 {
+					continue
+				}
+				if min != "" && semver.Compare(fixed, min) >= 0 {
+					continue
+				}
+				if !isAffected(affected, fixed) {
+					min = fixed
+				}
+			}
+		}
+	}
+	return min
+}
+
+// isAffected reports whether the version is in an affected range.
+func isAffected(affected []osv.Affected, version string) bool {
+	for _, a := range affected {
+		if a.Ranges.AffectsSemver(version) {
+			return true
+		}
+	}
+	return false
+}
+
+// moduleVersions returns the versions of the modules looked up in the
+// vulnerability database, keyed by module path. Replaced modules are
+// looked up with the path and version of their replacements.
+func moduleVersions(queries []*osvutil.ModuleQuery) map[string]string {
+	versions := make(map[string]string)
+	for _, q := range queries {
+		m := q.Module
+		if m.Replace != nil {
+			m = m.Replace
+		}
+		if q.Skipped == "" && m.Version != "" {
+			versions[m.Path] = m.Version
+		}
+	}
+	return versions
+}
+
+// fixVersion returns the version of the module that fixes the
+// vulnerability, or the empty string if there is none.
+func fixVersion(ctx context.Context, modPath string, e *osv.Entry, lister VersionLister) string {
+	affected := affectedModule(modPath, e)
+	fixed := govulncheck.LatestFixed(affected)
+	if fixed == "" {
+		return ""
+	}
+	fixed = isem.CanonicalizeSemverPrefix(fixed)
+	if lister == nil || modPath == stdlib.ModulePath {
+		return fixed
+	}
+	versions, err := lister.Versions(ctx, modPath)
+	if err != nil {
+		return fixed
+	}
+	retractions, err := lister.Retractions(ctx, modPath)
+	if err != nil {
+		return fixed
+	}
+next:
+	for _, v := range versions {
+		if semver.Compare(v, fixed) < 0 {
+			continue
+		}
+		for _, r := range retractions {
+			if semver.Compare(r.Low, v) <= 0 && semver.Compare(v, r.High) <= 0 {
+				continue next
+			}
+		}
+		if isAffected(affected, v) {
+			continue
+		}
+		return v
+	}
+	return ""
+}
@@ -0,0 +1,94 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import (
+	"context"
+	"testing"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/vuln/osv"
+)
+
+type fakeLister struct {
+	versions    []string
+	retractions []modfile.VersionInterval
+}
+
+func (l fakeLister) Versions(context.Context, string) ([]string, error) { return l.versions, nil }
+func (l fakeLister) Retractions(context.Context, string) ([]modfile.VersionInterval, error) {
+	return l.retractions, nil
+}
+
+func TestResolveFixes(t *testing.T) {
+	entry := &osv.Entry{
+		ID: "GO-2022-0001",
+		Affected: []osv.Affected{{
+			Package: osv.Package{Name: "example.com/m", Ecosystem: osv.GoEcosystem},
+			Ranges: osv.Affects{{
+				Type: osv.TypeSemver,
+				Events: []osv.RangeEvent{
+					{Introduced: "0"}, {Fixed: "1.1.0"},
+					{Introduced: "1.3.0"}, {Fixed: "1.3.1"},
+				},
+			}},
+		}},
+	}
+	key := Key{ID: "GO-2022-0001", ModulePath: "example.com/m", PackagePath: "example.com/m/p", Symbol: "F"}
+	summary := map[Key]Value{key: {}}
+	pkg2vulns := map[string][]*osv.Entry{"example.com/m/p": {entry}}
+
+	for _, tc := range []struct {
+		name   string
+		lister VersionLister
+		want   string
+	}{
+		{"unverified", nil, "v1.3.1"},
+		{"available", fakeLister{versions: []string{"v1.0.0", "v1.3.1", "v1.4.0"}}, "v1.3.1"},
+		{"missing", fakeLister{versions: []string{"v1.0.0", "v1.3.0", "v1.3.2"}}, "v1.3.2"},
+		{"retracted", fakeLister{
+			versions:    []string{"v1.3.1", "v1.3.2", "v1.4.0"},
+			retractions: []modfile.VersionInterval{{Low: "v1.3.1", High: "v1.3.2"}},
+		}, "v1.4.0"},
+		{"none", fakeLister{versions: []string{"v1.0.0"}}, ""},
+	} {
+		got := ResolveFixes(context.Background(), summary, pkg2vulns, tc.lister)
+		if fix := got[key].Fix; fix != tc.want {
+			t.Errorf("%s: Fix = %q, want %q", tc.name, fix, tc.want)
+		}
+	}
+}
+
+func TestMinFix(t *testing.T) {
+	entry := &osv.Entry{
+		ID: "GO-2022-0001",
+		Affected: []osv.Affected{{
+			Package: osv.Package{Name: "example.com/m", Ecosystem: osv.GoEcosystem},
+			Ranges: osv.Affects{{
+				Type: osv.TypeSemver,
+				Events: []osv.RangeEvent{
+					{Introduced: "0"}, {Fixed: "1.1.0"},
+					{Introduced: "1.3.0"}, {Fixed: "1.3.1"},
+				},
+			}},
+		}},
+	}
+	key := Key{ID: "GO-2022-0001", ModulePath: "example.com/m", PackagePath: "example.com/m/p", Symbol: "F"}
+	pkg2vulns := map[string][]*osv.Entry{"example.com/m/p": {entry}}
+
+	for _, tc := range []struct {
+		version, want string
+	}{
+		{"v1.0.2", "v1.1.0"},
+		{"v1.3.0", "v1.3.1"},
+		{"", "v1.1.0"},
+	} {
+		summary := map[Key]Value{key: {Version: tc.version}}
+		got := ResolveFixes(context.Background(), summary, pkg2vulns, nil)
+		if v := got[key]; v.MinFix != tc.want || v.Fix != "v1.3.1" {
+			t.Errorf("version %q: MinFix, Fix = %q, %q, want %q, %q", tc.version, v.MinFix, v.Fix, tc.want, "v1.3.1")
+		}
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+	"golang.org/x/tools/go/packages"
+)
+
+// GoModBuildList returns an approximation of the build list of the
+// main module containing dir, or of the workspace of its go.work
+// file, for AnalyzeModules at the ScanGoMod level. It reads the
+// go.mod, go.sum, and go.work files directly, without the go
+// command, so it needs neither the module cache nor the network.
+//
+// The requirements of the main modules are merged, keeping the
+// highest version of each module, and the replace directives of the
+// go.work file, then of the go.mod files, are applied. The go.mod
+// files of modules at go 1.17 or later list every module providing
+// packages to the build. For earlier ones, the modules missing in
+// go.mod are taken from go.sum, at the highest version with a
+// checksum of its content, which may include modules no longer in
+// the build list.
+//
+// As with "go list -m all", the main modules come first and the
+// others are sorted by path.
+func GoModBuildList(dir string) ([]*packages.Module, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		gomods   []string
+		replaces []*modfile.Replace // go.work ones first
+	)
+	if work := findWorkFile(dir); work != "" {
+		data, err := os.ReadFile(work)
+		if err != nil {
+			return nil, err
+		}
+		wf, err := modfile.ParseWork(work, data, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range wf.Use {
+			d := filepath.FromSlash(u.Path)
+			if !filepath.IsAbs(d) {
+				d = filepath.Join(filepath.Dir(work), d)
+			}
+			gomods = append(gomods, filepath.Join(d, "go.mod"))
+		}
+		replaces = append(replaces, wf.Replace...)
+	} else if gomod := findUp(dir, "go.mod"); gomod != "" {
+		gomods = append(gomods, gomod)
+	} else {
+		return nil, fmt.Errorf("no go.mod file in %s or its parent directories", dir)
+	}
+
+	var mains []*packages.Module
+	isMain := make(map[string]bool)
+	reqs := make(map[string]*packages.Module)
+	require := func(path, version string, indirect bool) {
+		if r, ok := reqs[path]; !ok || semver.Compare(version, r.Version) > 0 {
+			reqs[path] = &packages.Module{Path: path, Version: version, Indirect: indirect && (!ok || r.Indirect)}
+		} else if !indirect {
+			r.Indirect = false
+		}
+	}
+	for _, gomod := range gomods {
+		data, err := os.ReadFile(gomod)
+		if err != nil {
+			return nil, err
+		}
+		f, err := modfile.Parse(gomod, data, nil)
+		if err != nil {
+			return nil, err
+		}
+		if f.Module == nil {
+			return nil, fmt.Errorf("%s: no module directive", gomod)
+		}
+		m := &packages.Module{Path: f.Module.Mod.Path, Main: true, Dir: filepath.Dir(gomod), GoMod: gomod}
+		if f.Go != nil {
+			m.GoVersion = f.Go.Version
+		}
+		mains = append(mains, m)
+		isMain[m.Path] = true
+		for _, r := range f.Require {
+			require(r.Mod.Path, r.Mod.Version, r.Indirect)
+		}
+		replaces = append(replaces, f.Replace...)
+		if m.GoVersion == "" || semver.Compare("v"+m.GoVersion, "v1.17") < 0 {
+			sums, err := goSumVersions(filepath.Join(m.Dir, "go.sum"))
+			if err != nil {
+				return nil, err
+			}
+			for path, v := range sums {
+				if _, ok := reqs[path]; !ok {
+					require(path, v, true)
+				}
+			}
+		}
+	}
+
+	var deps []*packages.Module
+	for path, m := range reqs {
+		if isMain[path] {
+			continue
+		}
+		for _, r := range replaces {
+			if r.Old.Path == path && (r.Old.Version == "" || r.Old.Version == m.Version) {
+				m.Replace = &packages.Module{Path: r.New.Path, Version: r.New.Version}
+				if r.New.Version == "" {
+					m.Replace.Dir = r.New.Path
+				}
+				break
+			}
+		}
+		deps = append(deps, m)
+	}
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Path < deps[j].Path })
+	return append(mains, deps...), nil
+}
+
+// findWorkFile returns the go.work file of the workspace of dir,
+// following $GOWORK as the go command does, or "" if there is none.
+func findWorkFile(dir string) string {
+	switch gowork := os.Getenv("GOWORK"); gowork {
+	case "off":
+		return ""
+	case "":
+		return findUp(dir, "go.work")
+	default:
+		return gowork
+	}
+}
+
+// findUp returns the file with the name in dir or the closest of its
+// parent directories, or "" if there is none.
+func findUp(dir, name string) string {
+	for {
+		f := filepath.Join(dir, name)
+		if fi, err := os.Stat(f); err == nil && !fi.IsDir() {
+			return f
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// goSumVersions returns the highest version of each module with a
+// checksum of its content in the go.sum file, which is missing if
+// the module has no dependencies.
+func goSumVersions(file string) (map[string]string, error) {
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	versions := make(map[string]string)
+	for s := bufio.NewScanner(bytes.NewReader(data)); s.Scan(); {
+		fields := strings.Fields(s.Text())
+		if len(fields) != 3 || strings.HasSuffix(fields[1], "/go.mod") {
+			continue
+		}
+		path, v := fields[0], fields[1]
+		if semver.Compare(v, versions[path]) > 0 {
+			versions[path] = v
+		}
+	}
+	return versions, nil
+}
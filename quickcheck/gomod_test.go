@@ -0,0 +1,135 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+)
+
+func TestGoModBuildList(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		files map[string]string
+		dir   string
+		want  []string
+	}{
+		{
+			name: "module",
+			files: map[string]string{
+				"go.mod": `module example.com/app
+
+go 1.18
+
+require (
+	example.com/a v1.2.0
+	example.com/b v0.1.0 // indirect
+	example.com/c v1.0.0
+)
+
+replace example.com/c => example.com/fork v1.0.1
+`,
+				// Ignored at go 1.17 and later.
+				"go.sum":      "example.com/old v1.0.0 h1:x=\n",
+				"cmd/main.go": "package main\n",
+			},
+			dir: "cmd",
+			want: []string{
+				"example.com/app main",
+				"example.com/a@v1.2.0",
+				"example.com/b@v0.1.0 indirect",
+				"example.com/c@v1.0.0 => example.com/fork@v1.0.1",
+			},
+		},
+		{
+			name: "go.sum before go 1.17",
+			files: map[string]string{
+				"go.mod": "module example.com/app\n\ngo 1.16\n\nrequire example.com/a v1.2.0\n",
+				"go.sum": `example.com/a v1.2.0 h1:x=
+example.com/a v1.2.0/go.mod h1:x=
+example.com/b v0.1.0 h1:x=
+example.com/b v0.2.0 h1:x=
+example.com/c v1.0.0/go.mod h1:x=
+`,
+			},
+			want: []string{
+				"example.com/app main",
+				"example.com/a@v1.2.0",
+				"example.com/b@v0.2.0 indirect",
+			},
+		},
+		{
+			name: "workspace",
+			files: map[string]string{
+				"go.work": "go 1.18\n\nuse (\n\t./app\n\t./lib\n)\n\nreplace example.com/a v1.3.0 => ./a\n",
+				"app/go.mod": `module example.com/app
+
+go 1.18
+
+require (
+	example.com/a v1.2.0
+	example.com/lib v0.0.0
+)
+`,
+				"lib/go.mod": "module example.com/lib\n\ngo 1.18\n\nrequire example.com/a v1.3.0 // indirect\n",
+			},
+			dir: "app",
+			want: []string{
+				"example.com/app main",
+				"example.com/lib main",
+				"example.com/a@v1.3.0 => ./a",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tc.files {
+				file := filepath.Join(dir, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(file), 0777); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(file, []byte(content), 0666); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Setenv("GOWORK", "")
+			mods, err := GoModBuildList(filepath.Join(dir, tc.dir))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, m := range mods {
+				got = append(got, moduleString(m))
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("GoModBuildList =\n%q\nwant\n%q", got, tc.want)
+			}
+		})
+	}
+	if _, err := GoModBuildList(t.TempDir()); err == nil {
+		t.Error("GoModBuildList succeeded without a go.mod file")
+	}
+}
+
+func moduleString(m *packages.Module) string {
+	if m.Main {
+		return m.Path + " main"
+	}
+	s := m.Path + "@" + m.Version
+	if m.Indirect {
+		s += " indirect"
+	}
+	if r := m.Replace; r != nil {
+		s += " => " + r.Path
+		if r.Version != "" {
+			s += "@" + r.Version
+		}
+	}
+	return s
+}
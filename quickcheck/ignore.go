@@ -0,0 +1,88 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import (
+	"fmt"
+	"strings"
+)
+
+// An IgnoreRule suppresses findings of a vulnerability.
+// A rule with only ID set suppresses all findings of the vulnerability.
+// A rule with PackagePath and Symbol set suppresses only the findings
+// of that symbol, so other symbols of the same vulnerability are still
+// reported when they become reachable.
+type IgnoreRule struct {
+	ID          string
+	PackagePath string
+	Symbol      string // e.g. "ParseConfig" or "Decoder.Decode"
+}
+
+// ParseIgnoreRule parses a rule of the form "ID" or "ID:pkgpath.Symbol",
+// e.g. "GO-2022-0001:example.com/foo.ParseConfig".
+func ParseIgnoreRule(s string) (IgnoreRule, error) {
+	id, sym, found := strings.Cut(strings.TrimSpace(s), ":")
+	if id == "" {
+		return IgnoreRule{}, fmt.Errorf("invalid ignore rule %q: missing vulnerability ID", s)
+	}
+	if !found {
+		return IgnoreRule{ID: id}, nil
+	}
+	symID, ok := ParseSymbolID(sym)
+	if !ok {
+		return IgnoreRule{}, fmt.Errorf("invalid ignore rule %q: want ID:pkgpath.Symbol", s)
+	}
+	return IgnoreRule{ID: id, PackagePath: symID.PkgPath, Symbol: symID.Symbol()}, nil
+}
+
+// ParseIgnoreRules parses a comma-separated list of ignore rules.
+func ParseIgnoreRules(s string) ([]IgnoreRule, error) {
+	var rules []IgnoreRule
+	for _, f := range strings.Split(s, ",") {
+		if strings.TrimSpace(f) == "" {
+			continue
+		}
+		r, err := ParseIgnoreRule(f)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+func (r IgnoreRule) String() string {
+	if r.PackagePath == "" {
+		return r.ID
+	}
+	return r.ID + ":" + r.PackagePath + "." + r.Symbol
+}
+
+// Match reports whether the rule suppresses the finding with the key.
+func (r IgnoreRule) Match(k Key) bool {
+	if r.ID != k.ID {
+		return false
+	}
+	if r.PackagePath == "" {
+		return true
+	}
+	return r.PackagePath == k.PackagePath && r.Symbol == k.Symbol
+}
+
+// Ignore returns a copy of summary without the findings
+// matched by any of the rules.
+func Ignore(summary map[Key]Value, rules []IgnoreRule) map[Key]Value {
+	res := make(map[Key]Value, len(summary))
+next:
+	for k, v := range summary {
+		for _, r := range rules {
+			if r.Match(k) {
+				continue next
+			}
+		}
+		res[k] = v
+	}
+	return res
+}
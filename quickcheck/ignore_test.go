@@ -0,0 +1,59 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import "testing"
+
+func TestParseIgnoreRule(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		want    IgnoreRule
+		wantErr bool
+	}{
+		{in: "GO-2022-0001", want: IgnoreRule{ID: "GO-2022-0001"}},
+		{in: "GO-2022-0001:example.com/foo.ParseConfig", want: IgnoreRule{"GO-2022-0001", "example.com/foo", "ParseConfig"}},
+		{in: "GO-2022-0001:example.com/foo.Decoder.Decode", want: IgnoreRule{"GO-2022-0001", "example.com/foo", "Decoder.Decode"}},
+		{in: "GO-2022-0001:net/http.Get", want: IgnoreRule{"GO-2022-0001", "net/http", "Get"}},
+		{in: "GO-2022-0001:fmt.Println", want: IgnoreRule{"GO-2022-0001", "fmt", "Println"}},
+		{in: "GO-2022-0001:gopkg.in/yaml.v2.Unmarshal", want: IgnoreRule{"GO-2022-0001", "gopkg.in/yaml.v2", "Unmarshal"}},
+		{in: ":example.com/foo.ParseConfig", wantErr: true},
+		{in: "GO-2022-0001:ParseConfig", wantErr: true},
+	} {
+		got, err := ParseIgnoreRule(tc.in)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("ParseIgnoreRule(%q) error = %v, want error %v", tc.in, err, tc.wantErr)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("ParseIgnoreRule(%q) = %+v, want %+v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestIgnore(t *testing.T) {
+	parse := Key{ID: "GO-2022-0001", PackagePath: "example.com/foo", Symbol: "ParseConfig"}
+	load := Key{ID: "GO-2022-0001", PackagePath: "example.com/foo", Symbol: "Load"}
+	other := Key{ID: "GO-2022-0002", PackagePath: "example.com/foo", Symbol: "ParseConfig"}
+	summary := map[Key]Value{parse: {}, load: {}, other: {}}
+
+	rules, err := ParseIgnoreRules("GO-2022-0001:example.com/foo.ParseConfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := Ignore(summary, rules)
+	if _, ok := got[parse]; ok {
+		t.Errorf("ignored finding %v was kept", parse)
+	}
+	if _, ok := got[load]; !ok {
+		t.Errorf("finding %v of another symbol was dropped", load)
+	}
+	if _, ok := got[other]; !ok {
+		t.Errorf("finding %v of another vulnerability was dropped", other)
+	}
+
+	if got := Ignore(summary, []IgnoreRule{{ID: "GO-2022-0001"}}); len(got) != 1 {
+		t.Errorf("ignoring the whole vulnerability left %d findings, want 1", len(got))
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyangah/vulns/internal/osvutil"
+	isem "github.com/hyangah/vulns/internal/xvuln/semver"
+	"golang.org/x/mod/semver"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/vuln/client"
+	"golang.org/x/vuln/osv"
+)
+
+// Reasons a known vulnerability of a module in the import closure
+// does not affect the analyzed packages.
+const (
+	// ReasonVersion means the version of the module
+	// is outside the affected ranges.
+	ReasonVersion = "version-not-affected"
+	// ReasonPlatform means the vulnerable packages are
+	// vulnerable only on other platforms than the target
+	// GOOS/GOARCH.
+	ReasonPlatform = "platform-mismatch"
+	// ReasonNotImported means none of the vulnerable
+	// packages is imported.
+	ReasonNotImported = "package-not-imported"
+	// ReasonUnreachable means a vulnerable package is
+	// imported, but no vulnerable symbol is reachable.
+	ReasonUnreachable = "symbols-unreachable"
+)
+
+// A NotAffected explains why a known vulnerability of a module in the
+// import closure of the analyzed packages does not affect them, as
+// evidence that it is not applicable.
+type NotAffected struct {
+	ID         string
+	ModulePath string
+	Version    string
+	Reason     string
+	// Detail describes the evidence, such as the platforms
+	// the vulnerable packages are vulnerable on.
+	Detail string `json:",omitempty"`
+	// Fixed is, for ReasonVersion, the highest version fixing the
+	// vulnerability that is not above Version, or the empty string
+	// if Version predates the vulnerability.
+	Fixed string `json:",omitempty"`
+}
+
+// NotAffectedBy returns the known vulnerabilities of the modules in the
+// import closure of pkgs that do not affect them, with the reason,
+// sorted by module path and ID. summary is the result of Analyze for
+// the same packages and determines which vulnerable symbols are
+// reachable.
+func NotAffectedBy(ctx context.Context, pkgs []*packages.Package, dbClient client.Client, summary map[Key]Value) ([]*NotAffected, error) {
+	modEntries, err := osvutil.FetchModuleOSVEntries(ctx, dbClient, pkgs)
+	if err != nil {
+		return nil, err
+	}
+	return notAffected(modEntries, osvutil.ImportedPackages(pkgs), summary), nil
+}
+
+func notAffected(modEntries []*osvutil.ModuleEntries, imported map[string]bool, summary map[Key]Value) []*NotAffected {
+	var res []*NotAffected
+	for _, me := range modEntries {
+		m := me.Module
+		if m.Replace != nil {
+			m = m.Replace
+		}
+		for _, e := range me.Unaffected {
+			affected := affectedModule(m.Path, e)
+			res = append(res, &NotAffected{
+				ID:         e.ID,
+				ModulePath: m.Path,
+				Version:    m.Version,
+				Reason:     ReasonVersion,
+				Detail:     "affected versions: " + affectedRanges(affected),
+				Fixed:      maxFixedVersion(affected, m.Version),
+			})
+		}
+		affecting := make(map[string]bool)
+		for _, e := range me.Entries {
+			affecting[e.ID] = true
+		}
+		platforms := make(map[string][]string) // ID -> platforms of the excluded packages
+		var excluded []string
+		for _, ex := range me.Excluded {
+			if affecting[ex.Entry.ID] {
+				continue
+			}
+			if platforms[ex.Entry.ID] == nil {
+				excluded = append(excluded, ex.Entry.ID)
+			}
+			platforms[ex.Entry.ID] = append(platforms[ex.Entry.ID], fmt.Sprintf("%s (%s)", ex.Import.Path, platformList(ex.Import.GOOS, ex.Import.GOARCH)))
+		}
+		for _, id := range excluded {
+			res = append(res, &NotAffected{
+				ID:         id,
+				ModulePath: m.Path,
+				Version:    m.Version,
+				Reason:     ReasonPlatform,
+				Detail:     "vulnerable only on other platforms: " + strings.Join(platforms[id], ", "),
+			})
+		}
+	}
+	for _, mv := range dependencies(modEntries, imported, summary) {
+		for _, v := range mv.Vulns {
+			na := &NotAffected{ID: v.ID, ModulePath: mv.Path, Version: mv.Version}
+			switch v.Verdict {
+			case VerdictNotImported:
+				na.Reason = ReasonNotImported
+			case VerdictImported:
+				na.Reason = ReasonUnreachable
+			default:
+				continue
+			}
+			res = append(res, na)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].ModulePath != res[j].ModulePath {
+			return res[i].ModulePath < res[j].ModulePath
+		}
+		return res[i].ID < res[j].ID
+	})
+	return res
+}
+
+// platformList formats the platforms of a vulnerable package,
+// such as "GOOS=windows,plan9".
+func platformList(goos, goarch []string) string {
+	var parts []string
+	if len(goos) > 0 {
+		parts = append(parts, "GOOS="+strings.Join(goos, ","))
+	}
+	if len(goarch) > 0 {
+		parts = append(parts, "GOARCH="+strings.Join(goarch, ","))
+	}
+	return strings.Join(parts, " ")
+}
+
+// affectedRanges formats the semver ranges of the affected entries,
+// such as "[0, v1.1.0), [v1.3.0, v1.3.1)".
+func affectedRanges(affected []osv.Affected) string {
+	var ranges []string
+	for _, a := range affected {
+		for _, r := range a.Ranges {
+			if r.Type != osv.TypeSemver {
+				continue
+			}
+			introduced := ""
+			for _, ev := range r.Events {
+				switch {
+				case ev.Introduced != "":
+					if introduced != "" {
+						ranges = append(ranges, fmt.Sprintf("[%s, )", introduced))
+					}
+					introduced = semverOf(ev.Introduced)
+				case ev.Fixed != "":
+					if introduced == "" {
+						introduced = "0"
+					}
+					ranges = append(ranges, fmt.Sprintf("[%s, %s)", introduced, semverOf(ev.Fixed)))
+					introduced = ""
+				}
+			}
+			if introduced != "" {
+				ranges = append(ranges, fmt.Sprintf("[%s, )", introduced))
+			}
+		}
+	}
+	return strings.Join(ranges, ", ")
+}
+
+// maxFixedVersion returns the highest fixed version recorded in the
+// affected ranges that is not above the version, or the empty string
+// if there is none.
+func maxFixedVersion(affected []osv.Affected, version string) string {
+	max := ""
+	for _, a := range affected {
+		for _, r := range a.Ranges {
+			if r.Type != osv.TypeSemver {
+				continue
+			}
+			for _, ev := range r.Events {
+				if ev.Fixed == "" {
+					continue
+				}
+				fixed := isem.CanonicalizeSemverPrefix(ev.Fixed)
+				if semver.Compare(fixed, version) <= 0 && (max == "" || semver.Compare(fixed, max) > 0) {
+					max = fixed
+				}
+			}
+		}
+	}
+	return max
+}
+
+// semverOf returns the OSV version as a semantic version,
+// but for "0", which stands for all versions.
+func semverOf(v string) string {
+	if v == "0" {
+		return v
+	}
+	return isem.CanonicalizeSemverPrefix(v)
+}
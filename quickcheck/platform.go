@@ -0,0 +1,59 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import (
+	"context"
+	"sort"
+
+	"github.com/hyangah/vulns/internal/osvutil"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/vuln/client"
+)
+
+// A FilteredFinding is a vulnerability of an imported package that
+// is excluded from the analysis because it affects only platforms
+// other than the target GOOS/GOARCH. Builds for those platforms
+// may still be affected.
+type FilteredFinding struct {
+	ID          string
+	PackagePath string
+	ModulePath  string
+	GOOS        []string `json:",omitempty"`
+	GOARCH      []string `json:",omitempty"`
+}
+
+// Filtered returns the vulnerabilities of the packages in the import
+// closure of pkgs that are excluded because of platform mismatch,
+// sorted by ID and package path.
+func Filtered(ctx context.Context, pkgs []*packages.Package, dbClient client.Client) ([]*FilteredFinding, error) {
+	modEntries, err := osvutil.FetchModuleOSVEntries(ctx, dbClient, pkgs)
+	if err != nil {
+		return nil, err
+	}
+	imported := osvutil.ImportedPackages(pkgs)
+	var res []*FilteredFinding
+	for _, me := range modEntries {
+		for _, ex := range me.Excluded {
+			if !imported[ex.Import.Path] {
+				continue
+			}
+			res = append(res, &FilteredFinding{
+				ID:          ex.Entry.ID,
+				PackagePath: ex.Import.Path,
+				ModulePath:  me.Module.Path,
+				GOOS:        ex.Import.GOOS,
+				GOARCH:      ex.Import.GOARCH,
+			})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].ID != res[j].ID {
+			return res[i].ID < res[j].ID
+		}
+		return res[i].PackagePath < res[j].PackagePath
+	})
+	return res, nil
+}
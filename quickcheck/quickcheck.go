@@ -10,11 +10,13 @@ package quickcheck
 import (
 	"context"
 	"fmt"
+	"os"
+	"sort"
 	"strings"
 
 	vulnsanalysis "github.com/hyangah/vulns/analysis"
-	"github.com/hyangah/vulns/internal/checker"
 	"github.com/hyangah/vulns/internal/osvutil"
+	"github.com/hyangah/vulns/internal/xtools/checker"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/packages"
 	"golang.org/x/vuln/client"
@@ -35,9 +37,80 @@ type Key struct {
 	PackagePath string
 	ModulePath  string
 }
+
+// A SymbolID identifies a vulnerable symbol.
+type SymbolID = vulnsanalysis.SymbolID
+
+// ParseSymbolID parses a qualified symbol name, such as
+// "example.com/foo.Decoder.Decode". See vulnsanalysis.ParseSymbolID.
+func ParseSymbolID(s string) (SymbolID, bool) { return vulnsanalysis.ParseSymbolID(s) }
+
+// SymbolID returns the identity of the vulnerable symbol of the
+// finding. It is the zero SymbolID for findings of whole modules.
+func (k Key) SymbolID() SymbolID {
+	sym := SymbolID{PkgPath: k.PackagePath, Name: k.Symbol}
+	if recv, name, ok := strings.Cut(k.Symbol, "."); ok {
+		sym.Recv, sym.Name = recv, name
+	}
+	return sym
+}
+
 type Value struct {
 	Trace []string
 	Count int64
+	// Entries lists the distinct entry points of the traces to the
+	// finding, that is, their first symbols, sorted. Only the
+	// shortest trace is kept in Trace.
+	Entries []string `json:",omitempty"`
+	// EntryTraces maps each of the Entries to the shortest trace
+	// from it, for reports keyed by entry point, such as the exposed
+	// API of a library analyzed with -roots=exported.
+	EntryTraces map[string][]string `json:"-"`
+	// Provenance is where the OSV entry for the finding came
+	// from, if known.
+	Provenance *Provenance `json:",omitempty"`
+	// Attrs lists the attributes of the static context of the
+	// finding, such as AttrTestOnly.
+	Attrs []string `json:",omitempty"`
+	// Fix is the version of the module that fixes the
+	// vulnerability, if known (see ResolveFixes).
+	Fix string `json:",omitempty"`
+	// Version is the version of the module in use, if known.
+	Version string `json:",omitempty"`
+	// MinFix is the smallest version of the module above Version
+	// that fixes the vulnerability according to the OSV entry,
+	// if known (see ResolveFixes).
+	MinFix string `json:",omitempty"`
+}
+
+// Provenance records where an OSV entry came from.
+type Provenance = osvutil.Provenance
+
+// A Finding is a vulnerable symbol reachable from the analyzed
+// packages along with a representative reference path.
+type Finding struct {
+	Key
+	Value
+}
+
+// Findings returns the entries of summary as a list sorted
+// by vulnerability ID, package path, and symbol.
+func Findings(summary map[Key]Value) []*Finding {
+	findings := make([]*Finding, 0, len(summary))
+	for k, v := range summary {
+		findings = append(findings, &Finding{Key: k, Value: v})
+	}
+	sort.Slice(findings, func(i, j int) bool {
+		fi, fj := findings[i], findings[j]
+		if fi.ID != fj.ID {
+			return fi.ID < fj.ID
+		}
+		if fi.PackagePath != fj.PackagePath {
+			return fi.PackagePath < fj.PackagePath
+		}
+		return fi.Symbol < fj.Symbol
+	})
+	return findings
 }
 
 // Analyze runs the reference graph analysis on the given packages.
@@ -48,6 +121,22 @@ type Value struct {
 // this function first writes the OSV entries to the disk first
 // and let the analyzer read them from the file back.
 func Analyze(ctx context.Context, pkgs []*packages.Package, dbClient client.Client) (map[Key]Value, map[string][]*osv.Entry, error) {
+	byPkg, pkg2vulns, err := AnalyzeByPackage(ctx, pkgs, dbClient)
+	if err != nil || pkg2vulns == nil {
+		return nil, nil, err
+	}
+	summaries := make([]map[Key]Value, 0, len(pkgs))
+	for _, p := range pkgs {
+		summaries = append(summaries, byPkg[p.ID])
+	}
+	return Merge(summaries...), pkg2vulns, nil
+}
+
+// AnalyzeByPackage is like Analyze, but returns the findings reachable
+// from each of pkgs separately, keyed by package ID. Programs that
+// analyze the packages again after some of them change can merge the
+// new findings of those with the previous findings of the others.
+func AnalyzeByPackage(ctx context.Context, pkgs []*packages.Package, dbClient client.Client) (map[string]map[Key]Value, map[string][]*osv.Entry, error) {
 	var a = vulnsanalysis.Analyzer // singleton!
 	analyzers := []*analysis.Analyzer{a}
 
@@ -58,7 +147,8 @@ func Analyze(ctx context.Context, pkgs []*packages.Package, dbClient client.Clie
 	if len(pkg2vulns) == 0 {
 		return nil, nil, nil
 	}
-	vulnsJSONFile, err := vulnsanalysis.DumpVulnInfo(pkg2vulns)
+	prov := osvutil.Provenances(dbClient, pkg2vulns)
+	vulnsJSONFile, err := vulnsanalysis.DumpCatalog(&vulnsanalysis.Catalog{PkgToVulns: pkg2vulns, Provenance: prov})
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to prepare vulns-json file (%d vulns): %v)", len(pkg2vulns), err)
 	}
@@ -67,25 +157,32 @@ func Analyze(ctx context.Context, pkgs []*packages.Package, dbClient client.Clie
 	a.Flags.Set("vulns-json", vulnsJSONFile)
 
 	results := checker.Analyze(pkgs, analyzers)
+	// The analyzer has read the catalog, and the next call, such as
+	// in watch mode, writes another one.
+	os.Remove(vulnsJSONFile)
 
-	summary := make(map[Key]Value)
-
+	direct := directModules(pkgs)
+	versions := moduleVersions(osvutil.Modules(pkgs))
+	byPkg := make(map[string]map[Key]Value)
 	for _, r := range results {
+		summary := make(map[Key]Value)
+		contexts := make(map[Key]callContext)
+		entries := make(map[Key]map[string]bool)
+		entryTraces := make(map[Key]map[string][]string)
 		// ASK(adonovan): can we make Diagnostics carry arbitrary
 		// serializable data in Diagnostics? Here it would be nice
 		// I could just carry structured data (package, symbol, path, ...)
 		for _, d := range r.Diagnostics {
-			// Category carries ID:packagepath.symbol info.
-			id, objname, found := strings.Cut(d.Category, ":")
-			if !found {
-				panic(fmt.Sprintf("invalid diagnostics category obeserved: %+v", d))
+			// Category carries the ID and the vulnerable symbol.
+			id, sym, err := vulnsanalysis.ParseCategory(d.Category)
+			if err != nil {
+				panic(fmt.Sprintf("invalid diagnostics category obeserved: %+v: %v", d, err))
 			}
-			pkgpath, name := parseObjectNameStr(objname)
 			modpath := ""
-			if vul := pkg2vulns[pkgpath]; len(vul) > 0 {
+			if vul := pkg2vulns[sym.PkgPath]; len(vul) > 0 {
 				modpath = vul[0].Affected[0].Package.Name
 			}
-			key := Key{ID: id, ModulePath: modpath, PackagePath: pkgpath, Symbol: name}
+			key := Key{ID: id, ModulePath: modpath, PackagePath: sym.PkgPath, Symbol: sym.Symbol()}
 			_, paths, found := strings.Cut(d.Message, "|")
 			if !found {
 				paths = d.Message
@@ -103,20 +200,119 @@ func Analyze(ctx context.Context, pkgs []*packages.Package, dbClient client.Clie
 				}
 			}
 			summary[key] = value
+			if entries[key] == nil {
+				entries[key] = make(map[string]bool)
+			}
+			entry, _, _ := strings.Cut(paths, "\t")
+			entry, _, _ = strings.Cut(entry, " ")
+			entries[key][entry] = true
+			trace := strings.Split(paths, "\t")
+			if entryTraces[key] == nil {
+				entryTraces[key] = make(map[string][]string)
+			}
+			if prev, ok := entryTraces[key][entry]; !ok || len(trace) < len(prev) {
+				entryTraces[key][entry] = trace
+			}
+
+			c := occurrenceContext(r.Package, d.Pos)
+			c.typeUsage = vulnsanalysis.ViaType(trace)
+			c.linkname = vulnsanalysis.ViaLinkname(trace)
+			c.renamedReceiver = renamedReceiver(pkg2vulns[sym.PkgPath], id, sym)
+			if prev, ok := contexts[key]; ok {
+				c = prev.merge(c)
+			}
+			contexts[key] = c
+		}
+		for k, v := range summary {
+			v.Attrs = contexts[k].attrs()
+			if a := requirementAttr(pkgs, direct, k.ModulePath); a != "" {
+				v.Attrs = append(v.Attrs, a)
+			}
+			v.Version = versions[k.ModulePath]
+			v.Entries = sortedSet(entries[k])
+			v.EntryTraces = entryTraces[k]
+			summary[k] = v
 		}
+		addProvenance(summary, prov)
+		byPkg[r.Package.ID] = summary
 	}
-	return summary, pkg2vulns, nil
+	return byPkg, pkg2vulns, nil
 }
 
-func parseObjectNameStr(unquotedName string) (pkgpath, name string) {
-	lastSlash := strings.LastIndex(unquotedName, "/")
-	if lastSlash < 0 {
-		return "", unquotedName
+// Merge merges the findings of the summaries, such as the ones
+// returned by AnalyzeByPackage, as if the packages were analyzed
+// together. The counts add up, the shortest trace wins, and an
+// attribute holds only if it holds in all the summaries with the
+// finding. The entry points are the union of the ones in the
+// summaries, each with its shortest trace.
+func Merge(summaries ...map[Key]Value) map[Key]Value {
+	merged := make(map[Key]Value)
+	for _, summary := range summaries {
+		for k, v := range summary {
+			prev, ok := merged[k]
+			if !ok {
+				merged[k] = v
+				continue
+			}
+			prev.Count += v.Count
+			if len(v.Trace) < len(prev.Trace) {
+				prev.Trace = v.Trace
+			}
+			var attrs []string
+			for _, a := range prev.Attrs {
+				if v.HasAttr(a) {
+					attrs = append(attrs, a)
+				}
+			}
+			prev.Attrs = attrs
+			if len(v.Entries) > 0 {
+				set := make(map[string]bool)
+				for _, e := range prev.Entries {
+					set[e] = true
+				}
+				for _, e := range v.Entries {
+					set[e] = true
+				}
+				prev.Entries = sortedSet(set)
+			}
+			if len(v.EntryTraces) > 0 {
+				traces := make(map[string][]string)
+				for e, t := range prev.EntryTraces {
+					traces[e] = t
+				}
+				for e, t := range v.EntryTraces {
+					if pt, ok := traces[e]; !ok || len(t) < len(pt) {
+						traces[e] = t
+					}
+				}
+				prev.EntryTraces = traces
+			}
+			merged[k] = prev
+		}
 	}
-	before, after := unquotedName[:lastSlash], unquotedName[lastSlash:]
-	beforeDot, afterDot, found := strings.Cut(after, ".")
-	if !found {
-		return "", unquotedName
+	return merged
+}
+
+// sortedSet returns the elements of the set, sorted.
+func sortedSet(set map[string]bool) []string {
+	if len(set) == 0 {
+		return nil
+	}
+	list := make([]string, 0, len(set))
+	for s := range set {
+		list = append(list, s)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// addProvenance sets the Provenance field of the findings
+// in summary to the provenance of their entries in prov.
+func addProvenance(summary map[Key]Value, prov map[string]Provenance) {
+	for k, v := range summary {
+		if p, ok := prov[k.ID]; ok {
+			v.Provenance = &p
+			summary[k] = v
+		}
 	}
-	return before + beforeDot, afterDot
 }
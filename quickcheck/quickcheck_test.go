@@ -0,0 +1,36 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMerge(t *testing.T) {
+	both := Key{ID: "GO-2022-0001", PackagePath: "example.com/foo", Symbol: "ParseConfig"}
+	only := Key{ID: "GO-2022-0002", PackagePath: "example.com/foo", Symbol: "Load"}
+	a := map[Key]Value{
+		both: {Trace: []string{"a.Run", "foo.ParseConfig"}, Count: 1, Entries: []string{"a.Run"}, Attrs: []string{AttrTestOnly, AttrDirect},
+			EntryTraces: map[string][]string{"a.Run": {"a.Run", "foo.ParseConfig"}}},
+	}
+	b := map[Key]Value{
+		both: {Trace: []string{"b.Run", "b.run", "foo.ParseConfig"}, Count: 2, Entries: []string{"a.Run", "b.Run"}, Attrs: []string{AttrDirect},
+			EntryTraces: map[string][]string{"a.Run": {"a.Run", "a.run", "b.run", "foo.ParseConfig"}, "b.Run": {"b.Run", "b.run", "foo.ParseConfig"}}},
+		only: {Trace: []string{"b.Load", "foo.Load"}, Count: 1, Attrs: []string{AttrTestOnly}},
+	}
+	want := map[Key]Value{
+		both: {Trace: []string{"a.Run", "foo.ParseConfig"}, Count: 3, Entries: []string{"a.Run", "b.Run"}, Attrs: []string{AttrDirect},
+			EntryTraces: map[string][]string{"a.Run": {"a.Run", "foo.ParseConfig"}, "b.Run": {"b.Run", "b.run", "foo.ParseConfig"}}},
+		only: {Trace: []string{"b.Load", "foo.Load"}, Count: 1, Attrs: []string{AttrTestOnly}},
+	}
+	if diff := cmp.Diff(want, Merge(a, b)); diff != "" {
+		t.Errorf("Merge(a, b) mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(want, Merge(b, a)); diff != "" {
+		t.Errorf("Merge(b, a) mismatch (-want +got):\n%s", diff)
+	}
+}
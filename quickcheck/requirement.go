@@ -0,0 +1,90 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import (
+	"os"
+
+	"github.com/hyangah/vulns/stdlib"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/tools/go/packages"
+)
+
+// Attributes describing how the vulnerable module is required.
+// The remediation differs: a direct requirement is upgraded in
+// place, while an indirect one needs an explicit requirement.
+const (
+	// AttrDirect marks findings in modules directly
+	// required by a main module.
+	AttrDirect = "direct-dependency"
+	// AttrIndirect marks findings in modules only
+	// required through other dependencies.
+	AttrIndirect = "indirect-dependency"
+)
+
+// directModules returns the set of the paths of the modules
+// directly required by the main modules of pkgs, as recorded
+// in their go.mod files without an // indirect comment.
+func directModules(pkgs []*packages.Package) map[string]bool {
+	var mods []*packages.Module
+	for _, p := range pkgs {
+		mods = append(mods, p.Module)
+	}
+	return directRequirements(mods)
+}
+
+// directRequirements is like directModules, but
+// for the main modules among mods.
+func directRequirements(mods []*packages.Module) map[string]bool {
+	direct := make(map[string]bool)
+	seen := make(map[string]bool)
+	for _, m := range mods {
+		if m == nil || !m.Main || m.GoMod == "" || seen[m.GoMod] {
+			continue
+		}
+		seen[m.GoMod] = true
+		data, err := os.ReadFile(m.GoMod)
+		if err != nil {
+			continue
+		}
+		f, err := modfile.ParseLax(m.GoMod, data, nil)
+		if err != nil {
+			continue
+		}
+		for _, r := range f.Require {
+			if !r.Indirect {
+				direct[r.Mod.Path] = true
+			}
+		}
+	}
+	return direct
+}
+
+// isDependency reports whether the module is a dependency,
+// as opposed to a main module or the standard library.
+func isDependency(pkgs []*packages.Package, modPath string) bool {
+	if modPath == "" || modPath == stdlib.ModulePath {
+		return false
+	}
+	for _, m := range MainModules(pkgs) {
+		if m == modPath {
+			return false
+		}
+	}
+	return true
+}
+
+// requirementAttr returns the attribute describing
+// how the module is required, if it is a dependency.
+func requirementAttr(pkgs []*packages.Package, direct map[string]bool, modPath string) string {
+	switch {
+	case !isDependency(pkgs, modPath):
+		return ""
+	case direct[modPath]:
+		return AttrDirect
+	default:
+		return AttrIndirect
+	}
+}
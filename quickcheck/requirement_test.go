@@ -0,0 +1,44 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+)
+
+func TestRequirementAttr(t *testing.T) {
+	gomod := filepath.Join(t.TempDir(), "go.mod")
+	if err := os.WriteFile(gomod, []byte(`module example.com/main
+
+go 1.18
+
+require (
+	example.com/direct v1.0.0
+	example.com/indirect v1.0.0 // indirect
+)
+`), 0666); err != nil {
+		t.Fatal(err)
+	}
+	pkgs := []*packages.Package{{
+		PkgPath: "example.com/main",
+		Module:  &packages.Module{Path: "example.com/main", Main: true, GoMod: gomod},
+	}}
+	direct := directModules(pkgs)
+	for mod, want := range map[string]string{
+		"example.com/direct":   AttrDirect,
+		"example.com/indirect": AttrIndirect,
+		"example.com/unlisted": AttrIndirect,
+		"example.com/main":     "",
+		"stdlib":               "",
+	} {
+		if got := requirementAttr(pkgs, direct, mod); got != want {
+			t.Errorf("requirementAttr(%q) = %q, want %q", mod, got, want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/hyangah/vulns/stdlib"
+	"golang.org/x/mod/semver"
+	"golang.org/x/tools/go/packages"
+)
+
+// The subsets of the SPDX 2 and CycloneDX JSON formats read by SBOMModules.
+type (
+	sbomJSON struct {
+		// CycloneDX
+		BOMFormat string `json:"bomFormat"`
+		Metadata  struct {
+			Component *cdxComponent `json:"component"`
+		} `json:"metadata"`
+		Components []*cdxComponent `json:"components"`
+		// SPDX
+		SPDXVersion string        `json:"spdxVersion"`
+		Packages    []spdxPackage `json:"packages"`
+	}
+	cdxComponent struct {
+		PURL       string          `json:"purl"`
+		Components []*cdxComponent `json:"components"`
+	}
+	spdxPackage struct {
+		ExternalRefs []struct {
+			ReferenceType    string `json:"referenceType"`
+			ReferenceLocator string `json:"referenceLocator"`
+		} `json:"externalRefs"`
+	}
+)
+
+// SBOMModules returns the Go modules listed in the SBOM file, for use
+// with AnalyzeModules, such as to scan an artifact delivered by a
+// third party without its source. The SBOM is an SPDX 2 document, in
+// the JSON or the tag-value format, or a CycloneDX JSON document. The
+// modules are the components or the packages with a pkg:golang package
+// URL, in the order of the file, without duplicates; the standard
+// library is the "stdlib" module, at a semantic version or a Go version
+// such as "go1.19.1". The component described by a CycloneDX document
+// is a main module.
+func SBOMModules(path string) ([]*packages.Module, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var purls []string
+	var main string
+	switch {
+	case bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")):
+		var doc sbomJSON
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		switch {
+		case doc.BOMFormat == "CycloneDX":
+			if c := doc.Metadata.Component; c != nil {
+				main = c.PURL
+				purls = append(purls, c.PURL)
+			}
+			var walk func([]*cdxComponent)
+			walk = func(cs []*cdxComponent) {
+				for _, c := range cs {
+					purls = append(purls, c.PURL)
+					walk(c.Components)
+				}
+			}
+			walk(doc.Components)
+		case strings.HasPrefix(doc.SPDXVersion, "SPDX-"):
+			for _, p := range doc.Packages {
+				for _, ref := range p.ExternalRefs {
+					if ref.ReferenceType == "purl" {
+						purls = append(purls, ref.ReferenceLocator)
+					}
+				}
+			}
+		default:
+			return nil, fmt.Errorf("%s: not an SPDX or CycloneDX JSON document", path)
+		}
+	case bytes.Contains(data, []byte("SPDXVersion:")):
+		// ExternalRef: PACKAGE-MANAGER purl pkg:golang/...
+		for s := bufio.NewScanner(bytes.NewReader(data)); s.Scan(); {
+			f := strings.Fields(s.Text())
+			if len(f) == 4 && f[0] == "ExternalRef:" && f[2] == "purl" {
+				purls = append(purls, f[3])
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%s: unsupported SBOM format, want SPDX (JSON or tag-value) or CycloneDX JSON", path)
+	}
+
+	var mods []*packages.Module
+	seen := make(map[string]bool)
+	for _, p := range purls {
+		m, ok := parseGoPURL(p)
+		if !ok || seen[m.Path+"@"+m.Version] {
+			continue
+		}
+		seen[m.Path+"@"+m.Version] = true
+		m.Main = p == main
+		mods = append(mods, m)
+	}
+	return mods, nil
+}
+
+// parseGoPURL returns the module of the pkg:golang package URL, such
+// as pkg:golang/github.com/gin-gonic/gin@v1.6.0, and whether it is one.
+// The version is empty if the URL has none.
+func parseGoPURL(purl string) (*packages.Module, bool) {
+	const prefix = "pkg:golang/"
+	if len(purl) < len(prefix) || !strings.EqualFold(purl[:len(prefix)], prefix) {
+		return nil, false
+	}
+	purl = purl[len(prefix):]
+	purl, _, _ = strings.Cut(purl, "#") // subpath
+	purl, _, _ = strings.Cut(purl, "?") // qualifiers
+	var version string
+	if i := strings.LastIndex(purl, "@"); i >= 0 {
+		purl, version = purl[:i], purl[i+1:]
+	}
+	path, err := url.PathUnescape(purl)
+	if err != nil || path == "" {
+		return nil, false
+	}
+	if version, err = url.PathUnescape(version); err != nil {
+		return nil, false
+	}
+	if path == stdlib.ModulePath || path == "std" {
+		if strings.HasPrefix(version, "go") {
+			return stdlib.Module(version), true
+		}
+		path = stdlib.ModulePath
+	}
+	if version != "" && !strings.HasPrefix(version, "v") && semver.IsValid("v"+version) {
+		version = "v" + version
+	}
+	return &packages.Module{Path: path, Version: version}, true
+}
@@ -0,0 +1,96 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSBOMModules(t *testing.T) {
+	for _, tc := range []struct {
+		name, sbom string
+		want       []string
+	}{
+		{
+			name: "cyclonedx",
+			sbom: `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.4",
+  "metadata": {"component": {"name": "app", "purl": "pkg:golang/example.com/app@v0.1.0"}},
+  "components": [
+    {"purl": "pkg:golang/github.com/gin-gonic/gin@v1.6.0?type=module", "components": [
+      {"purl": "pkg:golang/golang.org/x/text@v0.3.5#language"}
+    ]},
+    {"purl": "pkg:npm/left-pad@1.0.0"},
+    {"purl": "pkg:golang/stdlib@go1.19.1"},
+    {"purl": "pkg:golang/github.com/gin-gonic/gin@v1.6.0"}
+  ]
+}`,
+			want: []string{
+				"example.com/app main",
+				"github.com/gin-gonic/gin@v1.6.0",
+				"golang.org/x/text@v0.3.5",
+				"stdlib@v1.19.1",
+			},
+		},
+		{
+			name: "spdx json",
+			sbom: `{
+  "spdxVersion": "SPDX-2.3",
+  "packages": [
+    {"name": "gin", "externalRefs": [{"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl", "referenceLocator": "pkg:golang/github.com/gin-gonic/gin@1.6.0"}]},
+    {"name": "no-refs"},
+    {"name": "std", "externalRefs": [{"referenceType": "purl", "referenceLocator": "pkg:golang/stdlib@1.19.1"}]}
+  ]
+}`,
+			want: []string{
+				"github.com/gin-gonic/gin@v1.6.0",
+				"stdlib@v1.19.1",
+			},
+		},
+		{
+			name: "spdx tag-value",
+			sbom: `SPDXVersion: SPDX-2.3
+DataLicense: CC0-1.0
+
+PackageName: github.com/BurntSushi/toml
+ExternalRef: PACKAGE-MANAGER purl pkg:golang/github.com/%21burnt%21sushi/toml@v1.2.0
+ExternalRef: SECURITY cpe23Type cpe:2.3:a:toml:toml:1.2.0:*:*:*:*:*:*:*
+`,
+			want: []string{"github.com/!burnt!sushi/toml@v1.2.0"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "sbom")
+			if err := os.WriteFile(file, []byte(tc.sbom), 0666); err != nil {
+				t.Fatal(err)
+			}
+			mods, err := SBOMModules(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, m := range mods {
+				got = append(got, moduleString(m))
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("SBOMModules =\n%q\nwant\n%q", got, tc.want)
+			}
+		})
+	}
+
+	for _, bad := range []string{`{"bomFormat": "other"}`, `<bom xmlns="http://cyclonedx.org/schema/bom/1.4"/>`} {
+		file := filepath.Join(t.TempDir(), "sbom")
+		if err := os.WriteFile(file, []byte(bad), 0666); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := SBOMModules(file); err == nil {
+			t.Errorf("SBOMModules succeeded with %s", bad)
+		}
+	}
+}
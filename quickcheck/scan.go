@@ -0,0 +1,104 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import (
+	"context"
+
+	"github.com/hyangah/vulns/internal/osvutil"
+	"github.com/hyangah/vulns/stdlib"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/vuln/client"
+	"golang.org/x/vuln/osv"
+)
+
+// Scan levels, from the cheapest and least precise to the most
+// expensive and precise one.
+const (
+	// ScanGoMod is like ScanModule, but reads the build list from the
+	// go.mod, go.sum, and go.work files without running the go
+	// command (see GoModBuildList). It takes seconds, for coarse
+	// inventory scans.
+	ScanGoMod = "gomod"
+	// ScanModule reports the vulnerabilities affecting the versions
+	// of the modules in the build list. It needs only go.mod data.
+	ScanModule = "module"
+	// ScanPackage reports the vulnerabilities affecting the packages
+	// in the import closure. It needs only the import graph.
+	ScanPackage = "package"
+	// ScanSymbol reports the vulnerable symbols reachable from the
+	// analyzed packages. It needs type-checked syntax (see Analyze).
+	ScanSymbol = "symbol"
+)
+
+// AnalyzeModules is like Analyze, but at the ScanModule or ScanGoMod
+// level. The findings have neither package path nor symbol, and the
+// returned entries are keyed by module path rather than package path.
+// modules is the build list, such as the one reported by
+// "go list -m all" or by GoModBuildList; it includes the main modules.
+func AnalyzeModules(ctx context.Context, modules []*packages.Module, dbClient client.Client) (map[Key]Value, map[string][]*osv.Entry, error) {
+	modEntries, err := osvutil.FetchBuildListOSVEntries(ctx, dbClient, modules)
+	if err != nil {
+		return nil, nil, err
+	}
+	direct := directRequirements(modules)
+	// Without go.mod files, as for a binary's build list,
+	// how the modules are required is unknown.
+	hasGoMod := false
+	for _, m := range modules {
+		hasGoMod = hasGoMod || (m.Main && m.GoMod != "")
+	}
+	versions := moduleVersions(osvutil.BuildListModules(modules))
+	summary := make(map[Key]Value)
+	mod2vulns := make(map[string][]*osv.Entry)
+	for _, me := range modEntries {
+		if len(me.Entries) == 0 {
+			continue
+		}
+		m := me.Module
+		if m.Replace != nil {
+			m = m.Replace
+		}
+		mod2vulns[m.Path] = me.Entries
+		var attrs []string
+		if hasGoMod && m.Path != stdlib.ModulePath {
+			if direct[m.Path] {
+				attrs = []string{AttrDirect}
+			} else {
+				attrs = []string{AttrIndirect}
+			}
+		}
+		for _, e := range me.Entries {
+			summary[Key{ID: e.ID, ModulePath: m.Path}] = Value{Count: 1, Attrs: attrs, Version: versions[m.Path]}
+		}
+	}
+	addProvenance(summary, osvutil.Provenances(dbClient, mod2vulns))
+	return summary, mod2vulns, nil
+}
+
+// AnalyzePackages is like Analyze, but at the ScanPackage level.
+// The findings have no symbol and no trace. pkgs need only the
+// import graph and the module information.
+func AnalyzePackages(ctx context.Context, pkgs []*packages.Package, dbClient client.Client) (map[Key]Value, map[string][]*osv.Entry, error) {
+	pkg2vulns, err := osvutil.FetchOSVEntries(ctx, dbClient, pkgs)
+	if err != nil {
+		return nil, nil, err
+	}
+	direct := directModules(pkgs)
+	versions := moduleVersions(osvutil.Modules(pkgs))
+	summary := make(map[Key]Value)
+	for pkgpath, vulns := range pkg2vulns {
+		modpath := vulns[0].Affected[0].Package.Name
+		v := Value{Count: 1, Version: versions[modpath]}
+		if a := requirementAttr(pkgs, direct, modpath); a != "" && !stdlib.Contains(modpath) {
+			v.Attrs = []string{a}
+		}
+		for _, e := range vulns {
+			summary[Key{ID: e.ID, ModulePath: modpath, PackagePath: pkgpath}] = v
+		}
+	}
+	addProvenance(summary, osvutil.Provenances(dbClient, pkg2vulns))
+	return summary, pkg2vulns, nil
+}
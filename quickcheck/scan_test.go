@@ -0,0 +1,97 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hyangah/vulns/testutils"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/vuln/client"
+)
+
+func TestScanLevels(t *testing.T) {
+	ctx := context.Background()
+	db, err := testutils.NewDatabase(ctx, []byte(`
+-- GO-2020-0001.yaml --
+modules:
+  - module: example.com/m
+    versions:
+      - fixed: 1.1.0
+    packages:
+      - package: example.com/m/p
+description: |
+    Something.
+published: 2021-04-14T20:04:52Z
+-- GO-2020-0002.yaml --
+modules:
+  - module: example.com/m
+    versions:
+      - fixed: 1.1.0
+    packages:
+      - package: example.com/m/q
+description: |
+    Something else.
+published: 2021-04-14T20:04:52Z
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Clean()
+	cli, err := client.NewClient([]string{db.URI()}, client.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOVERSION", "go1.19.1")
+
+	main := &packages.Module{Path: "example.com/app", Main: true}
+	dep := &packages.Module{Path: "example.com/m", Version: "v1.0.0"}
+	p := &packages.Package{PkgPath: "example.com/m/p", Module: dep}
+	app := &packages.Package{PkgPath: "example.com/app", Module: main, Imports: map[string]*packages.Package{p.PkgPath: p}}
+
+	keys := func(summary map[Key]Value) []Key {
+		var keys []Key
+		for _, f := range Findings(summary) {
+			keys = append(keys, f.Key)
+		}
+		return keys
+	}
+
+	// Module level: both vulnerabilities of the module version.
+	summary, _, err := AnalyzeModules(ctx, []*packages.Module{main, dep}, cli)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Key{
+		{ID: "GO-2020-0001", ModulePath: "example.com/m"},
+		{ID: "GO-2020-0002", ModulePath: "example.com/m"},
+	}
+	if diff := cmp.Diff(want, keys(summary)); diff != "" {
+		t.Errorf("AnalyzeModules mismatch (-want +got):\n%s", diff)
+	}
+	for _, k := range want {
+		if v := summary[k]; v.Version != dep.Version {
+			t.Errorf("AnalyzeModules version of %s = %q, want %q", k.ID, v.Version, dep.Version)
+		}
+	}
+
+	// Package level: only the one of the imported package.
+	summary, _, err = AnalyzePackages(ctx, []*packages.Package{app}, cli)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []Key{{ID: "GO-2020-0001", ModulePath: "example.com/m", PackagePath: "example.com/m/p"}}
+	if diff := cmp.Diff(want, keys(summary)); diff != "" {
+		t.Errorf("AnalyzePackages mismatch (-want +got):\n%s", diff)
+	}
+	if v := summary[want[0]]; !v.HasAttr(AttrIndirect) {
+		t.Errorf("AnalyzePackages attrs = %v, want %v", v.Attrs, AttrIndirect)
+	}
+	if v := summary[want[0]]; v.Version != dep.Version {
+		t.Errorf("AnalyzePackages version = %q, want %q", v.Version, dep.Version)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import (
+	"sort"
+
+	"golang.org/x/vuln/osv"
+)
+
+// A Summary counts the known vulnerabilities of the imported
+// packages without the details of the findings, for dashboards.
+type Summary struct {
+	Modules []*ModuleSummary
+	// Vulns is the number of known vulnerabilities
+	// of the imported packages.
+	Vulns int
+	// Reachable is the number of them with a reachable
+	// vulnerable symbol, and ImportOnly the number of the others.
+	Reachable  int
+	ImportOnly int
+	// Packages is the number of imported packages affected
+	// by known vulnerabilities.
+	Packages int
+}
+
+// A ModuleSummary counts the known vulnerabilities of a module.
+type ModuleSummary struct {
+	Path       string
+	Vulns      int
+	Reachable  int
+	ImportOnly int
+}
+
+// Summarize counts the vulnerabilities in pkg2vulns, as returned by
+// Analyze, and which of them are reachable according to summary.
+// The modules are sorted by path.
+func Summarize(summary map[Key]Value, pkg2vulns map[string][]*osv.Entry) *Summary {
+	reachable := make(map[string]bool)
+	for k := range summary {
+		reachable[k.ID] = true
+	}
+	s := &Summary{Packages: len(pkg2vulns)}
+	vulns := make(map[string]map[string]bool) // module path -> IDs
+	for pkg, entries := range pkg2vulns {
+		for _, e := range entries {
+			mod := moduleOfPackage(e, pkg)
+			if vulns[mod] == nil {
+				vulns[mod] = make(map[string]bool)
+			}
+			vulns[mod][e.ID] = true
+		}
+	}
+	for mod, ids := range vulns {
+		ms := &ModuleSummary{Path: mod, Vulns: len(ids)}
+		for id := range ids {
+			if reachable[id] {
+				ms.Reachable++
+			} else {
+				ms.ImportOnly++
+			}
+		}
+		s.Modules = append(s.Modules, ms)
+		s.Vulns += ms.Vulns
+		s.Reachable += ms.Reachable
+		s.ImportOnly += ms.ImportOnly
+	}
+	sort.Slice(s.Modules, func(i, j int) bool { return s.Modules[i].Path < s.Modules[j].Path })
+	return s
+}
+
+// moduleOfPackage returns the module of the package affected by the
+// entry, or the first affected module if the package is not listed.
+func moduleOfPackage(e *osv.Entry, pkg string) string {
+	for _, a := range e.Affected {
+		for _, p := range a.EcosystemSpecific.Imports {
+			if p.Path == pkg {
+				return a.Package.Name
+			}
+		}
+	}
+	if len(e.Affected) > 0 {
+		return e.Affected[0].Package.Name
+	}
+	return ""
+}
@@ -0,0 +1,117 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import (
+	"sort"
+
+	"github.com/hyangah/vulns/stdlib"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+)
+
+// An Upgrade is a module version to require
+// to fix the vulnerabilities of the findings.
+type Upgrade struct {
+	Path string
+	From string   // version in use, if known
+	To   string   // version fixing all the vulnerabilities
+	IDs  []string // IDs of the fixed vulnerabilities, sorted
+}
+
+// Reasons EditGoMod does not apply an upgrade.
+const (
+	SkipStdlib   = "the standard library is upgraded with the Go toolchain"
+	SkipReplaced = "the module is replaced in go.mod"
+)
+
+// Upgrades returns, for each module with findings in summary, the
+// smallest version fixing all of the vulnerabilities found in it, as
+// computed by ResolveFixes: the highest MinFix of the findings, or Fix
+// if MinFix is unknown. Modules with a vulnerability without a fix
+// are left out. The upgrades are sorted by module path.
+func Upgrades(summary map[Key]Value) []*Upgrade {
+	byPath := make(map[string]*Upgrade)
+	unfixed := make(map[string]bool)
+	for k, v := range summary {
+		fix := v.MinFix
+		if fix == "" {
+			fix = v.Fix
+		}
+		if fix == "" {
+			unfixed[k.ModulePath] = true
+			continue
+		}
+		u := byPath[k.ModulePath]
+		if u == nil {
+			u = &Upgrade{Path: k.ModulePath, From: v.Version}
+			byPath[k.ModulePath] = u
+		}
+		if u.To == "" || semver.Compare(fix, u.To) > 0 {
+			u.To = fix
+		}
+		u.IDs = append(u.IDs, k.ID)
+	}
+	var ups []*Upgrade
+	for path, u := range byPath {
+		if unfixed[path] {
+			continue
+		}
+		sort.Strings(u.IDs)
+		ids := u.IDs[:0]
+		for i, id := range u.IDs {
+			if i == 0 || id != u.IDs[i-1] {
+				ids = append(ids, id)
+			}
+		}
+		u.IDs = ids
+		ups = append(ups, u)
+	}
+	sort.Slice(ups, func(i, j int) bool { return ups[i].Path < ups[j].Path })
+	return ups
+}
+
+// EditGoMod returns the content of the go.mod file with the
+// requirements of the upgraded modules raised to the versions of the
+// upgrades, adding requirements of the modules required only
+// indirectly. Upgrades that cannot be applied to go.mod, such as the
+// ones of the standard library, are skipped; skipped maps their
+// module paths to the reasons.
+func EditGoMod(file string, data []byte, ups []*Upgrade) (_ []byte, skipped map[string]string, err error) {
+	f, err := modfile.Parse(file, data, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	replaced := make(map[string]bool)
+	for _, r := range f.Replace {
+		replaced[r.Old.Path] = true
+		replaced[r.New.Path] = true
+	}
+	required := make(map[string]string)
+	for _, r := range f.Require {
+		required[r.Mod.Path] = r.Mod.Version
+	}
+	skipped = make(map[string]string)
+	for _, u := range ups {
+		switch {
+		case u.Path == stdlib.ModulePath:
+			skipped[u.Path] = SkipStdlib
+		case replaced[u.Path]:
+			skipped[u.Path] = SkipReplaced
+		case semver.Compare(required[u.Path], u.To) >= 0:
+			// Already required at the fixed version or later.
+		default:
+			if err := f.AddRequire(u.Path, u.To); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	f.Cleanup()
+	out, err := f.Format()
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, skipped, nil
+}
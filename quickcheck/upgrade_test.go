@@ -0,0 +1,83 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quickcheck
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUpgrades(t *testing.T) {
+	summary := map[Key]Value{
+		{ID: "GO-2022-0001", ModulePath: "example.com/a", Symbol: "F"}:      {Version: "v1.0.0", MinFix: "v1.0.2", Fix: "v1.2.0"},
+		{ID: "GO-2022-0001", ModulePath: "example.com/a", Symbol: "G"}:      {Version: "v1.0.0", MinFix: "v1.0.2", Fix: "v1.2.0"},
+		{ID: "GO-2022-0002", ModulePath: "example.com/a", Symbol: "H"}:      {Version: "v1.0.0", MinFix: "v1.1.0", Fix: "v1.1.0"},
+		{ID: "GO-2022-0003", ModulePath: "example.com/b", Symbol: "F"}:      {Version: "v0.1.0", Fix: "v0.2.0"},
+		{ID: "GO-2022-0004", ModulePath: "example.com/c", Symbol: "F"}:      {Version: "v2.0.0"},
+		{ID: "GO-2022-0005", ModulePath: "stdlib", PackagePath: "net/http"}: {Version: "v1.19.1", MinFix: "v1.19.2"},
+	}
+	want := []*Upgrade{
+		{Path: "example.com/a", From: "v1.0.0", To: "v1.1.0", IDs: []string{"GO-2022-0001", "GO-2022-0002"}},
+		{Path: "example.com/b", From: "v0.1.0", To: "v0.2.0", IDs: []string{"GO-2022-0003"}},
+		{Path: "stdlib", From: "v1.19.1", To: "v1.19.2", IDs: []string{"GO-2022-0005"}},
+	}
+	if diff := cmp.Diff(want, Upgrades(summary)); diff != "" {
+		t.Errorf("Upgrades mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestEditGoMod(t *testing.T) {
+	gomod := `module example.com/app
+
+go 1.18
+
+require (
+	example.com/a v1.0.0
+	example.com/d v1.5.0
+	example.com/r v1.0.0
+)
+
+require example.com/b v0.1.0 // indirect
+
+replace example.com/r => ../r
+`
+	ups := []*Upgrade{
+		{Path: "example.com/a", To: "v1.1.0"},
+		{Path: "example.com/b", To: "v0.2.0"},
+		{Path: "example.com/c", To: "v2.0.1"},
+		{Path: "example.com/d", To: "v1.4.0"},
+		{Path: "example.com/r", To: "v1.0.1"},
+		{Path: "stdlib", To: "v1.19.2"},
+	}
+	got, skipped, err := EditGoMod("go.mod", []byte(gomod), ups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `module example.com/app
+
+go 1.18
+
+require (
+	example.com/a v1.1.0
+	example.com/d v1.5.0
+	example.com/r v1.0.0
+)
+
+require (
+	example.com/b v0.2.0 // indirect
+	example.com/c v2.0.1
+)
+
+replace example.com/r => ../r
+`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("EditGoMod mismatch (-want +got):\n%s", diff)
+	}
+	wantSkipped := map[string]string{"example.com/r": SkipReplaced, "stdlib": SkipStdlib}
+	if diff := cmp.Diff(wantSkipped, skipped); diff != "" {
+		t.Errorf("skipped mismatch (-want +got):\n%s", diff)
+	}
+}
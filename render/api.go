@@ -0,0 +1,94 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/hyangah/vulns/quickcheck"
+)
+
+// An APIGroup holds the vulnerabilities reachable from an entry
+// point, such as an exported function of a library analyzed with
+// -roots=exported.
+type APIGroup struct {
+	Entry     string // the qualified name of the entry point
+	Exposures []*Exposure
+}
+
+// An Exposure is a vulnerability reachable from an entry point.
+type Exposure struct {
+	Finding *quickcheck.Finding
+	Trace   []string // the shortest trace from the entry point
+}
+
+// APIGroups returns the findings grouped by entry point, sorted by
+// entry point, with the exposures of each in the order of r.Findings.
+// Findings without traces by entry point (see
+// quickcheck.Value.EntryTraces) are grouped by the entry point of
+// their trace.
+func (r *Report) APIGroups() []*APIGroup {
+	groups := map[string]*APIGroup{}
+	for _, f := range r.Findings {
+		traces := f.EntryTraces
+		if len(traces) == 0 && len(f.Trace) > 0 {
+			traces = map[string][]string{ParseFrame(f.Trace[0]).Symbol: f.Trace}
+		}
+		for entry, trace := range traces {
+			g := groups[entry]
+			if g == nil {
+				g = &APIGroup{Entry: entry}
+				groups[entry] = g
+			}
+			g.Exposures = append(g.Exposures, &Exposure{Finding: f, Trace: trace})
+		}
+	}
+	list := make([]*APIGroup, 0, len(groups))
+	for _, g := range groups {
+		list = append(list, g)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Entry < list[j].Entry })
+	return list
+}
+
+// API writes the vulnerabilities reachable from each entry point in
+// Markdown, for library maintainers to quote in their own advisories.
+// The traces list only symbols, as the file positions are local to
+// the scan.
+func API(w io.Writer, r *Report) error {
+	fmt.Fprintf(w, "# Vulnerabilities exposed by the API\n\n")
+	if r.Skipped != "" {
+		_, err := fmt.Fprintf(w, "Scan skipped: %s\n", r.Skipped)
+		return err
+	}
+	groups := r.APIGroups()
+	if len(groups) == 0 {
+		_, err := fmt.Fprintf(w, "No vulnerabilities are reachable from the API.\n")
+		return err
+	}
+	for _, g := range groups {
+		fmt.Fprintf(w, "## %s\n\n", g.Entry)
+		for _, e := range g.Exposures {
+			f := e.Finding
+			fmt.Fprintf(w, "- `%s` exposes %s", g.Entry, markdownLink(f.ID, r.URL(f.ID)))
+			if f.Version != "" {
+				fmt.Fprintf(w, " in `%s@%s`", f.ModulePath, f.Version)
+			}
+			if f.Fix != "" {
+				fmt.Fprintf(w, " (fixed in `%s@%s`)", f.ModulePath, f.Fix)
+			}
+			var via []string
+			for _, fr := range e.Trace {
+				via = append(via, "`"+ParseFrame(fr).Symbol+"`")
+			}
+			fmt.Fprintf(w, " via %s.\n", strings.Join(via, " → "))
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
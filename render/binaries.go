@@ -0,0 +1,56 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/hyangah/vulns/quickcheck"
+)
+
+// BinariesText writes the per-binary results of scanning
+// a directory in a human-readable format.
+func BinariesText(w io.Writer, results []*quickcheck.BinaryResult) error {
+	affected := 0
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			fmt.Fprintf(w, "%v: failed to scan: %v\n", r.Path, r.Err)
+		case len(r.Findings) == 0:
+			fmt.Fprintf(w, "%v: no vulnerabilities found\n", r.Path)
+		default:
+			affected++
+			fmt.Fprintf(w, "%v:\n", r.Path)
+			for _, k := range r.Findings {
+				fmt.Fprintf(w, "\t%v (%v)\n", k.ID, k.SymbolID())
+			}
+		}
+	}
+	_, err := fmt.Fprintf(w, "\n%d binaries scanned, %d affected\n", len(results), affected)
+	return err
+}
+
+type jsonBinaryResult struct {
+	*quickcheck.BinaryResult
+	Error string `json:",omitempty"`
+}
+
+// BinariesJSON writes the per-binary results of scanning
+// a directory as a JSON array.
+func BinariesJSON(w io.Writer, results []*quickcheck.BinaryResult) error {
+	out := []jsonBinaryResult{}
+	for _, r := range results {
+		jr := jsonBinaryResult{BinaryResult: r}
+		if r.Err != nil {
+			jr.Error = r.Err.Error()
+		}
+		out = append(out, jr)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
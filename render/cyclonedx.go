@@ -0,0 +1,233 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyangah/vulns/quickcheck"
+	"golang.org/x/tools/go/packages"
+)
+
+// The subset of the CycloneDX 1.5 BOM written by the CycloneDX
+// renderer. See https://cyclonedx.org/docs/1.5/json.
+type (
+	cdxBOM struct {
+		BOMFormat       string             `json:"bomFormat"`
+		SpecVersion     string             `json:"specVersion"`
+		SerialNumber    string             `json:"serialNumber"`
+		Version         int                `json:"version"`
+		Metadata        cdxMetadata        `json:"metadata"`
+		Components      []cdxComponent     `json:"components"`
+		Vulnerabilities []cdxVulnerability `json:"vulnerabilities"`
+	}
+	cdxMetadata struct {
+		Timestamp string        `json:"timestamp"`
+		Tools     cdxTools      `json:"tools"`
+		Component *cdxComponent `json:"component,omitempty"`
+	}
+	cdxTools struct {
+		Components []cdxComponent `json:"components"`
+	}
+	cdxComponent struct {
+		Type    string `json:"type"`
+		BOMRef  string `json:"bom-ref,omitempty"`
+		Name    string `json:"name"`
+		Version string `json:"version,omitempty"`
+		PURL    string `json:"purl,omitempty"`
+	}
+	cdxVulnerability struct {
+		ID             string         `json:"id"`
+		Source         *cdxSource     `json:"source,omitempty"`
+		References     []cdxReference `json:"references,omitempty"`
+		Ratings        []cdxRating    `json:"ratings,omitempty"`
+		Description    string         `json:"description,omitempty"`
+		Recommendation string         `json:"recommendation,omitempty"`
+		Analysis       cdxAnalysis    `json:"analysis"`
+		Affects        []cdxAffect    `json:"affects"`
+	}
+	cdxSource struct {
+		Name string `json:"name"`
+		URL  string `json:"url,omitempty"`
+	}
+	cdxReference struct {
+		ID     string    `json:"id"`
+		Source cdxSource `json:"source"`
+	}
+	cdxRating struct {
+		Severity string `json:"severity"`
+	}
+	cdxAnalysis struct {
+		State         string `json:"state"`
+		Justification string `json:"justification,omitempty"`
+		Detail        string `json:"detail,omitempty"`
+	}
+	cdxAffect struct {
+		Ref string `json:"ref"`
+	}
+)
+
+// CycloneDX analysis states and justifications.
+const (
+	cdxExploitable = "exploitable"
+	cdxNotAffected = "not_affected"
+	cdxResolved    = "resolved"
+
+	cdxCodeNotReachable    = "code_not_reachable"
+	cdxCodeNotPresent      = "code_not_present"
+	cdxRequiresEnvironment = "requires_environment"
+)
+
+// CycloneDX writes the report as a CycloneDX BOM listing the modules
+// of r.Modules as components, with the vulnerabilities of the findings
+// and of r.NotAffected linked to the components of their modules, so
+// that one scan feeds both inventory and vulnerability tooling. The
+// findings of a vulnerability in a module make one vulnerability in
+// the "exploitable" state, since a vulnerable symbol is reachable.
+// Each of r.NotAffected is in the "resolved" state if the version in
+// use fixes it, or else in the "not_affected" state, justified as
+// code_not_reachable if a vulnerable package is imported but no
+// vulnerable symbol is reachable, requires_environment if only other
+// platforms are affected, and code_not_present otherwise.
+func CycloneDX(w io.Writer, r *Report) error {
+	bom := cdxBOM{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: "1.5",
+		Version:     1,
+		Metadata: cdxMetadata{
+			Timestamp: now().UTC().Format(time.RFC3339),
+			Tools: cdxTools{Components: []cdxComponent{{
+				Type:    "application",
+				Name:    "vulns",
+				Version: toolVersion(),
+			}}},
+		},
+		Components:      []cdxComponent{},
+		Vulnerabilities: []cdxVulnerability{},
+	}
+
+	// refs maps module paths to the bom-refs of their components.
+	refs := make(map[string]string)
+	addComponent := func(m *packages.Module) {
+		if _, ok := refs[m.Path]; ok {
+			return
+		}
+		c := cdxComponent{Type: "library", Name: m.Path, Version: m.Version}
+		if m.Replace != nil && m.Replace.Version != "" {
+			c.Version = m.Replace.Version
+		}
+		c.PURL = purl(m.Path, c.Version)
+		c.BOMRef = c.PURL
+		refs[m.Path] = c.BOMRef
+		if m.Main {
+			c.Type = "application"
+			if bom.Metadata.Component == nil {
+				bom.Metadata.Component = &c
+				return
+			}
+		}
+		bom.Components = append(bom.Components, c)
+	}
+	for _, m := range r.Modules {
+		addComponent(m)
+	}
+
+	type key struct{ id, module string }
+	var keys []key
+	first := make(map[key]*quickcheck.Finding)
+	symbols := make(map[key][]string)
+	for _, f := range r.Findings {
+		k := key{f.ID, f.ModulePath}
+		if first[k] == nil {
+			first[k] = f
+			keys = append(keys, k)
+			addComponent(&packages.Module{Path: f.ModulePath, Version: f.Version})
+		}
+		symbols[k] = append(symbols[k], subject(f))
+	}
+	for _, k := range keys {
+		f := first[k]
+		v := r.cdxVulnerability(f.ID, refs[f.ModulePath])
+		v.Analysis = cdxAnalysis{State: cdxExploitable, Detail: "reachable: " + strings.Join(symbols[k], ", ")}
+		if f.Fix != "" {
+			v.Recommendation = fmt.Sprintf("Upgrade %s to %s or later.", f.ModulePath, f.Fix)
+		} else {
+			v.Recommendation = "No fixed version is available; avoid the vulnerable symbols."
+		}
+		bom.Vulnerabilities = append(bom.Vulnerabilities, v)
+	}
+	for _, na := range r.NotAffected {
+		addComponent(&packages.Module{Path: na.ModulePath, Version: na.Version})
+		v := r.cdxVulnerability(na.ID, refs[na.ModulePath])
+		v.Analysis = cdxAnalysis{State: cdxNotAffected, Detail: na.Detail}
+		switch na.Reason {
+		case quickcheck.ReasonUnreachable:
+			v.Analysis.Justification = cdxCodeNotReachable
+		case quickcheck.ReasonPlatform:
+			v.Analysis.Justification = cdxRequiresEnvironment
+		case quickcheck.ReasonVersion:
+			if na.Fixed != "" {
+				v.Analysis = cdxAnalysis{State: cdxResolved, Detail: fmt.Sprintf("fixed in %s", na.Fixed)}
+				break
+			}
+			v.Analysis.Justification = cdxCodeNotPresent
+		default:
+			v.Analysis.Justification = cdxCodeNotPresent
+		}
+		bom.Vulnerabilities = append(bom.Vulnerabilities, v)
+	}
+	sort.Slice(bom.Components, func(i, j int) bool {
+		return bom.Components[i].BOMRef < bom.Components[j].BOMRef
+	})
+	sort.SliceStable(bom.Vulnerabilities, func(i, j int) bool {
+		return bom.Vulnerabilities[i].ID < bom.Vulnerabilities[j].ID
+	})
+
+	data, err := json.Marshal([]any{bom.Components, bom.Vulnerabilities})
+	if err != nil {
+		return err
+	}
+	h := sha256.Sum256(data)
+	bom.SerialNumber = "urn:uuid:" + uuidOf(hex.EncodeToString(h[:16]))
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(bom)
+}
+
+// cdxVulnerability returns the vulnerability with the ID affecting
+// the component with the bom-ref.
+func (r *Report) cdxVulnerability(id, ref string) cdxVulnerability {
+	v := cdxVulnerability{
+		ID:      id,
+		Source:  &cdxSource{Name: "Go Vulnerability Database", URL: r.URL(id)},
+		Affects: []cdxAffect{{Ref: ref}},
+	}
+	if e := r.Entries[id]; e != nil {
+		v.Description = e.Details
+		for _, a := range e.Aliases {
+			var src cdxSource
+			switch {
+			case strings.HasPrefix(a, "CVE-"):
+				src = cdxSource{Name: "NVD", URL: "https://nvd.nist.gov/vuln/detail/" + a}
+			case strings.HasPrefix(a, "GHSA-"):
+				src = cdxSource{Name: "GitHub", URL: "https://github.com/advisories/" + a}
+			default:
+				continue
+			}
+			v.References = append(v.References, cdxReference{ID: a, Source: src})
+		}
+	}
+	if r.Severity != nil {
+		v.Ratings = []cdxRating{{Severity: strings.ToLower(r.SeverityOf(id))}}
+	}
+	return v
+}
@@ -0,0 +1,54 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/hyangah/vulns/quickcheck"
+)
+
+// DepsText writes the dependency vulnerability inventory
+// in a human-readable format.
+func DepsText(w io.Writer, mods []*quickcheck.ModuleVulns) error {
+	for _, m := range mods {
+		version := m.Version
+		if version == "" {
+			version = "(unknown version)"
+		}
+		fmt.Fprintf(w, "%v %v\n", m.Path, version)
+		for _, v := range m.Vulns {
+			fmt.Fprintf(w, "\t%v\t%v\n", v.ID, v.Verdict)
+		}
+	}
+	_, err := fmt.Fprintf(w, "\n%d modules, %d known vulnerabilities, %d reachable\n", len(mods), countVerdict(mods, ""), countVerdict(mods, quickcheck.VerdictReachable))
+	return err
+}
+
+// DepsJSON writes the dependency vulnerability inventory as a JSON array.
+func DepsJSON(w io.Writer, mods []*quickcheck.ModuleVulns) error {
+	if mods == nil {
+		mods = []*quickcheck.ModuleVulns{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(mods)
+}
+
+// countVerdict counts the vulnerabilities with the verdict,
+// or all vulnerabilities if verdict is empty.
+func countVerdict(mods []*quickcheck.ModuleVulns, verdict string) int {
+	n := 0
+	for _, m := range mods {
+		for _, v := range m.Vulns {
+			if verdict == "" || v.Verdict == verdict {
+				n++
+			}
+		}
+	}
+	return n
+}
@@ -0,0 +1,179 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// The subset of the GitLab security report schema, version 15, used
+// by the GitLab renderer. See
+// https://gitlab.com/gitlab-org/security-products/security-report-schemas.
+type (
+	gitlabReport struct {
+		Version         string                `json:"version"`
+		Scan            gitlabScan            `json:"scan"`
+		Vulnerabilities []gitlabVulnerability `json:"vulnerabilities"`
+	}
+	gitlabScan struct {
+		Analyzer  gitlabScanner   `json:"analyzer"`
+		Scanner   gitlabScanner   `json:"scanner"`
+		Type      string          `json:"type"`
+		StartTime string          `json:"start_time"`
+		EndTime   string          `json:"end_time"`
+		Status    string          `json:"status"`
+		Messages  []gitlabMessage `json:"messages,omitempty"`
+	}
+	gitlabScanner struct {
+		ID      string       `json:"id"`
+		Name    string       `json:"name"`
+		URL     string       `json:"url,omitempty"`
+		Version string       `json:"version"`
+		Vendor  gitlabVendor `json:"vendor"`
+	}
+	gitlabVendor struct {
+		Name string `json:"name"`
+	}
+	gitlabMessage struct {
+		Level string `json:"level"`
+		Value string `json:"value"`
+	}
+	gitlabVulnerability struct {
+		ID          string             `json:"id"`
+		Name        string             `json:"name"`
+		Description string             `json:"description,omitempty"`
+		Severity    string             `json:"severity"`
+		Solution    string             `json:"solution,omitempty"`
+		Identifiers []gitlabIdentifier `json:"identifiers"`
+		Links       []gitlabLink       `json:"links,omitempty"`
+		Location    gitlabLocation     `json:"location"`
+	}
+	gitlabIdentifier struct {
+		Type  string `json:"type"`
+		Name  string `json:"name"`
+		Value string `json:"value"`
+		URL   string `json:"url,omitempty"`
+	}
+	gitlabLink struct {
+		URL string `json:"url"`
+	}
+	gitlabLocation struct {
+		File      string `json:"file,omitempty"`
+		StartLine int    `json:"start_line,omitempty"`
+		Class     string `json:"class,omitempty"`
+		Method    string `json:"method,omitempty"`
+	}
+)
+
+// gitlabTime is the format of the times in GitLab security reports.
+const gitlabTime = "2006-01-02T15:04:05"
+
+// now returns the current time; tests replace it.
+var now = time.Now
+
+// GitLab writes the report as a GitLab SAST security report, so that
+// the findings show in the security dashboard and the merge request
+// widgets of GitLab. Each finding is a vulnerability located at the
+// first frame of its trace in first-party code, with the ID and the
+// aliases of the entry as identifiers. The severities are the ones of
+// r.Severity. If r.TraceFormat.Dir is set, the files in it are named
+// relative to it, as GitLab expects paths relative to the project
+// directory. A skipped scan is a failed scan with the reason as its
+// message.
+func GitLab(w io.Writer, r *Report) error {
+	t := now().UTC().Format(gitlabTime)
+	scanner := gitlabScanner{
+		ID:      "vulns",
+		Name:    "vulns",
+		URL:     "https://github.com/hyangah/vulns",
+		Version: toolVersion(),
+		Vendor:  gitlabVendor{Name: "vulns"},
+	}
+	report := gitlabReport{
+		Version: "15.0.6",
+		Scan: gitlabScan{
+			Analyzer:  scanner,
+			Scanner:   scanner,
+			Type:      "sast",
+			StartTime: t,
+			EndTime:   t,
+			Status:    "success",
+		},
+		Vulnerabilities: []gitlabVulnerability{},
+	}
+	if r.Skipped != "" {
+		report.Scan.Status = "failure"
+		report.Scan.Messages = []gitlabMessage{{Level: "warn", Value: "scan skipped: " + r.Skipped}}
+	}
+	for _, f := range r.Findings {
+		v := gitlabVulnerability{
+			ID:          uuidOf(f.Fingerprint(r.Boundary)),
+			Name:        sarifText(f),
+			Severity:    r.SeverityOf(f.ID),
+			Identifiers: []gitlabIdentifier{{Type: "go", Name: f.ID, Value: f.ID, URL: r.URL(f.ID)}},
+			Location:    gitlabLocation{Class: f.PackagePath, Method: f.Symbol},
+		}
+		if f.Fix != "" {
+			v.Solution = fmt.Sprintf("Upgrade %s to %s or later.", f.ModulePath, f.Fix)
+		}
+		if u := r.URL(f.ID); u != "" {
+			v.Links = append(v.Links, gitlabLink{URL: u})
+		}
+		if e := r.Entries[f.ID]; e != nil {
+			v.Description = e.Details
+			for _, a := range e.Aliases {
+				typ := strings.ToLower(a)
+				typ, _, _ = strings.Cut(typ, "-")
+				v.Identifiers = append(v.Identifiers, gitlabIdentifier{Type: typ, Name: a, Value: a})
+			}
+		}
+		if fr := ParseFrame(r.locationFrame(f.Trace)); fr.File != "" {
+			v.Location.File = filepath.ToSlash(relativeFile(r.TraceFormat.Dir, fr.File))
+			v.Location.StartLine = fr.Line
+		}
+		report.Vulnerabilities = append(report.Vulnerabilities, v)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// locationFrame returns the first frame of the trace in first-party
+// code, or the first frame if there is none or no boundary.
+func (r *Report) locationFrame(trace []string) string {
+	if len(trace) == 0 {
+		return ""
+	}
+	if len(r.Boundary) > 0 {
+		if local, _ := r.Boundary.Split(trace); len(local) > 0 {
+			return local[0]
+		}
+	}
+	return trace[0]
+}
+
+// uuidOf formats the 32 hexadecimal digits of a fingerprint as a UUID,
+// the form of the IDs of the vulnerabilities in GitLab reports.
+func uuidOf(fingerprint string) string {
+	if len(fingerprint) != 32 {
+		return fingerprint
+	}
+	return fingerprint[:8] + "-" + fingerprint[8:12] + "-" + fingerprint[12:16] + "-" + fingerprint[16:20] + "-" + fingerprint[20:]
+}
+
+// toolVersion returns the version of the main module of the running
+// program, or "devel" if it is unknown.
+func toolVersion() string {
+	if bi, ok := debug.ReadBuildInfo(); ok && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+		return bi.Main.Version
+	}
+	return "devel"
+}
@@ -0,0 +1,135 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/hyangah/vulns/quickcheck"
+)
+
+// A graph is the union of the traces of the findings,
+// with the nodes grouped by package.
+type graph struct {
+	pkgs  []*graphPackage // in order of first appearance
+	edges [][2]int        // node indices, in order of first appearance
+	nodes []*graphNode
+}
+
+type graphPackage struct {
+	path  string
+	nodes []int
+}
+
+type graphNode struct {
+	symbol     string // qualified symbol
+	name       string // symbol without the package path
+	vulnerable bool   // the last frame of a trace
+}
+
+func newGraph(r *Report) *graph {
+	g := &graph{}
+	nodeIndex := map[string]int{}
+	pkgIndex := map[string]*graphPackage{}
+	edgeSeen := map[[2]int]bool{}
+	node := func(frame string) int {
+		sym := ParseFrame(frame).Symbol
+		if i, ok := nodeIndex[sym]; ok {
+			return i
+		}
+		pkgpath := quickcheck.FramePackage(frame)
+		p := pkgIndex[pkgpath]
+		if p == nil {
+			p = &graphPackage{path: pkgpath}
+			pkgIndex[pkgpath] = p
+			g.pkgs = append(g.pkgs, p)
+		}
+		name := strings.TrimPrefix(strings.TrimPrefix(sym, pkgpath), ".")
+		if name == "" {
+			name = sym // import of the package
+		}
+		i := len(g.nodes)
+		g.nodes = append(g.nodes, &graphNode{symbol: sym, name: name})
+		nodeIndex[sym] = i
+		p.nodes = append(p.nodes, i)
+		return i
+	}
+	for _, f := range r.Findings {
+		prev := -1
+		for _, frame := range f.Trace {
+			n := node(frame)
+			if e := [2]int{prev, n}; prev >= 0 && prev != n && !edgeSeen[e] {
+				edgeSeen[e] = true
+				g.edges = append(g.edges, e)
+			}
+			prev = n
+		}
+		if prev >= 0 {
+			g.nodes[prev].vulnerable = true
+		}
+	}
+	return g
+}
+
+// DOT writes the union of the traces of the findings
+// as a Graphviz graph, with a cluster for each package.
+// Vulnerable symbols are colored red.
+func DOT(w io.Writer, r *Report) error {
+	g := newGraph(r)
+	fmt.Fprintf(w, "digraph vulns {\n\trankdir=LR;\n\tnode [shape=box];\n")
+	for i, p := range g.pkgs {
+		fmt.Fprintf(w, "\tsubgraph cluster_%d {\n\t\tlabel=%s;\n", i, strconv.Quote(p.path))
+		for _, n := range p.nodes {
+			attrs := "label=" + strconv.Quote(g.nodes[n].name)
+			if g.nodes[n].vulnerable {
+				attrs += ", color=red, fontcolor=red"
+			}
+			fmt.Fprintf(w, "\t\tn%d [%s];\n", n, attrs)
+		}
+		fmt.Fprintf(w, "\t}\n")
+	}
+	for _, e := range g.edges {
+		fmt.Fprintf(w, "\tn%d -> n%d;\n", e[0], e[1])
+	}
+	_, err := fmt.Fprintf(w, "}\n")
+	return err
+}
+
+// Mermaid writes the union of the traces of the findings
+// as a Mermaid flowchart, with a subgraph for each package.
+// Vulnerable symbols are styled red.
+func Mermaid(w io.Writer, r *Report) error {
+	g := newGraph(r)
+	fmt.Fprintf(w, "graph LR\n")
+	for i, p := range g.pkgs {
+		fmt.Fprintf(w, "\tsubgraph p%d [%s]\n", i, mermaidQuote(p.path))
+		for _, n := range p.nodes {
+			fmt.Fprintf(w, "\t\tn%d[%s]\n", n, mermaidQuote(g.nodes[n].name))
+		}
+		fmt.Fprintf(w, "\tend\n")
+	}
+	for _, e := range g.edges {
+		fmt.Fprintf(w, "\tn%d --> n%d\n", e[0], e[1])
+	}
+	var vulnerable []string
+	for i, n := range g.nodes {
+		if n.vulnerable {
+			vulnerable = append(vulnerable, fmt.Sprintf("n%d", i))
+		}
+	}
+	if len(vulnerable) == 0 {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "\tclassDef vulnerable stroke:red,color:red\n\tclass %s vulnerable\n", strings.Join(vulnerable, ","))
+	return err
+}
+
+// mermaidQuote quotes s as a Mermaid label.
+func mermaidQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "#quot;") + `"`
+}
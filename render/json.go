@@ -0,0 +1,93 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/hyangah/vulns/internal/jsonyaml"
+	"github.com/hyangah/vulns/quickcheck"
+)
+
+type jsonFinding struct {
+	*quickcheck.Finding
+	// Fingerprint identifies the finding across runs
+	// (see quickcheck.Finding.Fingerprint).
+	Fingerprint string
+	// Frames is Trace with the positions parsed,
+	// for consumers that do not want to parse Trace.
+	Frames  []Frame
+	Snippet *Snippet `json:",omitempty"`
+	// Effort estimates the scope of the remediation
+	// (see quickcheck.Finding.Effort).
+	Effort quickcheck.Effort
+}
+
+// JSON writes the findings of the report as a JSON array, in the
+// order of the groups of the text report if the report has a
+// GroupBy (see Report.TextGroups and Report.Groups). If the scan was skipped, it writes an object instead, with
+// the reason in its Skipped field. If the report has NotAffected
+// explanations, it writes an object with the Findings and
+// NotAffected fields.
+func JSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if r.Skipped != "" {
+		return enc.Encode(struct{ Skipped string }{r.Skipped})
+	}
+	findings := []jsonFinding{}
+	for _, f := range r.groupedFindings() {
+		frames := make([]Frame, 0, len(f.Trace))
+		for _, t := range f.Trace {
+			frames = append(frames, ParseFrame(t))
+		}
+		findings = append(findings, jsonFinding{Finding: f, Fingerprint: f.Fingerprint(r.Boundary), Frames: frames, Snippet: r.snippet(f), Effort: f.Effort(r.Boundary)})
+	}
+	if r.NotAffected != nil {
+		return enc.Encode(struct {
+			Findings    []jsonFinding
+			NotAffected []*quickcheck.NotAffected
+		}{findings, r.NotAffected})
+	}
+	return enc.Encode(findings)
+}
+
+// groupedFindings returns the findings of r in the order of the
+// groups of the text report if r has a GroupBy, or else r.Findings.
+func (r *Report) groupedFindings() []*quickcheck.Finding {
+	var findings []*quickcheck.Finding
+	switch r.GroupBy {
+	case "":
+		return r.Findings
+	case GroupByEntry:
+		for _, g := range r.Groups() {
+			findings = append(findings, g.Findings...)
+		}
+	default:
+		for _, m := range r.TextGroups() {
+			for _, v := range m.Vulns {
+				findings = append(findings, v.Findings...)
+			}
+		}
+	}
+	return findings
+}
+
+// YAML writes the findings of the report as a YAML list,
+// with the same fields as JSON.
+func YAML(w io.Writer, r *Report) error {
+	var buf bytes.Buffer
+	if err := JSON(&buf, r); err != nil {
+		return err
+	}
+	data, err := jsonyaml.Convert(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,115 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The subset of the JUnit XML format read by CI test reporters
+// such as Jenkins and Buildkite.
+type (
+	junitTestSuites struct {
+		XMLName  xml.Name         `xml:"testsuites"`
+		Name     string           `xml:"name,attr"`
+		Tests    int              `xml:"tests,attr"`
+		Failures int              `xml:"failures,attr"`
+		Skipped  int              `xml:"skipped,attr"`
+		Suites   []junitTestSuite `xml:"testsuite"`
+	}
+	junitTestSuite struct {
+		Name     string          `xml:"name,attr"`
+		Tests    int             `xml:"tests,attr"`
+		Failures int             `xml:"failures,attr"`
+		Skipped  int             `xml:"skipped,attr"`
+		Cases    []junitTestCase `xml:"testcase"`
+	}
+	junitTestCase struct {
+		ClassName string        `xml:"classname,attr"`
+		Name      string        `xml:"name,attr"`
+		Failure   *junitFailure `xml:"failure,omitempty"`
+		Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	}
+	junitFailure struct {
+		Message string `xml:"message,attr"`
+		Type    string `xml:"type,attr"`
+		Text    string `xml:",cdata"`
+	}
+	junitSkipped struct {
+		Message string `xml:"message,attr"`
+	}
+)
+
+// JUnit writes the report in the JUnit XML format, so that CI test
+// report UIs display the findings natively. Each vulnerability of a
+// package is a failed test case, named by the ID and classed by the
+// package, whose failure holds the traces of the findings. A skipped
+// scan is a skipped test case with the reason.
+func JUnit(w io.Writer, r *Report) error {
+	suite := junitTestSuite{Name: "vulns"}
+	if r.Skipped != "" {
+		suite.Cases = append(suite.Cases, junitTestCase{
+			ClassName: "vulns",
+			Name:      "scan",
+			Skipped:   &junitSkipped{Message: r.Skipped},
+		})
+		suite.Skipped++
+	}
+	byVuln := *r
+	byVuln.GroupBy = GroupByVuln
+	for _, g := range byVuln.Groups() {
+		f := g.Findings[0]
+		class := g.PackagePath
+		if class == "" {
+			class = f.ModulePath
+		}
+		message := g.ID
+		if e := r.Entries[g.ID]; e != nil && e.Details != "" {
+			message += ": " + strings.TrimSpace(strings.SplitN(e.Details, "\n\n", 2)[0])
+		}
+		var b strings.Builder
+		fmt.Fprintf(&b, "%s\n", r.URL(g.ID))
+		if f.Version != "" {
+			fmt.Fprintf(&b, "Found in: %s@%s\n", f.ModulePath, f.Version)
+		}
+		if f.Fix != "" {
+			fmt.Fprintf(&b, "Fixed in: %s@%s\n", f.ModulePath, f.Fix)
+		}
+		for _, f := range g.Findings {
+			if len(f.Trace) == 0 {
+				continue
+			}
+			fmt.Fprintf(&b, "\n%s:\n", subject(f))
+			r.TraceFormat.Write(&b, f.Trace)
+		}
+		suite.Cases = append(suite.Cases, junitTestCase{
+			ClassName: class,
+			Name:      g.ID,
+			Failure:   &junitFailure{Message: message, Type: "vulnerability", Text: b.String()},
+		})
+		suite.Failures++
+	}
+	suite.Tests = len(suite.Cases)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitTestSuites{
+		Name:     "vulns",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Suites:   []junitTestSuite{suite},
+	}); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
@@ -0,0 +1,97 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/hyangah/vulns/quickcheck"
+)
+
+// Lines writes a line per finding in the form
+//
+//	file:line:col: GO-XXXX-YYYY: message
+//
+// of compiler errors, located at the first frame of its trace in
+// first-party code, so that Vim's quickfix list, Emacs's
+// compilation-mode, and the problem matchers of editors can jump to
+// it. If r.TraceFormat.Dir is set, the files in it are named relative
+// to it. The findings without a trace, as at the coarser scan levels,
+// have no location.
+func Lines(w io.Writer, r *Report) error {
+	return writeLines(w, r, false)
+}
+
+// VerboseLines writes the lines of Lines, each followed by a line
+// per frame of the trace of the finding, in the same form, so that
+// editors can jump along the call stack.
+func VerboseLines(w io.Writer, r *Report) error {
+	return writeLines(w, r, true)
+}
+
+func writeLines(w io.Writer, r *Report, verbose bool) error {
+	if r.Skipped != "" {
+		_, err := fmt.Fprintf(w, "Scan skipped: %s\n", r.Skipped)
+		return err
+	}
+	for _, f := range r.Findings {
+		if _, err := fmt.Fprintf(w, "%s%s: %s\n", r.position(ParseFrame(r.locationFrame(f.Trace))), f.ID, linesMessage(f)); err != nil {
+			return err
+		}
+		if !verbose {
+			continue
+		}
+		for _, t := range f.Trace {
+			fr := ParseFrame(t)
+			if _, err := fmt.Fprintf(w, "%s%s: trace: %s\n", r.position(fr), f.ID, fr.Symbol); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// position returns the "file:line:col: " prefix of the lines
+// of the frame, or "" if the frame has no position.
+func (r *Report) position(fr Frame) string {
+	if fr.File == "" {
+		return ""
+	}
+	file := relativeFile(r.TraceFormat.Dir, fr.File)
+	switch {
+	case fr.Line == 0:
+		return file + ": "
+	case fr.Column == 0:
+		return fmt.Sprintf("%s:%d: ", file, fr.Line)
+	}
+	return fmt.Sprintf("%s:%d:%d: ", file, fr.Line, fr.Column)
+}
+
+// relativeFile returns the file relative to dir if it is in dir,
+// or else the file.
+func relativeFile(dir, file string) string {
+	if dir == "" {
+		return file
+	}
+	if rel, err := filepath.Rel(dir, file); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return file
+}
+
+// linesMessage returns the message of the line of the finding.
+func linesMessage(f *quickcheck.Finding) string {
+	msg := "affects " + subject(f)
+	if f.Symbol != "" {
+		msg = "reaches vulnerable symbol " + subject(f)
+	}
+	if f.Fix != "" {
+		msg += fmt.Sprintf(" (fixed in %s@%s)", f.ModulePath, f.Fix)
+	}
+	return msg
+}
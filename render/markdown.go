@@ -0,0 +1,166 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+// Markdown writes the report as a Markdown document with a
+// section for each vulnerable package.
+func Markdown(w io.Writer, r *Report) error {
+	groups := r.Groups()
+	fmt.Fprintf(w, "# Vulnerability report\n\n")
+	if r.Skipped != "" {
+		_, err := fmt.Fprintf(w, "Scan skipped: %s\n", r.Skipped)
+		return err
+	}
+	if len(groups) == 0 {
+		_, err := fmt.Fprintf(w, "No vulnerabilities found.\n")
+		if err != nil || len(r.Filtered)+len(r.Unreachable) == 0 {
+			return err
+		}
+		fmt.Fprintln(w)
+	}
+	for _, g := range groups {
+		f := g.Findings[0]
+		where := g.PackagePath
+		if where == "" {
+			where = f.ModulePath
+		}
+		fmt.Fprintf(w, "## %s (%s)\n\n", markdownLink(g.ID, r.URL(g.ID)), where)
+		if e := r.Entries[g.ID]; e != nil && e.Details != "" {
+			fmt.Fprintf(w, "%s\n\n", e.Details)
+		}
+		if f.Version != "" {
+			fmt.Fprintf(w, "Found in `%s@%s`.\n\n", f.ModulePath, f.Version)
+		}
+		if f.Fix != "" {
+			fmt.Fprintf(w, "Fixed in `%s@%s`", f.ModulePath, f.Fix)
+			if f.MinFix != "" && f.MinFix != f.Fix {
+				fmt.Fprintf(w, " (minimum fixed version: `%s`)", f.MinFix)
+			}
+			fmt.Fprintf(w, ".\n\n")
+		}
+		if len(f.Trace) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "Call stack in your code:\n\n```\n")
+		r.TraceFormat.Write(w, f.Trace)
+		if _, err := fmt.Fprintf(w, "```\n\n"); err != nil {
+			return err
+		}
+	}
+	if len(r.Filtered) > 0 {
+		fmt.Fprintf(w, "## Filtered by platform\n\n")
+		for _, f := range r.Filtered {
+			fmt.Fprintf(w, "- %s (%s)%s\n", markdownLink(f.ID, r.URL(f.ID)), f.PackagePath, platforms(f))
+		}
+	}
+	if len(r.Unreachable) > 0 {
+		if len(r.Filtered) > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "## Informational\n\n")
+		fmt.Fprintf(w, "Known vulnerabilities without reachable vulnerable symbols:\n\n")
+		for _, u := range r.Unreachable {
+			fmt.Fprintf(w, "- %s in `%s", markdownLink(u.ID, r.URL(u.ID)), u.ModulePath)
+			if u.Version != "" {
+				fmt.Fprintf(w, "@%s", u.Version)
+			}
+			fmt.Fprintf(w, "`")
+			if u.Fix != "" {
+				fmt.Fprintf(w, " (fixed in `%s`)", u.Fix)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+	return nil
+}
+
+var htmlTemplate = template.Must(template.New("html").Funcs(template.FuncMap{
+	"details": func(r *Report, id string) string {
+		if e := r.Entries[id]; e != nil {
+			return e.Details
+		}
+		return ""
+	},
+	"trace": func(r *Report, trace []string) string {
+		return strings.Join(r.TraceFormat.Lines(trace), "\n")
+	},
+	"severity": func(r *Report, id string) string {
+		if r.Severity == nil {
+			return ""
+		}
+		return r.SeverityOf(id)
+	},
+	"severitySummary": func(r *Report) string {
+		if r.Severity == nil || len(r.Findings) == 0 {
+			return ""
+		}
+		counts := r.SeverityCounts()
+		var parts []string
+		for _, b := range Severities() {
+			if n := counts[b]; n > 0 {
+				parts = append(parts, fmt.Sprintf("%d %s", n, b))
+			}
+		}
+		return strings.Join(parts, ", ")
+	},
+	"severityColor": func(bucket string) string {
+		return htmlSeverityColors[bucket]
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Vulnerability report</title></head>
+<body>
+<h1>Vulnerability report</h1>
+{{- if .Skipped}}
+<p>Scan skipped: {{.Skipped}}</p>
+{{- else}}
+{{- with severitySummary .}}
+<p>Summary: {{.}}</p>
+{{- end}}
+{{- $r := .}}
+{{- range .Groups}}
+<h2>{{with $r.URL .ID}}<a href="{{.}}">{{end}}{{.ID}}{{if $r.URL .ID}}</a>{{end}} ({{.PackagePath}})
+{{- with severity $r .ID}} <span style="color: {{severityColor .}}">[{{.}}]</span>{{end}}</h2>
+{{- with details $r .ID}}
+<p>{{.}}</p>
+{{- end}}
+<pre>{{trace $r (index .Findings 0).Trace}}</pre>
+{{- else}}
+<p>No vulnerabilities found.</p>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
+
+// Colors of the severity buckets in HTML output.
+var htmlSeverityColors = map[string]string{
+	SeverityCritical: "darkred",
+	SeverityHigh:     "red",
+	SeverityMedium:   "darkorange",
+	SeverityLow:      "teal",
+	SeverityUnknown:  "gray",
+}
+
+// markdownLink returns a link with the text to the URL,
+// or just the text if the URL is empty.
+func markdownLink(text, url string) string {
+	if url == "" {
+		return text
+	}
+	return fmt.Sprintf("[%s](%s)", text, url)
+}
+
+// HTML writes the report as a standalone HTML page.
+func HTML(w io.Writer, r *Report) error {
+	return htmlTemplate.Execute(w, r)
+}
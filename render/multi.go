@@ -0,0 +1,126 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/hyangah/vulns/quickcheck"
+)
+
+// A RepoResult is the result of scanning one repository
+// of a multi-repository scan.
+type RepoResult struct {
+	Name     string
+	URL      string
+	Err      error `json:"-"`
+	Findings []*quickcheck.Finding
+}
+
+// A ModuleUsage summarizes the reachable vulnerabilities
+// of a module across repositories.
+type ModuleUsage struct {
+	Path  string
+	Repos []string // repositories reaching a vulnerability of the module
+	Vulns []string // IDs of the reachable vulnerabilities
+}
+
+// TopModules returns the modules with reachable vulnerabilities in
+// the results, sorted by the number of affected repositories and
+// then by the number of vulnerabilities, most first.
+func TopModules(results []*RepoResult) []*ModuleUsage {
+	type set map[string]bool
+	repos, vulns := make(map[string]set), make(map[string]set)
+	for _, r := range results {
+		for _, f := range r.Findings {
+			if repos[f.ModulePath] == nil {
+				repos[f.ModulePath], vulns[f.ModulePath] = set{}, set{}
+			}
+			repos[f.ModulePath][r.Name] = true
+			vulns[f.ModulePath][f.ID] = true
+		}
+	}
+	keys := func(s set) []string {
+		var l []string
+		for k := range s {
+			l = append(l, k)
+		}
+		sort.Strings(l)
+		return l
+	}
+	var mods []*ModuleUsage
+	for path := range repos {
+		mods = append(mods, &ModuleUsage{Path: path, Repos: keys(repos[path]), Vulns: keys(vulns[path])})
+	}
+	sort.Slice(mods, func(i, j int) bool {
+		mi, mj := mods[i], mods[j]
+		if len(mi.Repos) != len(mj.Repos) {
+			return len(mi.Repos) > len(mj.Repos)
+		}
+		if len(mi.Vulns) != len(mj.Vulns) {
+			return len(mi.Vulns) > len(mj.Vulns)
+		}
+		return mi.Path < mj.Path
+	})
+	return mods
+}
+
+// MultiText writes the per-repository status and the modules
+// with the most affected repositories in a human-readable format.
+func MultiText(w io.Writer, results []*RepoResult) error {
+	affected := 0
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			fmt.Fprintf(w, "%v: failed to scan: %v\n", r.Name, r.Err)
+		case len(r.Findings) == 0:
+			fmt.Fprintf(w, "%v: no vulnerabilities found\n", r.Name)
+		default:
+			affected++
+			fmt.Fprintf(w, "%v: %d findings\n", r.Name, len(r.Findings))
+			for _, f := range r.Findings {
+				fmt.Fprintf(w, "\t%v (%v)\n", f.ID, f.SymbolID())
+			}
+		}
+	}
+	if mods := TopModules(results); len(mods) > 0 {
+		fmt.Fprintf(w, "\nTop vulnerable modules:\n")
+		for _, m := range mods {
+			fmt.Fprintf(w, "\t%v: %d repositories, %d vulnerabilities\n", m.Path, len(m.Repos), len(m.Vulns))
+		}
+	}
+	_, err := fmt.Fprintf(w, "\n%d repositories scanned, %d affected\n", len(results), affected)
+	return err
+}
+
+type jsonRepoResult struct {
+	*RepoResult
+	Error string `json:",omitempty"`
+}
+
+// MultiJSON writes the per-repository results and the
+// modules with the most affected repositories as a JSON object.
+func MultiJSON(w io.Writer, results []*RepoResult) error {
+	out := struct {
+		Repos      []jsonRepoResult
+		TopModules []*ModuleUsage
+	}{Repos: []jsonRepoResult{}, TopModules: TopModules(results)}
+	if out.TopModules == nil {
+		out.TopModules = []*ModuleUsage{}
+	}
+	for _, r := range results {
+		jr := jsonRepoResult{RepoResult: r}
+		if r.Err != nil {
+			jr.Error = r.Err.Error()
+		}
+		out.Repos = append(out.Repos, jr)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
@@ -0,0 +1,183 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyangah/vulns/quickcheck"
+)
+
+// The OpenVEX 0.2.0 document written by the OpenVEX renderer.
+// See https://github.com/openvex/spec.
+type (
+	vexDocument struct {
+		Context    string         `json:"@context"`
+		ID         string         `json:"@id"`
+		Author     string         `json:"author"`
+		Timestamp  string         `json:"timestamp"`
+		Version    int            `json:"version"`
+		Tooling    string         `json:"tooling,omitempty"`
+		Statements []vexStatement `json:"statements"`
+	}
+	vexStatement struct {
+		Vulnerability   vexVulnerability `json:"vulnerability"`
+		Products        []vexComponent   `json:"products"`
+		Status          string           `json:"status"`
+		StatusNotes     string           `json:"status_notes,omitempty"`
+		Justification   string           `json:"justification,omitempty"`
+		ImpactStatement string           `json:"impact_statement,omitempty"`
+		ActionStatement string           `json:"action_statement,omitempty"`
+	}
+	vexVulnerability struct {
+		ID      string   `json:"@id,omitempty"`
+		Name    string   `json:"name"`
+		Aliases []string `json:"aliases,omitempty"`
+	}
+	vexComponent struct {
+		ID            string         `json:"@id"`
+		Subcomponents []vexComponent `json:"subcomponents,omitempty"`
+	}
+)
+
+// OpenVEX statuses and justifications.
+const (
+	vexAffected    = "affected"
+	vexNotAffected = "not_affected"
+	vexFixed       = "fixed"
+
+	vexNotInExecutePath = "vulnerable_code_not_in_execute_path"
+	vexNotPresent       = "vulnerable_code_not_present"
+)
+
+// OpenVEX writes the report as an OpenVEX document, for the consumers
+// of SBOMs. The products are the modules of r.Boundary, or else the
+// modules of the vulnerable packages themselves. Each finding
+// is an "affected" statement of its vulnerability, with the module of
+// the vulnerable package as the subcomponent. Each of r.NotAffected
+// is a "fixed" statement if the version in use fixes it, or else a
+// "not_affected" one, justified by vulnerable_code_not_in_execute_path
+// if a vulnerable package is imported but no vulnerable symbol is
+// reachable, and by vulnerable_code_not_present otherwise.
+func OpenVEX(w io.Writer, r *Report) error {
+	var products []string
+	for _, m := range r.Boundary {
+		products = append(products, purl(m, ""))
+	}
+	product := func(sub string) []vexComponent {
+		if len(products) == 0 {
+			return []vexComponent{{ID: sub}}
+		}
+		var res []vexComponent
+		for _, p := range products {
+			res = append(res, vexComponent{ID: p, Subcomponents: []vexComponent{{ID: sub}}})
+		}
+		return res
+	}
+
+	// The findings of a vulnerability in a module make one statement.
+	type key struct{ id, module string }
+	var keys []key
+	first := make(map[key]*quickcheck.Finding)
+	symbols := make(map[key][]string)
+	for _, f := range r.Findings {
+		k := key{f.ID, f.ModulePath}
+		if first[k] == nil {
+			first[k] = f
+			keys = append(keys, k)
+		}
+		symbols[k] = append(symbols[k], subject(f))
+	}
+	statements := []vexStatement{}
+	for _, k := range keys {
+		f := first[k]
+		s := vexStatement{
+			Vulnerability: r.vexVulnerability(f.ID),
+			Products:      product(purl(f.ModulePath, f.Version)),
+			Status:        vexAffected,
+			StatusNotes:   "reachable: " + strings.Join(symbols[k], ", "),
+		}
+		if f.Fix != "" {
+			s.ActionStatement = fmt.Sprintf("Upgrade %s to %s or later.", f.ModulePath, f.Fix)
+		} else {
+			s.ActionStatement = "No fixed version is available; avoid the vulnerable symbols."
+		}
+		statements = append(statements, s)
+	}
+	for _, na := range r.NotAffected {
+		s := vexStatement{
+			Vulnerability: r.vexVulnerability(na.ID),
+			Products:      product(purl(na.ModulePath, na.Version)),
+			Status:        vexNotAffected,
+			StatusNotes:   na.Reason,
+		}
+		switch na.Reason {
+		case quickcheck.ReasonUnreachable:
+			s.Justification = vexNotInExecutePath
+			s.ImpactStatement = "A vulnerable package is imported, but no vulnerable symbol is reachable."
+		case quickcheck.ReasonVersion:
+			if na.Fixed != "" {
+				s.Status = vexFixed
+				s.StatusNotes = fmt.Sprintf("fixed in %s", na.Fixed)
+				break
+			}
+			s.Justification = vexNotPresent
+			s.ImpactStatement = "The version in use predates the vulnerability; " + na.Detail + "."
+		case quickcheck.ReasonNotImported:
+			s.Justification = vexNotPresent
+			s.ImpactStatement = "No vulnerable package is imported."
+		default:
+			s.Justification = vexNotPresent
+			s.ImpactStatement = na.Detail
+		}
+		statements = append(statements, s)
+	}
+	sort.SliceStable(statements, func(i, j int) bool {
+		return statements[i].Vulnerability.Name < statements[j].Vulnerability.Name
+	})
+
+	data, err := json.Marshal(statements)
+	if err != nil {
+		return err
+	}
+	h := sha256.Sum256(data)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(vexDocument{
+		Context:    "https://openvex.dev/ns/v0.2.0",
+		ID:         "https://openvex.dev/docs/public/vex-" + hex.EncodeToString(h[:]),
+		Author:     "vulns",
+		Timestamp:  now().UTC().Format(time.RFC3339),
+		Version:    1,
+		Tooling:    "vulns " + toolVersion(),
+		Statements: statements,
+	})
+}
+
+// vexVulnerability returns the vulnerability of the statements
+// of the ID.
+func (r *Report) vexVulnerability(id string) vexVulnerability {
+	v := vexVulnerability{ID: r.URL(id), Name: id}
+	if e := r.Entries[id]; e != nil {
+		v.Aliases = e.Aliases
+	}
+	return v
+}
+
+// purl returns the package URL of the Go module at the version,
+// if known.
+func purl(modulePath, version string) string {
+	if version == "" {
+		return "pkg:golang/" + modulePath
+	}
+	return "pkg:golang/" + modulePath + "@" + version
+}
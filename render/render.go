@@ -0,0 +1,445 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package render formats quickcheck results for presentation.
+//
+// Renderers are registered by name. The vulns command looks up the
+// renderer selected with its -format flag, so programs embedding
+// the scanner can add their own output formats by registering a
+// Renderer before rendering a Report.
+package render
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hyangah/vulns/quickcheck"
+	"github.com/hyangah/vulns/tracefmt"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/vuln/osv"
+)
+
+// A Renderer writes a Report in a particular format.
+type Renderer interface {
+	Render(w io.Writer, r *Report) error
+}
+
+// RendererFunc adapts a function to the Renderer interface.
+type RendererFunc func(w io.Writer, r *Report) error
+
+// Render calls f(w, r).
+func (f RendererFunc) Render(w io.Writer, r *Report) error { return f(w, r) }
+
+// A Report is the input to a Renderer.
+type Report struct {
+	// Findings is the list of reachable vulnerable symbols,
+	// sorted by ID, package path, and symbol, or as set by Sort.
+	Findings []*quickcheck.Finding
+	// Entries maps OSV IDs to the entries referenced by Findings.
+	Entries map[string]*osv.Entry
+	// Boundary identifies first-party code. If set, traces are
+	// split into the frames in first-party code and the frames
+	// in dependencies.
+	Boundary quickcheck.Boundary
+	// GroupBy selects how Groups groups findings: GroupByVuln
+	// (the default) or GroupByEntry, and how the text report and
+	// JSON output group them (see TextGroups).
+	GroupBy string
+	// SortBy is the order of Findings set by Sort, which the
+	// groups of findings follow: SortByID (the default),
+	// SortBySeverity, or SortByModule.
+	SortBy string
+	// SnippetContext is the number of lines of source around the
+	// first frame in first-party code that structured renderers
+	// include with each finding. Negative disables snippets.
+	SnippetContext int
+	// Filtered lists the vulnerabilities excluded from the analysis
+	// because they affect only other platforms. Renderers that
+	// support it show them in a separate section.
+	Filtered []*quickcheck.FilteredFinding
+	// Unreachable lists the known vulnerabilities of required
+	// modules without reachable vulnerable symbols, which govulncheck
+	// mentions as informational. Renderers that support it show them
+	// in a separate section.
+	Unreachable []*quickcheck.UnreachableVuln
+	// NotAffected, if non-nil, explains why the known vulnerabilities
+	// of the modules in the import closure that are not findings do
+	// not affect the packages. JSON and YAML output include it.
+	NotAffected []*quickcheck.NotAffected
+	// Modules, if set, lists the modules of the build, for
+	// renderers of inventories such as CycloneDX.
+	Modules []*packages.Module
+	// Severity, if set, returns the severity of the vulnerability
+	// with the ID, such as "High". The Go vulnerability database
+	// does not record severities, so programs embedding the scanner
+	// provide them from another source. Unrecognized severities
+	// are in the SeverityUnknown bucket.
+	Severity func(id string) string
+	// Color enables colorizing text output by severity.
+	Color bool
+	// Coverage, if set, tells how much of the import closure was
+	// analyzed for symbol reachability. Renderers that support it
+	// show it in the summary.
+	Coverage *quickcheck.Coverage
+	// TraceFormat formats the traces in text, markdown, and HTML
+	// output. The zero value prints the frames as they are.
+	TraceFormat tracefmt.Formatter
+	// Skipped, if set, is the reason the scan was skipped, such as
+	// an unreachable vulnerability database. The report then has no
+	// findings, and renderers that support it say that the scan was
+	// skipped rather than that no vulnerabilities were found.
+	Skipped string
+}
+
+// Grouping modes of a Report.
+const (
+	// GroupByVuln groups findings by vulnerability and package.
+	// The text output lists each vulnerability with the modules
+	// it affects.
+	GroupByVuln = "vuln"
+	// GroupByModule groups findings as GroupByVuln, but the text
+	// output groups the vulnerabilities by module, as it does when
+	// GroupBy is not set (see Report.ModuleGroups).
+	GroupByModule = "module"
+	// GroupByPackage groups findings as GroupByVuln, but the text
+	// output groups the vulnerabilities by affected package.
+	GroupByPackage = "package"
+	// GroupByEntry groups findings by the first-party package
+	// where their traces enter, as determined by the Boundary.
+	GroupByEntry = "entry"
+)
+
+// NewReport creates a Report from the results of quickcheck.Analyze,
+// or of its coarser variants such as quickcheck.AnalyzePackages.
+func NewReport(summary map[quickcheck.Key]quickcheck.Value, pkg2vulns map[string][]*osv.Entry) *Report {
+	r := &Report{
+		Findings:       quickcheck.Findings(summary),
+		Entries:        make(map[string]*osv.Entry),
+		SnippetContext: -1,
+	}
+	for _, vulns := range pkg2vulns {
+		for _, v := range vulns {
+			r.Entries[v.ID] = v
+		}
+	}
+	return r
+}
+
+// A Group is a set of related findings. Depending on the grouping
+// mode, it holds the findings of a vulnerability in a package, or
+// the findings entering first-party code through a package.
+type Group struct {
+	ID          string // set when grouped by vulnerability
+	PackagePath string // set when grouped by vulnerability
+	Entry       string // set when grouped by entry package
+	Findings    []*quickcheck.Finding
+}
+
+// Groups returns the findings grouped according to r.GroupBy,
+// in the order of r.Findings.
+func (r *Report) Groups() []*Group {
+	var groups []*Group
+	index := map[[2]string]*Group{}
+	for _, f := range r.Findings {
+		var k [2]string
+		g := &Group{}
+		if r.GroupBy == GroupByEntry {
+			g.Entry = r.Boundary.EntryPackage(f.Trace)
+			k[0] = g.Entry
+		} else {
+			g.ID, g.PackagePath = f.ID, f.PackagePath
+			k = [2]string{f.ID, f.PackagePath}
+		}
+		if existing := index[k]; existing != nil {
+			g = existing
+		} else {
+			index[k] = g
+			groups = append(groups, g)
+		}
+		g.Findings = append(g.Findings, f)
+	}
+	return groups
+}
+
+// A ModuleGroup holds the findings in a module, grouped by
+// vulnerability. The groups of TextGroups for GroupByPackage and
+// GroupByVuln have the same form, with the findings in a package
+// or of a vulnerability, in the module of their first finding.
+type ModuleGroup struct {
+	Path    string
+	Version string // the version in use, if known
+	Package string // set when grouped by package
+	ID      string // set when grouped by vulnerability
+	Vulns   []*VulnGroup
+}
+
+// A VulnGroup holds the findings of a vulnerability in a module.
+type VulnGroup struct {
+	ID       string
+	Packages []string // the affected packages, sorted
+	// Finding is the representative finding of the vulnerability:
+	// the one with the shortest trace.
+	Finding  *quickcheck.Finding
+	Findings []*quickcheck.Finding
+	// Entries is the number of distinct entry points of the
+	// traces to the findings (see quickcheck.Value.Entries).
+	Entries int
+}
+
+// ModuleGroups returns the findings grouped by module, sorted by
+// module path, or in the order of r.Findings when sorted by
+// severity, and then by vulnerability, in the order of r.Findings.
+func (r *Report) ModuleGroups() []*ModuleGroup {
+	return r.vulnGroups(GroupByModule)
+}
+
+// TextGroups returns the groups of findings of the text report, in
+// which order the JSON output lists the findings: the ModuleGroups,
+// or with GroupByPackage, the groups of the findings in a package,
+// sorted by module and package path unless sorted by severity, or
+// with GroupByVuln, the groups of the findings of a vulnerability,
+// in the order of r.Findings. Findings without a package path are
+// grouped by module.
+func (r *Report) TextGroups() []*ModuleGroup {
+	switch r.GroupBy {
+	case GroupByPackage, GroupByVuln:
+		return r.vulnGroups(r.GroupBy)
+	}
+	return r.ModuleGroups()
+}
+
+// vulnGroups returns the findings grouped by module, package, or
+// vulnerability, and then by vulnerability and module, in the order
+// of r.Findings. The groups of modules and packages are sorted by
+// path unless r is sorted by severity.
+func (r *Report) vulnGroups(by string) []*ModuleGroup {
+	key := func(f *quickcheck.Finding) string {
+		switch by {
+		case GroupByPackage:
+			return f.ModulePath + " " + f.PackagePath
+		case GroupByVuln:
+			return f.ID
+		}
+		return f.ModulePath
+	}
+	var groups []*ModuleGroup
+	index := map[string]*ModuleGroup{}
+	vulns := map[[3]string]*VulnGroup{}
+	entries := map[*VulnGroup]map[string]bool{}
+	for _, f := range r.Findings {
+		m := index[key(f)]
+		if m == nil {
+			m = &ModuleGroup{Path: f.ModulePath}
+			switch by {
+			case GroupByPackage:
+				m.Package = f.PackagePath
+			case GroupByVuln:
+				m.ID = f.ID
+			}
+			index[key(f)] = m
+			groups = append(groups, m)
+		}
+		if m.Version == "" && m.Path == f.ModulePath {
+			m.Version = f.Version
+		}
+		k := [3]string{key(f), f.ModulePath, f.ID}
+		v := vulns[k]
+		if v == nil {
+			v = &VulnGroup{ID: f.ID, Finding: f}
+			vulns[k] = v
+			entries[v] = make(map[string]bool)
+			m.Vulns = append(m.Vulns, v)
+		}
+		v.Findings = append(v.Findings, f)
+		addEntries(entries[v], f)
+		v.Entries = len(entries[v])
+		if f.PackagePath != "" && !contains(v.Packages, f.PackagePath) {
+			v.Packages = append(v.Packages, f.PackagePath)
+		}
+		if len(f.Trace) > 0 && (len(v.Finding.Trace) == 0 || len(f.Trace) < len(v.Finding.Trace)) {
+			v.Finding = f
+		}
+	}
+	if r.SortBy != SortBySeverity && by != GroupByVuln {
+		sort.SliceStable(groups, func(i, j int) bool {
+			if groups[i].Path != groups[j].Path {
+				return groups[i].Path < groups[j].Path
+			}
+			return groups[i].Package < groups[j].Package
+		})
+	}
+	for _, m := range groups {
+		for _, v := range m.Vulns {
+			sort.Strings(v.Packages)
+		}
+	}
+	return groups
+}
+
+// A TraceGroup is a set of findings whose traces reach the same
+// vulnerable frame, such as the findings of several vulnerabilities
+// affecting a symbol. Their traces are printed once.
+type TraceGroup struct {
+	Findings []*quickcheck.Finding
+	// Trace is the shortest of the traces of the findings.
+	Trace []string
+	// Entries is the number of distinct entry points of the
+	// traces to the findings (see quickcheck.Value.Entries).
+	Entries int
+}
+
+// TraceGroups groups the findings whose traces end with the same
+// frame, in the order of the findings. Findings without traces are
+// in groups of their own.
+func (r *Report) TraceGroups(findings []*quickcheck.Finding) []*TraceGroup {
+	var groups []*TraceGroup
+	byFrame := make(map[string]*TraceGroup)
+	entries := make(map[*TraceGroup]map[string]bool)
+	for _, f := range findings {
+		var g *TraceGroup
+		if len(f.Trace) > 0 {
+			last := f.Trace[len(f.Trace)-1]
+			if g = byFrame[last]; g == nil {
+				g = &TraceGroup{Trace: f.Trace}
+				byFrame[last] = g
+				entries[g] = make(map[string]bool)
+			}
+			if len(f.Trace) < len(g.Trace) {
+				g.Trace = f.Trace
+			}
+			addEntries(entries[g], f)
+			g.Entries = len(entries[g])
+		}
+		if g == nil || len(g.Findings) == 0 {
+			if g == nil {
+				g = &TraceGroup{}
+			}
+			groups = append(groups, g)
+		}
+		g.Findings = append(g.Findings, f)
+	}
+	return groups
+}
+
+// addEntries adds the entry points of the traces to the finding
+// to the set.
+func addEntries(set map[string]bool, f *quickcheck.Finding) {
+	for _, e := range f.Entries {
+		set[e] = true
+	}
+	if len(f.Trace) > 0 {
+		set[ParseFrame(f.Trace[0]).Symbol] = true
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	mu        sync.Mutex
+	renderers = make(map[string]Renderer)
+)
+
+// subject returns what the finding is about: the vulnerable
+// symbol, or the package or the module for findings of the
+// coarser scan levels (see quickcheck.ScanModule).
+func subject(f *quickcheck.Finding) string {
+	switch {
+	case f.Symbol != "":
+		return f.SymbolID().String()
+	case f.PackagePath != "":
+		return f.PackagePath
+	}
+	return f.ModulePath
+}
+
+// Register makes a renderer available by the provided name.
+// If Register is called twice with the same name or if r is nil,
+// it panics.
+func Register(name string, r Renderer) {
+	mu.Lock()
+	defer mu.Unlock()
+	if r == nil {
+		panic("render: Register renderer is nil")
+	}
+	if _, dup := renderers[name]; dup {
+		panic("render: Register called twice for renderer " + name)
+	}
+	renderers[name] = r
+}
+
+// Lookup returns the renderer registered with the name,
+// or nil if there is none.
+func Lookup(name string) Renderer {
+	mu.Lock()
+	defer mu.Unlock()
+	return renderers[name]
+}
+
+// Names returns the sorted list of the names of registered renderers.
+func Names() []string {
+	mu.Lock()
+	defer mu.Unlock()
+	var names []string
+	for name := range renderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// URL returns the URL of the advisory of the vulnerability with the ID:
+// its page on pkg.go.dev for entries of the Go vulnerability database,
+// and otherwise the first ADVISORY or WEB reference of its entry, such
+// as the page of an internal advisory. It returns the empty string if
+// the entry has no such reference.
+func (r *Report) URL(id string) string {
+	if strings.HasPrefix(id, "GO-") {
+		return "https://pkg.go.dev/vuln/" + id
+	}
+	e := r.Entries[id]
+	if e == nil {
+		return ""
+	}
+	for _, typ := range []string{"ADVISORY", "WEB"} {
+		for _, ref := range e.References {
+			if ref.Type == typ {
+				return ref.URL
+			}
+		}
+	}
+	return ""
+}
+
+func init() {
+	Register("text", RendererFunc(Text))
+	Register("json", RendererFunc(JSON))
+	Register("yaml", RendererFunc(YAML))
+	Register("sarif", RendererFunc(SARIF))
+	Register("junit", RendererFunc(JUnit))
+	Register("gitlab-sast", RendererFunc(GitLab))
+	Register("openvex", RendererFunc(OpenVEX))
+	Register("cyclonedx", RendererFunc(CycloneDX))
+	Register("lines", RendererFunc(Lines))
+	Register("lines-verbose", RendererFunc(VerboseLines))
+	Register("markdown", RendererFunc(Markdown))
+	Register("html", RendererFunc(HTML))
+	Register("api", RendererFunc(API))
+	Register("dot", RendererFunc(DOT))
+	Register("mermaid", RendererFunc(Mermaid))
+}
+
+// A Frame is a parsed entry of a finding's trace.
+type Frame = tracefmt.Frame
+
+// ParseFrame parses a trace entry of the form "symbol file:line:col".
+func ParseFrame(s string) Frame { return tracefmt.ParseFrame(s) }
@@ -0,0 +1,913 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyangah/vulns/quickcheck"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/vuln/osv"
+	"gopkg.in/yaml.v3"
+)
+
+func testReport() *Report {
+	summary := map[quickcheck.Key]quickcheck.Value{
+		{ID: "GO-2022-0002", PackagePath: "b.com/m/vuln", ModulePath: "b.com/m", Symbol: "Vuln"}: {
+			Trace: []string{"work/x.X /tmp/x/x.go:4:9", "b.com/m/vuln.Vuln /tmp/b/vuln.go:2:9"},
+			Count: 2,
+		},
+		{ID: "GO-2022-0001", PackagePath: "a.com/m/vuln", ModulePath: "a.com/m", Symbol: "Vuln"}: {
+			Trace: []string{"work/y.Y /tmp/y/y.go:3:9", "a.com/m/vuln.Vuln /tmp/a/vuln.go:2:9"},
+			Count: 1,
+		},
+	}
+	pkg2vulns := map[string][]*osv.Entry{
+		"a.com/m/vuln": {{ID: "GO-2022-0001", Details: "first"}},
+		"b.com/m/vuln": {{ID: "GO-2022-0002", Details: "second"}},
+	}
+	return NewReport(summary, pkg2vulns)
+}
+
+func TestRegistry(t *testing.T) {
+	for _, name := range []string{"text", "json", "yaml", "sarif", "junit", "gitlab-sast", "openvex", "cyclonedx", "markdown", "html", "dot", "mermaid"} {
+		if Lookup(name) == nil {
+			t.Errorf("Lookup(%q) = nil, want a builtin renderer", name)
+		}
+	}
+	Register("test-custom", RendererFunc(func(w io.Writer, r *Report) error {
+		_, err := io.WriteString(w, "custom")
+		return err
+	}))
+	var buf bytes.Buffer
+	if err := Lookup("test-custom").Render(&buf, testReport()); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "custom" {
+		t.Errorf("custom renderer wrote %q", got)
+	}
+}
+
+func TestText(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Text(&buf, testReport()); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	first := strings.Index(got, "Module a.com/m: 1 vulnerability\n\n  GO-2022-0001\n    Packages: a.com/m/vuln\n")
+	second := strings.Index(got, "Module b.com/m: 1 vulnerability\n\n  GO-2022-0002\n    Packages: b.com/m/vuln\n")
+	if first < 0 || second < first {
+		t.Errorf("unexpected text output:\n%s", got)
+	}
+	if want := "    Call stack in your code:\n      work/x.X /tmp/x/x.go:4:9\n      b.com/m/vuln.Vuln /tmp/b/vuln.go:2:9\n"; !strings.Contains(got, want) {
+		t.Errorf("text output does not contain %q:\n%s", want, got)
+	}
+	if strings.Contains(got, "\x1b[") {
+		t.Errorf("uncolored text output contains escape sequences:\n%s", got)
+	}
+}
+
+func TestTextColor(t *testing.T) {
+	r := testReport()
+	r.Color = true
+	r.Boundary = quickcheck.ParseBoundary("work")
+	r.Severity = func(id string) string { return "Critical" }
+	var buf bytes.Buffer
+	if err := Text(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"\x1b[1mModule a.com/m\x1b[0m: 1 vulnerability",
+		"  \x1b[1;31mGO-2022-0001 [Critical]\x1b[0m\n",
+		"    Via dependencies:\n\x1b[2m      a.com/m/vuln.Vuln /tmp/a/vuln.go:2:9\x1b[0m\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("text output does not contain %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestModuleGroups(t *testing.T) {
+	r := testReport()
+	long := &quickcheck.Finding{
+		Key:   quickcheck.Key{ID: "GO-2022-0002", PackagePath: "b.com/m/other", ModulePath: "b.com/m", Symbol: "F"},
+		Value: quickcheck.Value{Trace: []string{"work/x.X", "b.com/m/vuln.G", "b.com/m/other.F"}},
+	}
+	r.Findings = append([]*quickcheck.Finding{long}, r.Findings...)
+	groups := r.ModuleGroups()
+	if len(groups) != 2 || groups[0].Path != "a.com/m" || groups[1].Path != "b.com/m" {
+		t.Fatalf("unexpected module groups %+v", groups)
+	}
+	v := groups[1].Vulns[0]
+	if len(groups[1].Vulns) != 1 || len(v.Findings) != 2 {
+		t.Fatalf("unexpected vulnerability groups %+v", groups[1].Vulns)
+	}
+	if want := []string{"b.com/m/other", "b.com/m/vuln"}; !reflect.DeepEqual(v.Packages, want) {
+		t.Errorf("packages = %v, want %v", v.Packages, want)
+	}
+	if v.Finding == long {
+		t.Errorf("representative finding has the longer trace")
+	}
+}
+
+func TestTextVersions(t *testing.T) {
+	r := testReport()
+	f := r.Findings[0]
+	f.Version, f.Fix, f.MinFix = "v1.0.0", "v1.3.1", "v1.1.0"
+	var buf bytes.Buffer
+	if err := Text(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	want := "    Found in: a.com/m@v1.0.0\n    Fixed in: a.com/m@v1.3.1\n    Minimum fixed version: a.com/m@v1.1.0\n"
+	if got := buf.String(); !strings.Contains(got, want) {
+		t.Errorf("text output does not contain %q:\n%s", want, got)
+	}
+}
+
+func TestTraceGroups(t *testing.T) {
+	r := testReport()
+	r.Boundary = quickcheck.ParseBoundary("work")
+	same := *r.Findings[0]
+	same.ID = "GO-2022-0003"
+	same.Entries = []string{"work/y.Y", "work/z.Z"}
+	r.Findings = append(r.Findings, &same)
+
+	groups := r.TraceGroups(r.Findings)
+	if len(groups) != 2 || len(groups[0].Findings) != 2 || groups[0].Findings[1] != &same || groups[0].Entries != 2 {
+		t.Fatalf("unexpected trace groups %+v", groups)
+	}
+
+	var buf bytes.Buffer
+	if err := Text(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	if want := "  GO-2022-0003\n    Packages: a.com/m/vuln\n\n    Call stack: same as GO-2022-0001\n"; !strings.Contains(buf.String(), want) {
+		t.Errorf("text output does not contain %q:\n%s", want, buf.String())
+	}
+	buf.Reset()
+	r.GroupBy = GroupByEntry
+	if err := Text(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	want := "GO-2022-0001 (a.com/m/vuln.Vuln)\nGO-2022-0003 (a.com/m/vuln.Vuln)\n\nCall stacks in your code (2 entry points):\n\twork/y.Y"
+	if got := buf.String(); !strings.Contains(got, want) || strings.Count(got, "a.com/m/vuln.Vuln /tmp/a/vuln.go:2:9") != 1 {
+		t.Errorf("text output does not print the shared trace once:\n%s", got)
+	}
+}
+
+func TestParseTemplate(t *testing.T) {
+	r, err := ParseTemplate("custom", `{{range .Groups}}{{.ID}}{{with $.Severity .ID}} {{.}}{{end}}: {{join ($.LocalTrace (index .Findings 0).Trace "\t") ";"}}
+{{end}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := testReport()
+	report.Severity = func(id string) string { return "High" }
+	var buf bytes.Buffer
+	if err := r.Render(&buf, report); err != nil {
+		t.Fatal(err)
+	}
+	want := "GO-2022-0001 High: \twork/y.Y /tmp/y/y.go:3:9;\ta.com/m/vuln.Vuln /tmp/a/vuln.go:2:9\n" +
+		"GO-2022-0002 High: \twork/x.X /tmp/x/x.go:4:9;\tb.com/m/vuln.Vuln /tmp/b/vuln.go:2:9\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if _, err := ParseTemplate("bad", "{{.Groups"); err == nil {
+		t.Error("ParseTemplate succeeded on an invalid template")
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := JSON(&buf, testReport()); err != nil {
+		t.Fatal(err)
+	}
+	var got []jsonFinding
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].ID != "GO-2022-0001" || got[1].Count != 2 {
+		t.Fatalf("unexpected JSON output:\n%s", buf.Bytes())
+	}
+	if len(got[1].Frames) != len(got[1].Trace) {
+		t.Fatalf("got %d frames for %d trace entries", len(got[1].Frames), len(got[1].Trace))
+	}
+	if fr, want := got[1].Frames[0], ParseFrame(got[1].Trace[0]); fr != want {
+		t.Errorf("frame = %+v, want %+v", fr, want)
+	}
+	if got[0].Fingerprint == "" || got[0].Fingerprint == got[1].Fingerprint {
+		t.Errorf("unexpected fingerprints %q, %q", got[0].Fingerprint, got[1].Fingerprint)
+	}
+	if got[0].Effort.EntryPoints != 1 {
+		t.Errorf("Effort = %+v, want 1 entry point", got[0].Effort)
+	}
+}
+
+func TestJSONNotAffected(t *testing.T) {
+	r := testReport()
+	r.NotAffected = []*quickcheck.NotAffected{{ID: "GO-2022-0003", ModulePath: "example.com/m", Version: "v1.0.0", Reason: quickcheck.ReasonUnreachable}}
+	var buf bytes.Buffer
+	if err := JSON(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Findings    []jsonFinding
+		NotAffected []*quickcheck.NotAffected
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Findings) != 2 || len(got.NotAffected) != 1 || *got.NotAffected[0] != *r.NotAffected[0] {
+		t.Fatalf("unexpected JSON output:\n%s", buf.Bytes())
+	}
+}
+
+func TestYAML(t *testing.T) {
+	var jsonBuf, yamlBuf bytes.Buffer
+	if err := JSON(&jsonBuf, testReport()); err != nil {
+		t.Fatal(err)
+	}
+	if err := YAML(&yamlBuf, testReport()); err != nil {
+		t.Fatal(err)
+	}
+	var fromJSON, fromYAML []map[string]any
+	if err := json.Unmarshal(jsonBuf.Bytes(), &fromJSON); err != nil {
+		t.Fatal(err)
+	}
+	if err := yaml.Unmarshal(yamlBuf.Bytes(), &fromYAML); err != nil {
+		t.Fatal(err)
+	}
+	// YAML decodes integers as int, JSON as float64.
+	for _, f := range fromYAML {
+		f["Count"] = float64(f["Count"].(int))
+		effort := f["Effort"].(map[string]any)
+		effort["EntryPoints"] = float64(effort["EntryPoints"].(int))
+		for _, fr := range f["Frames"].([]any) {
+			for k, v := range fr.(map[string]any) {
+				if n, ok := v.(int); ok {
+					fr.(map[string]any)[k] = float64(n)
+				}
+			}
+		}
+	}
+	if !reflect.DeepEqual(fromJSON, fromYAML) {
+		t.Errorf("YAML output differs from JSON output:\n%s\n%s", yamlBuf.Bytes(), jsonBuf.Bytes())
+	}
+}
+
+func TestSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := SARIF(&buf, testReport()); err != nil {
+		t.Fatal(err)
+	}
+	var got sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	run := got.Runs[0]
+	if len(run.Tool.Driver.Rules) != 2 || len(run.Results) != 2 {
+		t.Fatalf("unexpected SARIF output:\n%s", buf.Bytes())
+	}
+	loc := run.Results[0].Locations[0].PhysicalLocation
+	if loc.ArtifactLocation.URI != "/tmp/y/y.go" || loc.Region.StartLine != 3 {
+		t.Errorf("unexpected location %+v", loc)
+	}
+	if got, want := run.Results[0].PartialFingerprints[sarifFingerprintKey], testReport().Findings[0].Fingerprint(nil); got != want {
+		t.Errorf("fingerprint = %q, want %q", got, want)
+	}
+}
+
+func TestJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := JUnit(&buf, testReport()); err != nil {
+		t.Fatal(err)
+	}
+	var got junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Tests != 2 || got.Failures != 2 || len(got.Suites) != 1 || len(got.Suites[0].Cases) != 2 {
+		t.Fatalf("unexpected JUnit output:\n%s", buf.Bytes())
+	}
+	c := got.Suites[0].Cases[0]
+	if c.ClassName != "a.com/m/vuln" || c.Name != "GO-2022-0001" || c.Failure == nil || c.Failure.Message != "GO-2022-0001: first" {
+		t.Errorf("unexpected test case %+v", c)
+	}
+	if want := "a.com/m/vuln.Vuln:\nwork/y.Y /tmp/y/y.go:3:9\n"; !strings.Contains(c.Failure.Text, want) {
+		t.Errorf("failure text %q does not contain the trace %q", c.Failure.Text, want)
+	}
+
+	r := testReport()
+	r.Findings, r.Skipped = nil, "database unavailable"
+	buf.Reset()
+	if err := JUnit(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	if want := `<skipped message="database unavailable">`; !strings.Contains(buf.String(), want) {
+		t.Errorf("skipped output does not contain %q:\n%s", want, buf.String())
+	}
+}
+
+func TestGitLab(t *testing.T) {
+	defer func(old func() time.Time) { now = old }(now)
+	now = func() time.Time { return time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC) }
+
+	r := testReport()
+	r.Entries["GO-2022-0001"].Aliases = []string{"CVE-2022-0001"}
+	r.Severity = func(id string) string { return "high" }
+	r.TraceFormat.Dir = "/tmp"
+	var buf bytes.Buffer
+	if err := GitLab(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	var got gitlabReport
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Scan.Type != "sast" || got.Scan.Status != "success" || got.Scan.StartTime != "2022-10-01T12:00:00" || len(got.Vulnerabilities) != 2 {
+		t.Fatalf("unexpected GitLab output:\n%s", buf.Bytes())
+	}
+	v := got.Vulnerabilities[0]
+	want := []gitlabIdentifier{
+		{Type: "go", Name: "GO-2022-0001", Value: "GO-2022-0001", URL: "https://pkg.go.dev/vuln/GO-2022-0001"},
+		{Type: "cve", Name: "CVE-2022-0001", Value: "CVE-2022-0001"},
+	}
+	if !reflect.DeepEqual(v.Identifiers, want) {
+		t.Errorf("identifiers = %+v, want %+v", v.Identifiers, want)
+	}
+	if v.Severity != SeverityHigh || v.Location.File != "y/y.go" || v.Location.StartLine != 3 || v.Description != "first" {
+		t.Errorf("unexpected vulnerability %+v", v)
+	}
+	if len(v.ID) != 36 || v.ID == got.Vulnerabilities[1].ID {
+		t.Errorf("unexpected vulnerability IDs %q, %q", v.ID, got.Vulnerabilities[1].ID)
+	}
+
+	r = testReport()
+	r.Findings, r.Skipped = nil, "database unavailable"
+	buf.Reset()
+	if err := GitLab(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Scan.Status != "failure" || len(got.Scan.Messages) != 1 || len(got.Vulnerabilities) != 0 {
+		t.Errorf("unexpected skipped output:\n%s", buf.Bytes())
+	}
+}
+
+func TestOpenVEX(t *testing.T) {
+	r := testReport()
+	r.Boundary = quickcheck.Boundary{"work"}
+	r.Findings[0].Version, r.Findings[0].Fix = "v1.0.0", "v1.0.1"
+	r.NotAffected = []*quickcheck.NotAffected{
+		{ID: "GO-2022-0003", ModulePath: "c.com/m", Version: "v1.0.0", Reason: quickcheck.ReasonUnreachable},
+		{ID: "GO-2022-0004", ModulePath: "c.com/m", Version: "v1.0.0", Reason: quickcheck.ReasonVersion, Fixed: "v0.9.0"},
+		{ID: "GO-2022-0005", ModulePath: "c.com/m", Version: "v1.0.0", Reason: quickcheck.ReasonNotImported},
+	}
+	var buf bytes.Buffer
+	if err := OpenVEX(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	var got vexDocument
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Context != "https://openvex.dev/ns/v0.2.0" || len(got.Statements) != 5 {
+		t.Fatalf("unexpected OpenVEX output:\n%s", buf.Bytes())
+	}
+	type status struct{ name, status, justification string }
+	var statuses []status
+	for _, s := range got.Statements {
+		statuses = append(statuses, status{s.Vulnerability.Name, s.Status, s.Justification})
+	}
+	want := []status{
+		{"GO-2022-0001", "affected", ""},
+		{"GO-2022-0002", "affected", ""},
+		{"GO-2022-0003", "not_affected", "vulnerable_code_not_in_execute_path"},
+		{"GO-2022-0004", "fixed", ""},
+		{"GO-2022-0005", "not_affected", "vulnerable_code_not_present"},
+	}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Errorf("statuses = %v, want %v", statuses, want)
+	}
+	s := got.Statements[0]
+	wantProducts := []vexComponent{{ID: "pkg:golang/work", Subcomponents: []vexComponent{{ID: "pkg:golang/a.com/m@v1.0.0"}}}}
+	if !reflect.DeepEqual(s.Products, wantProducts) || s.ActionStatement != "Upgrade a.com/m to v1.0.1 or later." {
+		t.Errorf("unexpected affected statement %+v", s)
+	}
+}
+
+func TestLines(t *testing.T) {
+	r := testReport()
+	r.Boundary = quickcheck.ParseBoundary("work")
+	r.TraceFormat.Dir = "/tmp/x"
+	r.Findings[1].Fix = "v1.2.0"
+	var buf bytes.Buffer
+	if err := Lines(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	want := `/tmp/y/y.go:3:9: GO-2022-0001: reaches vulnerable symbol a.com/m/vuln.Vuln
+x.go:4:9: GO-2022-0002: reaches vulnerable symbol b.com/m/vuln.Vuln (fixed in b.com/m@v1.2.0)
+`
+	if got := buf.String(); got != want {
+		t.Errorf("lines output =\n%s\nwant\n%s", got, want)
+	}
+
+	buf.Reset()
+	if err := VerboseLines(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	if want := "x.go:4:9: GO-2022-0002: trace: work/x.X\n/tmp/b/vuln.go:2:9: GO-2022-0002: trace: b.com/m/vuln.Vuln\n"; !strings.Contains(buf.String(), want) {
+		t.Errorf("verbose lines output does not contain %q:\n%s", want, buf.String())
+	}
+}
+
+func TestURL(t *testing.T) {
+	r := testReport()
+	r.Entries["CORP-2024-001"] = &osv.Entry{ID: "CORP-2024-001", References: []osv.Reference{
+		{Type: "REPORT", URL: "https://tracker.corp.example/1"},
+		{Type: "ADVISORY", URL: "https://advisories.corp.example/CORP-2024-001"},
+	}}
+	r.Entries["CORP-2024-002"] = &osv.Entry{ID: "CORP-2024-002"}
+	for id, want := range map[string]string{
+		"GO-2022-0001":  "https://pkg.go.dev/vuln/GO-2022-0001",
+		"CORP-2024-001": "https://advisories.corp.example/CORP-2024-001",
+		"CORP-2024-002": "",
+	} {
+		if got := r.URL(id); got != want {
+			t.Errorf("URL(%q) = %q, want %q", id, got, want)
+		}
+	}
+
+	r.Findings[0].ID = "CORP-2024-002"
+	var buf bytes.Buffer
+	if err := Markdown(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); !strings.Contains(got, "## CORP-2024-002 (a.com/m/vuln)") || !strings.Contains(got, "## [GO-2022-0002](https://pkg.go.dev/vuln/GO-2022-0002)") {
+		t.Errorf("unexpected advisory links in Markdown output:\n%s", got)
+	}
+}
+
+func TestTextByEntry(t *testing.T) {
+	r := testReport()
+	r.Boundary = quickcheck.ParseBoundary("work")
+	r.GroupBy = GroupByEntry
+	var buf bytes.Buffer
+	if err := Text(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{"Entry package work/x:", "Entry package work/y:", "Via dependencies:\n\tb.com/m/vuln.Vuln"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("text output does not contain %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "x.go")
+	src := "package x\n\nimport \"b.com/m/vuln\"\n\nfunc X() {\n\tvuln.Vuln()\n}\n"
+	if err := os.WriteFile(file, []byte(src), 0666); err != nil {
+		t.Fatal(err)
+	}
+	r := testReport()
+	r.Findings[0].Trace[0] = "work/x.X " + file + ":5:6"
+	r.SnippetContext = 1
+
+	var buf bytes.Buffer
+	if err := JSON(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	var got []jsonFinding
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := &Snippet{File: file, StartLine: 4, Line: 5, Text: "\nfunc X() {\n\tvuln.Vuln()"}
+	if s := got[0].Snippet; s == nil || *s != *want {
+		t.Errorf("snippet = %+v, want %+v", s, want)
+	}
+	if got[1].Snippet != nil {
+		t.Errorf("unexpected snippet for a finding with an unreadable file: %+v", got[1].Snippet)
+	}
+}
+
+func TestDepsText(t *testing.T) {
+	mods := []*quickcheck.ModuleVulns{
+		{Path: "a.com/m", Version: "v1.0.0", Vulns: []*quickcheck.DepVuln{
+			{ID: "GO-2022-0001", Verdict: quickcheck.VerdictReachable},
+			{ID: "GO-2022-0003", Verdict: quickcheck.VerdictNotImported},
+		}},
+		{Path: "c.com/m", Version: "v0.1.0"},
+	}
+	var buf bytes.Buffer
+	if err := DepsText(&buf, mods); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{"a.com/m v1.0.0\n\tGO-2022-0001\treachable\n", "c.com/m v0.1.0\n", "2 modules, 2 known vulnerabilities, 1 reachable"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("text output does not contain %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestSummaryText(t *testing.T) {
+	s := &quickcheck.Summary{
+		Modules: []*quickcheck.ModuleSummary{
+			{Path: "a.com/m", Vulns: 2, Reachable: 1, ImportOnly: 1},
+			{Path: "c.com/m", Vulns: 1, ImportOnly: 1},
+		},
+		Vulns:      3,
+		Reachable:  1,
+		ImportOnly: 2,
+		Packages:   1,
+	}
+	var buf bytes.Buffer
+	if err := SummaryText(&buf, s); err != nil {
+		t.Fatal(err)
+	}
+	want := `a.com/m	2 vulnerabilities (1 symbol-reachable, 1 import-only)
+c.com/m	1 vulnerability (0 symbol-reachable, 1 import-only)
+
+3 vulnerabilities (1 symbol-reachable, 2 import-only) in 1 affected package
+`
+	if got := buf.String(); got != want {
+		t.Errorf("text output mismatch:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFiltered(t *testing.T) {
+	r := testReport()
+	r.Filtered = []*quickcheck.FilteredFinding{{ID: "GO-2022-0003", PackagePath: "c.com/m/win", ModulePath: "c.com/m", GOOS: []string{"windows"}}}
+	var buf bytes.Buffer
+	if err := Text(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Filtered by platform (may affect builds for other platforms):\n\tGO-2022-0003 (c.com/m/win) GOOS=windows\n"; !strings.Contains(buf.String(), want) {
+		t.Errorf("text output does not contain %q:\n%s", want, buf.String())
+	}
+	buf.Reset()
+	if err := Markdown(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	if want := "## Filtered by platform\n\n- [GO-2022-0003]"; !strings.Contains(buf.String(), want) {
+		t.Errorf("markdown output does not contain %q:\n%s", want, buf.String())
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	r := testReport()
+	r.Coverage = &quickcheck.Coverage{
+		Packages: 8,
+		Analyzed: 6,
+		Skipped: []*quickcheck.SkippedPackage{
+			{Path: "a.com/m/broken", Reason: quickcheck.CoverageLoadErrors},
+			{Path: "a.com/m/other", Reason: quickcheck.CoverageLoadErrors},
+		},
+	}
+	var buf bytes.Buffer
+	if err := Text(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Coverage: 75.0% of 8 packages analyzed for symbol reachability (not analyzed: 2 load errors)"; !strings.Contains(buf.String(), want) {
+		t.Errorf("text output does not contain %q:\n%s", want, buf.String())
+	}
+}
+
+func TestAPI(t *testing.T) {
+	r := testReport()
+	// b.com/m/vuln.Vuln is reachable from two exported functions.
+	r.Findings[1].EntryTraces = map[string][]string{
+		"work/x.X": {"work/x.X /tmp/x/x.go:4:9", "b.com/m/vuln.Vuln /tmp/b/vuln.go:2:9"},
+		"work/x.Y": {"work/x.Y /tmp/x/x.go:8:9", "work/x.helper /tmp/x/x.go:12:9", "b.com/m/vuln.Vuln /tmp/b/vuln.go:2:9"},
+	}
+	r.Findings[1].Version, r.Findings[1].Fix = "v1.0.0", "v1.0.1"
+	var buf bytes.Buffer
+	if err := API(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	want := `# Vulnerabilities exposed by the API
+
+## work/x.X
+
+- ` + "`work/x.X` exposes [GO-2022-0002](https://pkg.go.dev/vuln/GO-2022-0002) in `b.com/m@v1.0.0` (fixed in `b.com/m@v1.0.1`) via `work/x.X` → `b.com/m/vuln.Vuln`" + `.
+
+## work/x.Y
+
+- ` + "`work/x.Y` exposes [GO-2022-0002](https://pkg.go.dev/vuln/GO-2022-0002) in `b.com/m@v1.0.0` (fixed in `b.com/m@v1.0.1`) via `work/x.Y` → `work/x.helper` → `b.com/m/vuln.Vuln`" + `.
+
+## work/y.Y
+
+- ` + "`work/y.Y` exposes [GO-2022-0001](https://pkg.go.dev/vuln/GO-2022-0001) via `work/y.Y` → `a.com/m/vuln.Vuln`" + `.
+
+`
+	if got := buf.String(); got != want {
+		t.Errorf("API output mismatch:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSkipped(t *testing.T) {
+	r := NewReport(nil, nil)
+	r.Skipped = "vuln.go.dev unreachable"
+	for _, name := range []string{"text", "markdown", "html"} {
+		var buf bytes.Buffer
+		if err := Lookup(name).Render(&buf, r); err != nil {
+			t.Fatal(err)
+		}
+		if want := "Scan skipped: vuln.go.dev unreachable"; !strings.Contains(buf.String(), want) {
+			t.Errorf("%s output does not contain %q:\n%s", name, want, buf.String())
+		}
+		if strings.Contains(buf.String(), "No vulnerabilities found") {
+			t.Errorf("%s output of a skipped scan reports no vulnerabilities:\n%s", name, buf.String())
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := JSON(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	var marker struct{ Skipped string }
+	if err := json.Unmarshal(buf.Bytes(), &marker); err != nil || marker.Skipped != r.Skipped {
+		t.Errorf("JSON output = %s, want an object with Skipped %q (error: %v)", buf.Bytes(), r.Skipped, err)
+	}
+
+	buf.Reset()
+	if err := SARIF(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	if inv := log.Runs[0].Invocations; len(inv) != 1 || inv[0].ExecutionSuccessful || len(inv[0].ToolExecutionNotifications) != 1 {
+		t.Errorf("unexpected SARIF invocations for a skipped scan:\n%s", buf.Bytes())
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	r := testReport()
+	r.Severity = func(id string) string {
+		if id == "GO-2022-0001" {
+			return "high"
+		}
+		return ""
+	}
+	var buf bytes.Buffer
+	if err := Text(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"GO-2022-0001 [High]\n", "GO-2022-0002 [Unknown]\n", "Summary: 1 High, 1 Unknown"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("text output does not contain %q:\n%s", want, buf.String())
+		}
+	}
+	buf.Reset()
+	if err := HTML(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	if want := `<span style="color: red">[High]</span>`; !strings.Contains(buf.String(), want) {
+		t.Errorf("html output does not contain %q:\n%s", want, buf.String())
+	}
+
+	min, err := ParseSeverity("MEDIUM")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.FilterSeverity(min)
+	if len(r.Findings) != 1 || r.Findings[0].ID != "GO-2022-0001" {
+		t.Errorf("FilterSeverity(%s) kept %v", min, r.Findings)
+	}
+	if _, err := ParseSeverity("urgent"); err == nil {
+		t.Error("ParseSeverity accepted an unknown bucket")
+	}
+}
+
+func TestSort(t *testing.T) {
+	r := testReport()
+	r.Severity = func(id string) string {
+		if id == "GO-2022-0002" {
+			return "Critical"
+		}
+		return "Low"
+	}
+	ids := func(findings []*quickcheck.Finding) []string {
+		var ids []string
+		for _, f := range findings {
+			ids = append(ids, f.ID)
+		}
+		return ids
+	}
+	if err := r.Sort(SortBySeverity); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ids(r.Findings), []string{"GO-2022-0002", "GO-2022-0001"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("findings sorted by severity = %v, want %v", got, want)
+	}
+	// Groups follow the order of the findings when sorted by severity.
+	if groups := r.ModuleGroups(); groups[0].Path != "b.com/m" {
+		t.Errorf("first module group is %s, want b.com/m", groups[0].Path)
+	}
+	var buf bytes.Buffer
+	r.GroupBy = GroupByModule
+	if err := JSON(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	var findings []*quickcheck.Finding
+	if err := json.Unmarshal(buf.Bytes(), &findings); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ids(findings), []string{"GO-2022-0002", "GO-2022-0001"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("JSON findings = %v, want %v", got, want)
+	}
+
+	if err := r.Sort(SortByID); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ids(r.Findings), []string{"GO-2022-0001", "GO-2022-0002"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("findings sorted by ID = %v, want %v", got, want)
+	}
+	if err := r.Sort("date"); err == nil {
+		t.Error("Sort accepted an unknown order")
+	}
+}
+
+func TestTextGroups(t *testing.T) {
+	r := testReport()
+	other := *r.Findings[0]
+	other.PackagePath = "a.com/m/other"
+	r.Findings = append(r.Findings, &other)
+	for _, tc := range []struct {
+		groupBy string
+		want    []string
+	}{
+		{GroupByVuln, []string{
+			"GO-2022-0001: 1 module\n\n  a.com/m\n    Packages: a.com/m/other, a.com/m/vuln\n",
+			"GO-2022-0002: 1 module\n\n  b.com/m\n",
+		}},
+		{GroupByPackage, []string{
+			"Package a.com/m/other (a.com/m): 1 vulnerability\n\n  GO-2022-0001\n    Packages: a.com/m/other\n",
+			"Package a.com/m/vuln (a.com/m): 1 vulnerability\n\n  GO-2022-0001\n    Packages: a.com/m/vuln\n",
+			"Package b.com/m/vuln (b.com/m): 1 vulnerability\n\n  GO-2022-0002\n",
+		}},
+	} {
+		r.GroupBy = tc.groupBy
+		var buf bytes.Buffer
+		if err := Text(&buf, r); err != nil {
+			t.Fatal(err)
+		}
+		prev := -1
+		for _, want := range tc.want {
+			i := strings.Index(buf.String(), want)
+			if i < 0 || i < prev {
+				t.Errorf("GroupBy %s: text output does not contain %q after the previous group:\n%s", tc.groupBy, want, buf.String())
+			}
+			prev = i
+		}
+	}
+}
+
+func TestGraph(t *testing.T) {
+	r := testReport()
+	var buf bytes.Buffer
+	if err := DOT(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"subgraph cluster_0 {\n\t\tlabel=\"work/y\";\n\t\tn0 [label=\"Y\"];",
+		"n1 [label=\"Vuln\", color=red, fontcolor=red];",
+		"n0 -> n1;",
+		"n2 -> n3;",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("dot output does not contain %q:\n%s", want, buf.String())
+		}
+	}
+	buf.Reset()
+	if err := Mermaid(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"subgraph p1 [\"a.com/m/vuln\"]\n\t\tn1[\"Vuln\"]\n\tend",
+		"n0 --> n1",
+		"class n1,n3 vulnerable",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("mermaid output does not contain %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestTopModules(t *testing.T) {
+	finding := func(id, mod string) *quickcheck.Finding {
+		return &quickcheck.Finding{Key: quickcheck.Key{ID: id, ModulePath: mod, PackagePath: mod, Symbol: "F"}}
+	}
+	results := []*RepoResult{
+		{Name: "app", Findings: []*quickcheck.Finding{finding("GO-1", "a.com/m"), finding("GO-2", "b.com/m"), finding("GO-3", "b.com/m")}},
+		{Name: "svc", Findings: []*quickcheck.Finding{finding("GO-1", "a.com/m")}},
+		{Name: "broken", Err: errors.New("clone failed")},
+	}
+	got := TopModules(results)
+	want := []*ModuleUsage{
+		{Path: "a.com/m", Repos: []string{"app", "svc"}, Vulns: []string{"GO-1"}},
+		{Path: "b.com/m", Repos: []string{"app"}, Vulns: []string{"GO-2", "GO-3"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TopModules = %+v, want %+v", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := MultiText(&buf, results); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"broken: failed to scan: clone failed", "a.com/m: 2 repositories, 1 vulnerabilities", "3 repositories scanned, 2 affected"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("text output does not contain %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestUnreachable(t *testing.T) {
+	r := testReport()
+	r.Unreachable = []*quickcheck.UnreachableVuln{
+		{ID: "GO-2022-0004", ModulePath: "d.com/m", Version: "v1.0.0", Fix: "v1.0.1", Verdict: quickcheck.VerdictNotImported},
+	}
+	var buf bytes.Buffer
+	if err := Text(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Informational (known vulnerabilities without reachable vulnerable symbols):\n\tGO-2022-0004 (d.com/m@v1.0.0), fixed in v1.0.1\n"; !strings.Contains(buf.String(), want) {
+		t.Errorf("text output does not contain %q:\n%s", want, buf.String())
+	}
+	buf.Reset()
+	if err := Markdown(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	if want := "## Informational\n\nKnown vulnerabilities without reachable vulnerable symbols:\n\n- [GO-2022-0004](https://pkg.go.dev/vuln/GO-2022-0004) in `d.com/m@v1.0.0` (fixed in `v1.0.1`)\n"; !strings.Contains(buf.String(), want) {
+		t.Errorf("markdown output does not contain %q:\n%s", want, buf.String())
+	}
+}
+
+func TestCycloneDX(t *testing.T) {
+	r := testReport()
+	r.Findings[0].Version = "v1.0.0"
+	r.Modules = []*packages.Module{
+		{Path: "work", Main: true},
+		{Path: "a.com/m", Version: "v1.0.0"},
+		{Path: "c.com/m", Version: "v1.0.0", Replace: &packages.Module{Path: "c.com/fork", Version: "v1.0.1"}},
+		{Path: "d.com/m", Version: "v0.1.0"},
+	}
+	r.NotAffected = []*quickcheck.NotAffected{
+		{ID: "GO-2022-0003", ModulePath: "c.com/m", Version: "v1.0.1", Reason: quickcheck.ReasonUnreachable},
+		{ID: "GO-2022-0004", ModulePath: "c.com/m", Version: "v1.0.1", Reason: quickcheck.ReasonVersion, Fixed: "v0.9.0"},
+		{ID: "GO-2022-0005", ModulePath: "c.com/m", Version: "v1.0.1", Reason: quickcheck.ReasonPlatform},
+	}
+	var buf bytes.Buffer
+	if err := CycloneDX(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	var got cdxBOM
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.BOMFormat != "CycloneDX" || !strings.HasPrefix(got.SerialNumber, "urn:uuid:") || got.Metadata.Component == nil || got.Metadata.Component.Name != "work" {
+		t.Fatalf("unexpected CycloneDX output:\n%s", buf.Bytes())
+	}
+	var refs []string
+	for _, c := range got.Components {
+		refs = append(refs, c.BOMRef)
+	}
+	// b.com/m is not in r.Modules, but has a finding.
+	wantRefs := []string{"pkg:golang/a.com/m@v1.0.0", "pkg:golang/b.com/m", "pkg:golang/c.com/m@v1.0.1", "pkg:golang/d.com/m@v0.1.0"}
+	if !reflect.DeepEqual(refs, wantRefs) {
+		t.Errorf("components = %v, want %v", refs, wantRefs)
+	}
+	type analysis struct{ id, ref, state, justification string }
+	var analyses []analysis
+	for _, v := range got.Vulnerabilities {
+		analyses = append(analyses, analysis{v.ID, v.Affects[0].Ref, v.Analysis.State, v.Analysis.Justification})
+	}
+	want := []analysis{
+		{"GO-2022-0001", "pkg:golang/a.com/m@v1.0.0", "exploitable", ""},
+		{"GO-2022-0002", "pkg:golang/b.com/m", "exploitable", ""},
+		{"GO-2022-0003", "pkg:golang/c.com/m@v1.0.1", "not_affected", "code_not_reachable"},
+		{"GO-2022-0004", "pkg:golang/c.com/m@v1.0.1", "resolved", ""},
+		{"GO-2022-0005", "pkg:golang/c.com/m@v1.0.1", "not_affected", "requires_environment"},
+	}
+	if !reflect.DeepEqual(analyses, want) {
+		t.Errorf("analyses = %v, want %v", analyses, want)
+	}
+}
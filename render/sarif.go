@@ -0,0 +1,152 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/hyangah/vulns/quickcheck"
+)
+
+// The subset of the SARIF 2.1.0 schema used by the SARIF renderer.
+// See https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html.
+type (
+	sarifLog struct {
+		Version string     `json:"version"`
+		Schema  string     `json:"$schema"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool        sarifTool         `json:"tool"`
+		Invocations []sarifInvocation `json:"invocations,omitempty"`
+		Results     []sarifResult     `json:"results"`
+	}
+	sarifInvocation struct {
+		ExecutionSuccessful        bool                `json:"executionSuccessful"`
+		ToolExecutionNotifications []sarifNotification `json:"toolExecutionNotifications,omitempty"`
+	}
+	sarifNotification struct {
+		Level   string       `json:"level"`
+		Message sarifMessage `json:"message"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name           string      `json:"name"`
+		InformationURI string      `json:"informationUri,omitempty"`
+		Rules          []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID               string       `json:"id"`
+		ShortDescription sarifMessage `json:"shortDescription"`
+		HelpURI          string       `json:"helpUri,omitempty"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifResult struct {
+		RuleID              string            `json:"ruleId"`
+		Level               string            `json:"level"`
+		Message             sarifMessage      `json:"message"`
+		Locations           []sarifLocation   `json:"locations,omitempty"`
+		PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+	}
+	sarifLocation struct {
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	}
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Region           sarifRegion           `json:"region"`
+	}
+	sarifArtifactLocation struct {
+		URI string `json:"uri"`
+	}
+	sarifRegion struct {
+		StartLine   int           `json:"startLine,omitempty"`
+		StartColumn int           `json:"startColumn,omitempty"`
+		Snippet     *sarifMessage `json:"snippet,omitempty"`
+	}
+)
+
+// sarifFingerprintKey is the partialFingerprints key of
+// quickcheck.Finding.Fingerprint. The version suffix changes
+// if the fingerprint computation changes.
+const sarifFingerprintKey = "vulnsFinding/v1"
+
+// SARIF writes the report in the SARIF 2.1.0 format.
+// Each vulnerability is a rule, and each finding is a result
+// located at the first frame of its trace. A skipped scan is an
+// unsuccessful invocation with the reason as its notification.
+func SARIF(w io.Writer, r *Report) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "vulns",
+			InformationURI: "https://github.com/hyangah/vulns",
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+	if r.Skipped != "" {
+		run.Invocations = []sarifInvocation{{
+			ToolExecutionNotifications: []sarifNotification{{
+				Level:   "warning",
+				Message: sarifMessage{Text: "scan skipped: " + r.Skipped},
+			}},
+		}}
+	}
+	seen := map[string]bool{}
+	for _, f := range r.Findings {
+		if !seen[f.ID] {
+			seen[f.ID] = true
+			rule := sarifRule{ID: f.ID, HelpURI: r.URL(f.ID)}
+			if e := r.Entries[f.ID]; e != nil {
+				rule.ShortDescription.Text = e.Details
+			}
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+		}
+		res := sarifResult{
+			RuleID:  f.ID,
+			Level:   "warning",
+			Message: sarifMessage{Text: sarifText(f)},
+			PartialFingerprints: map[string]string{
+				sarifFingerprintKey: f.Fingerprint(r.Boundary),
+			},
+		}
+		if len(f.Trace) > 0 {
+			if fr := ParseFrame(f.Trace[0]); fr.File != "" {
+				region := sarifRegion{StartLine: fr.Line, StartColumn: fr.Column}
+				if s := r.snippet(f); s != nil && s.File == fr.File {
+					region.Snippet = &sarifMessage{Text: s.Text}
+				}
+				res.Locations = append(res.Locations, sarifLocation{
+					PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(fr.File)},
+						Region:           region,
+					},
+				})
+			}
+		}
+		run.Results = append(run.Results, res)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []sarifRun{run},
+	})
+}
+
+// sarifText returns the message of the result for the finding.
+func sarifText(f *quickcheck.Finding) string {
+	if f.Symbol == "" {
+		return fmt.Sprintf("%s affects %s", f.ID, subject(f))
+	}
+	return fmt.Sprintf("%s reaches vulnerable symbol %s", f.ID, subject(f))
+}
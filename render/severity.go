@@ -0,0 +1,117 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyangah/vulns/quickcheck"
+)
+
+// Severity buckets, from the most to the least severe.
+const (
+	SeverityCritical = "Critical"
+	SeverityHigh     = "High"
+	SeverityMedium   = "Medium"
+	SeverityLow      = "Low"
+	SeverityUnknown  = "Unknown"
+)
+
+// Severities returns the severity buckets from the most
+// to the least severe.
+func Severities() []string {
+	return []string{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow, SeverityUnknown}
+}
+
+// ParseSeverity returns the severity bucket with the name,
+// ignoring case.
+func ParseSeverity(s string) (string, error) {
+	for _, b := range Severities() {
+		if strings.EqualFold(s, b) {
+			return b, nil
+		}
+	}
+	return "", fmt.Errorf("unknown severity %q (want one of %s)", s, strings.Join(Severities(), ", "))
+}
+
+// severityRank returns the rank of the bucket; lower is more severe.
+func severityRank(bucket string) int {
+	for i, b := range Severities() {
+		if b == bucket {
+			return i
+		}
+	}
+	return len(Severities()) - 1 // unknown
+}
+
+// AtLeast reports whether the severity bucket is at least as severe as min.
+func AtLeast(bucket, min string) bool {
+	return severityRank(bucket) <= severityRank(min)
+}
+
+// SeverityOf returns the severity bucket of the vulnerability
+// according to r.Severity, or SeverityUnknown.
+func (r *Report) SeverityOf(id string) string {
+	if r.Severity == nil {
+		return SeverityUnknown
+	}
+	b, err := ParseSeverity(r.Severity(id))
+	if err != nil {
+		return SeverityUnknown
+	}
+	return b
+}
+
+// SeverityCounts returns the number of groups in each severity bucket.
+func (r *Report) SeverityCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, g := range r.Groups() {
+		counts[r.SeverityOf(g.Findings[0].ID)]++
+	}
+	return counts
+}
+
+// FilterSeverity removes the findings less severe than min.
+func (r *Report) FilterSeverity(min string) {
+	var findings []*quickcheck.Finding
+	for _, f := range r.Findings {
+		if AtLeast(r.SeverityOf(f.ID), min) {
+			findings = append(findings, f)
+		}
+	}
+	r.Findings = findings
+}
+
+// ANSI colors of the severity buckets in text output.
+var severityColors = map[string]string{
+	SeverityCritical: "\x1b[1;31m", // bold red
+	SeverityHigh:     "\x1b[31m",   // red
+	SeverityMedium:   "\x1b[33m",   // yellow
+	SeverityLow:      "\x1b[36m",   // cyan
+}
+
+// ANSI styles of the other elements of text output.
+const (
+	styleBold = "\x1b[1m"
+	styleDim  = "\x1b[2m"
+)
+
+// colorize wraps s in the ANSI color of the bucket if r.Color is set.
+func (r *Report) colorize(bucket, s string) string {
+	c, ok := severityColors[bucket]
+	if !ok {
+		return s
+	}
+	return r.style(c, s)
+}
+
+// style wraps s in the ANSI style if r.Color is set.
+func (r *Report) style(style, s string) string {
+	if !r.Color || s == "" {
+		return s
+	}
+	return style + s + "\x1b[0m"
+}
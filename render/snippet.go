@@ -0,0 +1,69 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"os"
+	"strings"
+
+	"github.com/hyangah/vulns/quickcheck"
+)
+
+// A Snippet is an excerpt of source code around a trace frame.
+type Snippet struct {
+	File      string
+	StartLine int // 1-based line number of the first line of Text
+	Line      int // line of the frame
+	Text      string
+}
+
+// SourceSnippet returns the lines of the frame's source file from
+// context lines before to context lines after the frame's line.
+// It returns nil if the position of the frame is unknown or its
+// file cannot be read.
+func SourceSnippet(fr Frame, context int) *Snippet {
+	if fr.File == "" || fr.Line <= 0 || context < 0 {
+		return nil
+	}
+	data, err := os.ReadFile(fr.File)
+	if err != nil {
+		return nil
+	}
+	lines := strings.Split(string(data), "\n")
+	if fr.Line > len(lines) {
+		return nil
+	}
+	start, end := fr.Line-context, fr.Line+context
+	if start < 1 {
+		start = 1
+	}
+	if end > len(lines) {
+		end = len(lines)
+	}
+	return &Snippet{
+		File:      fr.File,
+		StartLine: start,
+		Line:      fr.Line,
+		Text:      strings.Join(lines[start-1:end], "\n"),
+	}
+}
+
+// snippet returns the source snippet around the first frame of the
+// finding's trace in first-party code, or nil if the report does
+// not request snippets.
+func (r *Report) snippet(f *quickcheck.Finding) *Snippet {
+	if r.SnippetContext < 0 || len(f.Trace) == 0 {
+		return nil
+	}
+	frame := f.Trace[0]
+	if len(r.Boundary) > 0 {
+		local, _ := r.Boundary.Split(f.Trace)
+		if len(local) == 0 {
+			return nil
+		}
+		frame = local[0]
+	}
+	return SourceSnippet(ParseFrame(frame), r.SnippetContext)
+}
@@ -0,0 +1,67 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyangah/vulns/quickcheck"
+)
+
+// Orders of the findings of a Report.
+const (
+	// SortByID sorts findings by vulnerability ID, package path,
+	// and symbol, the order of NewReport.
+	SortByID = "id"
+	// SortBySeverity sorts findings from the most severe according
+	// to Report.Severity, and then by ID. The findings of unknown
+	// severity come last.
+	SortBySeverity = "severity"
+	// SortByModule sorts findings by module path, and then by ID.
+	SortByModule = "module"
+)
+
+// SortOrders returns the orders Sort accepts.
+func SortOrders() []string {
+	return []string{SortByID, SortBySeverity, SortByModule}
+}
+
+// Sort sorts the findings of r in the order, one of SortOrders,
+// and records it in r.SortBy.
+func (r *Report) Sort(by string) error {
+	switch by {
+	case SortByID, SortBySeverity, SortByModule:
+	default:
+		return fmt.Errorf("unknown order %q (want one of %s)", by, strings.Join(SortOrders(), ", "))
+	}
+	r.SortBy = by
+	sort.SliceStable(r.Findings, func(i, j int) bool {
+		return r.less(r.Findings[i], r.Findings[j])
+	})
+	return nil
+}
+
+// less reports whether the finding a sorts before b in r.SortBy.
+func (r *Report) less(a, b *quickcheck.Finding) bool {
+	switch r.SortBy {
+	case SortBySeverity:
+		if ra, rb := severityRank(r.SeverityOf(a.ID)), severityRank(r.SeverityOf(b.ID)); ra != rb {
+			return ra < rb
+		}
+	case SortByModule:
+		if a.ModulePath != b.ModulePath {
+			return a.ModulePath < b.ModulePath
+		}
+	}
+	if a.ID != b.ID {
+		return a.ID < b.ID
+	}
+	if a.PackagePath != b.PackagePath {
+		return a.PackagePath < b.PackagePath
+	}
+	return a.Symbol < b.Symbol
+}
@@ -0,0 +1,45 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/hyangah/vulns/quickcheck"
+)
+
+// SummaryText writes the counts of the summary
+// in a human-readable format.
+func SummaryText(w io.Writer, s *quickcheck.Summary) error {
+	for _, m := range s.Modules {
+		fmt.Fprintf(w, "%v\t%d %s (%d symbol-reachable, %d import-only)\n", m.Path, m.Vulns, plural(m.Vulns, "vulnerability", "vulnerabilities"), m.Reachable, m.ImportOnly)
+	}
+	if len(s.Modules) > 0 {
+		fmt.Fprintln(w)
+	}
+	_, err := fmt.Fprintf(w, "%d %s (%d symbol-reachable, %d import-only) in %d %s\n",
+		s.Vulns, plural(s.Vulns, "vulnerability", "vulnerabilities"), s.Reachable, s.ImportOnly,
+		s.Packages, plural(s.Packages, "affected package", "affected packages"))
+	return err
+}
+
+// SummaryJSON writes the summary as a JSON object.
+func SummaryJSON(w io.Writer, s *quickcheck.Summary) error {
+	if s.Modules == nil {
+		s.Modules = []*quickcheck.ModuleSummary{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
@@ -0,0 +1,297 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/hyangah/vulns/quickcheck"
+)
+
+// DefaultTextTemplate is the text/template of the text report. Custom
+// templates, parsed with ParseTemplate, can start from a copy of it.
+//
+// Templates are executed with a TemplateData. Besides the builtin
+// functions of text/template, they can call
+//
+//	inc n        n+1, for numbering from 1
+//	join l sep   strings.Join
+//	time t       the time.Time t in the RFC 3339 format
+const DefaultTextTemplate = `
+{{- if .Skipped -}}
+Scan skipped: {{.Skipped}}
+{{else if eq .GroupBy "entry" -}}
+{{- range .Groups -}}
+Entry package {{or .Entry "(outside your code)"}}:
+{{range $.TraceGroups .Findings}}
+{{range .Findings}}{{.ID}} ({{$.Subject .}})
+{{end}}
+{{- if .Trace}}
+Call stacks in your code{{if gt .Entries 1}} ({{.Entries}} entry points){{end}}:
+{{range $.LocalTrace .Trace "\t"}}{{.}}
+{{end}}
+{{- with $.DependencyTrace .Trace "\t"}}
+Via dependencies:
+{{range .}}{{.}}
+{{end}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{end}}
+{{- else -}}
+{{- range $m := .TextGroups}}
+{{- $module := .Path}}{{with .Version}}{{$module = printf "%s@%s" $module .}}{{end}}
+{{- if .ID}}{{with $.Severity .ID}}{{$.Colorize . (printf "%s [%s]" $m.ID .)}}{{else}}{{$.Bold .ID}}{{end}}: {{len .Vulns}} {{if eq (len .Vulns) 1}}module{{else}}modules{{end}}
+{{- else if .Package}}{{$.Bold (printf "Package %s" .Package)}} ({{$module}}): {{len .Vulns}} {{if eq (len .Vulns) 1}}vulnerability{{else}}vulnerabilities{{end}}
+{{- else}}{{$.Bold (printf "Module %s" $module)}}: {{len .Vulns}} {{if eq (len .Vulns) 1}}vulnerability{{else}}vulnerabilities{{end}}
+{{- end}}
+{{range $v := .Vulns}}
+  {{if $m.ID}}{{$.Bold ($.Module .Finding)}}{{else}}{{with $.Severity .ID}}{{$.Colorize . (printf "%s [%s]" $v.ID .)}}{{else}}{{$.Bold .ID}}{{end}}{{end}}
+{{with .Packages}}    Packages: {{join . ", "}}
+{{end}}
+{{- with .Finding}}
+{{- range $.Versions .}}    {{.}}
+{{end}}
+{{- with .Attrs}}    Notes: {{join . ", "}}
+{{end}}
+{{- with .Provenance}}    Source: {{.Source}} (fetched {{time .Fetched}}, modified {{time .Modified}}{{if .AfterSnapshot}}, after the database snapshot{{end}})
+{{end}}
+{{- if .Trace}}
+{{with $.SameTrace $m $v}}    Call stack: same as {{.}}
+{{else}}    Call stack in your code{{with $.TraceCounts $v}} ({{.}}){{end}}:
+{{range $.LocalTrace .Trace "      "}}{{.}}
+{{end}}
+{{- with $.DependencyTrace .Trace "      "}}    Via dependencies:
+{{range .}}{{$.Dim .}}
+{{end}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{end}}
+{{- with .SeveritySummary}}Summary: {{.}}
+
+{{end}}
+{{- end}}
+{{- with .CoverageSummary}}Coverage: {{.}}
+
+{{end}}
+{{- with .Filtered -}}
+Filtered by platform (may affect builds for other platforms):
+{{range .}}	{{.ID}} ({{.PackagePath}}){{$.Platforms .}}
+{{end}}
+{{end}}
+{{- with .Unreachable -}}
+Informational (known vulnerabilities without reachable vulnerable symbols):
+{{range .}}	{{.ID}} ({{.ModulePath}}{{with .Version}}@{{.}}{{end}}){{with .Fix}}, fixed in {{.}}{{end}}
+{{end}}
+{{end}}`
+
+// TemplateData is the data report templates are executed with.
+// It embeds the Report, so the templates can refer to its fields,
+// such as .Findings, and its methods, such as .Groups.
+type TemplateData struct {
+	*Report
+}
+
+// Severity returns the severity bucket of the vulnerability with
+// the ID, or the empty string if the report has no severities.
+func (d TemplateData) Severity(id string) string {
+	if d.Report.Severity == nil {
+		return ""
+	}
+	return d.SeverityOf(id)
+}
+
+// Colorize returns s in the ANSI color of the severity bucket,
+// if the report is colored.
+func (d TemplateData) Colorize(bucket, s string) string {
+	return d.colorize(bucket, s)
+}
+
+// Module returns the module of the finding, with its version if known.
+func (d TemplateData) Module(f *quickcheck.Finding) string {
+	if f.Version == "" {
+		return f.ModulePath
+	}
+	return f.ModulePath + "@" + f.Version
+}
+
+// Subject returns what the finding is about: the vulnerable symbol,
+// or the package or the module for findings of the coarser scan levels.
+func (d TemplateData) Subject(f *quickcheck.Finding) string {
+	return subject(f)
+}
+
+// LocalTrace returns the lines of the frames of the trace in
+// first-party code, or of all of them if the report has no Boundary,
+// formatted with the TraceFormat of the report and indented with indent.
+func (d TemplateData) LocalTrace(trace []string, indent string) []string {
+	local, _ := d.splitTrace(trace)
+	return d.traceLines(local, indent)
+}
+
+// DependencyTrace returns the lines of the frames of the trace in
+// dependencies, if the report has a Boundary, formatted like LocalTrace.
+func (d TemplateData) DependencyTrace(trace []string, indent string) []string {
+	_, deps := d.splitTrace(trace)
+	return d.traceLines(deps, indent)
+}
+
+func (d TemplateData) splitTrace(trace []string) (local, deps []string) {
+	if len(d.Boundary) == 0 {
+		return trace, nil
+	}
+	return d.Boundary.Split(trace)
+}
+
+// SameTrace returns the ID of the first vulnerability of the module
+// group before v with the same representative trace as v, if any, so
+// that the trace is printed once.
+func (d TemplateData) SameTrace(m *ModuleGroup, v *VulnGroup) string {
+	for _, prev := range m.Vulns {
+		if prev == v {
+			break
+		}
+		if len(v.Finding.Trace) > 0 && equalTraces(prev.Finding.Trace, v.Finding.Trace) {
+			return prev.ID
+		}
+	}
+	return ""
+}
+
+func equalTraces(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// TraceCounts describes how many findings and distinct entry points
+// the representative trace of v stands for, such as "1 of 3 findings,
+// 5 entry points", or returns the empty string if it is the only one.
+func (d TemplateData) TraceCounts(v *VulnGroup) string {
+	var parts []string
+	if len(v.Findings) > 1 {
+		parts = append(parts, fmt.Sprintf("1 of %d findings", len(v.Findings)))
+	}
+	if v.Entries > 1 {
+		parts = append(parts, fmt.Sprintf("%d entry points", v.Entries))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Bold returns s in bold, if the report is colored.
+func (d TemplateData) Bold(s string) string {
+	return d.style(styleBold, s)
+}
+
+// Dim returns s dimmed, if the report is colored.
+func (d TemplateData) Dim(s string) string {
+	return d.style(styleDim, s)
+}
+
+func (d TemplateData) traceLines(trace []string, indent string) []string {
+	if len(trace) == 0 {
+		return nil
+	}
+	tf := d.TraceFormat
+	tf.Indent = indent + tf.Indent
+	return tf.Lines(trace)
+}
+
+// Versions returns the lines describing the version of the module
+// in use and the versions fixing the vulnerability of the finding.
+func (d TemplateData) Versions(f *quickcheck.Finding) []string {
+	return versionLines(f)
+}
+
+// SeveritySummary returns the number of vulnerabilities in each
+// severity bucket, or the empty string if the report has no
+// severities or no findings.
+func (d TemplateData) SeveritySummary() string {
+	if d.Report.Severity == nil || len(d.Findings) == 0 {
+		return ""
+	}
+	counts := d.SeverityCounts()
+	var parts []string
+	for _, b := range Severities() {
+		if n := counts[b]; n > 0 {
+			parts = append(parts, d.colorize(b, fmt.Sprintf("%d %s", n, b)))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// CoverageSummary returns the percentage of the packages analyzed
+// for symbol reachability and the reasons the others were not,
+// or the empty string if the report has no Coverage.
+func (d TemplateData) CoverageSummary() string {
+	c := d.Coverage
+	if c == nil {
+		return ""
+	}
+	s := fmt.Sprintf("%.1f%% of %d packages analyzed for symbol reachability", c.Percent(), c.Packages)
+	if len(c.Skipped) > 0 {
+		reasons := make(map[string]int)
+		var order []string
+		for _, sk := range c.Skipped {
+			if reasons[sk.Reason] == 0 {
+				order = append(order, sk.Reason)
+			}
+			reasons[sk.Reason]++
+		}
+		sort.Strings(order)
+		var parts []string
+		for _, reason := range order {
+			parts = append(parts, fmt.Sprintf("%d %s", reasons[reason], reason))
+		}
+		s += fmt.Sprintf(" (not analyzed: %s)", strings.Join(parts, ", "))
+	}
+	return s
+}
+
+// Platforms describes the platforms affected by the filtered finding,
+// such as " GOOS=windows", with a leading space.
+func (d TemplateData) Platforms(f *quickcheck.FilteredFinding) string {
+	return platforms(f)
+}
+
+var templateFuncs = template.FuncMap{
+	"inc":  func(i int) int { return i + 1 },
+	"join": strings.Join,
+	"time": func(t time.Time) string { return t.Format(time.RFC3339) },
+}
+
+// ParseTemplate parses a report template, such as a modified copy
+// of DefaultTextTemplate, and returns the Renderer executing it.
+func ParseTemplate(name, text string) (Renderer, error) {
+	t, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return RendererFunc(func(w io.Writer, r *Report) error {
+		return t.Execute(w, TemplateData{r})
+	}), nil
+}
+
+var textRenderer = func() Renderer {
+	r, err := ParseTemplate("text", DefaultTextTemplate)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}()
@@ -0,0 +1,60 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hyangah/vulns/quickcheck"
+)
+
+// Text writes the report in a human-readable format, by executing
+// DefaultTextTemplate. The vulnerabilities are grouped by module, and
+// a representative trace, the shortest, is printed for each of them.
+func Text(w io.Writer, r *Report) error {
+	return textRenderer.Render(w, r)
+}
+
+// versionLines describes the version of the module in use
+// and the versions fixing the vulnerability of the finding.
+func versionLines(f *quickcheck.Finding) []string {
+	var lines []string
+	if f.Version != "" {
+		lines = append(lines, fmt.Sprintf("Found in: %v@%v", f.ModulePath, f.Version))
+	}
+	if f.Fix != "" {
+		lines = append(lines, fmt.Sprintf("Fixed in: %v@%v%v", f.ModulePath, f.Fix, remediation(f)))
+	}
+	if f.MinFix != "" && f.MinFix != f.Fix {
+		lines = append(lines, fmt.Sprintf("Minimum fixed version: %v@%v", f.ModulePath, f.MinFix))
+	}
+	return lines
+}
+
+// remediation returns a hint on how to upgrade to the fixed
+// version, which depends on how the module is required.
+func remediation(f *quickcheck.Finding) string {
+	switch {
+	case f.HasAttr(quickcheck.AttrDirect):
+		return " (upgrade the requirement in go.mod)"
+	case f.HasAttr(quickcheck.AttrIndirect):
+		return fmt.Sprintf(" (indirect dependency; add \"require %v %v\" to go.mod or upgrade the module requiring it)", f.ModulePath, f.Fix)
+	}
+	return ""
+}
+
+// platforms describes the platforms affected by the filtered finding.
+func platforms(f *quickcheck.FilteredFinding) string {
+	var s string
+	if len(f.GOOS) > 0 {
+		s += " GOOS=" + strings.Join(f.GOOS, ",")
+	}
+	if len(f.GOARCH) > 0 {
+		s += " GOARCH=" + strings.Join(f.GOARCH, ",")
+	}
+	return s
+}
@@ -0,0 +1,58 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package schema holds the JSON Schema documents of the JSON outputs
+// of vulns that consumers can rely on across releases.
+//
+// The documents are versioned. Within a version, an output only
+// changes compatibly: fields may be added, as optional properties of
+// the document, but fields are not removed, renamed, or given other
+// types or meanings. Incompatible changes need a new version of the
+// document, and the documents of the previous versions are kept.
+// The golden outputs in testdata and the tests of the package check
+// that the outputs conform to the current versions.
+package schema
+
+import (
+	"embed"
+	"fmt"
+)
+
+// Names of the documented outputs.
+const (
+	// Findings is the output of render.JSON: the findings of a
+	// scan with -format=json, which -format=yaml converts to YAML.
+	Findings = "findings"
+	// SARIF is the mapping of the findings to SARIF 2.1.0 by
+	// render.SARIF, that is, the subset of SARIF that it writes.
+	SARIF = "sarif"
+	// Catalog is the catalog file of OSV entries written by
+	// analysis.WriteCatalog and the catalog and dump subcommands
+	// of vulns, which the analyzer reads with -vulns-json.
+	Catalog = "catalog"
+	// CatalogDiff is the output of the catalog-diff subcommand of
+	// vulns with -format=json, the changes of analysis.DiffCatalogs.
+	CatalogDiff = "catalog-diff"
+)
+
+// Versions maps the names of the documented outputs
+// to the current versions of their schemas.
+var Versions = map[string]int{
+	Findings:    1,
+	SARIF:       1,
+	Catalog:     1,
+	CatalogDiff: 1,
+}
+
+//go:embed *.json
+var documents embed.FS
+
+// Document returns the JSON Schema document of the version of the
+// named output.
+func Document(name string, version int) ([]byte, error) {
+	if version < 1 || version > Versions[name] {
+		return nil, fmt.Errorf("no version %d of the %s schema", version, name)
+	}
+	return documents.ReadFile(fmt.Sprintf("%s.v%d.json", name, version))
+}
@@ -0,0 +1,310 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package schema_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyangah/vulns/analysis"
+	"github.com/hyangah/vulns/quickcheck"
+	"github.com/hyangah/vulns/render"
+	"github.com/hyangah/vulns/schema"
+	"github.com/hyangah/vulns/testutils"
+	"golang.org/x/vuln/osv"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+var modified = time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)
+
+// testEntries returns the entries of the test outputs.
+func testEntries() map[string][]*osv.Entry {
+	parse := testutils.Entry("GO-2022-0001", "example.com/lib", "1.2.0", "example.com/lib/parse")
+	parse.Affected[0].EcosystemSpecific.Imports[0].Symbols = []string{"Parse", "Decoder.Decode"}
+	net := testutils.Entry("GO-2022-0002", "example.com/lib", "1.2.0", "example.com/lib/net")
+	for _, e := range []*osv.Entry{parse, net} {
+		e.Published, e.Modified = modified, modified
+		e.Aliases = []string{"CVE-2022-" + e.ID[len(e.ID)-4:]}
+		e.Details = "Details of " + e.ID + "."
+	}
+	return map[string][]*osv.Entry{
+		"example.com/lib/parse": {parse},
+		"example.com/lib/net":   {net},
+	}
+}
+
+// testReport returns the report of the test outputs, with all the
+// optional fields of the findings set.
+func testReport() *render.Report {
+	summary := map[quickcheck.Key]quickcheck.Value{
+		{ID: "GO-2022-0001", Symbol: "Decoder.Decode", PackagePath: "example.com/lib/parse", ModulePath: "example.com/lib"}: {
+			Trace:      []string{"example.com/app.Run /app/run.go:10:2", "example.com/lib/parse.Decoder.Decode /lib/parse/decode.go:20:6"},
+			Count:      2,
+			Entries:    []string{"example.com/app.Main /app/main.go:3:1", "example.com/app.Run /app/run.go:10:2"},
+			Provenance: &quickcheck.Provenance{Source: "https://vuln.go.dev", Fetched: modified, Modified: modified},
+			Attrs:      []string{quickcheck.AttrTestOnly},
+			Fix:        "v1.3.0",
+			Version:    "v1.1.0",
+			MinFix:     "v1.2.0",
+		},
+		{ID: "GO-2022-0002", PackagePath: "example.com/lib/net", ModulePath: "example.com/lib"}: {
+			Trace: []string{"example.com/app.Dial", "example.com/lib/net"},
+			Count: 1,
+		},
+	}
+	r := render.NewReport(summary, testEntries())
+	r.Boundary = quickcheck.Boundary{"example.com/app"}
+	r.SnippetContext = -1
+	return r
+}
+
+// outputs returns the outputs documented by the schemas,
+// keyed by the names of their golden files.
+func outputs(t *testing.T) map[string][]byte {
+	res := make(map[string][]byte)
+	var buf bytes.Buffer
+	if err := render.JSON(&buf, testReport()); err != nil {
+		t.Fatal(err)
+	}
+	res["findings.json"] = append([]byte(nil), buf.Bytes()...)
+
+	buf.Reset()
+	r := testReport()
+	r.NotAffected = []*quickcheck.NotAffected{{
+		ID: "GO-2022-0003", ModulePath: "example.com/other", Version: "v1.0.0",
+		Reason: quickcheck.ReasonVersion, Detail: "affected: [v1.1.0, v1.2.0)", Fixed: "",
+	}}
+	if err := render.JSON(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	res["findings-not-affected.json"] = append([]byte(nil), buf.Bytes()...)
+
+	buf.Reset()
+	if err := render.JSON(&buf, &render.Report{Skipped: "vulnerability database unreachable"}); err != nil {
+		t.Fatal(err)
+	}
+	res["findings-skipped.json"] = append([]byte(nil), buf.Bytes()...)
+
+	buf.Reset()
+	if err := render.SARIF(&buf, testReport()); err != nil {
+		t.Fatal(err)
+	}
+	res["sarif.json"] = append([]byte(nil), buf.Bytes()...)
+
+	buf.Reset()
+	old := &analysis.Catalog{PkgToVulns: testEntries(), Provenance: map[string]analysis.Provenance{
+		"GO-2022-0001": {Source: "https://vuln.go.dev", Fetched: modified, Modified: modified},
+	}}
+	if err := analysis.WriteCatalog(&buf, old); err != nil {
+		t.Fatal(err)
+	}
+	res["catalog.json"] = append([]byte(nil), buf.Bytes()...)
+
+	new := &analysis.Catalog{PkgToVulns: testEntries()}
+	new.PkgToVulns["example.com/lib/parse"][0].Affected[0].EcosystemSpecific.Imports[0].Symbols = []string{"Parse", "Encoder.Encode"}
+	data, err := json.MarshalIndent(analysis.DiffCatalogs(old, new), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res["catalog-diff.json"] = append(data, '\n')
+	return res
+}
+
+// TestGolden checks that the outputs did not change. Run the test
+// with -update to update the golden files after compatible changes
+// of the outputs, that is, additions of optional fields documented in
+// the schemas.
+func TestGolden(t *testing.T) {
+	for name, got := range outputs(t) {
+		file := filepath.Join("testdata", name)
+		if *update {
+			if err := os.WriteFile(file, got, 0666); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s changed; if the change is compatible, update the schema and run go test -update\ngot:\n%s", name, got)
+		}
+	}
+}
+
+// TestSchemas checks that the outputs conform to the current versions
+// of their schemas.
+func TestSchemas(t *testing.T) {
+	golden := map[string]string{
+		"findings.json":              schema.Findings,
+		"findings-not-affected.json": schema.Findings,
+		"findings-skipped.json":      schema.Findings,
+		"sarif.json":                 schema.SARIF,
+		"catalog.json":               schema.Catalog,
+		"catalog-diff.json":          schema.CatalogDiff,
+	}
+	outs := outputs(t)
+	for file, name := range golden {
+		doc, err := schema.Document(name, schema.Versions[name])
+		if err != nil {
+			t.Fatal(err)
+		}
+		var s, v interface{}
+		if err := json.Unmarshal(doc, &s); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := json.Unmarshal(outs[file], &v); err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		for _, err := range validate(s.(map[string]interface{}), s, v, "") {
+			t.Errorf("%s does not conform to the %s schema: %v", file, name, err)
+		}
+	}
+}
+
+// TestVersions checks that there is a document for each version of
+// each schema, so that released versions are not removed.
+func TestVersions(t *testing.T) {
+	var names []string
+	for name := range schema.Versions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if want := []string{schema.Catalog, schema.CatalogDiff, schema.Findings, schema.SARIF}; !reflect.DeepEqual(names, want) {
+		t.Errorf("schemas = %v, want %v", names, want)
+	}
+	for _, name := range names {
+		for v := 1; v <= schema.Versions[name]; v++ {
+			if _, err := schema.Document(name, v); err != nil {
+				t.Error(err)
+			}
+		}
+		if _, err := schema.Document(name, schema.Versions[name]+1); err == nil {
+			t.Errorf("Document(%q, %d) succeeded for a future version", name, schema.Versions[name]+1)
+		}
+	}
+}
+
+// validate checks the value v at the path against the subset of JSON
+// Schema used by the documents: $ref to $defs, type, enum, oneOf,
+// properties, required, additionalProperties, and items.
+func validate(root map[string]interface{}, s, v interface{}, path string) []error {
+	sch, ok := s.(map[string]interface{})
+	if !ok {
+		return nil // true
+	}
+	if ref, ok := sch["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/$defs/")
+		def, ok := root["$defs"].(map[string]interface{})[name]
+		if !ok {
+			return []error{fmt.Errorf("%s: unknown $ref %s", path, ref)}
+		}
+		return validate(root, def, v, path)
+	}
+	if alts, ok := sch["oneOf"].([]interface{}); ok {
+		// Without a match, report the errors of the closest schema
+		// of the type of v.
+		n := 0
+		var closest []error
+		typed := false
+		for _, alt := range alts {
+			errs := validate(root, alt, v, path)
+			if len(errs) == 0 {
+				n++
+				continue
+			}
+			typ, ok := alt.(map[string]interface{})["type"]
+			if ok && hasType(typ, v) && (!typed || len(errs) < len(closest)) {
+				closest, typed = errs, true
+			} else if closest == nil {
+				closest = errs
+			}
+		}
+		switch n {
+		case 0:
+			return closest
+		case 1:
+			return nil
+		}
+		return []error{fmt.Errorf("%s: matches %d of the oneOf schemas, want 1", path, n)}
+	}
+	if typ, ok := sch["type"]; ok && !hasType(typ, v) {
+		return []error{fmt.Errorf("%s: %T is not of type %v", path, v, typ)}
+	}
+	if enum, ok := sch["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			found = found || reflect.DeepEqual(e, v)
+		}
+		if !found {
+			return []error{fmt.Errorf("%s: %v is not one of %v", path, v, enum)}
+		}
+	}
+	var errs []error
+	switch v := v.(type) {
+	case map[string]interface{}:
+		props, _ := sch["properties"].(map[string]interface{})
+		required, _ := sch["required"].([]interface{})
+		for _, req := range required {
+			if _, ok := v[req.(string)]; !ok {
+				errs = append(errs, fmt.Errorf("%s: missing required property %s", path, req))
+			}
+		}
+		for k, pv := range v {
+			ps, ok := props[k]
+			if !ok {
+				ps, ok = sch["additionalProperties"]
+			}
+			if ok && ps == false {
+				errs = append(errs, fmt.Errorf("%s: undocumented property %s", path, k))
+				continue
+			}
+			errs = append(errs, validate(root, ps, pv, path+"/"+k)...)
+		}
+	case []interface{}:
+		for i, e := range v {
+			errs = append(errs, validate(root, sch["items"], e, fmt.Sprintf("%s/%d", path, i))...)
+		}
+	}
+	return errs
+}
+
+// hasType reports whether v is of the JSON Schema type or types.
+func hasType(typ, v interface{}) bool {
+	if types, ok := typ.([]interface{}); ok {
+		for _, t := range types {
+			if hasType(t, v) {
+				return true
+			}
+		}
+		return false
+	}
+	switch v := v.(type) {
+	case nil:
+		return typ == "null"
+	case bool:
+		return typ == "boolean"
+	case float64:
+		return typ == "number" || typ == "integer" && v == float64(int64(v))
+	case string:
+		return typ == "string"
+	case []interface{}:
+		return typ == "array"
+	case map[string]interface{}:
+		return typ == "object"
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package stamp records the result of a vulnerability scan in the
+// binary built from the scanned packages, so that deployed binaries
+// can report the vulnerability posture they were built with.
+//
+// The stamp is written by "vulns stamp", either as a JSON file to
+// embed:
+//
+//	vulns stamp -o vulns-stamp.json ./...
+//
+//	//go:embed vulns-stamp.json
+//	var vulnsStamp []byte
+//
+// or as a linker flag setting a string variable:
+//
+//	vulns stamp -format ldflags -var main.vulnsStamp -o stamp.flags ./...
+//	go build -ldflags "$(cat stamp.flags)"
+//
+// The binary parses it with Parse. The package has no dependencies
+// beyond the standard library, to keep the binaries small.
+package stamp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// A Stamp summarizes a vulnerability scan.
+type Stamp struct {
+	// Scanned is when the scan ran.
+	Scanned time.Time
+	// DBModified is when the vulnerability database
+	// used by the scan was last modified.
+	DBModified time.Time
+	// Found lists the IDs of the vulnerabilities reported
+	// by the scan, sorted.
+	Found []string `json:",omitempty"`
+	// Suppressed lists the IDs of the vulnerabilities found but
+	// left out of the report, for example because they were
+	// accepted in a baseline, sorted.
+	Suppressed []string `json:",omitempty"`
+}
+
+// Parse parses a stamp written by Marshal or LDFlags.
+func Parse(data []byte) (*Stamp, error) {
+	var s Stamp
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid vulnerability scan stamp: %v", err)
+	}
+	return &s, nil
+}
+
+// Marshal returns the stamp in the JSON format read by Parse.
+func (s *Stamp) Marshal() ([]byte, error) {
+	return json.Marshal(s)
+}
+
+// LDFlags returns the linker flag setting the string variable
+// with the qualified name, such as "main.vulnsStamp", to the stamp,
+// quoted for the -ldflags flag of the go command.
+func (s *Stamp) LDFlags(name string) (string, error) {
+	data, err := s.Marshal()
+	if err != nil {
+		return "", err
+	}
+	// Single quotes can only occur in JSON strings,
+	// where they can be escaped.
+	value := strings.ReplaceAll(string(data), "'", `\u0027`)
+	return fmt.Sprintf("-X '%s=%s'", name, value), nil
+}
+
+// String returns a one-line summary of the stamp.
+func (s *Stamp) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "scanned %s", s.Scanned.UTC().Format(time.RFC3339))
+	if !s.DBModified.IsZero() {
+		fmt.Fprintf(&b, " (database modified %s)", s.DBModified.UTC().Format(time.RFC3339))
+	}
+	if len(s.Found) == 0 {
+		b.WriteString(": no vulnerabilities found")
+	} else {
+		fmt.Fprintf(&b, ": %d vulnerabilities found (%s)", len(s.Found), strings.Join(s.Found, ", "))
+	}
+	if len(s.Suppressed) > 0 {
+		fmt.Fprintf(&b, ", %d suppressed (%s)", len(s.Suppressed), strings.Join(s.Suppressed, ", "))
+	}
+	return b.String()
+}
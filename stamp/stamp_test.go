@@ -0,0 +1,62 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stamp
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRoundTrip(t *testing.T) {
+	s := &Stamp{
+		Scanned:    time.Date(2022, 9, 20, 10, 0, 0, 0, time.UTC),
+		DBModified: time.Date(2022, 9, 16, 19, 49, 39, 0, time.UTC),
+		Found:      []string{"GO-2022-0001", "GO-2022-'02"},
+		Suppressed: []string{"GO-2022-0003"},
+	}
+	data, err := s.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(s, got); diff != "" {
+		t.Errorf("Parse(Marshal()) mismatch (-want +got):\n%s", diff)
+	}
+
+	flags, err := s.LDFlags("main.vulnsStamp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefix := "-X 'main.vulnsStamp="
+	value := strings.TrimPrefix(flags, prefix)
+	if !strings.HasPrefix(flags, prefix) || !strings.HasSuffix(value, "'") || strings.Contains(value[:len(value)-1], "'") {
+		t.Fatalf("LDFlags = %s, want a single-quoted -X flag", flags)
+	}
+	got, err = Parse([]byte(strings.TrimSuffix(value, "'")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(s, got); diff != "" {
+		t.Errorf("Parse(LDFlags()) mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestString(t *testing.T) {
+	s := &Stamp{
+		Scanned:    time.Date(2022, 9, 20, 10, 0, 0, 0, time.UTC),
+		Found:      []string{"GO-2022-0001"},
+		Suppressed: []string{"GO-2022-0003"},
+	}
+	want := "scanned 2022-09-20T10:00:00Z: 1 vulnerabilities found (GO-2022-0001), 1 suppressed (GO-2022-0003)"
+	if got := s.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
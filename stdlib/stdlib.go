@@ -0,0 +1,73 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package stdlib provides helpers for handling the Go standard
+// library and Go toolchain versions the way the Go vulnerability
+// database does.
+package stdlib
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	isem "github.com/hyangah/vulns/internal/xvuln/semver"
+	"golang.org/x/mod/module"
+	"golang.org/x/tools/go/packages"
+)
+
+// ModulePath is the pseudo module path the Go vulnerability
+// database uses for the standard library.
+const ModulePath = "stdlib"
+
+// Contains reports whether the given import path could be part of the Go
+// standard library, by reporting whether the first component lacks a '.'.
+// Invalid import paths, such as the empty path or the placeholders of
+// unknown paths in vulnerability reports, are not.
+func Contains(path string) bool {
+	if err := module.CheckImportPath(path); err != nil {
+		return false
+	}
+	// std packages do not have a "." in their path. For instance, see
+	// Contains in pkgsite/+/refs/heads/master/internal/stdlbib/stdlib.go.
+	if i := strings.IndexByte(path, '/'); i != -1 {
+		path = path[:i]
+	}
+	return !strings.Contains(path, ".")
+}
+
+// GoTagToSemver converts a Go version tag (e.g. "go1.19.1",
+// "go1.20rc1") to the corresponding semantic version
+// (e.g. "v1.19.1", "v1.20.0-rc.1"). It returns the empty string
+// if tag is not a valid Go version tag.
+func GoTagToSemver(tag string) string {
+	return isem.GoTagToSemver(tag)
+}
+
+// GoVersion returns the version of the go command in PATH,
+// e.g. "go1.19.1". The GOVERSION environment variable, if set,
+// takes precedence.
+func GoVersion() (string, error) {
+	if v := os.Getenv("GOVERSION"); v != "" {
+		// Unlikely to happen in practice, mostly used for testing.
+		return v, nil
+	}
+	out, err := exec.Command("go", "env", "GOVERSION").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine go version: %v", err)
+	}
+	return string(bytes.TrimSpace(out)), nil
+}
+
+// Module returns the pseudo module of the standard library
+// at the given Go version tag (e.g. "go1.19.1").
+// The module version is empty if goVersion is not a valid tag.
+func Module(goVersion string) *packages.Module {
+	return &packages.Module{
+		Path:    ModulePath,
+		Version: GoTagToSemver(goVersion),
+	}
+}
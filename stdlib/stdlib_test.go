@@ -0,0 +1,65 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stdlib
+
+import "testing"
+
+func TestContains(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want bool
+	}{
+		{"", false},
+		{"fmt", true},
+		{"net/http", true},
+		{"github.com/pkg/errors", false},
+		{"golang.org/x/net/http2", false},
+		{"cmd/go/internal/get", true},
+		{"vendor/golang.org/x/net/http2/hpack", true},
+		// Invalid import paths.
+		{"Path is unknown", false},
+		{"net//http", false},
+		{"/net/http", false},
+	} {
+		got := Contains(test.in)
+		if got != test.want {
+			t.Errorf("%q: got %t, want %t", test.in, got, test.want)
+		}
+	}
+}
+
+func TestGoTagToSemver(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want string
+	}{
+		{"", ""},
+		{"go1", "v1.0.0"},
+		{"go1.0", ""},
+		{"go1.19", "v1.19.0"},
+		{"go1.19.1", "v1.19.1"},
+		{"go1.20rc1", "v1.20.0-rc.1"},
+		{"go1.20-pre4", "v1.20.0-pre.4"},
+		{"go1.19.1 X:boringcrypto", "v1.19.1"},
+		{"devel +abcdef", ""},
+	} {
+		got := GoTagToSemver(test.in)
+		if got != test.want {
+			t.Errorf("%q: got %q, want %q", test.in, got, test.want)
+		}
+	}
+}
+
+func TestModule(t *testing.T) {
+	t.Setenv("GOVERSION", "go1.18.3")
+	v, err := GoVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := Module(v)
+	if m.Path != ModulePath || m.Version != "v1.18.3" {
+		t.Errorf("Module(%q) = %+v, want stdlib@v1.18.3", v, m)
+	}
+}
@@ -33,6 +33,15 @@ func NewDatabase(ctx context.Context, txtarReports []byte) (*DB, error) {
 	return &DB{disk: disk}, nil
 }
 
+// GenerateDatabase writes a database containing the provided
+// txtar-format collection of vulnerability reports, in the format
+// of NewDatabase, to the directory dir, so that it can be served as
+// it is, over HTTP or with a file URL. If indent is true, the JSON
+// files are indented.
+func GenerateDatabase(ctx context.Context, txtarReports []byte, dir string, indent bool) error {
+	return database.Generate(ctx, txtarReports, dir, indent)
+}
+
 type DB struct {
 	disk string
 }
@@ -4,6 +4,8 @@ package testutils
 import (
 	"context"
 	"encoding/json"
+	"net/url"
+	"path/filepath"
 	"testing"
 
 	"golang.org/x/vuln/client"
@@ -57,3 +59,37 @@ references:
 		t.Errorf("got %s\nwant GO-2020-0001 entry", m)
 	}
 }
+
+func TestGenerateDatabase(t *testing.T) {
+	ctx := context.Background()
+	in := []byte(`
+-- GO-2020-0001.yaml --
+modules:
+  - module: github.com/gin-gonic/gin
+    versions:
+      - fixed: 1.6.0
+    packages:
+      - package: github.com/gin-gonic/gin
+description: |
+    Something.
+published: 2021-04-14T20:04:52Z
+references:
+  - fix: https://github.com/gin-gonic/gin/pull/2237
+`)
+	dir := t.TempDir()
+	if err := GenerateDatabase(ctx, in, dir, true); err != nil {
+		t.Fatal(err)
+	}
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(dir)}
+	cli, err := client.NewClient([]string{u.String()}, client.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, err := cli.ListIDs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || ids[0] != "GO-2020-0001" {
+		t.Errorf("ListIDs = %v, want [GO-2020-0001]", ids)
+	}
+}
@@ -17,8 +17,7 @@ import (
 	"time"
 
 	"github.com/hyangah/vulns/testutils/internal/derrors"
-	"github.com/hyangah/vulns/testutils/internal/report"
-	"github.com/hyangah/vulns/testutils/internal/stdlib"
+	"github.com/hyangah/vulns/testutils/report"
 	"golang.org/x/tools/txtar"
 	"golang.org/x/vuln/client"
 	"golang.org/x/vuln/osv"
@@ -187,7 +186,7 @@ func GenerateOSVEntry(id, url string, lastModified time.Time, r report.Report) (
 	moduleMap := make(map[string]bool)
 	for _, m := range r.Modules {
 		switch m.Module {
-		case stdlib.ModulePath:
+		case report.StdModule:
 			moduleMap[stdFileName] = true
 		case cmdModule:
 			moduleMap[toolchainFileName] = true
@@ -245,7 +244,7 @@ func generateImports(m *report.Module) (imps []osv.EcosystemSpecificImport) {
 func generateAffected(m *report.Module, url string) osv.Affected {
 	name := m.Module
 	switch name {
-	case stdlib.ModulePath:
+	case report.StdModule:
 		name = "stdlib"
 	case cmdModule:
 		name = "toolchain"
@@ -12,7 +12,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"golang.org/x/vuln/osv"
-	"github.com/hyangah/vulns/testutils/internal/report"
+	"github.com/hyangah/vulns/testutils/report"
 )
 
 func TestGenerate(t *testing.T) {
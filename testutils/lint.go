@@ -0,0 +1,17 @@
+package testutils
+
+import "github.com/hyangah/vulns/testutils/report"
+
+// A LintIssue is a problem found in a vulnerability report by LintReport.
+// Rule identifies the check, Field is the path of the offending field
+// (such as "modules[0]"), and Line and Column locate it, if known.
+type LintIssue = report.Issue
+
+// LintReport checks the vulnerability report in YAML format, the
+// content of filename, with the rules of golang.org/x/vulndb, which
+// NewDatabase also applies. The base name of the directory of filename
+// selects additional rules: "reports" for regular reports, and
+// "excluded" for excluded reports.
+func LintReport(filename string, data []byte) ([]LintIssue, error) {
+	return report.LintFile(filename, data)
+}
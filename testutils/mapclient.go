@@ -0,0 +1,139 @@
+package testutils
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"golang.org/x/vuln/client"
+	"golang.org/x/vuln/osv"
+)
+
+// MapClient returns a client.Client serving the entries of the map,
+// keyed by module path, without a database on disk. Like the clients
+// over real databases, it returns fresh copies of the entries, so
+// callers may modify them.
+func MapClient(entries map[string][]*osv.Entry) client.Client {
+	c := &mapClient{byModule: entries}
+	for mod := range entries {
+		c.modules = append(c.modules, mod)
+	}
+	sort.Strings(c.modules)
+	return c
+}
+
+// Entry returns an OSV entry with the ID affecting the packages of the
+// Go module mod at the versions before fixed, or at all versions if
+// fixed is empty, for the maps of MapClient and other test fixtures.
+// The packages are affected as a whole; tests set the symbols and the
+// other fields they need.
+func Entry(id, mod, fixed string, pkgs ...string) *osv.Entry {
+	events := []osv.RangeEvent{{Introduced: "0"}}
+	if fixed != "" {
+		events = append(events, osv.RangeEvent{Fixed: fixed})
+	}
+	a := osv.Affected{
+		Package: osv.Package{Name: mod, Ecosystem: osv.GoEcosystem},
+		Ranges:  osv.Affects{{Type: osv.TypeSemver, Events: events}},
+	}
+	for _, p := range pkgs {
+		a.EcosystemSpecific.Imports = append(a.EcosystemSpecific.Imports, osv.EcosystemSpecificImport{Path: p})
+	}
+	return &osv.Entry{ID: id, Affected: []osv.Affected{a}}
+}
+
+type mapClient struct {
+	// client.Client is embedded only for its unexported method,
+	// which is never called. It is nil.
+	client.Client
+
+	byModule map[string][]*osv.Entry
+	modules  []string // sorted keys of byModule
+}
+
+func (c *mapClient) GetByModule(_ context.Context, modulePath string) ([]*osv.Entry, error) {
+	var res []*osv.Entry
+	for _, e := range c.byModule[modulePath] {
+		res = append(res, clone(e))
+	}
+	return res, nil
+}
+
+func (c *mapClient) GetByID(_ context.Context, id string) (*osv.Entry, error) {
+	var res *osv.Entry
+	c.each(func(e *osv.Entry) bool {
+		if e.ID == id {
+			res = clone(e)
+		}
+		return res == nil
+	})
+	return res, nil
+}
+
+func (c *mapClient) GetByAlias(_ context.Context, alias string) ([]*osv.Entry, error) {
+	var res []*osv.Entry
+	c.each(func(e *osv.Entry) bool {
+		for _, a := range e.Aliases {
+			if a == alias {
+				res = append(res, clone(e))
+				break
+			}
+		}
+		return true
+	})
+	return res, nil
+}
+
+func (c *mapClient) ListIDs(context.Context) ([]string, error) {
+	var ids []string
+	c.each(func(e *osv.Entry) bool {
+		ids = append(ids, e.ID)
+		return true
+	})
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func (c *mapClient) LastModifiedTime(context.Context) (time.Time, error) {
+	var t time.Time
+	c.each(func(e *osv.Entry) bool {
+		if e.Modified.After(t) {
+			t = e.Modified
+		}
+		return true
+	})
+	return t, nil
+}
+
+// each calls f for each distinct entry, in the order of the module
+// paths, until f returns false. An entry affecting several modules
+// may be listed for each of them.
+func (c *mapClient) each(f func(*osv.Entry) bool) {
+	seen := make(map[string]bool)
+	for _, mod := range c.modules {
+		for _, e := range c.byModule[mod] {
+			if seen[e.ID] {
+				continue
+			}
+			seen[e.ID] = true
+			if !f(e) {
+				return
+			}
+		}
+	}
+}
+
+// clone returns a deep copy of e, as decoded
+// by a client reading e from a database.
+func clone(e *osv.Entry) *osv.Entry {
+	data, err := json.Marshal(e)
+	if err != nil {
+		panic(err)
+	}
+	var c osv.Entry
+	if err := json.Unmarshal(data, &c); err != nil {
+		panic(err)
+	}
+	return &c
+}
@@ -0,0 +1,52 @@
+package testutils
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/vuln/osv"
+)
+
+func TestMapClient(t *testing.T) {
+	ctx := context.Background()
+	modified := time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)
+	e1 := &osv.Entry{ID: "GO-2022-0001", Aliases: []string{"CVE-2022-0001"}, Details: "One.", Modified: modified}
+	e2 := &osv.Entry{ID: "GO-2022-0002", Details: "Two.", Modified: modified.Add(time.Hour)}
+	cli := MapClient(map[string][]*osv.Entry{
+		"example.com/a": {e1},
+		"example.com/b": {e1, e2},
+	})
+
+	got, err := cli.GetByModule(ctx, "example.com/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].ID != e1.ID || got[1].ID != e2.ID {
+		t.Errorf("GetByModule = %v, want [%v %v]", got, e1.ID, e2.ID)
+	}
+	got[0].Details = "changed"
+	if e1.Details != "One." {
+		t.Error("GetByModule returned the entry of the map, not a copy")
+	}
+	if got, _ := cli.GetByModule(ctx, "example.com/c"); got != nil {
+		t.Errorf("GetByModule(unknown) = %v, want nil", got)
+	}
+
+	if e, _ := cli.GetByID(ctx, "GO-2022-0002"); e == nil || e.Details != "Two." {
+		t.Errorf("GetByID = %v, want %v", e, e2.ID)
+	}
+	if e, _ := cli.GetByID(ctx, "GO-2022-0003"); e != nil {
+		t.Errorf("GetByID(unknown) = %v, want nil", e)
+	}
+	if es, _ := cli.GetByAlias(ctx, "CVE-2022-0001"); len(es) != 1 || es[0].ID != e1.ID {
+		t.Errorf("GetByAlias = %v, want [%v]", es, e1.ID)
+	}
+	if ids, _ := cli.ListIDs(ctx); !reflect.DeepEqual(ids, []string{e1.ID, e2.ID}) {
+		t.Errorf("ListIDs = %v", ids)
+	}
+	if lm, _ := cli.LastModifiedTime(ctx); !lm.Equal(e2.Modified) {
+		t.Errorf("LastModifiedTime = %v, want %v", lm, e2.Modified)
+	}
+}
@@ -5,17 +5,19 @@
 package report
 
 import (
+	"bytes"
 	"fmt"
 	"net/url"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 
-	"github.com/hyangah/vulns/testutils/internal/stdlib"
-
+	"github.com/hyangah/vulns/stdlib"
 	"golang.org/x/exp/slices"
 	"golang.org/x/mod/module"
 	"golang.org/x/mod/semver"
+	"gopkg.in/yaml.v3"
 )
 
 var pseudoVersionRE = regexp.MustCompile(`^v[0-9]+\.(0\.0-|\d+\.\d+-([^+]*\.)?0\.)\d{14}-[A-Za-z0-9]+(\+[0-9A-Za-z-]+(\.[0-9A-Za-z-]+)*)?$`)
@@ -45,48 +47,50 @@ func versionExists(version string, versions map[string]bool) (err error) {
 	return nil
 }
 
-func (m *Module) lintStdLib(addPkgIssue func(string)) {
+func (m *Module) lintStdLib(addPkgIssue func(rule, msg string)) {
 	if len(m.Packages) == 0 {
-		addPkgIssue("missing package")
+		addPkgIssue("missing-package", "missing package")
 	}
 	for _, p := range m.Packages {
 		if p.Package == "" {
-			addPkgIssue("missing package")
+			addPkgIssue("missing-package", "missing package")
+		} else if !stdlib.Contains(p.Package) {
+			addPkgIssue("non-std-package", fmt.Sprintf("%q is not a standard library package", p.Package))
 		}
 	}
 }
 
-func (m *Module) lintThirdParty(addPkgIssue func(string)) {
+func (m *Module) lintThirdParty(addPkgIssue func(rule, msg string)) {
 	if m.Module == "" {
-		addPkgIssue("missing module")
+		addPkgIssue("missing-module", "missing module")
 		return
 	}
 	for _, p := range m.Packages {
 		if p.Package == "" {
-			addPkgIssue("missing package")
+			addPkgIssue("missing-package", "missing package")
 			continue
 		}
 		if !strings.HasPrefix(p.Package, m.Module) {
-			addPkgIssue("module must be a prefix of package")
+			addPkgIssue("package-outside-module", "module must be a prefix of package")
 		}
 		if err := module.CheckImportPath(p.Package); err != nil {
-			addPkgIssue(err.Error())
+			addPkgIssue("invalid-import-path", err.Error())
 		}
 	}
 }
 
-func (m *Module) lintVersions(addPkgIssue func(string)) {
+func (m *Module) lintVersions(addPkgIssue func(rule, msg string)) {
 	if m.VulnerableAt != "" && !m.VulnerableAt.IsValid() {
-		addPkgIssue(fmt.Sprintf("invalid vulnerable_at semantic version: %q", m.VulnerableAt))
+		addPkgIssue("invalid-version", fmt.Sprintf("invalid vulnerable_at semantic version: %q", m.VulnerableAt))
 	}
 	for i, vr := range m.Versions {
 		for _, v := range []Version{vr.Introduced, vr.Fixed} {
 			if v != "" && !v.IsValid() {
-				addPkgIssue(fmt.Sprintf("invalid semantic version: %q", v))
+				addPkgIssue("invalid-version", fmt.Sprintf("invalid semantic version: %q", v))
 			}
 		}
 		if vr.Fixed != "" && !vr.Introduced.Before(vr.Fixed) {
-			addPkgIssue(
+			addPkgIssue("version-order",
 				fmt.Sprintf("version %q >= %q", vr.Introduced, vr.Fixed))
 			continue
 		}
@@ -94,7 +98,7 @@ func (m *Module) lintVersions(addPkgIssue func(string)) {
 		// this one.
 		for _, vrPrev := range m.Versions[:i] {
 			if vrPrev.Introduced.Before(vr.Fixed) && vr.Introduced.Before(vrPrev.Fixed) {
-				addPkgIssue(fmt.Sprintf("version ranges overlap: [%v,%v), [%v,%v)", vr.Introduced, vr.Fixed, vr.Introduced, vrPrev.Fixed))
+				addPkgIssue("version-overlap", fmt.Sprintf("version ranges overlap: [%v,%v), [%v,%v)", vr.Introduced, vr.Fixed, vr.Introduced, vrPrev.Fixed))
 			}
 		}
 	}
@@ -102,28 +106,28 @@ func (m *Module) lintVersions(addPkgIssue func(string)) {
 
 var cveRegex = regexp.MustCompile(`^CVE-\d{4}-\d{4,}$`)
 
-func (r *Report) lintCVEs(addIssue func(string)) {
+func (r *Report) lintCVEs(addIssue func(rule, field, msg string)) {
 	if len(r.CVEs) > 0 && r.CVEMetadata != nil && r.CVEMetadata.ID != "" {
 		// TODO: consider removing one of these fields from the Report struct.
-		addIssue("only one of cve and cve_metadata.id should be present")
+		addIssue("cve-conflict", "cves", "only one of cve and cve_metadata.id should be present")
 	}
 
-	for _, cve := range r.CVEs {
+	for i, cve := range r.CVEs {
 		if !cveRegex.MatchString(cve) {
-			addIssue("malformed cve identifier")
+			addIssue("malformed-cve", fmt.Sprintf("cves[%d]", i), "malformed cve identifier")
 		}
 	}
 
 	if r.CVEMetadata != nil {
 		if r.CVEMetadata.ID == "" {
-			addIssue("cve_metadata.id is required")
+			addIssue("missing-cve", "cve_metadata", "cve_metadata.id is required")
 		} else if !cveRegex.MatchString(r.CVEMetadata.ID) {
-			addIssue("malformed cve_metadata.id identifier")
+			addIssue("malformed-cve", "cve_metadata.id", "malformed cve_metadata.id identifier")
 		}
 	}
 }
 
-func (r *Report) lintLineLength(field, content string, addIssue func(string)) {
+func (r *Report) lintLineLength(field, content string, addIssue func(rule, field, msg string)) {
 	const maxLineLength = 100
 	for _, line := range strings.Split(content, "\n") {
 		if len(line) <= maxLineLength {
@@ -132,7 +136,7 @@ func (r *Report) lintLineLength(field, content string, addIssue func(string)) {
 		if !strings.Contains(content, " ") {
 			continue // A single long word is OK.
 		}
-		addIssue(fmt.Sprintf("%v contains line > %v characters long", field, maxLineLength))
+		addIssue("line-too-long", field, fmt.Sprintf("%v contains line > %v characters long", field, maxLineLength))
 		return
 	}
 }
@@ -147,56 +151,58 @@ var (
 
 // Checks that the "links" section of a Report for a package in the
 // standard library contains all necessary links, and no third-party links.
-func (r *Report) lintStdLibLinks(addIssue func(string)) {
+func (r *Report) lintStdLibLinks(addIssue func(rule, field, msg string)) {
 	var (
 		hasFixLink      = false
 		hasReportLink   = false
 		hasAnnounceLink = false
 	)
-	for _, ref := range r.References {
+	for i, ref := range r.References {
+		field := fmt.Sprintf("references[%d]", i)
 		switch ref.Type {
 		case ReferenceTypeAdvisory:
-			addIssue(fmt.Sprintf("%q: advisory reference should not be set for first-party issues", ref.URL))
+			addIssue("stdlib-reference", field, fmt.Sprintf("%q: advisory reference should not be set for first-party issues", ref.URL))
 		case ReferenceTypeFix:
 			hasFixLink = true
 			if !prRegex.MatchString(ref.URL) && !commitRegex.MatchString(ref.URL) {
-				addIssue(fmt.Sprintf("%q: fix reference should match %q or %q", ref.URL, prRegex, commitRegex))
+				addIssue("stdlib-reference", field, fmt.Sprintf("%q: fix reference should match %q or %q", ref.URL, prRegex, commitRegex))
 			}
 		case ReferenceTypeReport:
 			hasReportLink = true
 			if !issueRegex.MatchString(ref.URL) {
-				addIssue(fmt.Sprintf("%q: report reference should match %q", ref.URL, issueRegex))
+				addIssue("stdlib-reference", field, fmt.Sprintf("%q: report reference should match %q", ref.URL, issueRegex))
 			}
 		case ReferenceTypeWeb:
 			if !announceRegex.MatchString(ref.URL) {
-				addIssue(fmt.Sprintf("%q: web references should only contain announcement links matching %q", ref.URL, announceRegex))
+				addIssue("stdlib-reference", field, fmt.Sprintf("%q: web references should only contain announcement links matching %q", ref.URL, announceRegex))
 			} else {
 				hasAnnounceLink = true
 			}
 		}
 	}
 	if !hasFixLink {
-		addIssue("references should contain at least one fix")
+		addIssue("missing-reference", "references", "references should contain at least one fix")
 	}
 	if !hasReportLink {
-		addIssue("references should contain at least one report")
+		addIssue("missing-reference", "references", "references should contain at least one report")
 	}
 	if !hasAnnounceLink {
-		addIssue(fmt.Sprintf("references should contain an announcement link matching %q", announceRegex))
+		addIssue("missing-reference", "references", fmt.Sprintf("references should contain an announcement link matching %q", announceRegex))
 	}
 }
 
-func (r *Report) lintLinks(addIssue func(string)) {
-	for _, ref := range r.References {
+func (r *Report) lintLinks(addIssue func(rule, field, msg string)) {
+	for i, ref := range r.References {
+		field := fmt.Sprintf("references[%d]", i)
 		if !slices.Contains(ReferenceTypes, ref.Type) {
-			addIssue(fmt.Sprintf("%q is not a valid reference type", ref.Type))
+			addIssue("invalid-reference-type", field, fmt.Sprintf("%q is not a valid reference type", ref.Type))
 		}
 		l := ref.URL
 		if _, err := url.ParseRequestURI(l); err != nil {
-			addIssue(fmt.Sprintf("%q is not a valid URL", l))
+			addIssue("invalid-url", field, fmt.Sprintf("%q is not a valid URL", l))
 		}
 		if fixed := fixURL(l); fixed != l {
-			addIssue(fmt.Sprintf("unfixed url: %q should be %q", l, fixURL(l)))
+			addIssue("unfixed-url", field, fmt.Sprintf("unfixed url: %q should be %q", l, fixURL(l)))
 		}
 	}
 }
@@ -206,44 +212,69 @@ func (r *Report) lintLinks(addIssue func(string)) {
 // TODO: It might make sense to include warnings or informational things
 // alongside errors, especially during for use during the triage process.
 func (r *Report) Lint(filename string) []string {
-	var issues []string
+	var msgs []string
+	for _, iss := range r.LintIssues(filename) {
+		msgs = append(msgs, iss.Message)
+	}
+	return msgs
+}
+
+// An Issue is a lint error found by LintIssues.
+type Issue struct {
+	// Rule identifies the check that failed, such as "missing-description".
+	Rule string
+	// Field is the path of the offending field, such as "modules[0]"
+	// or "cve_metadata.id", or empty if the issue is about the
+	// report as a whole.
+	Field string
+	// Message is the lint error, as reported by Lint.
+	Message string
+	// Line and Column locate Field in the YAML file, if known
+	// (see LintFile). Otherwise they are zero.
+	Line, Column int `json:",omitempty"`
+}
 
-	addIssue := func(iss string) {
-		issues = append(issues, iss)
+// LintIssues is like Lint, but reports the issues
+// along with their rule IDs and fields.
+func (r *Report) LintIssues(filename string) []Issue {
+	var issues []Issue
+
+	addIssue := func(rule, field, msg string) {
+		issues = append(issues, Issue{Rule: rule, Field: field, Message: msg})
 	}
 
 	switch filepath.Base(filepath.Dir(filename)) {
 	case "reports":
 		if r.Excluded != "" {
-			addIssue("report in reports/ must not have excluded set")
+			addIssue("unexpected-excluded", "excluded", "report in reports/ must not have excluded set")
 		}
 		if len(r.Modules) == 0 {
-			addIssue("no modules")
+			addIssue("missing-modules", "", "no modules")
 		}
 		if r.Description == "" {
-			addIssue("missing description")
+			addIssue("missing-description", "", "missing description")
 		}
 	case "excluded":
 		if r.Excluded == "" {
-			addIssue("report in excluded/ must have excluded set")
+			addIssue("missing-excluded", "", "report in excluded/ must have excluded set")
 		} else if !slices.Contains(ExcludedReasons, r.Excluded) {
-			addIssue(fmt.Sprintf("excluded (%q) is not in set %v", r.Excluded, ExcludedReasons))
+			addIssue("invalid-excluded", "excluded", fmt.Sprintf("excluded (%q) is not in set %v", r.Excluded, ExcludedReasons))
 		}
 		if len(r.Modules) != 0 {
-			addIssue("excluded report should not have modules")
+			addIssue("unexpected-modules", "modules", "excluded report should not have modules")
 		}
 		if len(r.CVEs) == 0 && len(r.GHSAs) == 0 {
-			addIssue("excluded report must have at least one associated CVE or GHSA")
+			addIssue("missing-alias", "", "excluded report must have at least one associated CVE or GHSA")
 		}
 	}
 
 	isStdLibReport := false
 	for i, m := range r.Modules {
-		addPkgIssue := func(iss string) {
-			addIssue(fmt.Sprintf("modules[%v]: %v", i, iss))
+		addPkgIssue := func(rule, iss string) {
+			addIssue(rule, fmt.Sprintf("modules[%v]", i), fmt.Sprintf("modules[%v]: %v", i, iss))
 		}
 
-		if m.Module == stdlib.ModulePath || m.Module == "cmd" {
+		if m.Module == StdModule || m.Module == "cmd" {
 			isStdLibReport = true
 			m.lintStdLib(addPkgIssue)
 		} else {
@@ -251,7 +282,7 @@ func (r *Report) Lint(filename string) []string {
 		}
 		for _, p := range m.Packages {
 			if strings.HasPrefix(p.Package, "cmd/") && m.Module != "cmd" {
-				addPkgIssue(fmt.Sprintf(`%q should be in module "cmd", not %q`, p.Package, m.Module))
+				addPkgIssue("cmd-package", fmt.Sprintf(`%q should be in module "cmd", not %q`, p.Package, m.Module))
 			}
 		}
 
@@ -272,6 +303,70 @@ func (r *Report) Lint(filename string) []string {
 	return issues
 }
 
+// LintFile reads the report in YAML format from data, the content of
+// filename, and lints it. Unlike LintIssues, it locates the issues in
+// the file.
+func LintFile(filename string, data []byte) ([]Issue, error) {
+	r, err := Read(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	issues := r.LintIssues(filename)
+	for i := range issues {
+		if n := locate(&doc, issues[i].Field); n != nil {
+			issues[i].Line, issues[i].Column = n.Line, n.Column
+		}
+	}
+	return issues, nil
+}
+
+// locate returns the node of the field, such as "modules[0]" or
+// "cve_metadata.id", in the YAML document: the key of a mapping
+// entry, or the item of a sequence. It returns nil if not found.
+func locate(doc *yaml.Node, field string) *yaml.Node {
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || field == "" {
+		return nil
+	}
+	var at *yaml.Node
+	n := doc.Content[0]
+	for _, part := range strings.Split(field, ".") {
+		name, index := part, -1
+		if i := strings.IndexByte(part, '['); i >= 0 {
+			idx, err := strconv.Atoi(strings.TrimSuffix(part[i+1:], "]"))
+			if err != nil {
+				return nil
+			}
+			name, index = part[:i], idx
+		}
+		if n.Kind != yaml.MappingNode {
+			return nil
+		}
+		var val *yaml.Node
+		for j := 0; j+1 < len(n.Content); j += 2 {
+			if n.Content[j].Value == name {
+				at, val = n.Content[j], n.Content[j+1]
+				break
+			}
+		}
+		if val == nil {
+			return nil
+		}
+		n = val
+		if index >= 0 {
+			if n.Kind != yaml.SequenceNode || index >= len(n.Content) {
+				return nil
+			}
+			n = n.Content[index]
+			at = n
+		}
+	}
+	return at
+}
+
 var commitHashRegex = regexp.MustCompile(`^[a-f0-9]+$`)
 
 func (r *Report) Fix() {
@@ -0,0 +1,66 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package report
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLintFile(t *testing.T) {
+	data := []byte(`modules:
+  - module: example.com/m
+    versions:
+      - fixed: 1.1.0
+    packages:
+      - package: example.org/p
+description: |
+    Something.
+cves:
+  - CVE-2022-1
+references:
+  - fix: https://github.com/golang/go/commit/abc
+`)
+	got, err := LintFile("reports/GO-2022-0001.yaml", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Issue{
+		{Rule: "package-outside-module", Field: "modules[0]", Message: "modules[0]: module must be a prefix of package", Line: 2, Column: 5},
+		{Rule: "malformed-cve", Field: "cves[0]", Message: "malformed cve identifier", Line: 10, Column: 5},
+		{Rule: "unfixed-url", Field: "references[0]", Message: `unfixed url: "https://github.com/golang/go/commit/abc" should be "https://go.googlesource.com/+/abc"`, Line: 12, Column: 5},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("LintFile mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLintStdLib(t *testing.T) {
+	data := []byte(`modules:
+  - module: std
+    versions:
+      - fixed: 1.19.1
+    packages:
+      - package: net/http
+      - package: golang.org/x/net/http2
+description: |
+    Something.
+references:
+  - fix: https://go.dev/cl/123
+  - report: https://go.dev/issue/456
+  - web: https://groups.google.com/g/golang-announce/c/x
+`)
+	got, err := LintFile("reports/GO-2022-0002.yaml", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Issue{
+		{Rule: "non-std-package", Field: "modules[0]", Message: `modules[0]: "golang.org/x/net/http2" is not a standard library package`, Line: 2, Column: 5},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("LintFile mismatch (-want +got):\n%s", diff)
+	}
+}
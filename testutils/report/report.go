@@ -112,6 +112,10 @@ var ExcludedReasons = []ExcludedReason{
 	"DEPENDENT_VULNERABILITY",
 }
 
+// StdModule is the module of the reports of standard library
+// vulnerabilities, the stdlib.ModulePath module of OSV entries.
+const StdModule = "std"
+
 // Reference type is a reference (link) type.
 type ReferenceType string
 
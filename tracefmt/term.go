@@ -0,0 +1,26 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tracefmt
+
+import (
+	"os"
+	"strconv"
+)
+
+// TerminalWidth returns the width of the terminal f writes to, for
+// Formatter.Width. The COLUMNS environment variable, if set, takes
+// precedence. It returns 0 if f is not a terminal, so that lines
+// written to files and pipes are not cut.
+func TerminalWidth(f *os.File) int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	return terminalWidth(f)
+}
+
+// IsTerminal reports whether f is a terminal.
+func IsTerminal(f *os.File) bool {
+	return isTerminal(f)
+}
@@ -0,0 +1,201 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package tracefmt formats the traces of quickcheck findings for
+// display. A trace is a list of frames of the form "symbol
+// file:line:col", from the entry point in the analyzed code to the
+// vulnerable symbol.
+//
+// The zero Formatter prints the frames as they are. Its options
+// shorten the symbols and the file names, group consecutive frames
+// of a package, and fit the lines to the width of a terminal:
+//
+//	f := &tracefmt.Formatter{Indent: "\t", ShortSymbols: true, Width: tracefmt.TerminalWidth(os.Stdout)}
+//	f.Write(os.Stdout, finding.Trace)
+package tracefmt
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// A Frame is a parsed entry of a finding's trace.
+type Frame struct {
+	Symbol string // qualified symbol name
+	File   string `json:",omitempty"` // empty if unknown
+	Line   int    `json:",omitempty"`
+	Column int    `json:",omitempty"`
+}
+
+// ParseFrame parses a trace entry of the form "symbol file:line:col".
+func ParseFrame(s string) Frame {
+	sym, pos, found := strings.Cut(s, " ")
+	fr := Frame{Symbol: sym}
+	if !found {
+		return fr
+	}
+	// The file name may contain colons (e.g. on Windows),
+	// so parse line and column from the end.
+	rest, col, ok := cutLastInt(pos)
+	if !ok {
+		return fr
+	}
+	file, line, ok := cutLastInt(rest)
+	if !ok {
+		// file:line form.
+		fr.File, fr.Line = rest, col
+		return fr
+	}
+	fr.File, fr.Line, fr.Column = file, line, col
+	return fr
+}
+
+func cutLastInt(s string) (string, int, bool) {
+	i := strings.LastIndexByte(s, ':')
+	if i < 0 {
+		return s, 0, false
+	}
+	n, err := strconv.Atoi(s[i+1:])
+	if err != nil {
+		return s, 0, false
+	}
+	return s[:i], n, true
+}
+
+// Position returns the file:line:col form of the frame's position,
+// or the empty string if unknown.
+func (fr Frame) Position() string {
+	switch {
+	case fr.File == "":
+		return ""
+	case fr.Column > 0:
+		return fmt.Sprintf("%s:%d:%d", fr.File, fr.Line, fr.Column)
+	default:
+		return fmt.Sprintf("%s:%d", fr.File, fr.Line)
+	}
+}
+
+// SplitSymbol splits a qualified symbol name, such as
+// "example.com/m/pkg.T.Method", into its package path and the
+// name in the package. It returns an empty package path if the
+// name is not qualified.
+func SplitSymbol(sym string) (pkgpath, name string) {
+	slash := strings.LastIndexByte(sym, '/')
+	dot := strings.IndexByte(sym[slash+1:], '.')
+	if dot < 0 {
+		return "", sym
+	}
+	dot += slash + 1
+	return sym[:dot], sym[dot+1:]
+}
+
+// A Formatter formats traces. The zero Formatter writes
+// each frame as it is on a line of its own.
+type Formatter struct {
+	// Indent is written at the start of every line.
+	Indent string
+	// ShortSymbols shortens the package paths of the symbols to
+	// their last element, as in "http.Get" for "net/http.Get".
+	ShortSymbols bool
+	// Dir, if set, shortens the names of the files in the
+	// directory tree rooted at Dir to relative paths.
+	Dir string
+	// GroupPackages writes consecutive frames in the same package
+	// under a line with the package path, followed by the names
+	// in the package, indented further.
+	GroupPackages bool
+	// Width, if positive, is the maximum width of the lines in
+	// columns, counting a tab as 8 columns. Longer lines lose the
+	// directories of the file name first, and are cut if still
+	// too long. See TerminalWidth.
+	Width int
+}
+
+// Lines returns the formatted lines of the trace, without newlines.
+func (f *Formatter) Lines(trace []string) []string {
+	var lines []string
+	if !f.ShortSymbols && f.Dir == "" && !f.GroupPackages && f.Width <= 0 {
+		for _, s := range trace {
+			lines = append(lines, f.Indent+s)
+		}
+		return lines
+	}
+	lastPkg := ""
+	for i, s := range trace {
+		fr := ParseFrame(s)
+		if f.Dir != "" && fr.File != "" {
+			if rel, err := filepath.Rel(f.Dir, fr.File); err == nil && !strings.HasPrefix(rel, "..") {
+				fr.File = rel
+			}
+		}
+		indent, sym := f.Indent, fr.Symbol
+		if f.GroupPackages {
+			pkg, name := SplitSymbol(fr.Symbol)
+			if pkg != "" {
+				if i == 0 || pkg != lastPkg {
+					lines = append(lines, f.fit(f.Indent+pkg, Frame{}))
+				}
+				lastPkg = pkg
+				indent, sym = f.Indent+"    ", name
+			}
+		} else if f.ShortSymbols {
+			sym = shortSymbol(sym)
+		}
+		lines = append(lines, f.fit(indent+sym, fr))
+	}
+	return lines
+}
+
+// Write writes the formatted lines of the trace to w.
+func (f *Formatter) Write(w io.Writer, trace []string) error {
+	for _, line := range f.Lines(trace) {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fit returns the line of the text followed by the position of the
+// frame, shortened to the width of the formatter.
+func (f *Formatter) fit(text string, fr Frame) string {
+	line := text
+	if pos := fr.Position(); pos != "" {
+		line += " " + pos
+	}
+	if f.Width <= 0 || width(line) <= f.Width {
+		return line
+	}
+	if fr.File != "" {
+		fr.File = filepath.Base(fr.File)
+		line = text + " " + fr.Position()
+	}
+	if width(line) <= f.Width {
+		return line
+	}
+	for line != "" && width(line+"…") > f.Width {
+		_, size := utf8.DecodeLastRuneInString(line)
+		line = line[:len(line)-size]
+	}
+	return line + "…"
+}
+
+// width returns the number of columns of s, counting a tab as 8.
+func width(s string) int {
+	return utf8.RuneCountInString(s) + 7*strings.Count(s, "\t")
+}
+
+// shortSymbol shortens the package path of the
+// symbol to its last element.
+func shortSymbol(sym string) string {
+	pkg, name := SplitSymbol(sym)
+	if pkg == "" {
+		return sym
+	}
+	return pkg[strings.LastIndexByte(pkg, '/')+1:] + "." + name
+}
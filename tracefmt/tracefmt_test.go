@@ -0,0 +1,84 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tracefmt
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseFrame(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want Frame
+	}{
+		{"a.com/m/vuln.Vuln /tmp/a/vuln.go:2:9", Frame{"a.com/m/vuln.Vuln", "/tmp/a/vuln.go", 2, 9}},
+		{"a.com/m/vuln.T.M C:/a/vuln.go:12:1", Frame{"a.com/m/vuln.T.M", "C:/a/vuln.go", 12, 1}},
+		{"a.com/m/vuln.Vuln vuln.go:2", Frame{"a.com/m/vuln.Vuln", "vuln.go", 2, 0}},
+		{"a.com/m/vuln.Vuln -", Frame{Symbol: "a.com/m/vuln.Vuln"}},
+		{"a.com/m/vuln", Frame{Symbol: "a.com/m/vuln"}},
+	} {
+		if got := ParseFrame(tc.in); got != tc.want {
+			t.Errorf("ParseFrame(%q) = %+v, want %+v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestSplitSymbol(t *testing.T) {
+	for _, tc := range []struct {
+		in, pkg, name string
+	}{
+		{"a.com/m/vuln.T.M", "a.com/m/vuln", "T.M"},
+		{"net/http.Get", "net/http", "Get"},
+		{"fmt.Println", "fmt", "Println"},
+		{"main", "", "main"},
+	} {
+		if pkg, name := SplitSymbol(tc.in); pkg != tc.pkg || name != tc.name {
+			t.Errorf("SplitSymbol(%q) = %q, %q, want %q, %q", tc.in, pkg, name, tc.pkg, tc.name)
+		}
+	}
+}
+
+func TestFormatter(t *testing.T) {
+	trace := []string{
+		"work/x.X /tmp/work/x/x.go:4:9",
+		"a.com/m/vuln.parse /tmp/a/vuln.go:8:2",
+		"a.com/m/vuln.Vuln /tmp/a/vuln.go:2:9",
+	}
+	for _, tc := range []struct {
+		name string
+		f    Formatter
+		want []string
+	}{
+		{"zero", Formatter{}, trace},
+		{"indent", Formatter{Indent: "\t"}, []string{
+			"\twork/x.X /tmp/work/x/x.go:4:9",
+			"\ta.com/m/vuln.parse /tmp/a/vuln.go:8:2",
+			"\ta.com/m/vuln.Vuln /tmp/a/vuln.go:2:9",
+		}},
+		{"short", Formatter{ShortSymbols: true, Dir: "/tmp/work"}, []string{
+			"x.X x/x.go:4:9",
+			"vuln.parse /tmp/a/vuln.go:8:2",
+			"vuln.Vuln /tmp/a/vuln.go:2:9",
+		}},
+		{"group", Formatter{GroupPackages: true}, []string{
+			"work/x",
+			"    X /tmp/work/x/x.go:4:9",
+			"a.com/m/vuln",
+			"    parse /tmp/a/vuln.go:8:2",
+			"    Vuln /tmp/a/vuln.go:2:9",
+		}},
+		{"width", Formatter{Indent: "\t", Width: 36}, []string{
+			"\twork/x.X x.go:4:9",
+			"\ta.com/m/vuln.parse vuln.go:…",
+			"\ta.com/m/vuln.Vuln vuln.go:2…",
+		}},
+	} {
+		if diff := cmp.Diff(tc.want, tc.f.Lines(trace)); diff != "" {
+			t.Errorf("%s: Lines mismatch (-want +got):\n%s", tc.name, diff)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vulncache
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/build"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/vuln/client"
+	"golang.org/x/vuln/osv"
+)
+
+var (
+	defaultDirOnce sync.Once
+	defaultDir     string
+)
+
+// DefaultDir returns the directory of the default disk cache, shared
+// with govulncheck: cache/download/vulndb in the module cache of the
+// go command, GOMODCACHE, which defaults to pkg/mod in the first
+// GOPATH entry.
+func DefaultDir() string {
+	defaultDirOnce.Do(func() {
+		defaultDir = filepath.Join(modCacheDir(), "cache", "download", "vulndb")
+	})
+	return defaultDir
+}
+
+// modCacheDir returns the module cache directory of the go command.
+func modCacheDir() string {
+	if out, err := exec.Command("go", "env", "GOMODCACHE").Output(); err == nil {
+		if dir := string(bytes.TrimSpace(out)); dir != "" {
+			return dir
+		}
+	}
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	var gopath string
+	if list := filepath.SplitList(build.Default.GOPATH); len(list) > 0 {
+		gopath = list[0]
+	}
+	return filepath.Join(gopath, "pkg", "mod")
+}
+
+// Disk returns a cache storing the responses in the directory,
+// in the layout used by govulncheck:
+//
+//	{dir}/{db name}/index.json: {"Retrieved": time, "Index": client.DBIndex}
+//	{dir}/{db name}/{escaped module path}/vulns.json: []*osv.Entry
+func Disk(dir string) Cache {
+	return &diskCache{dir: dir}
+}
+
+type diskCache struct {
+	mu  sync.Mutex // serializes file access within the process
+	dir string
+}
+
+type cachedIndex struct {
+	Retrieved time.Time
+	Index     client.DBIndex
+}
+
+func (c *diskCache) ReadIndex(dbName string) (client.DBIndex, time.Time, error) {
+	var index cachedIndex
+	if err := c.read(filepath.Join(dbName, "index.json"), &index); err != nil {
+		return nil, time.Time{}, err
+	}
+	return index.Index, index.Retrieved, nil
+}
+
+func (c *diskCache) WriteIndex(dbName string, index client.DBIndex, retrieved time.Time) error {
+	return c.write(filepath.Join(dbName, "index.json"), cachedIndex{Retrieved: retrieved, Index: index})
+}
+
+func (c *diskCache) ReadEntries(dbName, modulePath string) ([]*osv.Entry, error) {
+	ep, err := client.EscapeModulePath(modulePath)
+	if err != nil {
+		return nil, err
+	}
+	var entries []*osv.Entry
+	if err := c.read(filepath.Join(dbName, ep, "vulns.json"), &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (c *diskCache) WriteEntries(dbName, modulePath string, entries []*osv.Entry) error {
+	ep, err := client.EscapeModulePath(modulePath)
+	if err != nil {
+		return err
+	}
+	return c.write(filepath.Join(dbName, ep, "vulns.json"), entries)
+}
+
+// read decodes the file into v. A missing file leaves v unchanged.
+func (c *diskCache) read(name string, v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, err := os.ReadFile(filepath.Join(c.dir, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (c *diskCache) write(name string, v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(c.dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0666)
+}
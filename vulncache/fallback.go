@@ -0,0 +1,121 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vulncache
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/vuln/client"
+	"golang.org/x/vuln/osv"
+)
+
+// Fallback returns a cache storing the responses in primary until
+// primary fails, such as a disk cache in a read-only module cache
+// of a hermetic CI sandbox. On the first failure, fallback is called
+// with the error and returns the cache used from then on, typically
+// a Disk cache in a temporary directory or a Memory cache. If it
+// returns nil, the error is returned instead.
+//
+// Once primary failed, the responses are read from the fallback
+// cache, and from primary if the fallback cache does not have them,
+// so that a read-only cache populated beforehand remains useful.
+func Fallback(primary Cache, fallback func(error) Cache) Cache {
+	return &fallbackCache{primary: primary, newFallback: fallback}
+}
+
+type fallbackCache struct {
+	primary     Cache
+	newFallback func(error) Cache
+
+	mu       sync.Mutex
+	fallback Cache // set once primary failed
+	err      error // the first error of primary if fallback is nil
+}
+
+// secondary returns the fallback cache, or nil if primary did not fail.
+func (c *fallbackCache) secondary() Cache {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.fallback
+}
+
+// fail records the failure of primary and returns the fallback
+// cache, or the error if there is none.
+func (c *fallbackCache) fail(err error) (Cache, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.fallback == nil && c.err == nil {
+		if c.fallback = c.newFallback(err); c.fallback == nil {
+			c.err = err
+		}
+	}
+	if c.fallback == nil {
+		return nil, c.err
+	}
+	return c.fallback, nil
+}
+
+func (c *fallbackCache) ReadIndex(dbName string) (client.DBIndex, time.Time, error) {
+	if f := c.secondary(); f != nil {
+		if index, retrieved, err := f.ReadIndex(dbName); err != nil || index != nil {
+			return index, retrieved, err
+		}
+	}
+	index, retrieved, err := c.primary.ReadIndex(dbName)
+	if err != nil {
+		f, err := c.fail(err)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		return f.ReadIndex(dbName)
+	}
+	return index, retrieved, nil
+}
+
+func (c *fallbackCache) WriteIndex(dbName string, index client.DBIndex, retrieved time.Time) error {
+	if f := c.secondary(); f != nil {
+		return f.WriteIndex(dbName, index, retrieved)
+	}
+	if err := c.primary.WriteIndex(dbName, index, retrieved); err != nil {
+		f, err := c.fail(err)
+		if err != nil {
+			return err
+		}
+		return f.WriteIndex(dbName, index, retrieved)
+	}
+	return nil
+}
+
+func (c *fallbackCache) ReadEntries(dbName, modulePath string) ([]*osv.Entry, error) {
+	if f := c.secondary(); f != nil {
+		if entries, err := f.ReadEntries(dbName, modulePath); err != nil || entries != nil {
+			return entries, err
+		}
+	}
+	entries, err := c.primary.ReadEntries(dbName, modulePath)
+	if err != nil {
+		f, err := c.fail(err)
+		if err != nil {
+			return nil, err
+		}
+		return f.ReadEntries(dbName, modulePath)
+	}
+	return entries, nil
+}
+
+func (c *fallbackCache) WriteEntries(dbName, modulePath string, entries []*osv.Entry) error {
+	if f := c.secondary(); f != nil {
+		return f.WriteEntries(dbName, modulePath, entries)
+	}
+	if err := c.primary.WriteEntries(dbName, modulePath, entries); err != nil {
+		f, err := c.fail(err)
+		if err != nil {
+			return err
+		}
+		return f.WriteEntries(dbName, modulePath, entries)
+	}
+	return nil
+}
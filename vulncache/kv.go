@@ -0,0 +1,82 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vulncache
+
+import (
+	"encoding/json"
+	"time"
+
+	"golang.org/x/vuln/client"
+	"golang.org/x/vuln/osv"
+)
+
+// A Store is a key-value store, typically remote, backing a KV cache.
+// An adapter for Redis, for example, implements Get with GET and Set
+// with SET.
+type Store interface {
+	// Get returns the value of the key, or nil and
+	// no error if the key is not set.
+	Get(key string) ([]byte, error)
+	// Set sets the value of the key.
+	Set(key string, value []byte) error
+}
+
+// KV returns a cache storing the responses in the store, as JSON
+// values under keys starting with prefix, which lets several caches
+// share a store. The keys are
+//
+//	{prefix}{db name}/index
+//	{prefix}{db name}/{module path}
+//
+// No module path is "index", so the keys do not collide.
+func KV(store Store, prefix string) Cache {
+	return &kvCache{store: store, prefix: prefix}
+}
+
+type kvCache struct {
+	store  Store
+	prefix string
+}
+
+func (c *kvCache) ReadIndex(dbName string) (client.DBIndex, time.Time, error) {
+	var index cachedIndex
+	if err := c.get(dbName+"/index", &index); err != nil {
+		return nil, time.Time{}, err
+	}
+	return index.Index, index.Retrieved, nil
+}
+
+func (c *kvCache) WriteIndex(dbName string, index client.DBIndex, retrieved time.Time) error {
+	return c.set(dbName+"/index", cachedIndex{Retrieved: retrieved, Index: index})
+}
+
+func (c *kvCache) ReadEntries(dbName, modulePath string) ([]*osv.Entry, error) {
+	var entries []*osv.Entry
+	if err := c.get(dbName+"/"+modulePath, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (c *kvCache) WriteEntries(dbName, modulePath string, entries []*osv.Entry) error {
+	return c.set(dbName+"/"+modulePath, entries)
+}
+
+// get decodes the value of the key into v. A missing key leaves v unchanged.
+func (c *kvCache) get(key string, v interface{}) error {
+	data, err := c.store.Get(c.prefix + key)
+	if err != nil || data == nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (c *kvCache) set(key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.store.Set(c.prefix+key, data)
+}
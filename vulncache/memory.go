@@ -0,0 +1,55 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vulncache
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/vuln/client"
+	"golang.org/x/vuln/osv"
+)
+
+// Memory returns a cache keeping the responses in memory.
+// It suits long-running processes and tests.
+func Memory() Cache {
+	return &memoryCache{
+		indexes: make(map[string]cachedIndex),
+		entries: make(map[[2]string][]*osv.Entry),
+	}
+}
+
+type memoryCache struct {
+	mu      sync.Mutex
+	indexes map[string]cachedIndex     // keyed by db name
+	entries map[[2]string][]*osv.Entry // keyed by db name and module path
+}
+
+func (c *memoryCache) ReadIndex(dbName string) (client.DBIndex, time.Time, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	index := c.indexes[dbName]
+	return index.Index, index.Retrieved, nil
+}
+
+func (c *memoryCache) WriteIndex(dbName string, index client.DBIndex, retrieved time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.indexes[dbName] = cachedIndex{Retrieved: retrieved, Index: index}
+	return nil
+}
+
+func (c *memoryCache) ReadEntries(dbName, modulePath string) ([]*osv.Entry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries[[2]string{dbName, modulePath}], nil
+}
+
+func (c *memoryCache) WriteEntries(dbName, modulePath string, entries []*osv.Entry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[[2]string{dbName, modulePath}] = entries
+	return nil
+}
@@ -0,0 +1,39 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package vulncache provides caches of vulnerability database
+// responses for golang.org/x/vuln/client (client.Options.HTTPCache).
+//
+// The client consults the cache for the index of an HTTP database and
+// for the entries of each module, and refetches them from the database
+// only when the index is stale or reports newer entries. Caching makes
+// repeated scans fast and lets them run offline for a while.
+//
+// Three backends are provided: Disk, the default, stores the responses
+// in the Go module cache, where govulncheck keeps them as well; Memory
+// keeps them for the lifetime of the process; and KV stores them in
+// any key-value store, such as a Redis server shared by CI workers,
+// through a small adapter implementing Store. Fallback switches from
+// a cache that fails, such as a disk cache in a read-only module
+// cache, to another one.
+package vulncache
+
+import (
+	"golang.org/x/vuln/client"
+)
+
+// A Cache caches the index and the entries of vulnerability databases.
+// A single cache holds the responses of several databases, each
+// identified by a name (the host name of the database).
+//
+// ReadIndex and ReadEntries return zero values and no error for
+// databases and modules that are not cached. Implementations must
+// be safe for concurrent use.
+type Cache = client.Cache
+
+// Default returns the cache used by the commands of this module,
+// the disk cache in DefaultDir.
+func Default() Cache {
+	return Disk(DefaultDir())
+}
@@ -0,0 +1,181 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vulncache
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyangah/vulns/testutils"
+	"golang.org/x/vuln/client"
+	"golang.org/x/vuln/osv"
+)
+
+// mapStore is a Store in memory.
+type mapStore struct {
+	mu sync.Mutex
+	m  map[string][]byte
+}
+
+func (s *mapStore) Get(key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.m[key], nil
+}
+
+func (s *mapStore) Set(key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m[key] = value
+	return nil
+}
+
+// readOnlyStore is a Store that fails to set keys,
+// as a cache in a read-only directory.
+type readOnlyStore map[string][]byte
+
+func (s readOnlyStore) Get(key string) ([]byte, error) { return s[key], nil }
+func (readOnlyStore) Set(string, []byte) error         { return errors.New("read-only file system") }
+
+func backends(t *testing.T) map[string]Cache {
+	return map[string]Cache{
+		"disk":     Disk(t.TempDir()),
+		"memory":   Memory(),
+		"kv":       KV(&mapStore{m: make(map[string][]byte)}, "vulns/"),
+		"fallback": Fallback(KV(readOnlyStore{}, ""), func(error) Cache { return Memory() }),
+	}
+}
+
+func TestCache(t *testing.T) {
+	const db = "vuln.example.com"
+	retrieved := time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)
+	index := client.DBIndex{"example.com/m": retrieved.Add(-time.Hour)}
+	entries := []*osv.Entry{{ID: "GO-2022-0001", Details: "vulnerable"}}
+
+	for name, c := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			if idx, r, err := c.ReadIndex(db); err != nil || idx != nil || !r.IsZero() {
+				t.Errorf("ReadIndex of an empty cache = %v, %v, %v; want nil, zero time, nil", idx, r, err)
+			}
+			if got, err := c.ReadEntries(db, "example.com/m"); err != nil || got != nil {
+				t.Errorf("ReadEntries of an empty cache = %v, %v; want nil, nil", got, err)
+			}
+
+			if err := c.WriteIndex(db, index, retrieved); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.WriteEntries(db, "example.com/m", entries); err != nil {
+				t.Fatal(err)
+			}
+			idx, r, err := c.ReadIndex(db)
+			if err != nil || !reflect.DeepEqual(idx, index) || !r.Equal(retrieved) {
+				t.Errorf("ReadIndex = %v, %v, %v; want %v, %v, nil", idx, r, err, index, retrieved)
+			}
+			got, err := c.ReadEntries(db, "example.com/m")
+			if err != nil || len(got) != 1 || got[0].ID != "GO-2022-0001" || got[0].Details != "vulnerable" {
+				t.Errorf("ReadEntries = %v, %v", got, err)
+			}
+			if got, err := c.ReadEntries("other.example.com", "example.com/m"); err != nil || got != nil {
+				t.Errorf("ReadEntries of another database = %v, %v; want nil, nil", got, err)
+			}
+		})
+	}
+}
+
+func TestClientUsesCache(t *testing.T) {
+	ctx := context.Background()
+	db, err := testutils.NewDatabase(ctx, []byte(`
+-- GO-2020-0001.yaml --
+modules:
+  - module: example.com/m
+    versions:
+      - fixed: 1.1.0
+    packages:
+      - package: example.com/m/p
+description: |
+    Something.
+published: 2021-04-14T20:04:52Z
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Clean()
+	u, err := url.Parse(db.URI())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.FileServer(http.Dir(u.Path)))
+	defer srv.Close()
+	host, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, c := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			cli, err := client.NewClient([]string{srv.URL}, client.Options{HTTPCache: c})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := cli.GetByModule(ctx, "example.com/m"); err != nil {
+				t.Fatal(err)
+			}
+			if idx, _, err := c.ReadIndex(host.Hostname()); err != nil || len(idx) == 0 {
+				t.Errorf("index not cached: %v, %v", idx, err)
+			}
+			if entries, err := c.ReadEntries(host.Hostname(), "example.com/m"); err != nil || len(entries) != 1 {
+				t.Errorf("entries not cached: %v, %v", entries, err)
+			}
+		})
+	}
+}
+
+func TestFallback(t *testing.T) {
+	const db = "vuln.example.com"
+	index := client.DBIndex{"example.com/m": time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)}
+	primary := readOnlyStore{db + "/example.com/m": []byte(`[{"id":"GO-2022-0001"}]`)}
+	var calls int
+	c := Fallback(KV(primary, ""), func(error) Cache {
+		calls++
+		return Memory()
+	})
+	if err := c.WriteIndex(db, index, time.Now()); err != nil {
+		t.Fatalf("WriteIndex: %v", err)
+	}
+	if err := c.WriteIndex(db, index, time.Now()); err != nil {
+		t.Fatalf("WriteIndex: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("fallback called %d times, want 1", calls)
+	}
+	if idx, _, err := c.ReadIndex(db); err != nil || !reflect.DeepEqual(idx, index) {
+		t.Errorf("ReadIndex = %v, %v; want %v, nil", idx, err, index)
+	}
+	// The entries cached in the read-only cache beforehand are still read.
+	got, err := c.ReadEntries(db, "example.com/m")
+	if err != nil || len(got) != 1 || got[0].ID != "GO-2022-0001" {
+		t.Errorf("ReadEntries = %v, %v; want the entry of the primary cache", got, err)
+	}
+
+	c = Fallback(KV(readOnlyStore{}, ""), func(error) Cache { return nil })
+	if err := c.WriteIndex(db, index, time.Now()); err == nil {
+		t.Error("WriteIndex without a fallback cache succeeded, want the error of the primary cache")
+	}
+}
+
+func TestModCacheDir(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("GOMODCACHE", dir)
+	if got := modCacheDir(); got != dir {
+		t.Errorf("modCacheDir() = %q, want GOMODCACHE %q", got, dir)
+	}
+}
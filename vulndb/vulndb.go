@@ -0,0 +1,65 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package vulndb is the client of the vulnerability databases used by
+// the scanner. Its Client implements golang.org/x/vuln/client.Client,
+// so it can be passed to quickcheck, and adds what cmd/vulns relies
+// on: the provenance of the entries, mirrors, ignored and selected
+// IDs, other ecosystems, concurrent and shared queries, and snapshot
+// pinning.
+package vulndb
+
+import (
+	"context"
+
+	"github.com/hyangah/vulns/internal/osvutil"
+	"golang.org/x/mod/module"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/vuln/client"
+	"golang.org/x/vuln/osv"
+)
+
+// A Client queries several vulnerability databases and records the
+// provenance of the entries it returns. See NewClient.
+type Client = osvutil.Client
+
+// Provenance records where an OSV entry came from.
+type Provenance = osvutil.Provenance
+
+// A DBError reports a failed query to a database source, as opposed
+// to a problem with the data it returned.
+type DBError = osvutil.DBError
+
+// SourceHealth is the result of probing a database source
+// with Client.Probe.
+type SourceHealth = osvutil.SourceHealth
+
+// NewClient returns a client of the databases with the given URLs,
+// such as https://vuln.go.dev or file:///path/to/db.
+func NewClient(urls []string, opts client.Options) (*Client, error) {
+	return osvutil.NewClient(urls, opts)
+}
+
+// URLs returns the database URLs of the GOVULNDB environment variable
+// of cfg or of the process, or the URL of the Go vulnerability
+// database if it is not set.
+func URLs(cfg *packages.Config) []string {
+	return osvutil.FindGOVULNDB(cfg)
+}
+
+// ReadIgnoreFile reads a file listing vulnerability IDs to ignore,
+// one per line, for Client.Ignore. Blank lines and text after #
+// are skipped.
+func ReadIgnoreFile(path string) ([]string, error) {
+	return osvutil.ReadIgnoreFile(path)
+}
+
+// FetchCatalog returns the OSV entries affecting the given modules,
+// keyed by the affected package paths, in the form the analyzer reads
+// with its -vulns-json flag. Modules with a version only get the
+// entries affecting that version. If mods is empty, the catalog
+// holds all entries of the database.
+func FetchCatalog(ctx context.Context, cli client.Client, mods []module.Version) (map[string][]*osv.Entry, error) {
+	return osvutil.FetchCatalog(ctx, cli, mods)
+}
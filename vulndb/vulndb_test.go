@@ -0,0 +1,47 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vulndb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hyangah/vulns/testutils"
+	"golang.org/x/vuln/client"
+)
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	db, err := testutils.NewDatabase(ctx, []byte(`
+-- GO-2020-0001.yaml --
+modules:
+  - module: example.com/m
+    versions:
+      - fixed: 1.1.0
+    packages:
+      - package: example.com/m/p
+description: |
+    Something.
+published: 2021-04-14T20:04:52Z
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Clean()
+	cli, err := NewClient([]string{db.URI()}, client.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := cli.GetByModule(ctx, "example.com/m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].ID != "GO-2020-0001" {
+		t.Fatalf("GetByModule = %v, want GO-2020-0001", entries)
+	}
+	if p, ok := cli.Provenance(entries[0].ID); !ok || p.Source != db.URI() {
+		t.Errorf("Provenance = %+v, %v; want the source %s", p, ok, db.URI())
+	}
+}